@@ -0,0 +1,32 @@
+package pulpe
+
+// Admin errors
+const (
+	ErrAdminRequired     = Error("instance administrator role required")
+	ErrAdminSelfDisabled = Error("administrators can't disable their own account")
+)
+
+// InstanceStats counts the content of the instance.
+type InstanceStats struct {
+	Users  int `json:"users"`
+	Boards int `json:"boards"`
+	Lists  int `json:"lists"`
+	Cards  int `json:"cards"`
+}
+
+// AdminService represents a service for administrating the instance.
+// Every method requires the authenticated user to be an instance administrator.
+type AdminService interface {
+	// Users returns the users whose login, email or full name contain search.
+	// An empty search returns every user.
+	Users(search string) ([]*User, error)
+	// SetUserDisabled disables or enables an account.
+	// Disabling an account revokes its sessions.
+	SetUserDisabled(id string, disabled bool) (*User, error)
+	// ResetPassword replaces the password of a user by a random one,
+	// revokes its sessions and returns the new password.
+	ResetPassword(id string) (string, error)
+	// RevokeSessions logs a user out of every device.
+	RevokeSessions(id string) error
+	Stats() (*InstanceStats, error)
+}
@@ -0,0 +1,128 @@
+package pulpe
+
+import "time"
+
+// Board archive errors
+const (
+	ErrBoardArchiveVersion = Error("unsupported board archive version")
+	ErrBoardArchiveInvalid = Error("invalid board archive")
+)
+
+// BoardArchiveVersion is the version of the archives produced by this version of pulpe.
+// It must be incremented every time the format changes in a way older versions can't read.
+// Card templates, custom fields and card field values were added without incrementing it:
+// they are optional, archives without them are still valid and older versions ignore them.
+const BoardArchiveVersion = 1
+
+// BoardArchive is a portable copy of a board with its lists and cards,
+// used to move boards between instances and keep backups.
+// Lists and cards reference each other by the ids they had when exported.
+type BoardArchive struct {
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exportedAt"`
+	Board      *ArchivedBoard  `json:"board"`
+	Lists      []*ArchivedList `json:"lists"`
+	Cards      []*ArchivedCard `json:"cards"`
+	// CardTemplates is missing from archives exported before card templates existed.
+	CardTemplates []*ArchivedCardTemplate `json:"cardTemplates,omitempty"`
+	// CustomFields is missing from archives exported before custom fields existed.
+	CustomFields []*ArchivedCustomField `json:"customFields,omitempty"`
+}
+
+// ArchivedBoard is the board of an archive.
+// Owners, members and share links are specific to an instance and aren't archived.
+type ArchivedBoard struct {
+	ID         string     `json:"id"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	Name       string     `json:"name"`
+	Slug       string     `json:"slug"`
+	Visibility string     `json:"visibility,omitempty"`
+}
+
+// ArchivedList is a list of an archive.
+type ArchivedList struct {
+	ID        string     `json:"id"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	Name      string     `json:"name"`
+	Slug      string     `json:"slug"`
+	Position  float64    `json:"position"`
+}
+
+// ArchivedCard is a card of an archive.
+// Assignees are specific to an instance and aren't archived.
+type ArchivedCard struct {
+	ID          string           `json:"id"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   *time.Time       `json:"updatedAt,omitempty"`
+	ListID      string           `json:"listID"`
+	Name        string           `json:"name"`
+	Slug        string           `json:"slug"`
+	Description string           `json:"description"`
+	Position    float64          `json:"position"`
+	Labels      []string         `json:"labels,omitempty"`
+	Checklist   []*ChecklistItem `json:"checklist,omitempty"`
+	Due         *time.Time       `json:"due,omitempty"`
+	// Fields contains the custom field values, keyed by archived field ID.
+	// It is missing from archives exported before custom fields existed.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// ArchivedCardTemplate is a card template of an archive.
+type ArchivedCardTemplate struct {
+	ID          string           `json:"id"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Labels      []string         `json:"labels,omitempty"`
+	Checklist   []*ChecklistItem `json:"checklist,omitempty"`
+}
+
+// ArchivedCustomField is a custom field of an archive.
+type ArchivedCustomField struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Options   []string  `json:"options,omitempty"`
+}
+
+// Validate checks the version and the consistency of the archive.
+func (a *BoardArchive) Validate() error {
+	if a.Version != BoardArchiveVersion {
+		return ErrBoardArchiveVersion
+	}
+
+	if a.Board == nil {
+		return ErrBoardArchiveInvalid
+	}
+
+	lists := make(map[string]bool, len(a.Lists))
+	for _, l := range a.Lists {
+		if l == nil || lists[l.ID] {
+			return ErrBoardArchiveInvalid
+		}
+		lists[l.ID] = true
+	}
+
+	for _, c := range a.Cards {
+		if c == nil || !lists[c.ListID] {
+			return ErrBoardArchiveInvalid
+		}
+	}
+
+	for _, t := range a.CardTemplates {
+		if t == nil {
+			return ErrBoardArchiveInvalid
+		}
+	}
+
+	for _, f := range a.CustomFields {
+		if f == nil {
+			return ErrBoardArchiveInvalid
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package pulpe
+
+// BackupStore is implemented by storage backends that can dump and restore
+// their whole content, regardless of the users and permissions.
+// Documents are opaque to the caller and encoded by the backend,
+// and returned by Dump in a stable key order.
+type BackupStore interface {
+	// Backend returns the name of the backend, which must be the same
+	// when restoring a backup.
+	Backend() string
+
+	// Collections returns the names of the collections holding the data of the instance.
+	Collections() []string
+
+	// Snapshot calls fn while the content of the store can't be modified,
+	// so that all the collections dumped by fn are consistent.
+	Snapshot(fn func() error) error
+
+	// Dump calls fn for every document of a collection.
+	Dump(collection string, fn func(doc []byte) error) error
+
+	// Validate checks that a document can be restored in a collection.
+	Validate(collection string, doc []byte) error
+
+	// Restore replaces the content of the collections by the given documents.
+	// Collections are only replaced once all of them are loaded,
+	// so that a failure while loading leaves the store untouched.
+	Restore(collections map[string][][]byte) error
+}
+
+// MigrationStore is a BackupStore that can copy collections in batches,
+// to move an instance from a backend to another one.
+// Documents are BSON encoded whatever the backend, and returned as they were written.
+type MigrationStore interface {
+	BackupStore
+
+	// Scan returns at most limit documents of a collection whose key is greater than after,
+	// in key order, with the key of the last one. An empty after starts from the first document.
+	Scan(collection, after string, limit int) (docs [][]byte, last string, err error)
+
+	// Upsert writes documents, replacing those with the same key.
+	Upsert(collection string, docs [][]byte) error
+
+	// Clear removes every document of a collection.
+	Clear(collection string) error
+}
@@ -0,0 +1,295 @@
+// Package backup dumps and restores a whole pulpe instance,
+// and migrates it from a storage backend to another.
+//
+// A backup is a gzipped tar archive holding one file per collection and a
+// manifest.json file describing them. Each collection file is a sequence of
+// documents, each prefixed by its length as a big-endian uint32.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+)
+
+// Errors
+var (
+	ErrInvalidArchive     = errors.New("backup: invalid archive")
+	ErrUnsupportedVersion = errors.New("backup: unsupported archive version")
+	ErrBackendMismatch    = errors.New("backup: archive created by another backend")
+)
+
+// Version is the version of the archives written by this version of pulpe.
+const Version = 1
+
+// name of the manifest in the archive.
+const manifestFile = "manifest.json"
+
+// maximum size of a document.
+const maxDocSize = 16 << 20
+
+// Manifest describes the content of a backup.
+type Manifest struct {
+	Version      int          `json:"version"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	PulpeVersion string       `json:"pulpeVersion"`
+	Backend      string       `json:"backend"`
+	Collections  []Collection `json:"collections"`
+}
+
+// Collection describes a collection file of a backup.
+type Collection struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Count  int    `json:"count"`
+	SHA256 string `json:"sha256"`
+}
+
+// Write dumps every collection of the store in a single snapshot
+// and writes the backup to w.
+func Write(w io.Writer, store pulpe.BackupStore, now time.Time) (*Manifest, error) {
+	m := Manifest{
+		Version:      Version,
+		CreatedAt:    now.UTC(),
+		PulpeVersion: pulpe.Version,
+		Backend:      store.Backend(),
+		Collections:  []Collection{},
+	}
+
+	files := make(map[string]*bytes.Buffer)
+
+	err := store.Snapshot(func() error {
+		for _, name := range store.Collections() {
+			var buf bytes.Buffer
+			c := Collection{
+				Name: name,
+				File: name + ".dat",
+			}
+
+			err := store.Dump(name, func(doc []byte) error {
+				c.Count++
+				return writeDoc(&buf, doc)
+			})
+			if err != nil {
+				return err
+			}
+
+			sum := sha256.Sum256(buf.Bytes())
+			c.SHA256 = hex.EncodeToString(sum[:])
+			m.Collections = append(m.Collections, c)
+			files[c.File] = &buf
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	for _, c := range m.Collections {
+		err = writeFile(tw, c.File, files[c.File].Bytes(), m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	manifest, err := json.MarshalIndent(&m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeFile(tw, manifestFile, manifest, m.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, gw.Close()
+}
+
+// writeDoc writes a document prefixed by its length.
+func writeDoc(w io.Writer, doc []byte) error {
+	err := binary.Write(w, binary.BigEndian, uint32(len(doc)))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(doc)
+	return err
+}
+
+// writeFile adds a file to a tar archive.
+func writeFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(data)
+	return err
+}
+
+// Archive is a backup read and validated against a store.
+type Archive struct {
+	Manifest *Manifest
+
+	// documents of each collection.
+	docs map[string][][]byte
+}
+
+// Read reads a backup and checks that every collection can be restored in the store,
+// without modifying it.
+func Read(r io.Reader, store pulpe.BackupStore) (*Archive, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+	defer gr.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidArchive
+		}
+
+		files[h.Name], err = ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, ErrInvalidArchive
+		}
+	}
+
+	data, ok := files[manifestFile]
+	if !ok {
+		return nil, ErrInvalidArchive
+	}
+
+	var m Manifest
+	err = json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, ErrInvalidArchive
+	}
+
+	if m.Version != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	if m.Backend != store.Backend() {
+		return nil, ErrBackendMismatch
+	}
+
+	known := make(map[string]bool)
+	for _, name := range store.Collections() {
+		known[name] = true
+	}
+
+	a := Archive{
+		Manifest: &m,
+		docs:     make(map[string][][]byte),
+	}
+
+	for _, c := range m.Collections {
+		if !known[c.Name] {
+			return nil, fmt.Errorf("backup: unknown collection %q", c.Name)
+		}
+
+		if _, ok := a.docs[c.Name]; ok {
+			return nil, fmt.Errorf("backup: duplicate collection %q", c.Name)
+		}
+
+		data, ok := files[c.File]
+		if !ok {
+			return nil, fmt.Errorf("backup: missing file %q", c.File)
+		}
+
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != c.SHA256 {
+			return nil, fmt.Errorf("backup: checksum mismatch for %q", c.Name)
+		}
+
+		docs, err := readDocs(data)
+		if err != nil {
+			return nil, fmt.Errorf("backup: %q: %v", c.Name, err)
+		}
+
+		if len(docs) != c.Count {
+			return nil, fmt.Errorf("backup: %q holds %d documents, expected %d", c.Name, len(docs), c.Count)
+		}
+
+		for i, doc := range docs {
+			err = store.Validate(c.Name, doc)
+			if err != nil {
+				return nil, fmt.Errorf("backup: %q: document %d: %v", c.Name, i, err)
+			}
+		}
+
+		a.docs[c.Name] = docs
+	}
+
+	return &a, nil
+}
+
+// readDocs splits a collection file into documents.
+func readDocs(data []byte) ([][]byte, error) {
+	docs := [][]byte{}
+
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, errors.New("truncated document")
+		}
+
+		n := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if n > maxDocSize || int(n) > len(data) {
+			return nil, errors.New("truncated document")
+		}
+
+		docs = append(docs, data[:n])
+		data = data[n:]
+	}
+
+	return docs, nil
+}
+
+// Restore replaces the content of the store by the content of the archive.
+// Collections of the store missing from the archive are emptied.
+func (a *Archive) Restore(store pulpe.BackupStore) error {
+	collections := make(map[string][][]byte)
+	for _, name := range store.Collections() {
+		docs := a.docs[name]
+		if docs == nil {
+			docs = [][]byte{}
+		}
+
+		collections[name] = docs
+	}
+
+	return store.Restore(collections)
+}
@@ -0,0 +1,284 @@
+package backup_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/backup"
+	"github.com/stretchr/testify/require"
+)
+
+// store keeps collections in memory.
+type store struct {
+	backend     string
+	names       []string
+	collections map[string][][]byte
+	snapshots   int
+}
+
+func newStore() *store {
+	return &store{
+		backend: "memory",
+		names:   []string{"boards", "cards", "users"},
+		collections: map[string][][]byte{
+			"boards": {[]byte("board1"), []byte("board2")},
+			"cards":  {},
+			"users":  {[]byte("user1")},
+		},
+	}
+}
+
+func (s *store) Backend() string { return s.backend }
+
+func (s *store) Collections() []string { return s.names }
+
+func (s *store) Snapshot(fn func() error) error {
+	s.snapshots++
+	return fn()
+}
+
+func (s *store) Dump(collection string, fn func(doc []byte) error) error {
+	for _, doc := range s.collections[collection] {
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *store) Validate(collection string, doc []byte) error {
+	if len(doc) == 0 {
+		return errors.New("empty document")
+	}
+
+	return nil
+}
+
+func (s *store) Restore(collections map[string][][]byte) error {
+	for name, docs := range collections {
+		s.collections[name] = docs
+	}
+
+	return nil
+}
+
+// Scan uses the part of documents before the first '=' as keys.
+func (s *store) Scan(collection, after string, limit int) ([][]byte, string, error) {
+	var docs [][]byte
+	var last string
+	for _, doc := range s.collections[collection] {
+		if k := key(doc); k > after && len(docs) < limit {
+			docs = append(docs, doc)
+			last = k
+		}
+	}
+
+	return docs, last, nil
+}
+
+func (s *store) Upsert(collection string, docs [][]byte) error {
+	for _, doc := range docs {
+		col := s.collections[collection]
+		i := sort.Search(len(col), func(i int) bool { return key(col[i]) >= key(doc) })
+		if i < len(col) && key(col[i]) == key(doc) {
+			col[i] = doc
+			continue
+		}
+
+		col = append(col, nil)
+		copy(col[i+1:], col[i:])
+		col[i] = doc
+		s.collections[collection] = col
+	}
+
+	return nil
+}
+
+func (s *store) Clear(collection string) error {
+	delete(s.collections, collection)
+	return nil
+}
+
+func key(doc []byte) string {
+	return strings.SplitN(string(doc), "=", 2)[0]
+}
+
+var _ pulpe.MigrationStore = new(store)
+
+var now = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// newBackup returns the files of a backup of s.
+func newBackup(t *testing.T, s *store) map[string][]byte {
+	var buf bytes.Buffer
+	_, err := backup.Write(&buf, s, now)
+	require.NoError(t, err)
+
+	gr, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if err != nil {
+			break
+		}
+		files[h.Name], err = ioutil.ReadAll(tr)
+		require.NoError(t, err)
+	}
+
+	return files
+}
+
+// newArchive returns a gzipped tar archive holding files.
+func newArchive(t *testing.T, files map[string][]byte) *bytes.Buffer {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data))}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return &buf
+}
+
+func TestWrite(t *testing.T) {
+	s := newStore()
+
+	var buf bytes.Buffer
+	m, err := backup.Write(&buf, s, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, s.snapshots)
+	require.Equal(t, backup.Version, m.Version)
+	require.Equal(t, now, m.CreatedAt)
+	require.Equal(t, pulpe.Version, m.PulpeVersion)
+	require.Equal(t, "memory", m.Backend)
+	require.Len(t, m.Collections, 3)
+	require.Equal(t, "boards", m.Collections[0].Name)
+	require.Equal(t, "boards.dat", m.Collections[0].File)
+	require.Equal(t, 2, m.Collections[0].Count)
+	require.Len(t, m.Collections[0].SHA256, 64)
+	require.Equal(t, 0, m.Collections[1].Count)
+
+	files := newBackup(t, s)
+	require.Len(t, files, 4)
+	require.Equal(t, []byte("\x00\x00\x00\x05user1"), files["users.dat"])
+	require.Contains(t, string(files["manifest.json"]), `"pulpeVersion": "`+pulpe.Version+`"`)
+}
+
+func TestRead(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		s := newStore()
+		var buf bytes.Buffer
+		_, err := backup.Write(&buf, s, now)
+		require.NoError(t, err)
+
+		target := newStore()
+		target.collections = map[string][][]byte{"boards": {[]byte("other")}}
+
+		a, err := backup.Read(&buf, target)
+		require.NoError(t, err)
+		require.Equal(t, now, a.Manifest.CreatedAt)
+
+		// nothing is restored before calling Restore
+		require.Equal(t, [][]byte{[]byte("other")}, target.collections["boards"])
+
+		err = a.Restore(target)
+		require.NoError(t, err)
+		require.Equal(t, s.collections, target.collections)
+	})
+
+	t.Run("MissingCollection", func(t *testing.T) {
+		s := newStore()
+		s.names = []string{"boards", "cards"}
+		var buf bytes.Buffer
+		_, err := backup.Write(&buf, s, now)
+		require.NoError(t, err)
+
+		// collections missing from the archive are emptied
+		target := newStore()
+		a, err := backup.Read(&buf, target)
+		require.NoError(t, err)
+		require.NoError(t, a.Restore(target))
+		require.Equal(t, [][]byte{}, target.collections["users"])
+		require.Len(t, target.collections["boards"], 2)
+	})
+
+	t.Run("DuplicateCollection", func(t *testing.T) {
+		files := newBackup(t, newStore())
+		files["manifest.json"] = bytes.Replace(files["manifest.json"], []byte(`"name": "users"`), []byte(`"name": "cards"`), 1)
+		_, err := backup.Read(newArchive(t, files), newStore())
+		require.EqualError(t, err, `backup: duplicate collection "cards"`)
+	})
+
+	t.Run("NotAnArchive", func(t *testing.T) {
+		_, err := backup.Read(bytes.NewReader([]byte("garbage")), newStore())
+		require.Equal(t, backup.ErrInvalidArchive, err)
+	})
+
+	t.Run("MissingManifest", func(t *testing.T) {
+		files := newBackup(t, newStore())
+		delete(files, "manifest.json")
+		_, err := backup.Read(newArchive(t, files), newStore())
+		require.Equal(t, backup.ErrInvalidArchive, err)
+	})
+
+	t.Run("UnsupportedVersion", func(t *testing.T) {
+		files := newBackup(t, newStore())
+		files["manifest.json"] = bytes.Replace(files["manifest.json"], []byte(`"version": 1`), []byte(`"version": 2`), 1)
+		_, err := backup.Read(newArchive(t, files), newStore())
+		require.Equal(t, backup.ErrUnsupportedVersion, err)
+	})
+
+	t.Run("BackendMismatch", func(t *testing.T) {
+		files := newBackup(t, newStore())
+		s := newStore()
+		s.backend = "other"
+		_, err := backup.Read(newArchive(t, files), s)
+		require.Equal(t, backup.ErrBackendMismatch, err)
+	})
+
+	t.Run("UnknownCollection", func(t *testing.T) {
+		files := newBackup(t, newStore())
+		files["manifest.json"] = bytes.Replace(files["manifest.json"], []byte(`"name": "users"`), []byte(`"name": "secrets"`), 1)
+		_, err := backup.Read(newArchive(t, files), newStore())
+		require.EqualError(t, err, `backup: unknown collection "secrets"`)
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		files := newBackup(t, newStore())
+		delete(files, "boards.dat")
+		_, err := backup.Read(newArchive(t, files), newStore())
+		require.EqualError(t, err, `backup: missing file "boards.dat"`)
+	})
+
+	t.Run("ChecksumMismatch", func(t *testing.T) {
+		files := newBackup(t, newStore())
+		files["users.dat"] = []byte("\x00\x00\x00\x05user2")
+		_, err := backup.Read(newArchive(t, files), newStore())
+		require.EqualError(t, err, `backup: checksum mismatch for "users"`)
+	})
+
+	t.Run("InvalidDocument", func(t *testing.T) {
+		s := newStore()
+		s.collections["cards"] = [][]byte{[]byte("card1"), {}}
+		var buf bytes.Buffer
+		_, err := backup.Write(&buf, s, now)
+		require.NoError(t, err)
+
+		_, err = backup.Read(&buf, s)
+		require.EqualError(t, err, `backup: "cards": document 1: empty document`)
+	})
+}
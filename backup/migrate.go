@@ -0,0 +1,138 @@
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/blankrobot/pulpe"
+)
+
+// MigrationState records the progress of a migration so that it can be resumed.
+type MigrationState struct {
+	Collections map[string]*MigrationProgress `json:"collections"`
+}
+
+// MigrationProgress is the progress of the migration of a collection.
+type MigrationProgress struct {
+	// Key of the last copied document.
+	Last   string `json:"last"`
+	Copied int    `json:"copied"`
+}
+
+// Migrate copies every collection of from into to, batchSize documents at a time,
+// keeping documents as they are. save is called with the state after every batch.
+// Calling Migrate again with the saved state resumes the migration and copies
+// the documents created in the meantime, but not the changes and deletions of the documents
+// already copied. A collection without progress in the state is cleared in to before being copied,
+// so that starting again with an empty state makes to an exact copy of from.
+func Migrate(from, to pulpe.MigrationStore, state *MigrationState, batchSize int, save func(*MigrationState) error) error {
+	known := make(map[string]bool)
+	for _, name := range to.Collections() {
+		known[name] = true
+	}
+
+	for _, name := range from.Collections() {
+		if !known[name] {
+			return fmt.Errorf("backup: unknown collection %q", name)
+		}
+	}
+
+	if state.Collections == nil {
+		state.Collections = make(map[string]*MigrationProgress)
+	}
+
+	for _, name := range from.Collections() {
+		p, ok := state.Collections[name]
+		if !ok {
+			err := to.Clear(name)
+			if err != nil {
+				return err
+			}
+
+			p = new(MigrationProgress)
+			state.Collections[name] = p
+		}
+
+		for {
+			docs, last, err := from.Scan(name, p.Last, batchSize)
+			if err != nil {
+				return err
+			}
+
+			if len(docs) == 0 {
+				break
+			}
+
+			err = to.Upsert(name, docs)
+			if err != nil {
+				return err
+			}
+
+			p.Last = last
+			p.Copied += len(docs)
+
+			err = save(state)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Comparison compares a collection of two stores.
+type Comparison struct {
+	Name         string
+	SourceCount  int
+	TargetCount  int
+	SourceSHA256 string
+	TargetSHA256 string
+}
+
+// Match returns true if both stores hold the same documents.
+func (c *Comparison) Match() bool {
+	return c.SourceCount == c.TargetCount && c.SourceSHA256 == c.TargetSHA256
+}
+
+// Verify compares the count and checksum of every collection of from with the ones of to.
+func Verify(from, to pulpe.BackupStore) ([]Comparison, error) {
+	var cmps []Comparison
+
+	for _, name := range from.Collections() {
+		c := Comparison{Name: name}
+
+		var err error
+		c.SourceCount, c.SourceSHA256, err = checksum(from, name)
+		if err != nil {
+			return nil, err
+		}
+
+		c.TargetCount, c.TargetSHA256, err = checksum(to, name)
+		if err != nil {
+			return nil, err
+		}
+
+		cmps = append(cmps, c)
+	}
+
+	return cmps, nil
+}
+
+// checksum returns the number of documents of a collection and the checksum
+// of its content, computed like in backup files.
+func checksum(store pulpe.BackupStore, collection string) (int, string, error) {
+	var count int
+	h := sha256.New()
+
+	err := store.Dump(collection, func(doc []byte) error {
+		count++
+		return writeDoc(h, doc)
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	return count, hex.EncodeToString(h.Sum(nil)), nil
+}
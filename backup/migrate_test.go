@@ -0,0 +1,118 @@
+package backup_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/blankrobot/pulpe/backup"
+	"github.com/stretchr/testify/require"
+)
+
+// newEmptyStore returns a store without any document.
+func newEmptyStore() *store {
+	s := newStore()
+	s.collections = map[string][][]byte{}
+	return s
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		from, to := newStore(), newEmptyStore()
+
+		var saves int
+		var state backup.MigrationState
+		err := backup.Migrate(from, to, &state, 1, func(s *backup.MigrationState) error {
+			saves++
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, saves)
+		require.Equal(t, &backup.MigrationProgress{Last: "board2", Copied: 2}, state.Collections["boards"])
+		require.Equal(t, &backup.MigrationProgress{}, state.Collections["cards"])
+		require.Equal(t, from.collections["boards"], to.collections["boards"])
+		require.Equal(t, from.collections["users"], to.collections["users"])
+
+		cmps, err := backup.Verify(from, to)
+		require.NoError(t, err)
+		require.Len(t, cmps, 3)
+		for _, c := range cmps {
+			require.True(t, c.Match(), c.Name)
+		}
+		require.Equal(t, 2, cmps[0].TargetCount)
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		from, to := newStore(), newEmptyStore()
+
+		var state backup.MigrationState
+		err := backup.Migrate(from, to, &state, 1, func(s *backup.MigrationState) error {
+			return errors.New("interrupted")
+		})
+		require.EqualError(t, err, "interrupted")
+		require.Len(t, to.collections["boards"], 1)
+
+		err = backup.Migrate(from, to, &state, 10, func(s *backup.MigrationState) error { return nil })
+		require.NoError(t, err)
+		require.Equal(t, from.collections["boards"], to.collections["boards"])
+		require.Equal(t, 2, state.Collections["boards"].Copied)
+
+		// documents created since are copied on the next run
+		from.collections["boards"] = append(from.collections["boards"], []byte("board3"))
+		err = backup.Migrate(from, to, &state, 10, func(s *backup.MigrationState) error { return nil })
+		require.NoError(t, err)
+		require.Equal(t, from.collections["boards"], to.collections["boards"])
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		from, to := newStore(), newEmptyStore()
+
+		var state backup.MigrationState
+		err := backup.Migrate(from, to, &state, 10, func(s *backup.MigrationState) error { return nil })
+		require.NoError(t, err)
+
+		// changes and deletions of copied documents are only applied when starting again
+		from.collections["boards"] = [][]byte{[]byte("board2=renamed")}
+		err = backup.Migrate(from, to, &state, 10, func(s *backup.MigrationState) error { return nil })
+		require.NoError(t, err)
+		require.Len(t, to.collections["boards"], 2)
+
+		err = backup.Migrate(from, to, new(backup.MigrationState), 10, func(s *backup.MigrationState) error { return nil })
+		require.NoError(t, err)
+		require.Equal(t, from.collections["boards"], to.collections["boards"])
+
+		cmps, err := backup.Verify(from, to)
+		require.NoError(t, err)
+		for _, c := range cmps {
+			require.True(t, c.Match(), c.Name)
+		}
+	})
+
+	t.Run("UnknownCollection", func(t *testing.T) {
+		to := newEmptyStore()
+		to.names = []string{"boards"}
+
+		err := backup.Migrate(newStore(), to, new(backup.MigrationState), 10, func(s *backup.MigrationState) error { return nil })
+		require.EqualError(t, err, `backup: unknown collection "cards"`)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	from, to := newStore(), newStore()
+	to.collections["boards"] = [][]byte{[]byte("board1"), []byte("board2=renamed")}
+	to.collections["users"] = nil
+
+	cmps, err := backup.Verify(from, to)
+	require.NoError(t, err)
+	require.Len(t, cmps, 3)
+
+	require.Equal(t, "boards", cmps[0].Name)
+	require.False(t, cmps[0].Match())
+	require.Equal(t, cmps[0].SourceCount, cmps[0].TargetCount)
+	require.NotEqual(t, cmps[0].SourceSHA256, cmps[0].TargetSHA256)
+
+	require.True(t, cmps[1].Match())
+
+	require.False(t, cmps[2].Match())
+	require.Equal(t, 1, cmps[2].SourceCount)
+	require.Equal(t, 0, cmps[2].TargetCount)
+}
@@ -4,39 +4,149 @@ import "time"
 
 // Board errors
 const (
-	ErrBoardNotFound = Error("board not found")
+	ErrBoardNotFound          = Error("board not found")
+	ErrBoardInvalidVisibility = Error("invalid board visibility")
+	ErrBoardShareLinkNotFound = Error("share link not found")
+	ErrBoardForbidden         = Error("board admin role required")
+	ErrBoardInvalidRole       = Error("invalid board role")
+	ErrBoardInvalidCursor     = Error("invalid board cursor")
+)
+
+// Board roles
+const (
+	BoardRoleAdmin  = "admin"
+	BoardRoleMember = "member"
+)
+
+// Board visibilities
+const (
+	// Private boards are only visible to their members.
+	BoardVisibilityPrivate = "private"
+	// Public boards can be read by anyone, including anonymous users.
+	BoardVisibilityPublic = "public"
 )
 
 // A Board is a container of lists.
+// It is owned either by a user or by an organization.
 type Board struct {
-	ID        string     `json:"id"`
-	Slug      string     `json:"slug"`
-	CreatedAt time.Time  `json:"createdAt"`
-	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
-	Owner     *User      `json:"owner,omitempty"`
-	Name      string     `json:"name"`
-	Lists     []*List    `json:"lists,omitempty"`
-	Cards     []*Card    `json:"cards,omitempty"`
+	ID           string        `json:"id"`
+	Slug         string        `json:"slug"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    *time.Time    `json:"updatedAt,omitempty"`
+	Owner        *User         `json:"owner,omitempty"`
+	Organization *Organization `json:"organization,omitempty"`
+	Name         string        `json:"name"`
+	Visibility   string        `json:"visibility,omitempty"`
+	ImportID     string        `json:"importID,omitempty"`
+	// Template boards are listed in the template gallery.
+	Template bool `json:"template,omitempty"`
+	// ReadOnly is set when the board is accessed through its public
+	// visibility or a share link by someone who isn't a member.
+	ReadOnly     bool           `json:"readOnly,omitempty"`
+	Members      []*BoardMember `json:"members,omitempty"`
+	CustomFields []*CustomField `json:"customFields,omitempty"`
+	Lists        []*List        `json:"lists,omitempty"`
+	Cards        []*Card        `json:"cards,omitempty"`
+}
+
+// BoardMember is a user invited to a board.
+// Owners and organization members aren't listed.
+type BoardMember struct {
+	UserID   string `json:"userID"`
+	Login    string `json:"login"`
+	FullName string `json:"fullName"`
+	Role     string `json:"role"`
+}
+
+// Namespace returns the login or the organization slug under which the board is found.
+func (b *Board) Namespace() string {
+	if b.Organization != nil {
+		return b.Organization.Slug
+	}
+
+	if b.Owner != nil {
+		return b.Owner.Login
+	}
+
+	return ""
 }
 
 // BoardCreation is used to create a board.
 type BoardCreation struct {
 	Name string
+	// Slug of the organization owning the board. Empty for personal boards.
+	Organization string
+	// ImportID identifies the board in the tool it was imported from.
+	ImportID string
+	// TemplateID is the ID of a template board whose lists and cards are copied.
+	TemplateID string
 }
 
 // BoardUpdate is used to update a board.
 type BoardUpdate struct {
-	Name *string
+	Name       *string
+	Visibility *string
+	Template   *bool
+}
+
+// BoardCopy is used to copy a board.
+type BoardCopy struct {
+	// Name of the copy. The name of the board is kept if empty.
+	Name string
+	// Slug of the organization owning the copy. Empty for personal boards.
+	Organization string
+	// WithCards copies the cards along with the lists.
+	WithCards bool
+}
+
+// BoardShareLink gives read-only access to a private board
+// to anyone knowing its token, until it is revoked.
+type BoardShareLink struct {
+	ID        string    `json:"id"`
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 // BoardService represents a service for managing boards.
 type BoardService interface {
 	CreateBoard(board *BoardCreation) (*Board, error)
 	Board(id string, options ...BoardGetOption) (*Board, error)
+	// BoardByOwnerAndSlug returns a board by slug. The owner is either
+	// a user login or an organization slug. Public boards and boards
+	// opened with a valid share token can be read without being a member,
+	// even by anonymous users.
 	BoardByOwnerAndSlug(owner, slug string, options ...BoardGetOption) (*Board, error)
-	Boards() ([]*Board, error)
+	// Boards returns the boards of the authenticated user and of its organizations,
+	// sorted by creation date.
+	Boards(options ...BoardListOption) ([]*Board, error)
 	DeleteBoard(id string) error
 	UpdateBoard(id string, u *BoardUpdate) (*Board, error)
+	// CopyBoard creates a new board with the lists, and optionally the cards,
+	// of a board the authenticated user is a member of.
+	CopyBoard(id string, c *BoardCopy) (*Board, error)
+	// Templates returns the template boards the authenticated user is a member of.
+	Templates() ([]*Board, error)
+	// CreateShareLink, ShareLinks and RevokeShareLink manage the share links of a board.
+	// They are restricted to the board owner, or the organization admins.
+	CreateShareLink(boardID string) (*BoardShareLink, error)
+	ShareLinks(boardID string) ([]*BoardShareLink, error)
+	RevokeShareLink(boardID, linkID string) error
+	// CreateCardTemplate, CardTemplates, UpdateCardTemplate and DeleteCardTemplate
+	// manage the card templates of a board. They are available to every member.
+	CreateCardTemplate(boardID string, t *CardTemplateCreation) (*CardTemplate, error)
+	CardTemplates(boardID string) ([]*CardTemplate, error)
+	UpdateCardTemplate(boardID, templateID string, u *CardTemplateUpdate) (*CardTemplate, error)
+	DeleteCardTemplate(boardID, templateID string) error
+	// CreateCustomField, UpdateCustomField and DeleteCustomField manage the custom fields
+	// of a board. They are available to every member. Deleting a field removes its values.
+	CreateCustomField(boardID string, f *CustomFieldCreation) (*CustomField, error)
+	UpdateCustomField(boardID, fieldID string, u *CustomFieldUpdate) (*CustomField, error)
+	DeleteCustomField(boardID, fieldID string) error
+	// ExportBoard returns an archive of a board the authenticated user is a member of.
+	ExportBoard(id string) (*BoardArchive, error)
+	// ImportBoard recreates an archived board, owned by the authenticated user
+	// or by the organization with the given slug if not empty.
+	ImportBoard(a *BoardArchive, organization string) (*Board, error)
 }
 
 // BoardGetOption is a function used to customize the way a board is fetched.
@@ -44,8 +154,9 @@ type BoardGetOption func(*BoardGetOptions)
 
 // BoardGetOptions contains the list of options to customize the way a board is fetched.
 type BoardGetOptions struct {
-	WithCards bool
-	WithLists bool
+	WithCards  bool
+	WithLists  bool
+	ShareToken string
 }
 
 // WithCards is used to tell the BoardService to also fetch cards.
@@ -61,3 +172,30 @@ func WithLists() BoardGetOption {
 		b.WithLists = true
 	}
 }
+
+// WithShareToken is used to tell the BoardService to grant read-only access
+// to the board if the token matches one of its share links.
+func WithShareToken(token string) BoardGetOption {
+	return func(b *BoardGetOptions) {
+		b.ShareToken = token
+	}
+}
+
+// BoardListOption is a function used to customize the way boards are listed.
+type BoardListOption func(*BoardListOptions)
+
+// BoardListOptions contains the list of options to customize the way boards are listed.
+type BoardListOptions struct {
+	// Limit is the maximum number of boards returned. Zero means no limit.
+	Limit int
+	// After is the ID of the last board of the previous page.
+	After string
+}
+
+// BoardPage is used to fetch at most limit boards, following the board with the given ID if not empty.
+func BoardPage(limit int, after string) BoardListOption {
+	return func(o *BoardListOptions) {
+		o.Limit = limit
+		o.After = after
+	}
+}
@@ -1,24 +1,52 @@
 package pulpe
 
-import "time"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
 
 // Card errors
 const (
-	ErrCardNotFound = Error("card not found")
+	ErrCardNotFound         = Error("card not found")
+	ErrCardTemplateNotFound = Error("card template not found")
+	ErrCardInvalidAssignee  = Error("assignee is not a member of the board")
+	ErrCardInvalidCursor    = Error("invalid card cursor")
+)
+
+// Card sort orders. Cards are sorted by creation date by default.
+const (
+	CardSortPosition = "position"
+	CardSortDue      = "due"
+	CardSortUpdated  = "updated"
 )
 
 // A Card is a unit of information that is stored in a list.
 type Card struct {
-	ID          string     `json:"id"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
-	OwnerID     string     `json:"ownerID"`
-	ListID      string     `json:"listID"`
-	BoardID     string     `json:"boardID"`
-	Name        string     `json:"name"`
-	Slug        string     `json:"slug"`
-	Description string     `json:"description"`
-	Position    float64    `json:"position"`
+	ID          string           `json:"id"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   *time.Time       `json:"updatedAt,omitempty"`
+	OwnerID     string           `json:"ownerID"`
+	ListID      string           `json:"listID"`
+	BoardID     string           `json:"boardID"`
+	Name        string           `json:"name"`
+	Slug        string           `json:"slug"`
+	Description string           `json:"description"`
+	Position    float64          `json:"position"`
+	ImportID    string           `json:"importID,omitempty"`
+	Labels      []string         `json:"labels,omitempty"`
+	Checklist   []*ChecklistItem `json:"checklist,omitempty"`
+	Due         *time.Time       `json:"due,omitempty"`
+	// Assignees are the IDs of the board members the card is assigned to.
+	Assignees []string `json:"assignees,omitempty"`
+	// Fields contains the values of the custom fields of the board, keyed by field ID.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// ChecklistItem is an item of the checklist of a card.
+type ChecklistItem struct {
+	Name string `json:"name"`
+	Done bool   `json:"done"`
 }
 
 // CardCreation is used to create a Card.
@@ -26,14 +54,41 @@ type CardCreation struct {
 	Name        string
 	Description string
 	Position    float64
+	// ImportID identifies the card in the tool it was imported from.
+	ImportID  string
+	Labels    []string
+	Checklist []*ChecklistItem
+	Due       *time.Time
+	Assignees []string
+	// TemplateID is the ID of a card template of the board. Its description,
+	// labels and checklist are used when the card doesn't define them.
+	TemplateID string
+	// Fields contains the values of custom fields, keyed by field ID.
+	Fields map[string]interface{}
 }
 
 // CardUpdate is used to update a Card.
+// Nil labels, checklist and assignees are left unchanged, empty ones are removed.
+// A zero due date removes the due date.
+// Only the custom fields present in Fields are changed, nil values are removed.
 type CardUpdate struct {
 	Name        *string
 	Description *string
 	Position    *float64
-	ListID      *string
+	Labels      []string
+	Checklist   []*ChecklistItem
+	Due         *time.Time
+	Assignees   []string
+	Fields      map[string]interface{}
+	// ListID moves the card to another list, possibly on another board.
+	ListID *string
+}
+
+// CardCopy is used to copy a Card to a list.
+type CardCopy struct {
+	ListID string
+	// Position of the copy. The position of the card is kept if nil.
+	Position *float64
 }
 
 // CardService represents a service for managing cards.
@@ -42,7 +97,198 @@ type CardService interface {
 	Card(id string) (*Card, error)
 	DeleteCard(id string) error
 	UpdateCard(id string, u *CardUpdate) (*Card, error)
+	CopyCard(id string, c *CardCopy) (*Card, error)
 	DeleteCardsByListID(listID string) error
 	DeleteCardsByBoardID(boardID string) error
-	CardsByBoard(boardID string) ([]*Card, error)
+	// CardsByBoard returns the cards of a board, filtered and sorted by the options.
+	// Invalid filters, sort orders and cursors are reported as validation errors.
+	CardsByBoard(boardID string, options ...CardListOption) ([]*Card, error)
+}
+
+// CardListOption is a function used to filter and sort the cards of a board.
+type CardListOption func(*CardListOptions)
+
+// CardListOptions contains the filters, the sort order and the page of the cards of a board.
+type CardListOptions struct {
+	ListID   string
+	Label    string
+	Assignee string
+	// DueAfter and DueBefore are the inclusive and exclusive bounds of the due dates.
+	DueAfter  *time.Time
+	DueBefore *time.Time
+	// Text is searched in the name and the description of the cards, ignoring case.
+	Text string
+	// UpdatedSince filters the cards created or updated since the given date.
+	UpdatedSince *time.Time
+	// Fields filters the cards by custom field value, keyed by field ID.
+	// Values are parsed according to the type of the field.
+	Fields map[string]string
+	// Sort is one of the card sort orders.
+	Sort string
+	// SortField is the ID of the custom field the cards are sorted by. It takes precedence over Sort.
+	SortField string
+	// Descending reverses the sort order.
+	Descending bool
+	// Limit is the maximum number of cards returned. Zero means no limit.
+	Limit int
+	// After is the cursor of the last card of the previous page.
+	After string
+	// ShareToken grants read-only access to the cards if it matches a share link of the board.
+	ShareToken string
+}
+
+// Cursor returns the cursor of a card, used to fetch the cards that follow it in the sort order.
+func (o *CardListOptions) Cursor(c *Card) string {
+	var v interface{}
+
+	switch {
+	case o.SortField != "":
+		v = c.Fields[o.SortField]
+	case o.Sort == CardSortPosition:
+		v = c.Position
+	case o.Sort == CardSortDue:
+		v = c.Due
+	case o.Sort == CardSortUpdated:
+		v = c.UpdatedAt
+	}
+
+	// a cursor only contains JSON values, it can't fail
+	data, _ := json.Marshal([]interface{}{v, c.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCardCursor returns the sort value and the ID of the card of a cursor.
+// Dates are returned as RFC 3339 strings.
+func ParseCardCursor(cursor string) (interface{}, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, "", ErrCardInvalidCursor
+	}
+
+	var c []interface{}
+	err = json.Unmarshal(data, &c)
+	if err != nil || len(c) != 2 {
+		return nil, "", ErrCardInvalidCursor
+	}
+
+	id, ok := c[1].(string)
+	if !ok {
+		return nil, "", ErrCardInvalidCursor
+	}
+
+	return c[0], id, nil
+}
+
+// InList is used to only list the cards of a list.
+func InList(listID string) CardListOption {
+	return func(o *CardListOptions) {
+		o.ListID = listID
+	}
+}
+
+// WithLabel is used to only list the cards having a label.
+func WithLabel(label string) CardListOption {
+	return func(o *CardListOptions) {
+		o.Label = label
+	}
+}
+
+// AssignedTo is used to only list the cards assigned to a user.
+func AssignedTo(userID string) CardListOption {
+	return func(o *CardListOptions) {
+		o.Assignee = userID
+	}
+}
+
+// DueBetween is used to only list the cards due in a range. Nil bounds are ignored.
+func DueBetween(after, before *time.Time) CardListOption {
+	return func(o *CardListOptions) {
+		o.DueAfter = after
+		o.DueBefore = before
+	}
+}
+
+// MatchingText is used to only list the cards whose name or description contain a text.
+func MatchingText(text string) CardListOption {
+	return func(o *CardListOptions) {
+		o.Text = text
+	}
+}
+
+// UpdatedSince is used to only list the cards created or updated since a date.
+func UpdatedSince(t time.Time) CardListOption {
+	return func(o *CardListOptions) {
+		o.UpdatedSince = &t
+	}
+}
+
+// WithFieldValue is used to only list the cards whose custom field has the given value.
+func WithFieldValue(fieldID, value string) CardListOption {
+	return func(o *CardListOptions) {
+		if o.Fields == nil {
+			o.Fields = make(map[string]string)
+		}
+
+		o.Fields[fieldID] = value
+	}
+}
+
+// SortBy is used to sort the cards with one of the card sort orders.
+func SortBy(sort string, descending bool) CardListOption {
+	return func(o *CardListOptions) {
+		o.Sort = sort
+		o.SortField = ""
+		o.Descending = descending
+	}
+}
+
+// SortByField is used to sort the cards by the value of a custom field.
+func SortByField(fieldID string, descending bool) CardListOption {
+	return func(o *CardListOptions) {
+		o.Sort = ""
+		o.SortField = fieldID
+		o.Descending = descending
+	}
+}
+
+// Page is used to fetch at most limit cards, following the card of the cursor if not empty.
+func Page(limit int, after string) CardListOption {
+	return func(o *CardListOptions) {
+		o.Limit = limit
+		o.After = after
+	}
+}
+
+// SharedWith is used to read the cards of a board shared with a link, using the token of the link.
+func SharedWith(token string) CardListOption {
+	return func(o *CardListOptions) {
+		o.ShareToken = token
+	}
+}
+
+// A CardTemplate pre-fills the cards created on a board.
+type CardTemplate struct {
+	ID          string           `json:"id"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Labels      []string         `json:"labels"`
+	Checklist   []*ChecklistItem `json:"checklist"`
+}
+
+// CardTemplateCreation is used to create a CardTemplate.
+type CardTemplateCreation struct {
+	Name        string
+	Description string
+	Labels      []string
+	Checklist   []*ChecklistItem
+}
+
+// CardTemplateUpdate is used to update a CardTemplate.
+// Nil labels and checklist are left unchanged, empty ones are removed.
+type CardTemplateUpdate struct {
+	Name        *string
+	Description *string
+	Labels      []string
+	Checklist   []*ChecklistItem
 }
@@ -1,16 +1,27 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/backup"
 	"github.com/blankrobot/pulpe/http"
 	"github.com/blankrobot/pulpe/http/api"
+	"github.com/blankrobot/pulpe/ldap"
 	"github.com/blankrobot/pulpe/mongo"
+	"github.com/blankrobot/pulpe/oidc"
+	"github.com/blankrobot/pulpe/smtp"
+	"github.com/blankrobot/pulpe/trello"
 	"github.com/spf13/cobra"
 )
 
@@ -26,6 +37,15 @@ func New() *cobra.Command {
 	}
 
 	cmd.AddCommand(NewServerCmd())
+	cmd.AddCommand(NewLockoutsCmd())
+	cmd.AddCommand(NewAdminCmd())
+	cmd.AddCommand(NewImportCmd())
+	cmd.AddCommand(NewExportCmd())
+	cmd.AddCommand(NewBackupCmd())
+	cmd.AddCommand(NewRestoreCmd())
+	cmd.AddCommand(NewMigrateCmd())
+	cmd.AddCommand(NewDBCmd())
+	cmd.AddCommand(NewGCCmd())
 	cmd.AddCommand(NewVersionCmd())
 	return &cmd
 }
@@ -60,6 +80,39 @@ func NewServerCmd() *cobra.Command {
 	cmd.Flags().StringVar(&s.assetsPath, "assets", "./dist", "Assets directory")
 	cmd.Flags().StringVar(&s.templatesPath, "templates", "./dist", "Templates directory")
 	cmd.Flags().BoolVar(&s.dev, "dev", false, "Developer mode")
+	cmd.Flags().BoolVar(&s.trustProxy, "trust-proxy", false, "Use the client IP sent by a reverse proxy in X-Real-Ip or X-Forwarded-For, only when the server is not reachable directly")
+	cmd.Flags().DurationVar(&s.sessionTimeouts.Idle, "session-idle", mongo.DefaultSessionTimeouts.Idle, "Duration after which an unused session expires")
+	cmd.Flags().DurationVar(&s.sessionTimeouts.Remember, "session-remember", mongo.DefaultSessionTimeouts.Remember, "Duration after which an unused \"remember me\" session expires")
+	cmd.Flags().DurationVar(&s.sessionTimeouts.Max, "session-max", mongo.DefaultSessionTimeouts.Max, "Maximum lifetime of a session, 0 for no limit")
+	cmd.Flags().IntVar(&s.loginLimits.FreeAttempts, "login-free-attempts", mongo.DefaultLoginLimits.FreeAttempts, "Number of failed login attempts allowed before slowing down the next ones")
+	cmd.Flags().DurationVar(&s.loginLimits.BaseDelay, "login-base-delay", mongo.DefaultLoginLimits.BaseDelay, "Delay after the first throttled login attempt, doubled after each failure")
+	cmd.Flags().DurationVar(&s.loginLimits.MaxDelay, "login-max-delay", mongo.DefaultLoginLimits.MaxDelay, "Maximum delay between two login attempts")
+	cmd.Flags().IntVar(&s.loginLimits.ClientLockout, "login-client-lockout", mongo.DefaultLoginLimits.ClientLockout, "Number of failed login attempts on an account after which the client IP is locked out of it, 0 to disable")
+	cmd.Flags().IntVar(&s.loginLimits.IPLockout, "login-ip-lockout", mongo.DefaultLoginLimits.IPLockout, "Number of failed login attempts after which a client IP is locked, 0 to disable")
+	cmd.Flags().DurationVar(&s.loginLimits.LockoutDuration, "login-lockout-duration", mongo.DefaultLoginLimits.LockoutDuration, "Duration of a lockout")
+	cmd.Flags().DurationVar(&s.loginLimits.Window, "login-window", mongo.DefaultLoginLimits.Window, "Duration after which failed login attempts are forgotten")
+	cmd.Flags().StringVar(&s.oidc.Issuer, "oidc-issuer", "", "OpenID Connect issuer URL, enables single sign-on")
+	cmd.Flags().StringVar(&s.oidc.ClientID, "oidc-client-id", "", "OpenID Connect client ID")
+	cmd.Flags().StringVar(&s.oidc.ClientSecret, "oidc-client-secret", "", "OpenID Connect client secret")
+	cmd.Flags().StringVar(&s.oidc.RedirectURL, "oidc-redirect-url", "", "OpenID Connect redirect URL, e.g. https://pulpe.example.com/auth/oidc/callback")
+	cmd.Flags().StringVar(&s.ldap.URL, "ldap-url", "", "LDAP server URL, enables LDAP authentication instead of local passwords")
+	cmd.Flags().BoolVar(&s.ldap.StartTLS, "ldap-starttls", false, "Use StartTLS on ldap:// connections")
+	cmd.Flags().StringVar(&s.ldap.BindDN, "ldap-bind-dn", "", "DN used to search users, anonymous if empty")
+	cmd.Flags().StringVar(&s.ldap.BindPassword, "ldap-bind-password", "", "Password of the bind DN")
+	cmd.Flags().StringVar(&s.ldap.BaseDN, "ldap-base-dn", "", "Base DN of the user search")
+	cmd.Flags().StringVar(&s.ldap.UserFilter, "ldap-user-filter", ldap.DefaultUserFilter, "User search filter, %s is replaced by the login")
+	cmd.Flags().StringVar(&s.ldap.EmailAttribute, "ldap-email-attribute", ldap.DefaultEmailAttribute, "Email attribute of user entries")
+	cmd.Flags().StringVar(&s.ldap.NameAttribute, "ldap-name-attribute", ldap.DefaultNameAttribute, "Full name attribute of user entries")
+	cmd.Flags().StringVar(&s.ldap.GroupAttribute, "ldap-group-attribute", ldap.DefaultGroupAttribute, "Group membership attribute of user entries")
+	cmd.Flags().StringSliceVar(&s.ldapGroupRoles, "ldap-group-role", nil, "Grant a role to the members of a group, e.g. cn=admins,dc=example,dc=com=admin; the only role is admin, making them instance administrators")
+	cmd.Flags().DurationVar(&s.invitationLifetime, "invitation-lifetime", mongo.DefaultInvitationLifetime, "Default duration after which a board invitation expires")
+	cmd.Flags().DurationVar(&s.revisionRetention.MaxAge, "revision-max-age", mongo.DefaultRevisionRetention.MaxAge, "Duration after which a card revision is removed, 0 to keep them forever")
+	cmd.Flags().IntVar(&s.revisionRetention.MaxCount, "revision-max-count", mongo.DefaultRevisionRetention.MaxCount, "Number of revisions kept per card, 0 for no limit")
+	cmd.Flags().StringVar(&s.smtp.Addr, "smtp-addr", "", "SMTP server address, host:port, enables email invitations")
+	cmd.Flags().StringVar(&s.smtp.Username, "smtp-username", "", "SMTP username, no authentication if empty")
+	cmd.Flags().StringVar(&s.smtp.Password, "smtp-password", "", "SMTP password")
+	cmd.Flags().StringVar(&s.smtp.From, "smtp-from", "", "Sender address of emails")
+	cmd.Flags().StringVar(&s.smtp.BaseURL, "base-url", "http://localhost:4000", "Public URL of pulpe, used in links sent by email")
 
 	return &cmd
 }
@@ -71,11 +124,49 @@ type ServerCmd struct {
 	assetsPath    string
 	templatesPath string
 	dev           bool
+	trustProxy    bool
+
+	sessionTimeouts mongo.SessionTimeouts
+	loginLimits     mongo.LoginLimits
+	oidc            oidc.Config
+	ldap            ldap.Config
+	ldapGroupRoles  []string
+
+	invitationLifetime time.Duration
+	revisionRetention  mongo.RevisionRetention
+	smtp               smtp.Config
 }
 
 // Run creates a bolt client and runs the HTTP server.
 func (c *ServerCmd) Run(cmd *cobra.Command, args []string) error {
 	client := mongo.NewClient(c.mongoURI)
+	client.SessionTimeouts.Idle = c.sessionTimeouts.Idle
+	client.SessionTimeouts.Remember = c.sessionTimeouts.Remember
+	client.SessionTimeouts.Max = c.sessionTimeouts.Max
+	client.LoginLimits = c.loginLimits
+	client.InvitationLifetime = c.invitationLifetime
+	client.RevisionRetention = c.revisionRetention
+
+	if c.smtp.Addr != "" {
+		client.Mailer = smtp.NewMailer(c.smtp)
+	}
+
+	if c.ldap.URL != "" {
+		if len(c.ldapGroupRoles) > 0 {
+			c.ldap.GroupRoles = make(map[string]string)
+			for _, gr := range c.ldapGroupRoles {
+				// the group DN contains '=', the role is after the last one
+				i := strings.LastIndex(gr, "=")
+				if i <= 0 || gr[i+1:] != pulpe.RoleAdmin {
+					return fmt.Errorf("invalid group role %q", gr)
+				}
+				c.ldap.GroupRoles[gr[:i]] = gr[i+1:]
+			}
+		}
+
+		client.CredentialVerifier = ldap.NewVerifier(c.ldap)
+	}
+
 	err := client.Open()
 	if err != nil {
 		return err
@@ -87,9 +178,21 @@ func (c *ServerCmd) Run(cmd *cobra.Command, args []string) error {
 	connect := http.NewCookieConnector(client)
 
 	mux := http.NewServeMux()
+	mux.TrustProxy = c.trustProxy
 
 	api.Register(mux, connect)
 	http.RegisterStaticHandler(mux, c.assetsPath)
+
+	if c.oidc.Issuer != "" {
+		c.oidc.Scopes = []string{"openid", "email", "profile"}
+		provider, err := oidc.NewProvider(c.oidc)
+		if err != nil {
+			return err
+		}
+
+		http.RegisterOIDCHandler(mux, connect, provider)
+	}
+
 	http.RegisterPageHandler(mux, connect, c.templatesPath, c.dev)
 
 	srv := http.NewServer(c.addr, mux)
@@ -114,3 +217,741 @@ func (c *ServerCmd) Run(cmd *cobra.Command, args []string) error {
 	log.Println("OK")
 	return nil
 }
+
+// NewLockoutsCmd returns a command that lists the most recent login lockouts.
+func NewLockoutsCmd() *cobra.Command {
+	var mongoURI string
+
+	cmd := cobra.Command{
+		Use:   "lockouts",
+		Short: "List the most recent login lockouts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := mongo.NewClient(mongoURI)
+			err := client.Open()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			session := client.Connect()
+			defer session.Close()
+
+			lockouts, err := session.LoginAttemptService().Lockouts()
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "DATE\tSCOPE\tACCOUNT\tIP\tFAILURES\tUNTIL")
+			for _, l := range lockouts {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+					l.CreatedAt.Format(time.RFC3339), l.Scope, l.Account, l.IP, l.Failures, l.Until.Format(time.RFC3339))
+			}
+
+			return w.Flush()
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&mongoURI, "mongo", "mongodb://localhost:27017/pulpe", "MongoDB uri")
+
+	return &cmd
+}
+
+// NewAdminCmd returns a command that groups the instance administration commands.
+func NewAdminCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "admin",
+		Short: "Manage instance administrators",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.AddCommand(NewAdminGrantCmd())
+	return &cmd
+}
+
+// NewAdminGrantCmd returns a command that makes a user an instance administrator.
+func NewAdminGrantCmd() *cobra.Command {
+	var mongoURI string
+
+	cmd := cobra.Command{
+		Use:   "grant <login>",
+		Short: "Make a user an instance administrator",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+
+			client := mongo.NewClient(mongoURI)
+			err := client.Open()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			session := client.Connect()
+			defer session.Close()
+
+			err = session.AdminService().(*mongo.AdminService).GrantAdmin(args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s is now an administrator\n", args[0])
+			return nil
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&mongoURI, "mongo", "mongodb://localhost:27017/pulpe", "MongoDB uri")
+
+	return &cmd
+}
+
+// NewImportCmd returns a command that groups the commands importing boards from other tools.
+func NewImportCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "import",
+		Short: "Import boards from other tools",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.AddCommand(NewImportTrelloCmd())
+	cmd.AddCommand(NewImportArchiveCmd())
+	return &cmd
+}
+
+// NewImportTrelloCmd returns a command that imports a Trello JSON export.
+func NewImportTrelloCmd() *cobra.Command {
+	var mongoURI, login, organization string
+
+	cmd := cobra.Command{
+		Use:   "trello <export.json>",
+		Short: "Import a Trello board exported as JSON",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 || login == "" {
+				return cmd.Usage()
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			export, err := trello.Decode(f)
+			if err != nil {
+				return err
+			}
+
+			session, closeFn, err := connectAs(mongoURI, login)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			report, err := trello.Import(session, export, organization)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "KIND\tTRELLO ID\tNAME\tSTATUS\tREASON")
+			for _, e := range report.Entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Kind, e.TrelloID, e.Name, e.Status, e.Reason)
+			}
+
+			err = w.Flush()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("\n%s/%s: %d lists and %d cards imported, %d lists and %d cards skipped\n",
+				report.Board.Namespace(), report.Board.Slug,
+				report.Count(trello.KindList, trello.StatusImported), report.Count(trello.KindCard, trello.StatusImported),
+				report.Count(trello.KindList, trello.StatusSkipped), report.Count(trello.KindCard, trello.StatusSkipped))
+			return nil
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&mongoURI, "mongo", "mongodb://localhost:27017/pulpe", "MongoDB uri")
+	cmd.Flags().StringVar(&login, "user", "", "Login of the user owning the imported board")
+	cmd.Flags().StringVar(&organization, "organization", "", "Slug of the organization owning the imported board")
+
+	return &cmd
+}
+
+// NewImportArchiveCmd returns a command that imports a board archive created by pulpe export.
+func NewImportArchiveCmd() *cobra.Command {
+	var mongoURI, login, organization string
+
+	cmd := cobra.Command{
+		Use:   "archive <board.json>",
+		Short: "Import a board archive created by pulpe export",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 || login == "" {
+				return cmd.Usage()
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			var archive pulpe.BoardArchive
+			err = json.NewDecoder(f).Decode(&archive)
+			if err != nil {
+				return err
+			}
+
+			session, closeFn, err := connectAs(mongoURI, login)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			board, err := session.BoardService().ImportBoard(&archive, organization)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%s/%s: %d lists and %d cards imported\n", board.Namespace(), board.Slug, len(archive.Lists), len(archive.Cards))
+			return nil
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&mongoURI, "mongo", "mongodb://localhost:27017/pulpe", "MongoDB uri")
+	cmd.Flags().StringVar(&login, "user", "", "Login of the user owning the imported board")
+	cmd.Flags().StringVar(&organization, "organization", "", "Slug of the organization owning the imported board")
+
+	return &cmd
+}
+
+// NewExportCmd returns a command that writes the archive of a board.
+func NewExportCmd() *cobra.Command {
+	var mongoURI, login, output string
+
+	cmd := cobra.Command{
+		Use:   "export <owner>/<board>",
+		Short: "Export a board with its lists and cards",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 || login == "" {
+				return cmd.Usage()
+			}
+
+			parts := strings.SplitN(args[0], "/", 2)
+			if len(parts) != 2 {
+				return cmd.Usage()
+			}
+
+			session, closeFn, err := connectAs(mongoURI, login)
+			if err != nil {
+				return err
+			}
+			defer closeFn()
+
+			board, err := session.BoardService().BoardByOwnerAndSlug(parts[0], parts[1])
+			if err != nil {
+				return err
+			}
+
+			archive, err := session.BoardService().ExportBoard(board.ID)
+			if err != nil {
+				return err
+			}
+
+			w := os.Stdout
+			if output != "" {
+				w, err = os.Create(output)
+				if err != nil {
+					return err
+				}
+				defer w.Close()
+			}
+
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(archive)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&mongoURI, "mongo", "mongodb://localhost:27017/pulpe", "MongoDB uri")
+	cmd.Flags().StringVar(&login, "user", "", "Login of a member of the board")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file, defaults to the standard output")
+
+	return &cmd
+}
+
+// NewBackupCmd returns a command that writes a backup of the whole instance.
+func NewBackupCmd() *cobra.Command {
+	var mongoURI, output string
+
+	cmd := cobra.Command{
+		Use:   "backup",
+		Short: "Back up every collection of the instance",
+		Long: `Back up every collection of the instance in a gzipped tar archive.
+Writes to the MongoDB server, including to its other databases, are blocked while collections
+are dumped, so that the backup is consistent: run it during a maintenance window.
+Interrupting the command waits for the dump to finish and the server to be unlocked.
+If the process is killed meanwhile, run db.fsyncUnlock() from the mongo shell.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output == "" {
+				return cmd.Usage()
+			}
+
+			client := mongo.NewClient(mongoURI)
+			err := client.Open()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+
+			// the server must be unlocked before exiting
+			ch := make(chan os.Signal, 1)
+			signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+			defer func() {
+				signal.Stop(ch)
+				close(ch)
+			}()
+			go func() {
+				for range ch {
+					fmt.Fprintln(os.Stderr, "Waiting for the backup to finish and the database to be unlocked...")
+				}
+			}()
+
+			m, err := backup.Write(f, client.BackupStore(), client.Now())
+			if err == nil {
+				err = f.Close()
+			} else {
+				f.Close()
+			}
+			if err != nil {
+				os.Remove(output)
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "COLLECTION\tDOCUMENTS\tSHA256")
+			for _, c := range m.Collections {
+				fmt.Fprintf(w, "%s\t%d\t%s\n", c.Name, c.Count, c.SHA256)
+			}
+
+			return w.Flush()
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&mongoURI, "mongo", "mongodb://localhost:27017/pulpe", "MongoDB uri")
+	cmd.Flags().StringVar(&output, "out", "", "Backup file")
+
+	return &cmd
+}
+
+// NewRestoreCmd returns a command that replaces the content of the instance by a backup.
+func NewRestoreCmd() *cobra.Command {
+	var mongoURI, input string
+	var dryRun bool
+
+	cmd := cobra.Command{
+		Use:   "restore",
+		Short: "Replace the content of the instance by a backup",
+		Long: `Replace the content of the instance by a backup created by pulpe backup.
+The whole backup is validated before anything is modified, and collections replace
+the current ones only once all of them are loaded.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if input == "" {
+				return cmd.Usage()
+			}
+
+			f, err := os.Open(input)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			client := mongo.NewClient(mongoURI)
+			err = client.Open()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			store := client.BackupStore()
+			archive, err := backup.Read(f, store)
+			if err != nil {
+				return err
+			}
+
+			m := archive.Manifest
+			fmt.Printf("Backup created at %s by pulpe %s\n", m.CreatedAt.Format(time.RFC3339), m.PulpeVersion)
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "COLLECTION\tDOCUMENTS")
+			for _, c := range m.Collections {
+				fmt.Fprintf(w, "%s\t%d\n", c.Name, c.Count)
+			}
+
+			err = w.Flush()
+			if err != nil {
+				return err
+			}
+
+			if dryRun {
+				fmt.Println("Backup is valid, nothing was restored")
+				return nil
+			}
+
+			err = archive.Restore(store)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Backup restored")
+			return nil
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&mongoURI, "mongo", "mongodb://localhost:27017/pulpe", "MongoDB uri")
+	cmd.Flags().StringVar(&input, "in", "", "Backup file")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only validate the backup")
+
+	return &cmd
+}
+
+// NewMigrateCmd returns a command that copies an instance to another storage backend.
+func NewMigrateCmd() *cobra.Command {
+	var from, to, statePath string
+	var batchSize int
+	var restart bool
+
+	cmd := cobra.Command{
+		Use:   "migrate",
+		Short: "Copy every collection of the instance to another storage backend",
+		Long: `Copy every collection of the instance to another storage backend, keeping documents as they are.
+The progress is saved after every batch: running the command again resumes the migration
+and copies the documents created in the meantime. Changes and deletions of the documents
+already copied are only applied by starting again with --restart, which empties the target first.
+Counts and checksums of every collection are compared once the copy is done.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if from == "" || to == "" || batchSize <= 0 {
+				return cmd.Usage()
+			}
+
+			if from == to {
+				return errors.New("source and target are the same")
+			}
+
+			source, closeSource, err := openStore(from)
+			if err != nil {
+				return err
+			}
+			defer closeSource()
+
+			target, closeTarget, err := openStore(to)
+			if err != nil {
+				return err
+			}
+			defer closeTarget()
+
+			var state backup.MigrationState
+			if !restart {
+				data, err := ioutil.ReadFile(statePath)
+				if err == nil {
+					err = json.Unmarshal(data, &state)
+				}
+				if err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+
+			err = backup.Migrate(source, target, &state, batchSize, func(state *backup.MigrationState) error {
+				return saveJSON(statePath, state)
+			})
+			if err != nil {
+				return err
+			}
+
+			cmps, err := backup.Verify(source, target)
+			if err != nil {
+				return err
+			}
+
+			var mismatch bool
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "COLLECTION\tSOURCE\tTARGET\tCHECKSUM")
+			for _, c := range cmps {
+				status := "ok"
+				if !c.Match() {
+					status = "mismatch"
+					mismatch = true
+				}
+				fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", c.Name, c.SourceCount, c.TargetCount, status)
+			}
+
+			err = w.Flush()
+			if err != nil {
+				return err
+			}
+
+			if mismatch {
+				return errors.New("the target differs from the source, stop writes to the source and run the command again with --restart")
+			}
+
+			return nil
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "URI of the source instance, e.g. mongodb://localhost:27017/pulpe")
+	cmd.Flags().StringVar(&to, "to", "", "URI of the target instance")
+	cmd.Flags().StringVar(&statePath, "state", "pulpe-migrate.json", "File recording the progress of the migration")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 500, "Number of documents copied at once")
+	cmd.Flags().BoolVar(&restart, "restart", false, "Ignore the saved progress, empty the target and copy everything again")
+
+	return &cmd
+}
+
+// openStore opens the storage backend matching the scheme of uri.
+// The returned function closes it.
+func openStore(uri string) (pulpe.MigrationStore, func(), error) {
+	switch {
+	case strings.HasPrefix(uri, "mongodb://"):
+		client := mongo.NewClient(uri)
+		err := client.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return client.BackupStore(), func() { client.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported storage backend %q", uri)
+	}
+}
+
+// saveJSON atomically replaces the content of a file by the JSON encoding of v.
+func saveJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(path+".tmp", data, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// NewDBCmd returns a command that groups the database maintenance commands.
+func NewDBCmd() *cobra.Command {
+	cmd := cobra.Command{
+		Use:   "db",
+		Short: "Manage the database schema",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.AddCommand(NewDBStatusCmd())
+	cmd.AddCommand(NewDBMigrateCmd())
+	return &cmd
+}
+
+// NewDBStatusCmd returns a command that lists the schema migrations.
+func NewDBStatusCmd() *cobra.Command {
+	var mongoURI string
+
+	cmd := cobra.Command{
+		Use:   "status",
+		Short: "List the schema migrations and when they were applied",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := mongo.NewClient(mongoURI)
+			client.SkipMigrations = true
+			err := client.Open()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			list, err := client.MigrationStatus()
+			if err != nil {
+				return err
+			}
+
+			return printMigrations(list)
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&mongoURI, "mongo", "mongodb://localhost:27017/pulpe", "MongoDB uri")
+
+	return &cmd
+}
+
+// NewDBMigrateCmd returns a command that applies the pending schema migrations.
+func NewDBMigrateCmd() *cobra.Command {
+	var mongoURI string
+	var dryRun bool
+
+	cmd := cobra.Command{
+		Use:   "migrate",
+		Short: "Apply the pending schema migrations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := mongo.NewClient(mongoURI)
+			client.SkipMigrations = true
+			err := client.Open()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if dryRun {
+				list, err := client.MigrationStatus()
+				if err != nil {
+					return err
+				}
+
+				var pending []mongo.MigrationStatus
+				for _, m := range list {
+					if m.AppliedAt == nil {
+						pending = append(pending, m)
+					}
+				}
+
+				fmt.Printf("%d migrations would be applied\n", len(pending))
+				return printMigrations(pending)
+			}
+
+			done, err := client.Migrate()
+			fmt.Printf("%d migrations applied\n", len(done))
+			perr := printMigrations(done)
+			if err != nil {
+				return err
+			}
+
+			return perr
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&mongoURI, "mongo", "mongodb://localhost:27017/pulpe", "MongoDB uri")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the pending migrations")
+
+	return &cmd
+}
+
+// printMigrations writes a table of migrations to the standard output.
+func printMigrations(list []mongo.MigrationStatus) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tDESCRIPTION\tAPPLIED")
+	for _, m := range list {
+		applied := "pending"
+		if m.AppliedAt != nil {
+			applied = m.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Description, applied)
+	}
+
+	return w.Flush()
+}
+
+// NewGCCmd returns a command that removes the content left by interrupted deletions.
+func NewGCCmd() *cobra.Command {
+	var mongoURI string
+
+	cmd := cobra.Command{
+		Use:   "gc",
+		Short: "Finish interrupted deletions and remove orphaned lists and cards",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client := mongo.NewClient(mongoURI)
+			err := client.Open()
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			r, err := client.CollectGarbage()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%d interrupted deletions finished, %d lists and %d cards removed\n", r.Deletions, r.Lists, r.Cards)
+			return nil
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	cmd.Flags().StringVar(&mongoURI, "mongo", "mongodb://localhost:27017/pulpe", "MongoDB uri")
+
+	return &cmd
+}
+
+// connectAs opens the database and returns a session authenticated with a short lived
+// session of the user with the given login. The returned function closes everything.
+func connectAs(mongoURI, login string) (pulpe.Session, func(), error) {
+	client := mongo.NewClient(mongoURI)
+	client.Authenticator = new(mongo.Authenticator)
+	err := client.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session := client.Connect()
+	closeFn := func() {
+		session.Close()
+		client.Close()
+	}
+
+	user, err := session.UserService().UserByLogin(login)
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+
+	us, err := session.UserSessionService().CreateSession(user)
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+	session.SetAuthToken(us.ID)
+
+	return session, func() {
+		session.UserSessionService().DeleteSession(us.ID)
+		closeFn()
+	}, nil
+}
@@ -11,7 +11,15 @@ type Session interface {
 	ListService() ListService
 	BoardService() BoardService
 	UserService() UserService
+	OrganizationService() OrganizationService
+	InvitationService() InvitationService
 	UserSessionService() UserSessionService
+	TwoFactorService() TwoFactorService
+	LoginAttemptService() LoginAttemptService
+	AdminService() AdminService
+	SyncService() SyncService
+	HistoryService() HistoryService
+	RevisionService() RevisionService
 	Authenticate() (*User, error)
 	SetAuthToken(string)
 	Close() error
@@ -0,0 +1,47 @@
+package pulpe
+
+import "time"
+
+// Custom field errors
+const (
+	ErrCustomFieldNotFound    = Error("custom field not found")
+	ErrCustomFieldInvalidType = Error("invalid custom field type")
+)
+
+// Custom field types
+const (
+	// Text values are strings.
+	CustomFieldText = "text"
+	// Number values are float64.
+	CustomFieldNumber = "number"
+	// Date values are time.Time, encoded as RFC 3339 strings in JSON.
+	CustomFieldDate = "date"
+	// Dropdown values are strings, chosen among the options of the field.
+	CustomFieldDropdown = "dropdown"
+	// Checkbox values are bool.
+	CustomFieldCheckbox = "checkbox"
+)
+
+// A CustomField is a typed value that can be set on every card of a board.
+// The values are stored in the cards, keyed by the field ID.
+type CustomField struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Options   []string  `json:"options,omitempty"`
+}
+
+// CustomFieldCreation is used to create a CustomField.
+type CustomFieldCreation struct {
+	Name    string
+	Type    string
+	Options []string
+}
+
+// CustomFieldUpdate is used to update a CustomField.
+// The type of a field can't be changed. Nil options are left unchanged.
+type CustomFieldUpdate struct {
+	Name    *string
+	Options []string
+}
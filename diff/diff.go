@@ -0,0 +1,153 @@
+// Package diff compares texts line by line.
+package diff
+
+import "strings"
+
+// Operations applied to the lines of a text.
+const (
+	Equal  = "="
+	Insert = "+"
+	Delete = "-"
+)
+
+// maxEdits bounds the cost of a comparison. Texts that need more edits are
+// reported as entirely replaced, apart from their common first and last lines.
+const maxEdits = 1000
+
+// A Line is a line of text kept, inserted or deleted.
+type Line struct {
+	Op   string
+	Text string
+}
+
+// Lines returns the lines of a that are kept or deleted and the lines of b that are inserted
+// to turn a into b, in order. Deletions come before the insertions that replace them.
+func Lines(a, b string) []Line {
+	al, bl := split(a), split(b)
+
+	// common first and last lines are kept as is
+	var prefix, suffix int
+	for prefix < len(al) && prefix < len(bl) && al[prefix] == bl[prefix] {
+		prefix++
+	}
+
+	for suffix < len(al)-prefix && suffix < len(bl)-prefix && al[len(al)-1-suffix] == bl[len(bl)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(al)+len(bl)-prefix-suffix)
+	for _, l := range al[:prefix] {
+		lines = append(lines, Line{Op: Equal, Text: l})
+	}
+
+	lines = append(lines, edits(al[prefix:len(al)-suffix], bl[prefix:len(bl)-suffix])...)
+
+	for _, l := range al[len(al)-suffix:] {
+		lines = append(lines, Line{Op: Equal, Text: l})
+	}
+
+	return lines
+}
+
+// split returns the lines of a text. An empty text has no lines.
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
+
+// edits returns the shortest edit script turning a into b, using the algorithm
+// described by Eugene W. Myers in "An O(ND) Difference Algorithm and Its Variations".
+func edits(a, b []string) []Line {
+	n, m := len(a), len(b)
+	max := n + m
+	if max > maxEdits {
+		max = maxEdits
+	}
+
+	// v[offset+k] is the furthest x reached on the diagonal k = x - y
+	offset := max + 1
+	v := make([]int, 2*max+3)
+
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, offset)
+			}
+		}
+	}
+
+	// too many edits, a is replaced
+	lines := make([]Line, 0, n+m)
+	for _, l := range a {
+		lines = append(lines, Line{Op: Delete, Text: l})
+	}
+
+	for _, l := range b {
+		lines = append(lines, Line{Op: Insert, Text: l})
+	}
+
+	return lines
+}
+
+// backtrack follows the furthest paths recorded by edits back from the end of a and b.
+func backtrack(a, b []string, trace [][]int, offset int) []Line {
+	var lines []Line
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, Line{Op: Equal, Text: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				lines = append(lines, Line{Op: Insert, Text: b[y-1]})
+			} else {
+				lines = append(lines, Line{Op: Delete, Text: a[x-1]})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return lines
+}
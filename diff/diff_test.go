@@ -0,0 +1,72 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/blankrobot/pulpe/diff"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLines(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		require.Empty(t, diff.Lines("", ""))
+		require.Equal(t, []diff.Line{{Op: diff.Insert, Text: "a"}}, diff.Lines("", "a"))
+		require.Equal(t, []diff.Line{{Op: diff.Delete, Text: "a"}}, diff.Lines("a", ""))
+	})
+
+	t.Run("Equal", func(t *testing.T) {
+		require.Equal(t, []diff.Line{
+			{Op: diff.Equal, Text: "a"},
+			{Op: diff.Equal, Text: "b"},
+		}, diff.Lines("a\nb", "a\nb"))
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		require.Equal(t, []diff.Line{
+			{Op: diff.Equal, Text: "title"},
+			{Op: diff.Delete, Text: "old"},
+			{Op: diff.Insert, Text: "new"},
+			{Op: diff.Equal, Text: "kept"},
+			{Op: diff.Delete, Text: "removed"},
+			{Op: diff.Equal, Text: "end"},
+			{Op: diff.Insert, Text: "added"},
+		}, diff.Lines("title\nold\nkept\nremoved\nend", "title\nnew\nkept\nend\nadded"))
+	})
+
+	t.Run("Shortest", func(t *testing.T) {
+		lines := diff.Lines("a\nb\nc\na\nb\nb\na", "c\nb\na\nb\na\nc")
+
+		var edits int
+		var a, b []string
+		for _, l := range lines {
+			if l.Op != diff.Insert {
+				a = append(a, l.Text)
+			}
+			if l.Op != diff.Delete {
+				b = append(b, l.Text)
+			}
+			if l.Op != diff.Equal {
+				edits++
+			}
+		}
+
+		require.Equal(t, "a\nb\nc\na\nb\nb\na", strings.Join(a, "\n"))
+		require.Equal(t, "c\nb\na\nb\na\nc", strings.Join(b, "\n"))
+		require.Equal(t, 5, edits)
+	})
+
+	t.Run("Too many edits", func(t *testing.T) {
+		var a, b []string
+		for i := 0; i < 1000; i++ {
+			a = append(a, "a")
+			b = append(b, "b")
+		}
+
+		lines := diff.Lines("first\n"+strings.Join(a, "\n"), "first\n"+strings.Join(b, "\n"))
+		require.Len(t, lines, 2001)
+		require.Equal(t, diff.Line{Op: diff.Equal, Text: "first"}, lines[0])
+		require.Equal(t, diff.Line{Op: diff.Delete, Text: "a"}, lines[1])
+		require.Equal(t, diff.Line{Op: diff.Insert, Text: "b"}, lines[2000])
+	})
+}
@@ -0,0 +1,51 @@
+package pulpe
+
+import "time"
+
+// History errors
+const (
+	ErrNothingToUndo = Error("nothing to undo")
+	ErrNothingToRedo = Error("nothing to redo")
+	// ErrHistoryConflict is returned when the list or the card was changed, deleted or moved
+	// out of reach since the change being undone or redone. The change is dropped from the history.
+	ErrHistoryConflict = Error("changed by someone else since")
+	// ErrHistoryBusy is returned when the change is already being undone or redone.
+	ErrHistoryBusy = Error("already being undone or redone")
+)
+
+// History entities
+const (
+	HistoryList = "list"
+	HistoryCard = "card"
+)
+
+// History actions
+const (
+	HistoryUpdate = "update"
+	HistoryMove   = "move"
+	HistoryDelete = "delete"
+)
+
+// A HistoryEntry is a change of a list or a card made by a user, that can be undone and redone.
+type HistoryEntry struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Entity    string    `json:"entity"`
+	Action    string    `json:"action"`
+	EntityID  string    `json:"entityID"`
+	BoardID   string    `json:"boardID"`
+	// List or Card is the state once the change is undone or redone.
+	// Both are nil when the list or the card is deleted.
+	List *List `json:"list,omitempty"`
+	Card *Card `json:"card,omitempty"`
+	// Cards restored with their list.
+	Cards []*Card `json:"cards,omitempty"`
+}
+
+// HistoryService undoes and redoes the last updates, moves and deletions
+// of lists and cards made by the authenticated user.
+// Making a new change forgets the changes that were undone.
+type HistoryService interface {
+	Undo() (*HistoryEntry, error)
+	Redo() (*HistoryEntry, error)
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/julienschmidt/httprouter"
+)
+
+// registerAdminHandler register the adminHandler routes.
+func registerAdminHandler(router *httprouter.Router, c pulpeHttp.Connector) {
+	h := adminHandler{
+		connect: c,
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	router.HandlerFunc("GET", "/api/admin/users", h.handleGetUsers)
+	router.PATCH("/api/admin/users/:id", h.handlePatchUser)
+	router.POST("/api/admin/users/:id/password-reset", h.handlePasswordReset)
+	router.DELETE("/api/admin/users/:id/sessions", h.handleDeleteSessions)
+	router.HandlerFunc("GET", "/api/admin/stats", h.handleGetStats)
+}
+
+// adminHandler represents an HTTP API handler for instance administration.
+type adminHandler struct {
+	connect pulpeHttp.Connector
+	logger  *log.Logger
+}
+
+// handleGetUsers handles requests to list and search users.
+func (h *adminHandler) handleGetUsers(w http.ResponseWriter, r *http.Request) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	users, err := session.AdminService().Users(strings.TrimSpace(r.URL.Query().Get("q")))
+	switch err {
+	case nil:
+		encodeJSON(w, users, http.StatusOK, h.logger)
+	default:
+		h.error(w, r, err)
+	}
+}
+
+// handlePatchUser handles requests to disable or enable an account.
+func (h *adminHandler) handlePatchUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req AdminUserUpdateRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	user, err := session.AdminService().SetUserDisabled(ps.ByName("id"), *req.Disabled)
+	switch err {
+	case nil:
+		encodeJSON(w, user, http.StatusOK, h.logger)
+	case pulpe.ErrAdminSelfDisabled:
+		Error(w, validation.AddError(nil, "disabled", err), http.StatusBadRequest, h.logger)
+	default:
+		h.error(w, r, err)
+	}
+}
+
+// handlePasswordReset handles requests to replace the password of a user.
+func (h *adminHandler) handlePasswordReset(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	passwd, err := session.AdminService().ResetPassword(ps.ByName("id"))
+	switch err {
+	case nil:
+		encodeJSON(w, &passwordResetResponse{Password: passwd}, http.StatusOK, h.logger)
+	default:
+		h.error(w, r, err)
+	}
+}
+
+// handleDeleteSessions handles requests to log a user out of every device.
+func (h *adminHandler) handleDeleteSessions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	err := session.AdminService().RevokeSessions(ps.ByName("id"))
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		h.error(w, r, err)
+	}
+}
+
+// handleGetStats handles requests to count the content of the instance.
+func (h *adminHandler) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	stats, err := session.AdminService().Stats()
+	switch err {
+	case nil:
+		encodeJSON(w, stats, http.StatusOK, h.logger)
+	default:
+		h.error(w, r, err)
+	}
+}
+
+// error writes the response of the errors common to every admin route.
+func (h *adminHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case pulpe.ErrUserNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrAdminRequired:
+		Error(w, err, http.StatusForbidden, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// passwordResetResponse contains the password generated by a reset.
+type passwordResetResponse struct {
+	Password string `json:"password"`
+}
+
+// AdminUserUpdateRequest is used to disable or enable an account.
+type AdminUserUpdateRequest struct {
+	Disabled *bool `json:"disabled"`
+}
+
+// Validate account update payload.
+func (a *AdminUserUpdateRequest) Validate() error {
+	if a.Disabled == nil {
+		return validation.AddError(nil, "disabled", errors.New("disabled is required"))
+	}
+
+	return nil
+}
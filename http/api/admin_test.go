@@ -0,0 +1,244 @@
+package api_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandler_GetUsers(t *testing.T) {
+	t.Run("OK", testAdminHandler_GetUsers_OK)
+	t.Run("Forbidden", testAdminHandler_GetUsers_WithResponse(t, http.StatusForbidden, pulpe.ErrAdminRequired))
+	t.Run("Unauthorized", testAdminHandler_GetUsers_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testAdminHandler_GetUsers_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testAdminHandler_GetUsers_OK(t *testing.T) {
+	c := mock.NewClient()
+
+	c.AdminService.UsersFn = func(search string) ([]*pulpe.User, error) {
+		require.Equal(t, "snow", search)
+		return []*pulpe.User{
+			{ID: "1", CreatedAt: mock.Now, FullName: "Jon Snow", Login: "jonsnow", Email: "jon.snow@wall.com", Disabled: true},
+		}, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/admin/users?q=+snow+", nil)
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `[{
+		"id": "1",
+		"createdAt": "2000-01-01T00:00:00Z",
+		"fullName": "Jon Snow",
+		"login": "jonsnow",
+		"email": "jon.snow@wall.com",
+		"disabled": true
+	}]`, w.Body.String())
+}
+
+func testAdminHandler_GetUsers_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.AdminService.UsersFn = func(search string) ([]*pulpe.User, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/admin/users", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
+
+func TestAdminHandler_PatchUser(t *testing.T) {
+	t.Run("OK", testAdminHandler_PatchUser_OK)
+	t.Run("ErrInvalidJSON", testAdminHandler_PatchUser_ErrInvalidJSON)
+	t.Run("ErrValidation", testAdminHandler_PatchUser_ErrValidation)
+	t.Run("SelfDisabled", testAdminHandler_PatchUser_WithResponse(t, http.StatusBadRequest, pulpe.ErrAdminSelfDisabled))
+	t.Run("NotFound", testAdminHandler_PatchUser_WithResponse(t, http.StatusNotFound, pulpe.ErrUserNotFound))
+	t.Run("Forbidden", testAdminHandler_PatchUser_WithResponse(t, http.StatusForbidden, pulpe.ErrAdminRequired))
+	t.Run("Unauthorized", testAdminHandler_PatchUser_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testAdminHandler_PatchUser_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testAdminHandler_PatchUser_OK(t *testing.T) {
+	c := mock.NewClient()
+
+	c.AdminService.SetUserDisabledFn = func(id string, disabled bool) (*pulpe.User, error) {
+		require.Equal(t, "1", id)
+		require.False(t, disabled)
+		return &pulpe.User{ID: "1", CreatedAt: mock.Now, FullName: "Jon Snow", Login: "jonsnow", Email: "jon.snow@wall.com"}, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PATCH", "/api/admin/users/1", bytes.NewReader([]byte(`{"disabled": false}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{
+		"id": "1",
+		"createdAt": "2000-01-01T00:00:00Z",
+		"fullName": "Jon Snow",
+		"login": "jonsnow",
+		"email": "jon.snow@wall.com"
+	}`, w.Body.String())
+}
+
+func testAdminHandler_PatchUser_ErrInvalidJSON(t *testing.T) {
+	h := newHandler(mock.NewClient())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PATCH", "/api/admin/users/1", bytes.NewReader([]byte(`{"disabled": `)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "invalid_json"}`, w.Body.String())
+}
+
+func testAdminHandler_PatchUser_ErrValidation(t *testing.T) {
+	h := newHandler(mock.NewClient())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PATCH", "/api/admin/users/1", bytes.NewReader([]byte(`{}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{
+		"err": "validation error",
+		"fields": {
+			"disabled": ["disabled is required"]
+		}
+	}`, w.Body.String())
+}
+
+func testAdminHandler_PatchUser_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.AdminService.SetUserDisabledFn = func(id string, disabled bool) (*pulpe.User, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PATCH", "/api/admin/users/1", bytes.NewReader([]byte(`{"disabled": true}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
+
+func TestAdminHandler_PasswordReset(t *testing.T) {
+	t.Run("OK", testAdminHandler_PasswordReset_OK)
+	t.Run("NotFound", testAdminHandler_PasswordReset_WithResponse(t, http.StatusNotFound, pulpe.ErrUserNotFound))
+	t.Run("Forbidden", testAdminHandler_PasswordReset_WithResponse(t, http.StatusForbidden, pulpe.ErrAdminRequired))
+	t.Run("Unauthorized", testAdminHandler_PasswordReset_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testAdminHandler_PasswordReset_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testAdminHandler_PasswordReset_OK(t *testing.T) {
+	c := mock.NewClient()
+
+	c.AdminService.ResetPasswordFn = func(id string) (string, error) {
+		require.Equal(t, "1", id)
+		return "s3cr3t", nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/admin/users/1/password-reset", nil)
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"password": "s3cr3t"}`, w.Body.String())
+}
+
+func testAdminHandler_PasswordReset_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.AdminService.ResetPasswordFn = func(id string) (string, error) {
+			return "", err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/admin/users/1/password-reset", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
+
+func TestAdminHandler_DeleteSessions(t *testing.T) {
+	t.Run("OK", testAdminHandler_DeleteSessions_WithResponse(t, http.StatusNoContent, nil))
+	t.Run("NotFound", testAdminHandler_DeleteSessions_WithResponse(t, http.StatusNotFound, pulpe.ErrUserNotFound))
+	t.Run("Forbidden", testAdminHandler_DeleteSessions_WithResponse(t, http.StatusForbidden, pulpe.ErrAdminRequired))
+	t.Run("Unauthorized", testAdminHandler_DeleteSessions_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testAdminHandler_DeleteSessions_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testAdminHandler_DeleteSessions_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.AdminService.RevokeSessionsFn = func(id string) error {
+			require.Equal(t, "1", id)
+			return err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/api/admin/users/1/sessions", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.True(t, c.AdminService.RevokeSessionsInvoked)
+	}
+}
+
+func TestAdminHandler_GetStats(t *testing.T) {
+	t.Run("OK", testAdminHandler_GetStats_OK)
+	t.Run("Forbidden", testAdminHandler_GetStats_WithResponse(t, http.StatusForbidden, pulpe.ErrAdminRequired))
+	t.Run("Unauthorized", testAdminHandler_GetStats_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testAdminHandler_GetStats_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testAdminHandler_GetStats_OK(t *testing.T) {
+	c := mock.NewClient()
+
+	c.AdminService.StatsFn = func() (*pulpe.InstanceStats, error) {
+		return &pulpe.InstanceStats{Users: 3, Boards: 4, Lists: 5, Cards: 6}, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/admin/stats", nil)
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"users": 3, "boards": 4, "lists": 5, "cards": 6}`, w.Body.String())
+}
+
+func testAdminHandler_GetStats_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.AdminService.StatsFn = func() (*pulpe.InstanceStats, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/admin/stats", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
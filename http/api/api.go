@@ -14,8 +14,18 @@ func Register(mux *pulpeHttp.ServeMux, connect pulpeHttp.Connector) {
 	router := httprouter.New()
 	registerBoardHandler(router, connect)
 	registerCardHandler(router, connect)
+	registerCardTemplateHandler(router, connect)
+	registerCustomFieldHandler(router, connect)
 	registerListHandler(router, connect)
 	registerUserHandler(router, connect)
+	registerOrganizationHandler(router, connect)
+	registerInvitationHandler(router, connect)
+	registerTwoFactorHandler(router, connect)
+	registerAdminHandler(router, connect)
+	registerImportHandler(router, connect)
+	registerSyncHandler(router, connect)
+	registerHistoryHandler(router, connect)
+	registerRevisionHandler(router, connect)
 
 	mux.Handle("/api/", router)
 }
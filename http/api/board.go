@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -23,9 +24,17 @@ func registerBoardHandler(router *httprouter.Router, c pulpeHttp.Connector) {
 
 	router.HandlerFunc("GET", "/api/user/boards", h.handleGetBoards)
 	router.HandlerFunc("POST", "/api/user/boards", h.handlePostBoard)
+	router.HandlerFunc("GET", "/api/user/templates", h.handleGetTemplates)
+	// boards are read by owner and slug, like their pages, and changed by id.
+	// GET /api/boards/:id/... would conflict with /api/boards/:owner/:board.
 	router.GET("/api/boards/:owner/:board", h.handleGetBoard)
 	router.DELETE("/api/boards/:id", h.handleDeleteBoard)
 	router.PATCH("/api/boards/:id", h.handlePatchBoard)
+	router.GET("/api/boards/:owner/:board/links", h.handleGetShareLinks)
+	router.POST("/api/boards/:boardID/links", h.handlePostShareLink)
+	router.DELETE("/api/boards/:id/links/:link", h.handleDeleteShareLink)
+	router.GET("/api/boards/:owner/:board/export", h.handleGetExport)
+	router.POST("/api/boards/:boardID/copy", h.handleCopyBoard)
 }
 
 // boardHandler represents an HTTP API handler for boards.
@@ -50,13 +59,17 @@ func (h *boardHandler) handlePostBoard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	board, err := session.BoardService().CreateBoard(cr)
 	switch err {
 	case nil:
 		encodeJSON(w, board, http.StatusCreated, h.logger)
+	case pulpe.ErrOrganizationNotFound:
+		Error(w, validation.AddError(nil, "organization", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrBoardNotFound:
+		Error(w, validation.AddError(nil, "templateID", err), http.StatusBadRequest, h.logger)
 	case pulpe.ErrUserAuthenticationFailed:
 		Error(w, err, http.StatusUnauthorized, h.logger)
 	default:
@@ -64,14 +77,92 @@ func (h *boardHandler) handlePostBoard(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handlePostBoard handles requests to create a new board.
+// handleCopyBoard handles requests to copy a board.
+func (h *boardHandler) handleCopyBoard(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req BoardCopyRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	bc, err := req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	board, err := session.BoardService().CopyBoard(ps.ByName("boardID"), bc)
+	switch err {
+	case nil:
+		encodeJSON(w, board, http.StatusCreated, h.logger)
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrOrganizationNotFound:
+		Error(w, validation.AddError(nil, "organization", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleGetTemplates handles requests to list the template boards.
+func (h *boardHandler) handleGetTemplates(w http.ResponseWriter, r *http.Request) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	boards, err := session.BoardService().Templates()
+	switch err {
+	case nil:
+		encodeJSON(w, boards, http.StatusOK, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleGetBoards handles requests to list the boards of the user.
+// Boards are paginated only if a limit is given, the URL of the next page
+// is then sent in the Link header when the page is full.
+// With a since parameter, the changes since the sync token are returned instead.
 func (h *boardHandler) handleGetBoards(w http.ResponseWriter, r *http.Request) {
-	session := h.connect(r)
+	q := r.URL.Query()
+	if _, ok := q["since"]; ok {
+		h.handleGetBoardsChanges(w, r, q.Get("since"))
+		return
+	}
+
+	var limit int
+	if v := q.Get("limit"); v != "" {
+		var err error
+		limit, err = parseLimit(nil, v)
+		if err != nil {
+			Error(w, err, http.StatusBadRequest, h.logger)
+			return
+		}
+	}
+
+	session := h.connect(w, r)
 	defer session.Close()
 
-	boards, err := session.BoardService().Boards()
+	boards, err := session.BoardService().Boards(pulpe.BoardPage(limit, q.Get("after")))
+	if validation.IsError(err) {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
 	switch err {
 	case nil:
+		if limit > 0 && len(boards) == limit {
+			setNextPage(w, r, boards[len(boards)-1].ID)
+		}
+
 		encodeJSON(w, boards, http.StatusOK, h.logger)
 	case pulpe.ErrUserAuthenticationFailed:
 		Error(w, err, http.StatusUnauthorized, h.logger)
@@ -80,19 +171,95 @@ func (h *boardHandler) handleGetBoards(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleGetBoardsChanges handles requests to sync the boards of the user.
+func (h *boardHandler) handleGetBoardsChanges(w http.ResponseWriter, r *http.Request, since string) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	changes, err := session.SyncService().BoardsChanges(since)
+	switch err {
+	case nil:
+		encodeJSON(w, changes, http.StatusOK, h.logger)
+	case pulpe.ErrSyncTokenInvalid:
+		Error(w, validation.AddError(nil, "since", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrSyncTokenExpired:
+		Error(w, err, http.StatusGone, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
 // handleGetBoard handles requests to fetch a single board.
+// With a since parameter, the changes since the sync token are returned instead.
 func (h *boardHandler) handleGetBoard(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	owner := ps.ByName("owner")
 	slug := ps.ByName("board")
 
-	session := h.connect(r)
+	if since, ok := r.URL.Query()["since"]; ok {
+		h.handleGetBoardChanges(w, r, ps, since[0])
+		return
+	}
+
+	format, err := boardFormat(r)
+	if err != nil {
+		Error(w, validation.AddError(nil, "format", err), http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
 	defer session.Close()
 
 	// Get the board and all of its lists and cards
-	board, err := session.BoardService().BoardByOwnerAndSlug(owner, slug, pulpe.WithLists(), pulpe.WithCards())
+	board, err := session.BoardService().BoardByOwnerAndSlug(
+		owner,
+		slug,
+		pulpe.WithLists(),
+		pulpe.WithCards(),
+		pulpe.WithShareToken(r.URL.Query().Get("token")),
+	)
 	switch err {
 	case nil:
-		encodeJSON(w, board, http.StatusOK, h.logger)
+		switch format {
+		case formatCSV:
+			encodeCSV(w, board, h.logger)
+		case formatMarkdown:
+			encodeMarkdown(w, board, h.logger)
+		default:
+			encodeJSON(w, board, http.StatusOK, h.logger)
+		}
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleGetBoardChanges handles requests to sync a single board.
+func (h *boardHandler) handleGetBoardChanges(w http.ResponseWriter, r *http.Request, ps httprouter.Params, since string) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	token := pulpe.WithShareToken(r.URL.Query().Get("token"))
+
+	board, err := session.BoardService().BoardByOwnerAndSlug(ps.ByName("owner"), ps.ByName("board"), token)
+	if err == nil {
+		var changes *pulpe.Changes
+		changes, err = session.SyncService().BoardChanges(board.ID, since, token)
+		if err == nil {
+			encodeJSON(w, changes, http.StatusOK, h.logger)
+			return
+		}
+	}
+
+	switch err {
+	case pulpe.ErrSyncTokenInvalid:
+		Error(w, validation.AddError(nil, "since", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrSyncTokenExpired:
+		Error(w, err, http.StatusGone, h.logger)
 	case pulpe.ErrBoardNotFound:
 		http.NotFound(w, r)
 	case pulpe.ErrUserAuthenticationFailed:
@@ -106,7 +273,7 @@ func (h *boardHandler) handleGetBoard(w http.ResponseWriter, r *http.Request, ps
 func (h *boardHandler) handleDeleteBoard(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	id := ps.ByName("id")
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	err := session.BoardService().DeleteBoard(id)
@@ -115,6 +282,8 @@ func (h *boardHandler) handleDeleteBoard(w http.ResponseWriter, r *http.Request,
 		w.WriteHeader(http.StatusNoContent)
 	case pulpe.ErrBoardNotFound:
 		http.NotFound(w, r)
+	case pulpe.ErrOrganizationForbidden:
+		Error(w, err, http.StatusForbidden, h.logger)
 	case pulpe.ErrUserAuthenticationFailed:
 		Error(w, err, http.StatusUnauthorized, h.logger)
 	default:
@@ -139,13 +308,71 @@ func (h *boardHandler) handlePatchBoard(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	board, err := session.BoardService().UpdateBoard(id, bu)
 	switch err {
 	case nil:
 		encodeJSON(w, board, http.StatusOK, h.logger)
+	case pulpe.ErrBoardInvalidVisibility:
+		Error(w, validation.AddError(nil, "visibility", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrOrganizationForbidden, pulpe.ErrBoardForbidden:
+		Error(w, err, http.StatusForbidden, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleGetShareLinks handles requests to list the share links of a board.
+func (h *boardHandler) handleGetShareLinks(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	board, err := session.BoardService().BoardByOwnerAndSlug(ps.ByName("owner"), ps.ByName("board"))
+	if err == nil {
+		var links []*pulpe.BoardShareLink
+		links, err = session.BoardService().ShareLinks(board.ID)
+		if err == nil {
+			encodeJSON(w, links, http.StatusOK, h.logger)
+			return
+		}
+	}
+
+	switch err {
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrOrganizationForbidden:
+		Error(w, err, http.StatusForbidden, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleGetExport handles requests to download the archive of a board:
+// GET /api/boards/:owner/:board/export, the board is addressed like on GET /api/boards/:owner/:board.
+func (h *boardHandler) handleGetExport(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	board, err := session.BoardService().BoardByOwnerAndSlug(ps.ByName("owner"), ps.ByName("board"))
+	if err == nil {
+		var archive *pulpe.BoardArchive
+		archive, err = session.BoardService().ExportBoard(board.ID)
+		if err == nil {
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, board.Slug))
+			encodeJSON(w, archive, http.StatusOK, h.logger)
+			return
+		}
+	}
+
+	switch err {
 	case pulpe.ErrBoardNotFound:
 		http.NotFound(w, r)
 	case pulpe.ErrUserAuthenticationFailed:
@@ -155,27 +382,97 @@ func (h *boardHandler) handlePatchBoard(w http.ResponseWriter, r *http.Request,
 	}
 }
 
+// handlePostShareLink handles requests to create a share link.
+func (h *boardHandler) handlePostShareLink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	link, err := session.BoardService().CreateShareLink(ps.ByName("boardID"))
+	switch err {
+	case nil:
+		encodeJSON(w, link, http.StatusCreated, h.logger)
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrOrganizationForbidden:
+		Error(w, err, http.StatusForbidden, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleDeleteShareLink handles requests to revoke a share link.
+func (h *boardHandler) handleDeleteShareLink(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	err := session.BoardService().RevokeShareLink(ps.ByName("id"), ps.ByName("link"))
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case pulpe.ErrBoardNotFound, pulpe.ErrBoardShareLinkNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrOrganizationForbidden:
+		Error(w, err, http.StatusForbidden, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
 // BoardCreateRequest is used to create a board.
 type BoardCreateRequest struct {
-	Name string `json:"name" valid:"required,stringlength(1|64)"`
+	Name         string `json:"name" valid:"required,stringlength(1|64)"`
+	Organization string `json:"organization" valid:"stringlength(1|64)"`
+	TemplateID   string `json:"templateID"`
 }
 
 // Validate board creation payload.
 func (b *BoardCreateRequest) Validate() (*pulpe.BoardCreation, error) {
 	b.Name = strings.TrimSpace(b.Name)
+	b.Organization = strings.TrimSpace(b.Organization)
 	err := validation.Validate(b)
 	if err != nil {
 		return nil, err
 	}
 
 	return &pulpe.BoardCreation{
-		Name: b.Name,
+		Name:         b.Name,
+		Organization: b.Organization,
+		TemplateID:   b.TemplateID,
+	}, nil
+}
+
+// BoardCopyRequest is used to copy a board.
+type BoardCopyRequest struct {
+	Name         string `json:"name" valid:"stringlength(1|64)"`
+	Organization string `json:"organization" valid:"stringlength(1|64)"`
+	WithCards    bool   `json:"withCards"`
+}
+
+// Validate board copy payload.
+func (b *BoardCopyRequest) Validate() (*pulpe.BoardCopy, error) {
+	b.Name = strings.TrimSpace(b.Name)
+	b.Organization = strings.TrimSpace(b.Organization)
+	err := validation.Validate(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pulpe.BoardCopy{
+		Name:         b.Name,
+		Organization: b.Organization,
+		WithCards:    b.WithCards,
 	}, nil
 }
 
 // BoardUpdateRequest is used to update a board.
 type BoardUpdateRequest struct {
-	Name *string `json:"name" valid:"stringlength(1|64)"`
+	Name       *string `json:"name" valid:"stringlength(1|64)"`
+	Visibility *string `json:"visibility"`
+	Template   *bool   `json:"template"`
 }
 
 // Validate board update payload.
@@ -194,6 +491,8 @@ func (b *BoardUpdateRequest) Validate() (*pulpe.BoardUpdate, error) {
 	}
 
 	return &pulpe.BoardUpdate{
-		Name: b.Name,
+		Name:       b.Name,
+		Visibility: b.Visibility,
+		Template:   b.Template,
 	}, nil
 }
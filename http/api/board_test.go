@@ -2,14 +2,17 @@ package api_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/blankrobot/pulpe"
 	"github.com/blankrobot/pulpe/http/api"
 	"github.com/blankrobot/pulpe/mock"
+	"github.com/blankrobot/pulpe/validation"
 	"github.com/stretchr/testify/require"
 )
 
@@ -24,7 +27,7 @@ func testBoardHandler_Boards_OK(t *testing.T) {
 	h := newHandler(c)
 
 	// Mock service.
-	c.BoardService.BoardsFn = func() ([]*pulpe.Board, error) {
+	c.BoardService.BoardsFn = func(...pulpe.BoardListOption) ([]*pulpe.Board, error) {
 		return []*pulpe.Board{
 			&pulpe.Board{ID: "id", Name: "name", Slug: "slug", CreatedAt: mock.Now, UpdatedAt: &mock.Now, Owner: &pulpe.User{ID: "123"}},
 		}, nil
@@ -54,7 +57,7 @@ func testBoardHandler_Boards_InternalError(t *testing.T) {
 	h := newHandler(c)
 
 	// Mock service.
-	c.BoardService.BoardsFn = func() ([]*pulpe.Board, error) {
+	c.BoardService.BoardsFn = func(...pulpe.BoardListOption) ([]*pulpe.Board, error) {
 		return nil, errors.New("unexpected error")
 	}
 
@@ -70,7 +73,7 @@ func testBoardHandler_Boards_AuthenticationFailed(t *testing.T) {
 	c := mock.NewClient()
 	h := newHandler(c)
 
-	c.BoardService.BoardsFn = func() ([]*pulpe.Board, error) {
+	c.BoardService.BoardsFn = func(...pulpe.BoardListOption) ([]*pulpe.Board, error) {
 		return nil, pulpe.ErrUserAuthenticationFailed
 	}
 
@@ -85,6 +88,10 @@ func TestBoardHandler_CreateBoard(t *testing.T) {
 	t.Run("OK", testBoardHandler_CreateBoard_OK)
 	t.Run("ErrInvalidJSON", testBoardHandler_CreateBoard_ErrInvalidJSON)
 	t.Run("ValidationError", testBoardHandler_CreateBoard_ValidationError)
+	t.Run("Organization", testBoardHandler_CreateBoard_Organization)
+	t.Run("Template", testBoardHandler_CreateBoard_Template)
+	t.Run("Unknown template", testBoardHandler_CreateBoard_WithResponse(t, http.StatusBadRequest, pulpe.ErrBoardNotFound))
+	t.Run("Unknown organization", testBoardHandler_CreateBoard_WithResponse(t, http.StatusBadRequest, pulpe.ErrOrganizationNotFound))
 	t.Run("ErrInternal", testBoardHandler_CreateBoard_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
 	t.Run("Authfailed", testBoardHandler_CreateBoard_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
 }
@@ -139,6 +146,62 @@ func testBoardHandler_CreateBoard_ValidationError(t *testing.T) {
 	require.JSONEq(t, `{"err": "validation error", "fields": {"name": ["non zero value required"]}}`, w.Body.String())
 }
 
+func testBoardHandler_CreateBoard_Organization(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	// Mock service.
+	c.BoardService.CreateBoardFn = func(c *pulpe.BoardCreation) (*pulpe.Board, error) {
+		require.Equal(t, "name", c.Name)
+		require.Equal(t, "winterfell", c.Organization)
+
+		return &pulpe.Board{
+			ID:           "123",
+			CreatedAt:    mock.Now,
+			Name:         c.Name,
+			Slug:         "name",
+			Organization: &pulpe.Organization{ID: "456", Slug: "winterfell", Name: "Winterfell", CreatedAt: mock.Now},
+		}, nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/user/boards", bytes.NewReader([]byte(`{
+    "name": "name",
+    "organization": " winterfell "
+  }`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	date, _ := mock.Now.MarshalJSON()
+	require.JSONEq(t, `{
+		"id": "123",
+		"name": "name",
+		"slug": "name",
+		"createdAt": `+string(date)+`,
+		"organization": {"id": "456", "slug": "winterfell", "name": "Winterfell", "createdAt": `+string(date)+`}
+	}`, w.Body.String())
+}
+
+func testBoardHandler_CreateBoard_Template(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.BoardService.CreateBoardFn = func(c *pulpe.BoardCreation) (*pulpe.Board, error) {
+		require.Equal(t, "sprint 2", c.Name)
+		require.Equal(t, "XXX", c.TemplateID)
+
+		return &pulpe.Board{ID: "123", Name: c.Name, Slug: "sprint-2"}, nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/user/boards", bytes.NewReader([]byte(`{
+    "name": "sprint 2",
+    "templateID": "XXX"
+  }`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.True(t, c.BoardService.CreateBoardInvoked)
+}
+
 func testBoardHandler_CreateBoard_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
 	return func(t *testing.T) {
 		c := mock.NewClient()
@@ -162,6 +225,9 @@ func TestBoardHandler_Board(t *testing.T) {
 	t.Run("Not found", testBoardHandler_Board_NotFound)
 	t.Run("Internal error", testBoardHandler_Board_InternalError)
 	t.Run("Auth failed", testBoardHandler_Board_AuthenticationFailed)
+	t.Run("CSV", testBoardHandler_Board_CSV)
+	t.Run("Markdown", testBoardHandler_Board_Markdown)
+	t.Run("Unsupported format", testBoardHandler_Board_UnsupportedFormat)
 }
 
 func testBoardHandler_Board_OK(t *testing.T) {
@@ -172,7 +238,14 @@ func testBoardHandler_Board_OK(t *testing.T) {
 	c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
 		require.Equal(t, "user", owner)
 		require.Equal(t, "XXX", slug)
-		require.Len(t, options, 2)
+		require.Len(t, options, 3)
+
+		var opts pulpe.BoardGetOptions
+		for _, o := range options {
+			o(&opts)
+		}
+		require.Equal(t, "secret", opts.ShareToken)
+
 		return &pulpe.Board{
 			ID:    "XXX",
 			Owner: &pulpe.User{ID: "123"},
@@ -181,7 +254,7 @@ func testBoardHandler_Board_OK(t *testing.T) {
 
 	// Retrieve Board.
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("GET", "/api/boards/user/XXX", nil)
+	r, _ := http.NewRequest("GET", "/api/boards/user/XXX?token=secret", nil)
 	h.ServeHTTP(w, r)
 	require.Equal(t, http.StatusOK, w.Code)
 	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
@@ -240,9 +313,95 @@ func testBoardHandler_Board_AuthenticationFailed(t *testing.T) {
 	require.True(t, c.BoardService.BoardByOwnerAndSlugInvoked)
 }
 
+// newBoardWithContent returns a board whose lists and cards are not sorted by position.
+func newBoardWithContent() *pulpe.Board {
+	updatedAt := mock.Now.Add(time.Hour)
+
+	return &pulpe.Board{
+		ID:   "board1",
+		Name: "Roadmap",
+		Slug: "roadmap",
+		Lists: []*pulpe.List{
+			{ID: "list2", Name: "Done", Position: 2},
+			{ID: "list1", Name: "Todo", Position: 1},
+		},
+		Cards: []*pulpe.Card{
+			{ID: "card3", ListID: "list2", Name: "Release", Position: 1, CreatedAt: mock.Now},
+			{ID: "card2", ListID: "list1", Name: "=SUM(A1)", Position: 2.5, CreatedAt: mock.Now},
+			{ID: "card1", ListID: "list1", Name: "Write docs", Description: "In **markdown**\nwith, commas", Position: 1, CreatedAt: mock.Now, UpdatedAt: &updatedAt},
+		},
+	}
+}
+
+func testBoardHandler_Board_CSV(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+		return newBoardWithContent(), nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/boards/user/roadmap?format=csv", nil)
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, `attachment; filename="roadmap.csv"`, w.Header().Get("Content-Disposition"))
+	require.Equal(t, `list,position,name,description,createdAt,updatedAt
+Todo,1,Write docs,"In **markdown**
+with, commas",2000-01-01T00:00:00Z,2000-01-01T01:00:00Z
+Todo,2.5,'=SUM(A1),,2000-01-01T00:00:00Z,
+Done,1,Release,,2000-01-01T00:00:00Z,
+`, w.Body.String())
+}
+
+func testBoardHandler_Board_Markdown(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+		return newBoardWithContent(), nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/boards/user/roadmap", nil)
+	r.Header.Set("Accept", "text/markdown, */*;q=0.8")
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, `# Roadmap
+
+## Todo
+
+- Write docs
+
+  In **markdown**
+  with, commas
+
+- =SUM(A1)
+
+## Done
+
+- Release
+`, w.Body.String())
+}
+
+func testBoardHandler_Board_UnsupportedFormat(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/boards/user/roadmap?format=pdf", nil)
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "validation error", "fields": {"format": ["unsupported format"]}}`, w.Body.String())
+	require.False(t, c.BoardService.BoardByOwnerAndSlugInvoked)
+}
+
 func TestBoardHandler_DeleteBoard(t *testing.T) {
 	t.Run("OK", testBoardHandler_DeleteBoard_OK)
 	t.Run("Not found", testBoardHandler_DeleteBoard_NotFound)
+	t.Run("Forbidden", testBoardHandler_DeleteBoard_Forbidden)
 	t.Run("Internal error on delete board", testBoardHandler_DeleteBoard_InternalErrorOnDeleteBoard)
 }
 
@@ -281,6 +440,21 @@ func testBoardHandler_DeleteBoard_NotFound(t *testing.T) {
 	require.True(t, c.BoardService.DeleteBoardInvoked)
 }
 
+func testBoardHandler_DeleteBoard_Forbidden(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.BoardService.DeleteBoardFn = func(id string) error {
+		return pulpe.ErrOrganizationForbidden
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("DELETE", "/api/boards/XXX", nil)
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.JSONEq(t, `{"err": "organization admin role required"}`, w.Body.String())
+}
+
 func testBoardHandler_DeleteBoard_InternalErrorOnDeleteBoard(t *testing.T) {
 	c := mock.NewClient()
 	h := newHandler(c)
@@ -302,6 +476,8 @@ func TestBoardHandler_UpdateBoard(t *testing.T) {
 	t.Run("ErrInvalidJSON", testBoardHandler_UpdateBoard_ErrInvalidJSON)
 	t.Run("Not found", testBoardHandler_UpdateBoard_NotFound)
 	t.Run("Validation error", testBoardHandler_UpdateBoard_ValidationError)
+	t.Run("Visibility", testBoardHandler_UpdateBoard_Visibility)
+	t.Run("Template", testBoardHandler_UpdateBoard_Template)
 	t.Run("Internal error", testBoardHandler_UpdateBoard_InternalError)
 }
 
@@ -376,6 +552,68 @@ func testBoardHandler_UpdateBoard_ValidationError(t *testing.T) {
 	require.False(t, c.BoardService.UpdateBoardInvoked)
 }
 
+func testBoardHandler_UpdateBoard_Visibility(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.BoardService.UpdateBoardFn = func(id string, u *pulpe.BoardUpdate) (*pulpe.Board, error) {
+		require.Nil(t, u.Name)
+		require.NotNil(t, u.Visibility)
+
+		switch *u.Visibility {
+		case pulpe.BoardVisibilityPublic:
+			return &pulpe.Board{ID: id, Visibility: *u.Visibility}, nil
+		case pulpe.BoardVisibilityPrivate:
+			return nil, pulpe.ErrOrganizationForbidden
+		default:
+			return nil, pulpe.ErrBoardInvalidVisibility
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PATCH", "/api/boards/XXX", bytes.NewReader([]byte(`{"visibility": "public"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"id": "XXX", "name": "", "slug": "", "createdAt": "0001-01-01T00:00:00Z", "visibility": "public"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PATCH", "/api/boards/XXX", bytes.NewReader([]byte(`{"visibility": "private"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PATCH", "/api/boards/XXX", bytes.NewReader([]byte(`{"visibility": "secret"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "validation error", "fields": {"visibility": ["invalid board visibility"]}}`, w.Body.String())
+}
+
+func testBoardHandler_UpdateBoard_Template(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.BoardService.UpdateBoardFn = func(id string, u *pulpe.BoardUpdate) (*pulpe.Board, error) {
+		require.NotNil(t, u.Template)
+
+		if !*u.Template {
+			return nil, pulpe.ErrBoardForbidden
+		}
+
+		return &pulpe.Board{ID: id, Template: true}, nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PATCH", "/api/boards/XXX", bytes.NewReader([]byte(`{"template": true}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"id": "XXX", "name": "", "slug": "", "createdAt": "0001-01-01T00:00:00Z", "template": true}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PATCH", "/api/boards/XXX", bytes.NewReader([]byte(`{"template": false}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
 func testBoardHandler_UpdateBoard_InternalError(t *testing.T) {
 	c := mock.NewClient()
 	h := newHandler(c)
@@ -393,6 +631,217 @@ func testBoardHandler_UpdateBoard_InternalError(t *testing.T) {
 	require.True(t, c.BoardService.UpdateBoardInvoked)
 }
 
+func TestBoardHandler_CopyBoard(t *testing.T) {
+	t.Run("OK", testBoardHandler_CopyBoard_OK)
+	t.Run("ErrInvalidJSON", testBoardHandler_CopyBoard_ErrInvalidJSON)
+	t.Run("Not found", testBoardHandler_CopyBoard_WithResponse(t, http.StatusNotFound, pulpe.ErrBoardNotFound))
+	t.Run("Unknown organization", testBoardHandler_CopyBoard_WithResponse(t, http.StatusBadRequest, pulpe.ErrOrganizationNotFound))
+	t.Run("Internal error", testBoardHandler_CopyBoard_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+	t.Run("Auth failed", testBoardHandler_CopyBoard_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+}
+
+func testBoardHandler_CopyBoard_OK(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.BoardService.CopyBoardFn = func(id string, bc *pulpe.BoardCopy) (*pulpe.Board, error) {
+		require.Equal(t, "XXX", id)
+		require.Equal(t, "sprint 2", bc.Name)
+		require.Empty(t, bc.Organization)
+		require.True(t, bc.WithCards)
+
+		return &pulpe.Board{ID: "YYY", CreatedAt: mock.Now, Name: bc.Name, Slug: "sprint-2"}, nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/boards/XXX/copy", bytes.NewReader([]byte(`{
+		"name": " sprint 2 ",
+		"withCards": true
+	}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	date, _ := mock.Now.MarshalJSON()
+	require.JSONEq(t, `{
+		"id": "YYY",
+		"name": "sprint 2",
+		"slug": "sprint-2",
+		"createdAt": `+string(date)+`
+	}`, w.Body.String())
+}
+
+func testBoardHandler_CopyBoard_ErrInvalidJSON(t *testing.T) {
+	h := newHandler(mock.NewClient())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/boards/XXX/copy", bytes.NewReader([]byte(`{"name": "sp`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "invalid_json"}`, w.Body.String())
+}
+
+func testBoardHandler_CopyBoard_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.CopyBoardFn = func(id string, bc *pulpe.BoardCopy) (*pulpe.Board, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/boards/XXX/copy", bytes.NewReader([]byte(`{}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.True(t, c.BoardService.CopyBoardInvoked)
+	}
+}
+
+func TestBoardHandler_Templates(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.TemplatesFn = func() ([]*pulpe.Board, error) {
+			return []*pulpe.Board{{ID: "XXX", Name: "sprint", Slug: "sprint", Template: true}}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/user/templates", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `[{"id": "XXX", "name": "sprint", "slug": "sprint", "createdAt": "0001-01-01T00:00:00Z", "template": true}]`, w.Body.String())
+	})
+
+	t.Run("Auth failed", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.TemplatesFn = func() ([]*pulpe.Board, error) {
+			return nil, pulpe.ErrUserAuthenticationFailed
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/user/templates", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestBoardHandler_ShareLinks(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			require.Equal(t, "jonsnow", owner)
+			require.Equal(t, "wall", slug)
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.BoardService.ShareLinksFn = func(boardID string) ([]*pulpe.BoardShareLink, error) {
+			require.Equal(t, "XXX", boardID)
+			return []*pulpe.BoardShareLink{
+				{ID: "123", Token: "secret", CreatedAt: mock.Now},
+			}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/jonsnow/wall/links", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		date, _ := mock.Now.MarshalJSON()
+		require.JSONEq(t, `[{"id": "123", "token": "secret", "createdAt": `+string(date)+`}]`, w.Body.String())
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.BoardService.ShareLinksFn = func(boardID string) ([]*pulpe.BoardShareLink, error) {
+			return nil, pulpe.ErrOrganizationForbidden
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/winterfell/crypt/links", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/jonsnow/wall/links", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.False(t, c.BoardService.ShareLinksInvoked)
+	})
+}
+
+func TestBoardHandler_CreateShareLink(t *testing.T) {
+	t.Run("OK", testBoardHandler_CreateShareLink_WithResponse(t, http.StatusCreated, nil))
+	t.Run("Not found", testBoardHandler_CreateShareLink_WithResponse(t, http.StatusNotFound, pulpe.ErrBoardNotFound))
+	t.Run("Forbidden", testBoardHandler_CreateShareLink_WithResponse(t, http.StatusForbidden, pulpe.ErrOrganizationForbidden))
+	t.Run("Auth failed", testBoardHandler_CreateShareLink_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+}
+
+func testBoardHandler_CreateShareLink_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.CreateShareLinkFn = func(boardID string) (*pulpe.BoardShareLink, error) {
+			require.Equal(t, "XXX", boardID)
+			if err != nil {
+				return nil, err
+			}
+			return &pulpe.BoardShareLink{ID: "123", Token: "secret", CreatedAt: mock.Now}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/boards/XXX/links", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.True(t, c.BoardService.CreateShareLinkInvoked)
+	}
+}
+
+func TestBoardHandler_RevokeShareLink(t *testing.T) {
+	t.Run("OK", testBoardHandler_RevokeShareLink_WithResponse(t, http.StatusNoContent, nil))
+	t.Run("Board not found", testBoardHandler_RevokeShareLink_WithResponse(t, http.StatusNotFound, pulpe.ErrBoardNotFound))
+	t.Run("Link not found", testBoardHandler_RevokeShareLink_WithResponse(t, http.StatusNotFound, pulpe.ErrBoardShareLinkNotFound))
+	t.Run("Forbidden", testBoardHandler_RevokeShareLink_WithResponse(t, http.StatusForbidden, pulpe.ErrOrganizationForbidden))
+	t.Run("ErrInternal", testBoardHandler_RevokeShareLink_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testBoardHandler_RevokeShareLink_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.RevokeShareLinkFn = func(boardID, linkID string) error {
+			require.Equal(t, "XXX", boardID)
+			require.Equal(t, "123", linkID)
+			return err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/api/boards/XXX/links/123", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.True(t, c.BoardService.RevokeShareLinkInvoked)
+	}
+}
+
 func TestBoardCreateRequest_Validate(t *testing.T) {
 	t.Run("Empty", func(t *testing.T) {
 		var b api.BoardCreateRequest
@@ -454,3 +903,291 @@ func TestBoardUpdateRequest_Validate(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestBoardHandler_GetExport(t *testing.T) {
+	t.Run("OK", testBoardHandler_GetExport_OK)
+	t.Run("NotFound", testBoardHandler_GetExport_WithResponse(t, http.StatusNotFound, pulpe.ErrBoardNotFound))
+	t.Run("Unauthorized", testBoardHandler_GetExport_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testBoardHandler_GetExport_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testBoardHandler_GetExport_OK(t *testing.T) {
+	c := mock.NewClient()
+
+	c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+		require.Equal(t, "jonsnow", owner)
+		require.Equal(t, "roadmap", slug)
+		return &pulpe.Board{ID: "board1", Slug: "roadmap"}, nil
+	}
+
+	c.BoardService.ExportBoardFn = func(id string) (*pulpe.BoardArchive, error) {
+		require.Equal(t, "board1", id)
+		return &pulpe.BoardArchive{
+			Version:    pulpe.BoardArchiveVersion,
+			ExportedAt: mock.Now,
+			Board:      &pulpe.ArchivedBoard{ID: "board1", CreatedAt: mock.Now, Name: "Roadmap", Slug: "roadmap"},
+			Lists:      []*pulpe.ArchivedList{{ID: "list1", CreatedAt: mock.Now, Name: "Todo", Slug: "todo", Position: 1}},
+			Cards:      []*pulpe.ArchivedCard{{ID: "card1", CreatedAt: mock.Now, ListID: "list1", Name: "Docs", Slug: "docs", Description: "MD", Position: 2}},
+		}, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/api/boards/jonsnow/roadmap/export", nil)
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `attachment; filename="roadmap.json"`, w.Header().Get("Content-Disposition"))
+	require.JSONEq(t, `{
+		"version": 1,
+		"exportedAt": "2000-01-01T00:00:00Z",
+		"board": {"id": "board1", "createdAt": "2000-01-01T00:00:00Z", "name": "Roadmap", "slug": "roadmap"},
+		"lists": [{"id": "list1", "createdAt": "2000-01-01T00:00:00Z", "name": "Todo", "slug": "todo", "position": 1}],
+		"cards": [{"id": "card1", "createdAt": "2000-01-01T00:00:00Z", "listID": "list1", "name": "Docs", "slug": "docs", "description": "MD", "position": 2}]
+	}`, w.Body.String())
+}
+
+func testBoardHandler_GetExport_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return &pulpe.Board{ID: "board1", Slug: "roadmap"}, nil
+		}
+
+		c.BoardService.ExportBoardFn = func(id string) (*pulpe.BoardArchive, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/jonsnow/roadmap/export", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
+
+func TestBoardHandler_Boards_Page(t *testing.T) {
+	t.Run("Full page", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardsFn = func(options ...pulpe.BoardListOption) ([]*pulpe.Board, error) {
+			var opts pulpe.BoardListOptions
+			for _, o := range options {
+				o(&opts)
+			}
+
+			require.Equal(t, 2, opts.Limit)
+			require.Equal(t, "CCC", opts.After)
+			return []*pulpe.Board{{ID: "DDD"}, {ID: "EEE"}}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/user/boards?limit=2&after=CCC", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, `</api/user/boards?after=EEE&limit=2>; rel="next"`, w.Header().Get("Link"))
+	})
+
+	t.Run("No limit", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardsFn = func(options ...pulpe.BoardListOption) ([]*pulpe.Board, error) {
+			var opts pulpe.BoardListOptions
+			for _, o := range options {
+				o(&opts)
+			}
+
+			require.Zero(t, opts.Limit)
+			return []*pulpe.Board{{ID: "DDD"}}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/user/boards", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, w.Header().Get("Link"))
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/user/boards?limit=0", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.False(t, c.BoardService.BoardsInvoked)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardsFn = func(options ...pulpe.BoardListOption) ([]*pulpe.Board, error) {
+			return nil, validation.AddError(nil, "after", pulpe.ErrBoardInvalidCursor)
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/user/boards?after=bad", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.JSONEq(t, `{"err": "validation error", "fields": {"after": ["invalid board cursor"]}}`, w.Body.String())
+	})
+}
+
+func TestBoardHandler_BoardsChanges(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.SyncService.BoardsChangesFn = func(token string) (*pulpe.Changes, error) {
+			require.Equal(t, "TTT", token)
+			return &pulpe.Changes{
+				Boards:  []*pulpe.Board{{ID: "XXX", Name: "name", Slug: "slug", CreatedAt: mock.Now}},
+				Deleted: []*pulpe.Tombstone{{Kind: pulpe.TombstoneBoard, ID: "YYY", BoardID: "YYY", DeletedAt: mock.Now}},
+				Token:   "UUU",
+			}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/user/boards?since=TTT", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.False(t, c.BoardService.BoardsInvoked)
+		date, _ := mock.Now.MarshalJSON()
+		require.JSONEq(t, `{
+			"boards": [{"id": "XXX", "name": "name", "slug": "slug", "createdAt": `+string(date)+`}],
+			"deleted": [{"kind": "board", "id": "YYY", "boardID": "YYY", "deletedAt": `+string(date)+`}],
+			"token": "UUU"
+		}`, w.Body.String())
+	})
+
+	t.Run("Full sync", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.SyncService.BoardsChangesFn = func(token string) (*pulpe.Changes, error) {
+			require.Empty(t, token)
+			return &pulpe.Changes{Token: "UUU"}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/user/boards?since=", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, c.SyncService.BoardsChangesInvoked)
+	})
+
+	for _, test := range []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"Invalid token", pulpe.ErrSyncTokenInvalid, http.StatusBadRequest},
+		{"Expired token", pulpe.ErrSyncTokenExpired, http.StatusGone},
+		{"Auth failed", pulpe.ErrUserAuthenticationFailed, http.StatusUnauthorized},
+		{"Internal error", errors.New("unexpected error"), http.StatusInternalServerError},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := mock.NewClient()
+			h := newHandler(c)
+
+			c.SyncService.BoardsChangesFn = func(token string) (*pulpe.Changes, error) {
+				return nil, test.err
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/api/user/boards?since=TTT", nil)
+			h.ServeHTTP(w, r)
+			require.Equal(t, test.status, w.Code)
+		})
+	}
+}
+
+func TestBoardHandler_BoardChanges(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			require.Equal(t, "jonsnow", owner)
+			require.Equal(t, "wall", slug)
+
+			var opts pulpe.BoardGetOptions
+			for _, o := range options {
+				o(&opts)
+			}
+
+			require.False(t, opts.WithCards)
+			require.Equal(t, "secret", opts.ShareToken)
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.SyncService.BoardChangesFn = func(boardID, token string, options ...pulpe.BoardGetOption) (*pulpe.Changes, error) {
+			require.Equal(t, "XXX", boardID)
+			require.Equal(t, "TTT", token)
+
+			var opts pulpe.BoardGetOptions
+			for _, o := range options {
+				o(&opts)
+			}
+
+			require.Equal(t, "secret", opts.ShareToken)
+			return &pulpe.Changes{
+				Boards:  []*pulpe.Board{{ID: "XXX"}},
+				Cards:   []*pulpe.Card{{ID: "CCC", BoardID: "XXX"}},
+				Deleted: []*pulpe.Tombstone{{Kind: pulpe.TombstoneList, ID: "LLL", BoardID: "XXX"}},
+				Token:   "UUU",
+			}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/jonsnow/wall?since=TTT&token=secret&format=csv", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+		var changes pulpe.Changes
+		err := json.NewDecoder(w.Body).Decode(&changes)
+		require.NoError(t, err)
+		require.Equal(t, "UUU", changes.Token)
+		require.Len(t, changes.Cards, 1)
+		require.Equal(t, "LLL", changes.Deleted[0].ID)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/jonsnow/wall?since=TTT", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.False(t, c.SyncService.BoardChangesInvoked)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.SyncService.BoardChangesFn = func(boardID, token string, options ...pulpe.BoardGetOption) (*pulpe.Changes, error) {
+			return nil, pulpe.ErrSyncTokenExpired
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/jonsnow/wall?since=TTT", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusGone, w.Code)
+	})
+}
@@ -3,10 +3,14 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blankrobot/pulpe"
 	pulpeHttp "github.com/blankrobot/pulpe/http"
@@ -25,6 +29,8 @@ func registerCardHandler(router *httprouter.Router, c pulpeHttp.Connector) {
 	router.GET("/api/cards/:id", h.handleGetCard)
 	router.DELETE("/api/cards/:id", h.handleDeleteCard)
 	router.PATCH("/api/cards/:id", h.handlePatchCard)
+	router.POST("/api/cards/:id/copy", h.handleCopyCard)
+	router.GET("/api/boards/:owner/:board/cards", h.handleGetCards)
 }
 
 // cardHandler represents an HTTP API handler for cards.
@@ -48,17 +54,27 @@ func (h *cardHandler) handlePostCard(w http.ResponseWriter, r *http.Request, ps
 		return
 	}
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	listID := ps.ByName("listID")
 
 	card, err := session.CardService().CreateCard(listID, cc)
+	if validation.IsError(err) {
+		// custom field values are validated against the fields of the board
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
 	switch err {
 	case nil:
 		encodeJSON(w, card, http.StatusCreated, h.logger)
 	case pulpe.ErrListNotFound:
 		Error(w, err, http.StatusNotFound, h.logger)
+	case pulpe.ErrCardTemplateNotFound:
+		Error(w, validation.AddError(nil, "templateID", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrCardInvalidAssignee:
+		Error(w, validation.AddError(nil, "assignees", err), http.StatusBadRequest, h.logger)
 	case pulpe.ErrUserAuthenticationFailed:
 		Error(w, err, http.StatusUnauthorized, h.logger)
 	default:
@@ -70,7 +86,7 @@ func (h *cardHandler) handlePostCard(w http.ResponseWriter, r *http.Request, ps
 func (h *cardHandler) handleGetCard(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	id := ps.ByName("id")
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	card, err := session.CardService().Card(id)
@@ -90,7 +106,7 @@ func (h *cardHandler) handleGetCard(w http.ResponseWriter, r *http.Request, ps h
 func (h *cardHandler) handleDeleteCard(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	id := ps.ByName("id")
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	err := session.CardService().DeleteCard(id)
@@ -123,10 +139,16 @@ func (h *cardHandler) handlePatchCard(w http.ResponseWriter, r *http.Request, ps
 		return
 	}
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	card, err := session.CardService().UpdateCard(id, cu)
+	if validation.IsError(err) {
+		// custom field values are validated against the fields of the board
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
 	switch err {
 	case nil:
 		encodeJSON(w, card, http.StatusOK, h.logger)
@@ -134,6 +156,8 @@ func (h *cardHandler) handlePatchCard(w http.ResponseWriter, r *http.Request, ps
 		Error(w, err, http.StatusNotFound, h.logger)
 	case pulpe.ErrListNotFound:
 		Error(w, validation.AddError(nil, "listID", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrCardInvalidAssignee:
+		Error(w, validation.AddError(nil, "assignees", err), http.StatusBadRequest, h.logger)
 	case pulpe.ErrUserAuthenticationFailed:
 		Error(w, err, http.StatusUnauthorized, h.logger)
 	default:
@@ -141,11 +165,188 @@ func (h *cardHandler) handlePatchCard(w http.ResponseWriter, r *http.Request, ps
 	}
 }
 
+// handleCopyCard handles requests to copy a card to a list.
+func (h *cardHandler) handleCopyCard(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	var req CardCopyRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	cc, err := req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	card, err := session.CardService().CopyCard(id, cc)
+	switch err {
+	case nil:
+		encodeJSON(w, card, http.StatusCreated, h.logger)
+	case pulpe.ErrCardNotFound:
+		Error(w, err, http.StatusNotFound, h.logger)
+	case pulpe.ErrListNotFound:
+		Error(w, validation.AddError(nil, "listID", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleGetCards handles requests to list the cards of a board, filtered, sorted and paginated:
+// GET /api/boards/:owner/:board/cards, the board is addressed like on GET /api/boards/:owner/:board.
+// The URL of the next page is sent in the Link header when the page is full.
+func (h *cardHandler) handleGetCards(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	options, err := cardListOptions(r)
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	token := r.URL.Query().Get("token")
+	board, err := session.BoardService().BoardByOwnerAndSlug(ps.ByName("owner"), ps.ByName("board"), pulpe.WithShareToken(token))
+	if err == nil {
+		var cards []*pulpe.Card
+		cards, err = session.CardService().CardsByBoard(board.ID, append(options, pulpe.SharedWith(token))...)
+		if err == nil {
+			var opts pulpe.CardListOptions
+			for _, o := range options {
+				o(&opts)
+			}
+
+			if len(cards) > 0 && len(cards) == opts.Limit {
+				setNextPage(w, r, opts.Cursor(cards[len(cards)-1]))
+			}
+
+			encodeJSON(w, cards, http.StatusOK, h.logger)
+			return
+		}
+	}
+
+	if validation.IsError(err) {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	switch err {
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// Limits of the pages of cards and boards.
+const (
+	defaultCardPageSize = 100
+	maxPageSize         = 500
+)
+
+// cardListOptions parses the filters, the sort order and the page of a list of cards.
+// The sort order is position, due, updated or field.<id>, prefixed by - to reverse it.
+func cardListOptions(r *http.Request) ([]pulpe.CardListOption, error) {
+	var err error
+	q := r.URL.Query()
+
+	options := []pulpe.CardListOption{
+		pulpe.InList(q.Get("list")),
+		pulpe.WithLabel(q.Get("label")),
+		pulpe.AssignedTo(q.Get("assignee")),
+		pulpe.MatchingText(strings.TrimSpace(q.Get("q"))),
+	}
+
+	for k := range q {
+		if strings.HasPrefix(k, "field.") {
+			options = append(options, pulpe.WithFieldValue(strings.TrimPrefix(k, "field."), q.Get(k)))
+		}
+	}
+
+	var dates [3]*time.Time
+	for i, name := range []string{"dueAfter", "dueBefore", "updatedSince"} {
+		if v := q.Get(name); v != "" {
+			t, perr := time.Parse(time.RFC3339, v)
+			if perr != nil {
+				err = validation.AddError(err, name, errors.New("should be a RFC 3339 date"))
+				continue
+			}
+			dates[i] = &t
+		}
+	}
+
+	options = append(options, pulpe.DueBetween(dates[0], dates[1]))
+	if dates[2] != nil {
+		options = append(options, pulpe.UpdatedSince(*dates[2]))
+	}
+
+	if sort := q.Get("sort"); sort != "" {
+		descending := strings.HasPrefix(sort, "-")
+		sort = strings.TrimPrefix(sort, "-")
+
+		switch {
+		case strings.HasPrefix(sort, "field."):
+			options = append(options, pulpe.SortByField(strings.TrimPrefix(sort, "field."), descending))
+		case sort == pulpe.CardSortPosition, sort == pulpe.CardSortDue, sort == pulpe.CardSortUpdated:
+			options = append(options, pulpe.SortBy(sort, descending))
+		default:
+			err = validation.AddError(err, "sort", errors.New("unsupported sort order"))
+		}
+	}
+
+	limit := defaultCardPageSize
+	if v := q.Get("limit"); v != "" {
+		limit, err = parseLimit(err, v)
+	}
+
+	options = append(options, pulpe.Page(limit, q.Get("after")))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// parseLimit parses the size of a page and adds its error to err.
+func parseLimit(err error, v string) (int, error) {
+	limit, perr := strconv.Atoi(v)
+	if perr != nil || limit < 1 || limit > maxPageSize {
+		return 0, validation.AddError(err, "limit", fmt.Errorf("should be between 1 and %d", maxPageSize))
+	}
+
+	return limit, err
+}
+
+// setNextPage sends the URL of the page following the cursor in the Link header.
+func setNextPage(w http.ResponseWriter, r *http.Request, after string) {
+	q := r.URL.Query()
+	q.Set("after", after)
+	next := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
+
 // CardCreateRequest is the payload sent to create a card.
 type CardCreateRequest struct {
-	Name        string  `json:"name" valid:"required,stringlength(1|256)"`
-	Description string  `json:"description" valid:"stringlength(1|100000)"`
-	Position    float64 `json:"position"`
+	Name        string                 `json:"name" valid:"required,stringlength(1|256)"`
+	Description string                 `json:"description" valid:"stringlength(1|100000)"`
+	Position    float64                `json:"position"`
+	Labels      []string               `json:"labels"`
+	Checklist   []*pulpe.ChecklistItem `json:"checklist"`
+	TemplateID  string                 `json:"templateID"`
+	Due         *string                `json:"due"`
+	Assignees   []string               `json:"assignees"`
+	Fields      map[string]interface{} `json:"fields"`
 }
 
 // Validate list creation payload.
@@ -160,23 +361,45 @@ func (c *CardCreateRequest) Validate() (*pulpe.CardCreation, error) {
 		verr = validation.AddError(verr, "position", errors.New("position should be greater than zero"))
 	}
 
+	verr = validateLabels(verr, c.Labels)
+	verr = validateChecklist(verr, c.Checklist)
+	verr = validateAssignees(verr, c.Assignees)
+	verr = validateFieldValues(verr, c.Fields)
+
+	due, verr := parseDue(verr, c.Due)
 	if verr != nil {
 		return nil, verr
 	}
 
+	if due != nil && due.IsZero() {
+		due = nil
+	}
+
 	return &pulpe.CardCreation{
 		Name:        c.Name,
 		Description: c.Description,
 		Position:    c.Position,
+		Labels:      c.Labels,
+		Checklist:   c.Checklist,
+		TemplateID:  c.TemplateID,
+		Due:         due,
+		Assignees:   c.Assignees,
+		Fields:      c.Fields,
 	}, nil
 }
 
 // CardUpdateRequest is the payload sent to update a card.
 type CardUpdateRequest struct {
-	Name        *string  `json:"name" valid:"stringlength(1|256)"`
-	Description *string  `json:"description" valid:"stringlength(1|100000)"`
-	Position    *float64 `json:"position"`
-	ListID      *string  `json:"listID"`
+	Name        *string                `json:"name" valid:"stringlength(1|256)"`
+	Description *string                `json:"description" valid:"stringlength(1|100000)"`
+	Position    *float64               `json:"position"`
+	ListID      *string                `json:"listID"`
+	Labels      []string               `json:"labels"`
+	Checklist   []*pulpe.ChecklistItem `json:"checklist"`
+	// Due is removed if empty.
+	Due       *string                `json:"due"`
+	Assignees []string               `json:"assignees"`
+	Fields    map[string]interface{} `json:"fields"`
 }
 
 // Validate card update payload.
@@ -198,6 +421,12 @@ func (c *CardUpdateRequest) Validate() (*pulpe.CardUpdate, error) {
 		err = validation.AddError(err, "position", errors.New("position should be greater than zero"))
 	}
 
+	err = validateLabels(err, c.Labels)
+	err = validateChecklist(err, c.Checklist)
+	err = validateAssignees(err, c.Assignees)
+	err = validateFieldValues(err, c.Fields)
+
+	due, err := parseDue(err, c.Due)
 	if err != nil {
 		return nil, err
 	}
@@ -207,5 +436,114 @@ func (c *CardUpdateRequest) Validate() (*pulpe.CardUpdate, error) {
 		Description: c.Description,
 		Position:    c.Position,
 		ListID:      c.ListID,
+		Labels:      c.Labels,
+		Checklist:   c.Checklist,
+		Due:         due,
+		Assignees:   c.Assignees,
+		Fields:      c.Fields,
+	}, nil
+}
+
+// CardCopyRequest is the payload sent to copy a card.
+type CardCopyRequest struct {
+	ListID   string   `json:"listID" valid:"required"`
+	Position *float64 `json:"position"`
+}
+
+// Validate card copy payload.
+func (c *CardCopyRequest) Validate() (*pulpe.CardCopy, error) {
+	err := validation.Validate(c)
+
+	if c.Position != nil && *c.Position < 0 {
+		err = validation.AddError(err, "position", errors.New("position should be greater than zero"))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pulpe.CardCopy{
+		ListID:   c.ListID,
+		Position: c.Position,
 	}, nil
 }
+
+// Limits of the labels and checklists of cards and card templates.
+const (
+	maxLabels          = 20
+	maxLabelLength     = 32
+	maxChecklistItems  = 100
+	maxChecklistLength = 256
+	maxAssignees       = 20
+)
+
+// parseDue parses a RFC 3339 due date and adds its error to err.
+// An empty date is returned as a zero time.
+func parseDue(err error, due *string) (*time.Time, error) {
+	if due == nil {
+		return nil, err
+	}
+
+	var t time.Time
+	if *due != "" {
+		var perr error
+		t, perr = time.Parse(time.RFC3339, *due)
+		if perr != nil {
+			return nil, validation.AddError(err, "due", errors.New("should be a RFC 3339 date"))
+		}
+	}
+
+	return &t, err
+}
+
+// validateAssignees adds the errors of the assignees of a card to err.
+// Assignees are checked against the members of the board by the card service.
+func validateAssignees(err error, assignees []string) error {
+	if len(assignees) > maxAssignees {
+		return validation.AddError(err, "assignees", fmt.Errorf("should not contain more than %d assignees", maxAssignees))
+	}
+
+	for _, a := range assignees {
+		if a == "" {
+			return validation.AddError(err, "assignees", errors.New("assignees should not be empty"))
+		}
+	}
+
+	return err
+}
+
+// validateLabels trims the labels and adds their errors to err.
+func validateLabels(err error, labels []string) error {
+	if len(labels) > maxLabels {
+		return validation.AddError(err, "labels", fmt.Errorf("should not contain more than %d labels", maxLabels))
+	}
+
+	for i := range labels {
+		labels[i] = strings.TrimSpace(labels[i])
+		if labels[i] == "" || len(labels[i]) > maxLabelLength {
+			return validation.AddError(err, "labels", fmt.Errorf("labels should contain 1 to %d characters", maxLabelLength))
+		}
+	}
+
+	return err
+}
+
+// validateChecklist trims the names of the checklist items and adds their errors to err.
+func validateChecklist(err error, checklist []*pulpe.ChecklistItem) error {
+	if len(checklist) > maxChecklistItems {
+		return validation.AddError(err, "checklist", fmt.Errorf("should not contain more than %d items", maxChecklistItems))
+	}
+
+	for _, item := range checklist {
+		if item == nil {
+			return validation.AddError(err, "checklist", errors.New("items should not be null"))
+		}
+
+		item.Name = strings.TrimSpace(item.Name)
+		if item.Name == "" || len(item.Name) > maxChecklistLength {
+			return validation.AddError(err, "checklist", fmt.Errorf("item names should contain 1 to %d characters", maxChecklistLength))
+		}
+	}
+
+	return err
+}
@@ -5,7 +5,10 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/blankrobot/pulpe"
 	"github.com/blankrobot/pulpe/http/api"
@@ -18,6 +21,8 @@ func TestCardHandler_CreateCard(t *testing.T) {
 	t.Run("ErrInvalidJSON", testCardHandler_CreateCard_ErrInvalidJSON)
 	t.Run("ErrValidation", testCardHandler_CreateCard_ErrValidation)
 	t.Run("NotFound", testCardHandler_CreateCard_ListNotFound)
+	t.Run("Template", testCardHandler_CreateCard_Template)
+	t.Run("TemplateNotFound", testCardHandler_CreateCard_WithResponse(t, http.StatusBadRequest, pulpe.ErrCardTemplateNotFound))
 	t.Run("ErrInternal", testCardHandler_CreateCard_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
 	t.Run("ErrAuthFailed", testCardHandler_CreateCard_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
 }
@@ -100,6 +105,46 @@ func testCardHandler_CreateCard_ListNotFound(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func testCardHandler_CreateCard_Template(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.CardService.CreateCardFn = func(listID string, cc *pulpe.CardCreation) (*pulpe.Card, error) {
+		require.Equal(t, "XXX", cc.TemplateID)
+		require.Equal(t, []string{"urgent"}, cc.Labels)
+		require.Nil(t, cc.Checklist)
+		return &pulpe.Card{
+			ID:        "123",
+			ListID:    listID,
+			Name:      cc.Name,
+			Labels:    cc.Labels,
+			Checklist: []*pulpe.ChecklistItem{{Name: "Reproduce"}},
+		}, nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/lists/456/cards", bytes.NewReader([]byte(`{
+		"name": "name",
+		"labels": ["urgent"],
+		"templateID": "XXX"
+	}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.JSONEq(t, `{
+		"id": "123",
+		"listID": "456",
+		"boardID": "",
+		"ownerID": "",
+		"name": "name",
+		"slug": "",
+		"description": "",
+		"position": 0,
+		"createdAt": "0001-01-01T00:00:00Z",
+		"labels": ["urgent"],
+		"checklist": [{"name": "Reproduce", "done": false}]
+	}`, w.Body.String())
+}
+
 func testCardHandler_CreateCard_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
 	return func(t *testing.T) {
 		c := mock.NewClient()
@@ -434,6 +479,103 @@ func testCardHandler_UpdateCard_InternalError(t *testing.T) {
 	require.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
+func TestCardHandler_CopyCard(t *testing.T) {
+	t.Run("OK", testCardHandler_CopyCard_OK)
+	t.Run("ErrInvalidJSON", testCardHandler_CopyCard_ErrInvalidJSON)
+	t.Run("ErrValidation", testCardHandler_CopyCard_ErrValidation)
+	t.Run("Not found", testCardHandler_CopyCard_WithResponse(t, http.StatusNotFound, pulpe.ErrCardNotFound))
+	t.Run("List not found", testCardHandler_CopyCard_WithResponse(t, http.StatusBadRequest, pulpe.ErrListNotFound))
+	t.Run("Internal error", testCardHandler_CopyCard_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+	t.Run("Auth failed", testCardHandler_CopyCard_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+}
+
+func testCardHandler_CopyCard_OK(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.CardService.CopyCardFn = func(id string, cc *pulpe.CardCopy) (*pulpe.Card, error) {
+		require.Equal(t, "123", id)
+		require.Equal(t, "456", cc.ListID)
+		require.Nil(t, cc.Position)
+		return &pulpe.Card{
+			ID:          "321",
+			ListID:      "456",
+			BoardID:     "789",
+			OwnerID:     "678",
+			Name:        "name",
+			Slug:        "name",
+			Description: "description",
+			Position:    1,
+			CreatedAt:   mock.Now,
+		}, nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/cards/123/copy", bytes.NewReader([]byte(`{
+		"listID": "456"
+	}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.True(t, c.CardService.CopyCardInvoked)
+
+	date, _ := mock.Now.MarshalJSON()
+	require.JSONEq(t, `{
+		"id": "321",
+		"listID": "456",
+		"boardID": "789",
+		"ownerID": "678",
+		"name": "name",
+		"slug": "name",
+		"description": "description",
+		"position": 1,
+		"createdAt": `+string(date)+`
+	}`, w.Body.String())
+}
+
+func testCardHandler_CopyCard_ErrInvalidJSON(t *testing.T) {
+	h := newHandler(mock.NewClient())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/cards/123/copy", bytes.NewReader([]byte(`{
+		"listID": "45
+	}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "invalid_json"}`, w.Body.String())
+}
+
+func testCardHandler_CopyCard_ErrValidation(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/cards/123/copy", bytes.NewReader([]byte(`{
+		"position": -1
+	}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.False(t, c.CardService.CopyCardInvoked)
+}
+
+func testCardHandler_CopyCard_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.CardService.CopyCardFn = func(id string, cc *pulpe.CardCopy) (*pulpe.Card, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/cards/123/copy", bytes.NewReader([]byte(`{
+			"listID": "456",
+			"position": 2
+		}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
+
 func TestCardCreateRequest_Validate(t *testing.T) {
 	t.Run("Empty", func(t *testing.T) {
 		var cc api.CardCreateRequest
@@ -457,6 +599,15 @@ func TestCardCreateRequest_Validate(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("EmptyLabel", func(t *testing.T) {
+		cc := api.CardCreateRequest{
+			Name:   "Card name",
+			Labels: []string{"  "},
+		}
+		_, err := cc.Validate()
+		require.Error(t, err)
+	})
+
 	t.Run("NegativePosition", func(t *testing.T) {
 		cc := api.CardCreateRequest{
 			Name:     "Card name",
@@ -465,6 +616,23 @@ func TestCardCreateRequest_Validate(t *testing.T) {
 		_, err := cc.Validate()
 		require.Error(t, err)
 	})
+
+	t.Run("Due", func(t *testing.T) {
+		due := "2017-05-01T10:00:00+02:00"
+		cc := api.CardCreateRequest{
+			Name:      "Card name",
+			Due:       &due,
+			Assignees: []string{"XXX"},
+		}
+		c, err := cc.Validate()
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2017, 5, 1, 8, 0, 0, 0, time.UTC), c.Due.UTC())
+		require.Equal(t, []string{"XXX"}, c.Assignees)
+
+		due = "tomorrow"
+		_, err = cc.Validate()
+		require.Error(t, err)
+	})
 }
 
 func TestCardUpdate_Validate(t *testing.T) {
@@ -519,4 +687,149 @@ func TestCardUpdate_Validate(t *testing.T) {
 		_, err := cc.Validate()
 		require.Error(t, err)
 	})
+
+	t.Run("RemoveDue", func(t *testing.T) {
+		cc := api.CardUpdateRequest{
+			Due:       &emptyName,
+			Assignees: []string{},
+		}
+		cu, err := cc.Validate()
+		require.NoError(t, err)
+		require.True(t, cu.Due.IsZero())
+		require.Equal(t, []string{}, cu.Assignees)
+	})
+
+	t.Run("EmptyAssignee", func(t *testing.T) {
+		cc := api.CardUpdateRequest{
+			Assignees: []string{""},
+		}
+		_, err := cc.Validate()
+		require.Error(t, err)
+	})
+}
+
+func TestCardHandler_CardsByBoard_Filters(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.CardService.CardsByBoardFn = func(boardID string, options ...pulpe.CardListOption) ([]*pulpe.Card, error) {
+			var opts pulpe.CardListOptions
+			for _, o := range options {
+				o(&opts)
+			}
+
+			require.Equal(t, "LLL", opts.ListID)
+			require.Equal(t, "bug", opts.Label)
+			require.Equal(t, "UUU", opts.Assignee)
+			require.Equal(t, "crash", opts.Text)
+			require.Equal(t, time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC), opts.DueAfter.UTC())
+			require.Equal(t, time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC), opts.DueBefore.UTC())
+			require.Equal(t, time.Date(2017, 4, 1, 0, 0, 0, 0, time.UTC), opts.UpdatedSince.UTC())
+			require.Equal(t, pulpe.CardSortDue, opts.Sort)
+			require.True(t, opts.Descending)
+			require.Equal(t, 2, opts.Limit)
+			require.Equal(t, "CCC", opts.After)
+			return []*pulpe.Card{{ID: "AAA"}, {ID: "BBB"}}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/red/board/cards?list=LLL&label=bug&assignee=UUU&q=+crash+"+
+			"&dueAfter=2017-05-01T00:00:00Z&dueBefore=2017-06-01T00:00:00Z&updatedSince=2017-04-01T00:00:00Z"+
+			"&sort=-due&limit=2&after=CCC", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		opts := pulpe.CardListOptions{Sort: pulpe.CardSortDue}
+		next, err := url.Parse(strings.TrimSuffix(strings.TrimPrefix(w.Header().Get("Link"), "<"), `>; rel="next"`))
+		require.NoError(t, err)
+		require.Equal(t, "/api/boards/red/board/cards", next.Path)
+		require.Equal(t, opts.Cursor(&pulpe.Card{ID: "BBB"}), next.Query().Get("after"))
+		require.Equal(t, "bug", next.Query().Get("label"))
+	})
+
+	t.Run("Last page", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.CardService.CardsByBoardFn = func(boardID string, options ...pulpe.CardListOption) ([]*pulpe.Card, error) {
+			return []*pulpe.Card{{ID: "AAA"}}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/red/board/cards?sort=position", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, w.Header().Get("Link"))
+	})
+
+	t.Run("Share token", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			var opts pulpe.BoardGetOptions
+			for _, o := range options {
+				o(&opts)
+			}
+
+			require.Equal(t, "TTT", opts.ShareToken)
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.CardService.CardsByBoardFn = func(boardID string, options ...pulpe.CardListOption) ([]*pulpe.Card, error) {
+			var opts pulpe.CardListOptions
+			for _, o := range options {
+				o(&opts)
+			}
+
+			require.Equal(t, "TTT", opts.ShareToken)
+			return []*pulpe.Card{{ID: "AAA"}}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/red/board/cards?token=TTT", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Validation error", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/red/board/cards?dueAfter=yesterday&limit=1000&sort=name", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.JSONEq(t, `{"err": "validation error", "fields": {
+			"dueAfter": ["should be a RFC 3339 date"],
+			"limit": ["should be between 1 and 500"],
+			"sort": ["unsupported sort order"]
+		}}`, w.Body.String())
+		require.False(t, c.CardService.CardsByBoardInvoked)
+	})
+}
+
+func TestCardHandler_UpdateCard_InvalidAssignee(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.CardService.UpdateCardFn = func(id string, u *pulpe.CardUpdate) (*pulpe.Card, error) {
+		require.Equal(t, []string{"UUU"}, u.Assignees)
+		return nil, pulpe.ErrCardInvalidAssignee
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PATCH", "/api/cards/AAA", bytes.NewReader([]byte(`{"assignees": ["UUU"]}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "validation error", "fields": {"assignees": ["assignee is not a member of the board"]}}`, w.Body.String())
 }
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/julienschmidt/httprouter"
+)
+
+// registerCardTemplateHandler register the cardTemplateHandler routes.
+func registerCardTemplateHandler(router *httprouter.Router, c pulpeHttp.Connector) {
+	h := cardTemplateHandler{
+		connect: c,
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	router.GET("/api/boards/:owner/:board/card-templates", h.handleGetCardTemplates)
+	router.POST("/api/boards/:boardID/card-templates", h.handlePostCardTemplate)
+	router.PATCH("/api/boards/:id/card-templates/:template", h.handlePatchCardTemplate)
+	router.DELETE("/api/boards/:id/card-templates/:template", h.handleDeleteCardTemplate)
+}
+
+// cardTemplateHandler represents an HTTP API handler for card templates.
+type cardTemplateHandler struct {
+	connect pulpeHttp.Connector
+	logger  *log.Logger
+}
+
+// handleGetCardTemplates handles requests to list the card templates of a board.
+func (h *cardTemplateHandler) handleGetCardTemplates(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	board, err := session.BoardService().BoardByOwnerAndSlug(ps.ByName("owner"), ps.ByName("board"))
+	if err == nil {
+		var templates []*pulpe.CardTemplate
+		templates, err = session.BoardService().CardTemplates(board.ID)
+		if err == nil {
+			encodeJSON(w, templates, http.StatusOK, h.logger)
+			return
+		}
+	}
+
+	switch err {
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handlePostCardTemplate handles requests to create a card template.
+func (h *cardTemplateHandler) handlePostCardTemplate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req CardTemplateCreateRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	tc, err := req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	template, err := session.BoardService().CreateCardTemplate(ps.ByName("boardID"), tc)
+	switch err {
+	case nil:
+		encodeJSON(w, template, http.StatusCreated, h.logger)
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handlePatchCardTemplate handles requests to update a card template.
+func (h *cardTemplateHandler) handlePatchCardTemplate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req CardTemplateUpdateRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	tu, err := req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	template, err := session.BoardService().UpdateCardTemplate(ps.ByName("id"), ps.ByName("template"), tu)
+	switch err {
+	case nil:
+		encodeJSON(w, template, http.StatusOK, h.logger)
+	case pulpe.ErrBoardNotFound, pulpe.ErrCardTemplateNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleDeleteCardTemplate handles requests to delete a card template.
+func (h *cardTemplateHandler) handleDeleteCardTemplate(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	err := session.BoardService().DeleteCardTemplate(ps.ByName("id"), ps.ByName("template"))
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case pulpe.ErrBoardNotFound, pulpe.ErrCardTemplateNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// CardTemplateCreateRequest is the payload sent to create a card template.
+type CardTemplateCreateRequest struct {
+	Name        string                 `json:"name" valid:"required,stringlength(1|64)"`
+	Description string                 `json:"description" valid:"stringlength(1|100000)"`
+	Labels      []string               `json:"labels"`
+	Checklist   []*pulpe.ChecklistItem `json:"checklist"`
+}
+
+// Validate card template creation payload.
+func (c *CardTemplateCreateRequest) Validate() (*pulpe.CardTemplateCreation, error) {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Description = strings.TrimSpace(c.Description)
+
+	err := validation.Validate(c)
+	err = validateLabels(err, c.Labels)
+	err = validateChecklist(err, c.Checklist)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pulpe.CardTemplateCreation{
+		Name:        c.Name,
+		Description: c.Description,
+		Labels:      c.Labels,
+		Checklist:   c.Checklist,
+	}, nil
+}
+
+// CardTemplateUpdateRequest is the payload sent to update a card template.
+type CardTemplateUpdateRequest struct {
+	Name        *string                `json:"name" valid:"stringlength(1|64)"`
+	Description *string                `json:"description" valid:"stringlength(1|100000)"`
+	Labels      []string               `json:"labels"`
+	Checklist   []*pulpe.ChecklistItem `json:"checklist"`
+}
+
+// Validate card template update payload.
+func (c *CardTemplateUpdateRequest) Validate() (*pulpe.CardTemplateUpdate, error) {
+	if c.Name != nil {
+		*c.Name = strings.TrimSpace(*c.Name)
+	}
+
+	if c.Description != nil {
+		*c.Description = strings.TrimSpace(*c.Description)
+	}
+
+	err := validation.Validate(c)
+	if c.Name != nil && *c.Name == "" {
+		err = validation.AddError(err, "name", errors.New("name should not be empty"))
+	}
+
+	err = validateLabels(err, c.Labels)
+	err = validateChecklist(err, c.Checklist)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pulpe.CardTemplateUpdate{
+		Name:        c.Name,
+		Description: c.Description,
+		Labels:      c.Labels,
+		Checklist:   c.Checklist,
+	}, nil
+}
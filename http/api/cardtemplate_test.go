@@ -0,0 +1,232 @@
+package api_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/http/api"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardTemplateHandler_CardTemplates(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			require.Equal(t, "red", owner)
+			require.Equal(t, "board", slug)
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.BoardService.CardTemplatesFn = func(boardID string) ([]*pulpe.CardTemplate, error) {
+			require.Equal(t, "XXX", boardID)
+			return []*pulpe.CardTemplate{{
+				ID:          "YYY",
+				CreatedAt:   mock.Now,
+				Name:        "Bug report",
+				Description: "Steps",
+				Labels:      []string{"bug"},
+				Checklist:   []*pulpe.ChecklistItem{{Name: "Reproduce"}},
+			}}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/red/board/card-templates", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		date, _ := mock.Now.MarshalJSON()
+		require.JSONEq(t, `[{
+			"id": "YYY",
+			"createdAt": `+string(date)+`,
+			"name": "Bug report",
+			"description": "Steps",
+			"labels": ["bug"],
+			"checklist": [{"name": "Reproduce", "done": false}]
+		}]`, w.Body.String())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return &pulpe.Board{ID: "XXX", ReadOnly: true}, nil
+		}
+
+		c.BoardService.CardTemplatesFn = func(boardID string) ([]*pulpe.CardTemplate, error) {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/red/board/card-templates", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestCardTemplateHandler_CreateCardTemplate(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.CreateCardTemplateFn = func(boardID string, tc *pulpe.CardTemplateCreation) (*pulpe.CardTemplate, error) {
+			require.Equal(t, "XXX", boardID)
+			require.Equal(t, "Bug report", tc.Name)
+			require.Equal(t, []string{"bug"}, tc.Labels)
+			require.Equal(t, []*pulpe.ChecklistItem{{Name: "Reproduce"}}, tc.Checklist)
+			return &pulpe.CardTemplate{ID: "YYY", Name: tc.Name, Labels: tc.Labels, Checklist: tc.Checklist}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/boards/XXX/card-templates", bytes.NewReader([]byte(`{
+			"name": " Bug report ",
+			"labels": [" bug "],
+			"checklist": [{"name": "Reproduce"}]
+		}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.True(t, c.BoardService.CreateCardTemplateInvoked)
+	})
+
+	t.Run("Validation error", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/boards/XXX/card-templates", bytes.NewReader([]byte(`{
+			"name": "Bug report",
+			"checklist": [{"name": " "}]
+		}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.False(t, c.BoardService.CreateCardTemplateInvoked)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.CreateCardTemplateFn = func(boardID string, tc *pulpe.CardTemplateCreation) (*pulpe.CardTemplate, error) {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/boards/XXX/card-templates", bytes.NewReader([]byte(`{"name": "Bug report"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestCardTemplateHandler_UpdateCardTemplate(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.UpdateCardTemplateFn = func(boardID, templateID string, u *pulpe.CardTemplateUpdate) (*pulpe.CardTemplate, error) {
+			require.Equal(t, "XXX", boardID)
+			require.Equal(t, "YYY", templateID)
+			require.Equal(t, "Feature", *u.Name)
+			require.Nil(t, u.Description)
+			require.Equal(t, []string{}, u.Labels)
+			require.Nil(t, u.Checklist)
+			return &pulpe.CardTemplate{ID: templateID, Name: *u.Name}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PATCH", "/api/boards/XXX/card-templates/YYY", bytes.NewReader([]byte(`{
+			"name": "Feature",
+			"labels": []
+		}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.UpdateCardTemplateFn = func(boardID, templateID string, u *pulpe.CardTemplateUpdate) (*pulpe.CardTemplate, error) {
+			return nil, pulpe.ErrCardTemplateNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PATCH", "/api/boards/XXX/card-templates/YYY", bytes.NewReader([]byte(`{"name": "Feature"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestCardTemplateHandler_DeleteCardTemplate(t *testing.T) {
+	t.Run("OK", testCardTemplateHandler_DeleteCardTemplate_WithResponse(t, http.StatusNoContent, nil))
+	t.Run("Not found", testCardTemplateHandler_DeleteCardTemplate_WithResponse(t, http.StatusNotFound, pulpe.ErrCardTemplateNotFound))
+	t.Run("Internal error", testCardTemplateHandler_DeleteCardTemplate_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+	t.Run("Auth failed", testCardTemplateHandler_DeleteCardTemplate_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+}
+
+func testCardTemplateHandler_DeleteCardTemplate_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.DeleteCardTemplateFn = func(boardID, templateID string) error {
+			require.Equal(t, "XXX", boardID)
+			require.Equal(t, "YYY", templateID)
+			return err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/api/boards/XXX/card-templates/YYY", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
+
+func TestCardTemplateCreateRequest_Validate(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		var req api.CardTemplateCreateRequest
+		_, err := req.Validate()
+		require.Error(t, err)
+	})
+
+	t.Run("Too many labels", func(t *testing.T) {
+		req := api.CardTemplateCreateRequest{
+			Name:   "name",
+			Labels: make([]string, 21),
+		}
+		for i := range req.Labels {
+			req.Labels[i] = "label"
+		}
+
+		_, err := req.Validate()
+		require.Error(t, err)
+	})
+
+	t.Run("Null checklist item", func(t *testing.T) {
+		req := api.CardTemplateCreateRequest{
+			Name:      "name",
+			Checklist: []*pulpe.ChecklistItem{nil},
+		}
+
+		_, err := req.Validate()
+		require.Error(t, err)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		req := api.CardTemplateCreateRequest{
+			Name:      "name",
+			Labels:    []string{" bug "},
+			Checklist: []*pulpe.ChecklistItem{{Name: " Reproduce "}},
+		}
+
+		tc, err := req.Validate()
+		require.NoError(t, err)
+		require.Equal(t, []string{"bug"}, tc.Labels)
+		require.Equal(t, "Reproduce", tc.Checklist[0].Name)
+	})
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/julienschmidt/httprouter"
+)
+
+// Limits of the custom fields of a board.
+const (
+	maxCustomFields       = 50
+	maxCustomFieldOptions = 50
+	maxOptionLength       = 64
+)
+
+// registerCustomFieldHandler register the customFieldHandler routes.
+// Custom fields are listed with their board.
+func registerCustomFieldHandler(router *httprouter.Router, c pulpeHttp.Connector) {
+	h := customFieldHandler{
+		connect: c,
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	router.POST("/api/boards/:boardID/custom-fields", h.handlePostCustomField)
+	router.PATCH("/api/boards/:id/custom-fields/:field", h.handlePatchCustomField)
+	router.DELETE("/api/boards/:id/custom-fields/:field", h.handleDeleteCustomField)
+}
+
+// customFieldHandler represents an HTTP API handler for custom fields.
+type customFieldHandler struct {
+	connect pulpeHttp.Connector
+	logger  *log.Logger
+}
+
+// handlePostCustomField handles requests to create a custom field.
+func (h *customFieldHandler) handlePostCustomField(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req CustomFieldCreateRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	fc, err := req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	field, err := session.BoardService().CreateCustomField(ps.ByName("boardID"), fc)
+	switch err {
+	case nil:
+		encodeJSON(w, field, http.StatusCreated, h.logger)
+	case pulpe.ErrCustomFieldInvalidType:
+		Error(w, validation.AddError(nil, "type", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handlePatchCustomField handles requests to update a custom field.
+func (h *customFieldHandler) handlePatchCustomField(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req CustomFieldUpdateRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	fu, err := req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	field, err := session.BoardService().UpdateCustomField(ps.ByName("id"), ps.ByName("field"), fu)
+	switch err {
+	case nil:
+		encodeJSON(w, field, http.StatusOK, h.logger)
+	case pulpe.ErrBoardNotFound, pulpe.ErrCustomFieldNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleDeleteCustomField handles requests to delete a custom field.
+func (h *customFieldHandler) handleDeleteCustomField(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	err := session.BoardService().DeleteCustomField(ps.ByName("id"), ps.ByName("field"))
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case pulpe.ErrBoardNotFound, pulpe.ErrCustomFieldNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// CustomFieldCreateRequest is the payload sent to create a custom field.
+type CustomFieldCreateRequest struct {
+	Name    string   `json:"name" valid:"required,stringlength(1|64)"`
+	Type    string   `json:"type" valid:"required"`
+	Options []string `json:"options"`
+}
+
+// Validate custom field creation payload.
+func (c *CustomFieldCreateRequest) Validate() (*pulpe.CustomFieldCreation, error) {
+	c.Name = strings.TrimSpace(c.Name)
+
+	err := validation.Validate(c)
+	if c.Type == pulpe.CustomFieldDropdown {
+		if len(c.Options) == 0 {
+			err = validation.AddError(err, "options", errors.New("dropdown fields should have options"))
+		}
+
+		err = validateOptions(err, c.Options)
+	} else if len(c.Options) > 0 {
+		err = validation.AddError(err, "options", errors.New("only dropdown fields have options"))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pulpe.CustomFieldCreation{
+		Name:    c.Name,
+		Type:    c.Type,
+		Options: c.Options,
+	}, nil
+}
+
+// CustomFieldUpdateRequest is the payload sent to update a custom field.
+type CustomFieldUpdateRequest struct {
+	Name    *string  `json:"name" valid:"stringlength(1|64)"`
+	Options []string `json:"options"`
+}
+
+// Validate custom field update payload.
+func (c *CustomFieldUpdateRequest) Validate() (*pulpe.CustomFieldUpdate, error) {
+	if c.Name != nil {
+		*c.Name = strings.TrimSpace(*c.Name)
+	}
+
+	err := validation.Validate(c)
+	if c.Name != nil && *c.Name == "" {
+		err = validation.AddError(err, "name", errors.New("name should not be empty"))
+	}
+
+	if c.Options != nil && len(c.Options) == 0 {
+		err = validation.AddError(err, "options", errors.New("dropdown fields should have options"))
+	}
+
+	err = validateOptions(err, c.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pulpe.CustomFieldUpdate{
+		Name:    c.Name,
+		Options: c.Options,
+	}, nil
+}
+
+// validateOptions trims the options of a dropdown field and adds their errors to err.
+func validateOptions(err error, options []string) error {
+	if len(options) > maxCustomFieldOptions {
+		return validation.AddError(err, "options", fmt.Errorf("should not contain more than %d options", maxCustomFieldOptions))
+	}
+
+	seen := make(map[string]bool, len(options))
+	for i := range options {
+		options[i] = strings.TrimSpace(options[i])
+		if options[i] == "" || len(options[i]) > maxOptionLength {
+			return validation.AddError(err, "options", fmt.Errorf("options should contain 1 to %d characters", maxOptionLength))
+		}
+
+		if seen[options[i]] {
+			return validation.AddError(err, "options", errors.New("options should be unique"))
+		}
+		seen[options[i]] = true
+	}
+
+	return err
+}
+
+// validateFieldValues adds the errors of the custom field values of a card to err.
+// The values are checked against the fields of the board by the card service.
+func validateFieldValues(err error, values map[string]interface{}) error {
+	if len(values) > maxCustomFields {
+		return validation.AddError(err, "fields", fmt.Errorf("should not contain more than %d values", maxCustomFields))
+	}
+
+	return err
+}
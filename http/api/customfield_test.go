@@ -0,0 +1,284 @@
+package api_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomFieldHandler_CreateCustomField(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.CreateCustomFieldFn = func(boardID string, fc *pulpe.CustomFieldCreation) (*pulpe.CustomField, error) {
+			require.Equal(t, "XXX", boardID)
+			require.Equal(t, "Severity", fc.Name)
+			require.Equal(t, pulpe.CustomFieldDropdown, fc.Type)
+			require.Equal(t, []string{"low", "high"}, fc.Options)
+			return &pulpe.CustomField{ID: "YYY", CreatedAt: mock.Now, Name: fc.Name, Type: fc.Type, Options: fc.Options}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/boards/XXX/custom-fields", bytes.NewReader([]byte(`{
+			"name": " Severity ",
+			"type": "dropdown",
+			"options": [" low ", "high"]
+		}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusCreated, w.Code)
+		date, _ := mock.Now.MarshalJSON()
+		require.JSONEq(t, `{
+			"id": "YYY",
+			"createdAt": `+string(date)+`,
+			"name": "Severity",
+			"type": "dropdown",
+			"options": ["low", "high"]
+		}`, w.Body.String())
+	})
+
+	t.Run("Validation error", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		for _, payload := range []string{
+			`{"name": "Severity", "type": "dropdown"}`,
+			`{"name": "Severity", "type": "dropdown", "options": ["low", "low"]}`,
+			`{"name": "Points", "type": "number", "options": ["1"]}`,
+			`{"name": " ", "type": "number"}`,
+		} {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/api/boards/XXX/custom-fields", bytes.NewReader([]byte(payload)))
+			h.ServeHTTP(w, r)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+		}
+
+		require.False(t, c.BoardService.CreateCustomFieldInvoked)
+	})
+
+	t.Run("Invalid type", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.CreateCustomFieldFn = func(boardID string, fc *pulpe.CustomFieldCreation) (*pulpe.CustomField, error) {
+			return nil, pulpe.ErrCustomFieldInvalidType
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/boards/XXX/custom-fields", bytes.NewReader([]byte(`{"name": "Points", "type": "color"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.JSONEq(t, `{"err": "validation error", "fields": {"type": ["invalid custom field type"]}}`, w.Body.String())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.CreateCustomFieldFn = func(boardID string, fc *pulpe.CustomFieldCreation) (*pulpe.CustomField, error) {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/boards/XXX/custom-fields", bytes.NewReader([]byte(`{"name": "Points", "type": "number"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestCustomFieldHandler_UpdateCustomField(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.UpdateCustomFieldFn = func(boardID, fieldID string, u *pulpe.CustomFieldUpdate) (*pulpe.CustomField, error) {
+			require.Equal(t, "XXX", boardID)
+			require.Equal(t, "YYY", fieldID)
+			require.Nil(t, u.Name)
+			require.Equal(t, []string{"low", "medium", "high"}, u.Options)
+			return &pulpe.CustomField{ID: fieldID, Name: "Severity", Type: pulpe.CustomFieldDropdown, Options: u.Options}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PATCH", "/api/boards/XXX/custom-fields/YYY", bytes.NewReader([]byte(`{"options": ["low", "medium", "high"]}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, c.BoardService.UpdateCustomFieldInvoked)
+	})
+
+	t.Run("Validation error", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PATCH", "/api/boards/XXX/custom-fields/YYY", bytes.NewReader([]byte(`{"options": []}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.False(t, c.BoardService.UpdateCustomFieldInvoked)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.UpdateCustomFieldFn = func(boardID, fieldID string, u *pulpe.CustomFieldUpdate) (*pulpe.CustomField, error) {
+			return nil, pulpe.ErrCustomFieldNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PATCH", "/api/boards/XXX/custom-fields/YYY", bytes.NewReader([]byte(`{"name": "Points"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestCustomFieldHandler_DeleteCustomField(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.DeleteCustomFieldFn = func(boardID, fieldID string) error {
+			require.Equal(t, "XXX", boardID)
+			require.Equal(t, "YYY", fieldID)
+			return nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/api/boards/XXX/custom-fields/YYY", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.DeleteCustomFieldFn = func(boardID, fieldID string) error {
+			return pulpe.ErrCustomFieldNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/api/boards/XXX/custom-fields/YYY", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestCardHandler_CardsByBoard(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			require.Equal(t, "red", owner)
+			require.Equal(t, "board", slug)
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.CardService.CardsByBoardFn = func(boardID string, options ...pulpe.CardListOption) ([]*pulpe.Card, error) {
+			require.Equal(t, "XXX", boardID)
+
+			var opts pulpe.CardListOptions
+			for _, o := range options {
+				o(&opts)
+			}
+
+			require.Equal(t, map[string]string{"YYY": "high"}, opts.Fields)
+			require.Equal(t, "ZZZ", opts.SortField)
+			require.True(t, opts.Descending)
+			return []*pulpe.Card{{ID: "AAA", Fields: map[string]interface{}{"YYY": "high", "ZZZ": 3.0}}}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/red/board/cards?field.YYY=high&sort=-field.ZZZ", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `"fields":{"YYY":"high","ZZZ":3}`)
+	})
+
+	t.Run("Unsupported sort", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/red/board/cards?sort=color", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.False(t, c.CardService.CardsByBoardInvoked)
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.CardService.CardsByBoardFn = func(boardID string, options ...pulpe.CardListOption) ([]*pulpe.Card, error) {
+			return nil, validation.AddError(nil, "fields.YYY", pulpe.ErrCustomFieldNotFound)
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/red/board/cards?field.YYY=high", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.JSONEq(t, `{"err": "validation error", "fields": {"fields.YYY": ["custom field not found"]}}`, w.Body.String())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/red/board/cards", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestCardHandler_CustomFieldValues(t *testing.T) {
+	t.Run("Create", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.CardService.CreateCardFn = func(listID string, cc *pulpe.CardCreation) (*pulpe.Card, error) {
+			require.Equal(t, map[string]interface{}{"YYY": 3.0, "ZZZ": nil}, cc.Fields)
+			return &pulpe.Card{ID: "AAA", Fields: map[string]interface{}{"YYY": 3.0}}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/lists/XXX/cards", bytes.NewReader([]byte(`{
+			"name": "name",
+			"fields": {"YYY": 3, "ZZZ": null}
+		}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("Invalid value", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.CardService.UpdateCardFn = func(id string, u *pulpe.CardUpdate) (*pulpe.Card, error) {
+			require.Equal(t, map[string]interface{}{"YYY": "three"}, u.Fields)
+			return nil, validation.AddError(nil, "fields.YYY", pulpe.Error("should be a number"))
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PATCH", "/api/cards/AAA", bytes.NewReader([]byte(`{"fields": {"YYY": "three"}}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.JSONEq(t, `{"err": "validation error", "fields": {"fields.YYY": ["should be a number"]}}`, w.Body.String())
+	})
+}
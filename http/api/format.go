@@ -0,0 +1,158 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+)
+
+// Board formats
+const (
+	formatJSON     = "json"
+	formatCSV      = "csv"
+	formatMarkdown = "markdown"
+)
+
+// HTTP errors
+const (
+	ErrUnsupportedFormat = pulpe.Error("unsupported format")
+)
+
+// boardFormat returns the format requested with the "format" query parameter
+// or, if there is none, with the Accept header. JSON is the default.
+func boardFormat(r *http.Request) (string, error) {
+	switch f := r.URL.Query().Get("format"); f {
+	case "":
+	case formatJSON, formatCSV, formatMarkdown:
+		return f, nil
+	case "md":
+		return formatMarkdown, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+
+		switch mt {
+		case "application/json":
+			return formatJSON, nil
+		case "text/csv":
+			return formatCSV, nil
+		case "text/markdown":
+			return formatMarkdown, nil
+		}
+	}
+
+	return formatJSON, nil
+}
+
+// sortedContent returns the lists and cards of a board sorted by position,
+// and the cards indexed by list id.
+func sortedContent(board *pulpe.Board) ([]*pulpe.List, map[string][]*pulpe.Card) {
+	lists := make([]*pulpe.List, len(board.Lists))
+	copy(lists, board.Lists)
+	sort.SliceStable(lists, func(i, j int) bool { return lists[i].Position < lists[j].Position })
+
+	cards := make([]*pulpe.Card, len(board.Cards))
+	copy(cards, board.Cards)
+	sort.SliceStable(cards, func(i, j int) bool { return cards[i].Position < cards[j].Position })
+
+	byList := make(map[string][]*pulpe.Card)
+	for _, c := range cards {
+		byList[c.ListID] = append(byList[c.ListID], c)
+	}
+
+	return lists, byList
+}
+
+// encodeCSV writes the cards of a board in CSV, one row per card, ordered like on the board.
+// Errors are logged since the response status is already sent.
+func encodeCSV(w http.ResponseWriter, board *pulpe.Board, logger *log.Logger) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, board.Slug))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"list", "position", "name", "description", "createdAt", "updatedAt"})
+
+	lists, cards := sortedContent(board)
+	for _, l := range lists {
+		for _, c := range cards[l.ID] {
+			var updatedAt string
+			if c.UpdatedAt != nil {
+				updatedAt = c.UpdatedAt.Format(time.RFC3339)
+			}
+
+			cw.Write([]string{
+				csvCell(l.Name),
+				strconv.FormatFloat(c.Position, 'f', -1, 64),
+				csvCell(c.Name),
+				csvCell(c.Description),
+				c.CreatedAt.Format(time.RFC3339),
+				updatedAt,
+			})
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		logger.Println(err)
+	}
+}
+
+// csvCell prevents spreadsheets from evaluating user content as a formula.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsAny(s[:1], "=+-@\t\r") {
+		return "'" + s
+	}
+
+	return s
+}
+
+// encodeMarkdown writes a board in Markdown, lists as headings and cards as items.
+// Errors are logged since the response status is already sent.
+func encodeMarkdown(w http.ResponseWriter, board *pulpe.Board, logger *log.Logger) {
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# %s\n", board.Name)
+
+	lists, cards := sortedContent(board)
+	for _, l := range lists {
+		fmt.Fprintf(bw, "\n## %s\n\n", l.Name)
+		for _, c := range cards[l.ID] {
+			fmt.Fprintf(bw, "- %s\n", c.Name)
+
+			// the description is indented to stay part of the item
+			if desc := strings.TrimSpace(c.Description); desc != "" {
+				bw.WriteString("\n")
+				for _, line := range strings.Split(desc, "\n") {
+					if strings.TrimSpace(line) == "" {
+						bw.WriteString("\n")
+						continue
+					}
+					fmt.Fprintf(bw, "  %s\n", line)
+				}
+				bw.WriteString("\n")
+			}
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		logger.Println(err)
+	}
+}
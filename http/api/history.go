@@ -0,0 +1,61 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/julienschmidt/httprouter"
+)
+
+// registerHistoryHandler register the historyHandler routes.
+func registerHistoryHandler(router *httprouter.Router, c pulpeHttp.Connector) {
+	h := historyHandler{
+		connect: c,
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	router.POST("/api/undo", h.handlePostUndo)
+	router.POST("/api/redo", h.handlePostRedo)
+}
+
+// historyHandler represents an HTTP API handler for undoing and redoing changes.
+type historyHandler struct {
+	connect pulpeHttp.Connector
+	logger  *log.Logger
+}
+
+// handlePostUndo handles requests to undo the last change of the user.
+func (h *historyHandler) handlePostUndo(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	entry, err := session.HistoryService().Undo()
+	h.respond(w, entry, err)
+}
+
+// handlePostRedo handles requests to redo the last change undone by the user.
+func (h *historyHandler) handlePostRedo(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	entry, err := session.HistoryService().Redo()
+	h.respond(w, entry, err)
+}
+
+func (h *historyHandler) respond(w http.ResponseWriter, entry *pulpe.HistoryEntry, err error) {
+	switch err {
+	case nil:
+		encodeJSON(w, entry, http.StatusOK, h.logger)
+	case pulpe.ErrNothingToUndo, pulpe.ErrNothingToRedo:
+		Error(w, err, http.StatusNotFound, h.logger)
+	case pulpe.ErrHistoryConflict, pulpe.ErrHistoryBusy:
+		Error(w, err, http.StatusConflict, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryHandler_Undo(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.HistoryService.UndoFn = func() (*pulpe.HistoryEntry, error) {
+			return &pulpe.HistoryEntry{
+				ID:        "HHH",
+				CreatedAt: mock.Now,
+				Entity:    pulpe.HistoryCard,
+				Action:    pulpe.HistoryDelete,
+				EntityID:  "CCC",
+				BoardID:   "BBB",
+				Card:      &pulpe.Card{ID: "CCC", CreatedAt: mock.Now, Name: "name"},
+			}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/undo", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, c.HistoryService.UndoInvoked)
+		require.False(t, c.HistoryService.RedoInvoked)
+
+		var entry pulpe.HistoryEntry
+		err := json.NewDecoder(w.Body).Decode(&entry)
+		require.NoError(t, err)
+		require.Equal(t, "HHH", entry.ID)
+		require.Equal(t, pulpe.HistoryDelete, entry.Action)
+		require.Equal(t, "CCC", entry.Card.ID)
+		require.Nil(t, entry.List)
+	})
+
+	for _, test := range []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"Nothing to undo", pulpe.ErrNothingToUndo, http.StatusNotFound},
+		{"Conflict", pulpe.ErrHistoryConflict, http.StatusConflict},
+		{"Busy", pulpe.ErrHistoryBusy, http.StatusConflict},
+		{"Auth failed", pulpe.ErrUserAuthenticationFailed, http.StatusUnauthorized},
+		{"Internal error", errors.New("unexpected error"), http.StatusInternalServerError},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := mock.NewClient()
+			h := newHandler(c)
+
+			c.HistoryService.UndoFn = func() (*pulpe.HistoryEntry, error) {
+				return nil, test.err
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/api/undo", nil)
+			h.ServeHTTP(w, r)
+			require.Equal(t, test.status, w.Code)
+		})
+	}
+}
+
+func TestHistoryHandler_Redo(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.HistoryService.RedoFn = func() (*pulpe.HistoryEntry, error) {
+			return &pulpe.HistoryEntry{
+				ID:       "HHH",
+				Entity:   pulpe.HistoryList,
+				Action:   pulpe.HistoryMove,
+				EntityID: "LLL",
+				List:     &pulpe.List{ID: "LLL", Position: 2},
+			}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/redo", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, c.HistoryService.RedoInvoked)
+
+		var entry pulpe.HistoryEntry
+		err := json.NewDecoder(w.Body).Decode(&entry)
+		require.NoError(t, err)
+		require.Equal(t, pulpe.HistoryMove, entry.Action)
+		require.Equal(t, 2.0, entry.List.Position)
+	})
+
+	t.Run("Nothing to redo", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.HistoryService.RedoFn = func() (*pulpe.HistoryEntry, error) {
+			return nil, pulpe.ErrNothingToRedo
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/redo", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/trello"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/julienschmidt/httprouter"
+)
+
+// maximum size of an uploaded export.
+const maxImportSize = 32 << 20
+
+// registerImportHandler register the importHandler routes.
+func registerImportHandler(router *httprouter.Router, c pulpeHttp.Connector) {
+	h := importHandler{
+		connect: c,
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	router.HandlerFunc("POST", "/api/imports/trello", h.handlePostTrello)
+	router.HandlerFunc("POST", "/api/imports/archive", h.handlePostArchive)
+}
+
+// importHandler represents an HTTP API handler for importing boards from other tools.
+type importHandler struct {
+	connect pulpeHttp.Connector
+	logger  *log.Logger
+}
+
+// handlePostTrello handles requests to import a Trello export.
+// The board is created in the organization given by the "organization" query parameter, if any.
+func (h *importHandler) handlePostTrello(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.upload(w, r)
+	if !ok {
+		return
+	}
+	defer body.Close()
+
+	export, err := trello.Decode(body)
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	report, err := trello.Import(session, export, r.URL.Query().Get("organization"))
+	switch err {
+	case nil:
+		encodeJSON(w, report, http.StatusOK, h.logger)
+	case pulpe.ErrOrganizationNotFound:
+		Error(w, validation.AddError(nil, "organization", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handlePostArchive handles requests to import a board archive.
+// The board is created in the organization given by the "organization" query parameter, if any.
+func (h *importHandler) handlePostArchive(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.upload(w, r)
+	if !ok {
+		return
+	}
+	defer body.Close()
+
+	var archive pulpe.BoardArchive
+	err := json.NewDecoder(body).Decode(&archive)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	board, err := session.BoardService().ImportBoard(&archive, r.URL.Query().Get("organization"))
+	switch err {
+	case nil:
+		encodeJSON(w, board, http.StatusCreated, h.logger)
+	case pulpe.ErrBoardArchiveVersion, pulpe.ErrBoardArchiveInvalid:
+		Error(w, err, http.StatusBadRequest, h.logger)
+	case pulpe.ErrBoardInvalidVisibility:
+		Error(w, validation.AddError(nil, "visibility", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrOrganizationNotFound:
+		Error(w, validation.AddError(nil, "organization", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// upload returns the uploaded file, which is either the request body or the "file"
+// field of a multipart form. If it returns false, the error was already written.
+func (h *importHandler) upload(w http.ResponseWriter, r *http.Request) (io.ReadCloser, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "multipart/form-data" {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			Error(w, validation.AddError(nil, "file", err), http.StatusBadRequest, h.logger)
+			return nil, false
+		}
+
+		return f, true
+	}
+
+	return r.Body, true
+}
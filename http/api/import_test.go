@@ -0,0 +1,223 @@
+package api_test
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const trelloExport = `{
+	"id": "b1",
+	"name": "Roadmap",
+	"lists": [{"id": "l1", "name": "Todo", "pos": 1}],
+	"cards": [
+		{"id": "c1", "idList": "l1", "name": "Write docs", "pos": 1},
+		{"id": "c2", "idList": "l1", "name": "Old", "closed": true, "pos": 2}
+	]
+}`
+
+// newImportClient returns a mock client accepting the creation of the trelloExport board.
+func newImportClient(t *testing.T, organization string) *mock.Client {
+	c := mock.NewClient()
+
+	c.Session.AuthenticateFn = func() (*pulpe.User, error) {
+		return &pulpe.User{ID: "u1"}, nil
+	}
+
+	c.BoardService.BoardsFn = func(...pulpe.BoardListOption) ([]*pulpe.Board, error) {
+		return nil, nil
+	}
+
+	c.BoardService.CreateBoardFn = func(bc *pulpe.BoardCreation) (*pulpe.Board, error) {
+		require.Equal(t, organization, bc.Organization)
+		require.Equal(t, "trello:b1", bc.ImportID)
+		return &pulpe.Board{ID: "board1", Name: bc.Name, Slug: "roadmap", CreatedAt: mock.Now, ImportID: bc.ImportID}, nil
+	}
+
+	c.ListService.CreateListFn = func(boardID string, lc *pulpe.ListCreation) (*pulpe.List, error) {
+		require.Equal(t, "board1", boardID)
+		return &pulpe.List{ID: "list1", BoardID: boardID, Name: lc.Name, ImportID: lc.ImportID}, nil
+	}
+
+	c.CardService.CreateCardFn = func(listID string, cc *pulpe.CardCreation) (*pulpe.Card, error) {
+		require.Equal(t, "list1", listID)
+		return &pulpe.Card{ID: "card1", ListID: listID, Name: cc.Name, ImportID: cc.ImportID}, nil
+	}
+
+	return c
+}
+
+func TestImportHandler_Trello(t *testing.T) {
+	t.Run("OK", testImportHandler_Trello_OK)
+	t.Run("Multipart", testImportHandler_Trello_Multipart)
+	t.Run("InvalidExport", testImportHandler_Trello_InvalidExport)
+	t.Run("MissingFile", testImportHandler_Trello_MissingFile)
+	t.Run("OrganizationNotFound", testImportHandler_Trello_WithResponse(t, http.StatusBadRequest, pulpe.ErrOrganizationNotFound))
+	t.Run("Unauthorized", testImportHandler_Trello_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testImportHandler_Trello_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testImportHandler_Trello_OK(t *testing.T) {
+	c := newImportClient(t, "wall")
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/imports/trello?organization=wall", bytes.NewReader([]byte(trelloExport)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{
+		"board": {
+			"id": "board1",
+			"slug": "roadmap",
+			"createdAt": "2000-01-01T00:00:00Z",
+			"name": "Roadmap",
+			"importID": "trello:b1"
+		},
+		"entries": [
+			{"kind": "board", "trelloID": "b1", "name": "Roadmap", "status": "imported"},
+			{"kind": "list", "trelloID": "l1", "name": "Todo", "status": "imported"},
+			{"kind": "card", "trelloID": "c1", "name": "Write docs", "status": "imported"},
+			{"kind": "card", "trelloID": "c2", "name": "Old", "status": "skipped", "reason": "archived"}
+		]
+	}`, w.Body.String())
+}
+
+func testImportHandler_Trello_Multipart(t *testing.T) {
+	c := newImportClient(t, "")
+	h := newHandler(c)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile("file", "export.json")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(trelloExport))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/imports/trello", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.True(t, c.CardService.CreateCardInvoked)
+}
+
+func testImportHandler_Trello_InvalidExport(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/imports/trello", bytes.NewReader([]byte(`{"name": "something"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "trello: invalid export"}`, w.Body.String())
+}
+
+func testImportHandler_Trello_MissingFile(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	require.NoError(t, mw.WriteField("other", "value"))
+	require.NoError(t, mw.Close())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/imports/trello", &buf)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func testImportHandler_Trello_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := newImportClient(t, "")
+		h := newHandler(c)
+
+		c.BoardService.CreateBoardFn = func(bc *pulpe.BoardCreation) (*pulpe.Board, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/imports/trello", bytes.NewReader([]byte(trelloExport)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
+
+const boardArchive = `{
+	"version": 1,
+	"exportedAt": "2000-01-01T00:00:00Z",
+	"board": {"id": "board1", "createdAt": "2000-01-01T00:00:00Z", "name": "Roadmap", "slug": "roadmap"},
+	"lists": [{"id": "list1", "createdAt": "2000-01-01T00:00:00Z", "name": "Todo", "slug": "todo", "position": 1}],
+	"cards": [{"id": "card1", "createdAt": "2000-01-01T00:00:00Z", "listID": "list1", "name": "Docs", "slug": "docs", "description": "", "position": 2}]
+}`
+
+func TestImportHandler_Archive(t *testing.T) {
+	t.Run("OK", testImportHandler_Archive_OK)
+	t.Run("ErrInvalidJSON", testImportHandler_Archive_ErrInvalidJSON)
+	t.Run("VersionMismatch", testImportHandler_Archive_WithResponse(t, http.StatusBadRequest, pulpe.ErrBoardArchiveVersion))
+	t.Run("Invalid", testImportHandler_Archive_WithResponse(t, http.StatusBadRequest, pulpe.ErrBoardArchiveInvalid))
+	t.Run("InvalidVisibility", testImportHandler_Archive_WithResponse(t, http.StatusBadRequest, pulpe.ErrBoardInvalidVisibility))
+	t.Run("OrganizationNotFound", testImportHandler_Archive_WithResponse(t, http.StatusBadRequest, pulpe.ErrOrganizationNotFound))
+	t.Run("Unauthorized", testImportHandler_Archive_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testImportHandler_Archive_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testImportHandler_Archive_OK(t *testing.T) {
+	c := mock.NewClient()
+
+	c.BoardService.ImportBoardFn = func(a *pulpe.BoardArchive, organization string) (*pulpe.Board, error) {
+		require.Equal(t, "wall", organization)
+		require.Equal(t, "Roadmap", a.Board.Name)
+		require.Len(t, a.Lists, 1)
+		require.Equal(t, "list1", a.Cards[0].ListID)
+		return &pulpe.Board{ID: "board2", Name: "Roadmap", Slug: "roadmap-1", CreatedAt: mock.Now}, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/imports/archive?organization=wall", bytes.NewReader([]byte(boardArchive)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.JSONEq(t, `{
+		"id": "board2",
+		"createdAt": "2000-01-01T00:00:00Z",
+		"name": "Roadmap",
+		"slug": "roadmap-1"
+	}`, w.Body.String())
+}
+
+func testImportHandler_Archive_ErrInvalidJSON(t *testing.T) {
+	h := newHandler(mock.NewClient())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/imports/archive", bytes.NewReader([]byte(`{"version": `)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "invalid_json"}`, w.Body.String())
+}
+
+func testImportHandler_Archive_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.ImportBoardFn = func(a *pulpe.BoardArchive, organization string) (*pulpe.Board, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/imports/archive", bytes.NewReader([]byte(boardArchive)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
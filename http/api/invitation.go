@@ -0,0 +1,199 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/julienschmidt/httprouter"
+)
+
+// registerInvitationHandler register the invitationHandler routes.
+func registerInvitationHandler(router *httprouter.Router, c pulpeHttp.Connector) {
+	h := invitationHandler{
+		connect: c,
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	router.GET("/api/boards/:owner/:board/invitations", h.handleGetInvitations)
+	router.POST("/api/boards/:boardID/invitations", h.handlePostInvitation)
+	router.DELETE("/api/boards/:id/invitations/:invitation", h.handleDeleteInvitation)
+	router.HandlerFunc("POST", "/api/invitations/accept", h.handleAcceptInvitation)
+}
+
+// invitationHandler represents an HTTP API handler for board invitations.
+type invitationHandler struct {
+	connect pulpeHttp.Connector
+	logger  *log.Logger
+}
+
+// handleGetInvitations handles requests to list the invitations of a board.
+func (h *invitationHandler) handleGetInvitations(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	board, err := session.BoardService().BoardByOwnerAndSlug(ps.ByName("owner"), ps.ByName("board"))
+	if err == nil {
+		var invs []*pulpe.Invitation
+		invs, err = session.InvitationService().Invitations(board.ID)
+		if err == nil {
+			encodeJSON(w, invs, http.StatusOK, h.logger)
+			return
+		}
+	}
+
+	switch err {
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrBoardForbidden, pulpe.ErrOrganizationForbidden:
+		Error(w, err, http.StatusForbidden, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handlePostInvitation handles requests to invite someone to a board.
+func (h *invitationHandler) handlePostInvitation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req InvitationCreateRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	ic, err := req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	inv, err := session.InvitationService().CreateInvitation(ps.ByName("boardID"), ic)
+	switch err {
+	case nil:
+		encodeJSON(w, inv, http.StatusCreated, h.logger)
+	case pulpe.ErrBoardInvalidRole:
+		Error(w, validation.AddError(nil, "role", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrInvitationNoMailer:
+		Error(w, validation.AddError(nil, "email", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrBoardForbidden, pulpe.ErrOrganizationForbidden:
+		Error(w, err, http.StatusForbidden, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleDeleteInvitation handles requests to revoke an invitation.
+func (h *invitationHandler) handleDeleteInvitation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	err := session.InvitationService().RevokeInvitation(ps.ByName("id"), ps.ByName("invitation"))
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case pulpe.ErrBoardNotFound, pulpe.ErrInvitationNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrBoardForbidden, pulpe.ErrOrganizationForbidden:
+		Error(w, err, http.StatusForbidden, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleAcceptInvitation handles requests to join a board with an invitation token.
+func (h *invitationHandler) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req InvitationAcceptRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	board, err := session.InvitationService().AcceptInvitation(req.Token)
+	switch err {
+	case nil:
+		encodeJSON(w, board, http.StatusOK, h.logger)
+	case pulpe.ErrInvitationNotFound, pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrInvitationExpired:
+		Error(w, err, http.StatusGone, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// InvitationCreateRequest is used to invite someone to a board.
+type InvitationCreateRequest struct {
+	Email string `json:"email" valid:"email"`
+	Role  string `json:"role" valid:"required"`
+	// Lifetime in seconds.
+	ExpiresIn int `json:"expiresIn"`
+	MaxUses   int `json:"maxUses"`
+}
+
+// Validate invitation creation payload.
+func (i *InvitationCreateRequest) Validate() (*pulpe.InvitationCreation, error) {
+	i.Email = strings.TrimSpace(i.Email)
+	i.Role = strings.TrimSpace(i.Role)
+	err := validation.Validate(i)
+	if i.ExpiresIn < 0 {
+		err = validation.AddError(err, "expiresIn", errors.New("should not be negative"))
+	}
+
+	if i.MaxUses < 0 {
+		err = validation.AddError(err, "maxUses", errors.New("should not be negative"))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pulpe.InvitationCreation{
+		Email:     i.Email,
+		Role:      i.Role,
+		ExpiresIn: time.Duration(i.ExpiresIn) * time.Second,
+		MaxUses:   i.MaxUses,
+	}, nil
+}
+
+// InvitationAcceptRequest is used to accept an invitation.
+type InvitationAcceptRequest struct {
+	Token string `json:"token" valid:"required"`
+}
+
+// Validate invitation acceptance payload.
+func (i *InvitationAcceptRequest) Validate() error {
+	i.Token = strings.TrimSpace(i.Token)
+	return validation.Validate(i)
+}
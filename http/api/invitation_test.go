@@ -0,0 +1,231 @@
+package api_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newInvitation() *pulpe.Invitation {
+	return &pulpe.Invitation{
+		ID:        "123",
+		BoardID:   "XXX",
+		CreatedAt: mock.Now,
+		ExpiresAt: mock.Now.Add(time.Hour),
+		Email:     "arya@winterfell.com",
+		Token:     "secret",
+		Role:      pulpe.BoardRoleMember,
+		MaxUses:   1,
+	}
+}
+
+func TestInvitationHandler_Invitations(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			require.Equal(t, "jonsnow", owner)
+			require.Equal(t, "wall", slug)
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.InvitationService.InvitationsFn = func(boardID string) ([]*pulpe.Invitation, error) {
+			require.Equal(t, "XXX", boardID)
+			inv := newInvitation()
+			inv.Uses = 1
+			inv.Acceptances = []*pulpe.InvitationAcceptance{
+				{UserID: "456", Login: "aryastark", AcceptedAt: mock.Now},
+			}
+			return []*pulpe.Invitation{inv}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/jonsnow/wall/invitations", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		date, _ := mock.Now.MarshalJSON()
+		expires, _ := mock.Now.Add(time.Hour).MarshalJSON()
+		require.JSONEq(t, `[{
+			"id": "123",
+			"boardID": "XXX",
+			"createdAt": `+string(date)+`,
+			"expiresAt": `+string(expires)+`,
+			"email": "arya@winterfell.com",
+			"token": "secret",
+			"role": "member",
+			"maxUses": 1,
+			"uses": 1,
+			"acceptances": [{"userID": "456", "login": "aryastark", "acceptedAt": `+string(date)+`}]
+		}]`, w.Body.String())
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		c.InvitationService.InvitationsFn = func(boardID string) ([]*pulpe.Invitation, error) {
+			return nil, pulpe.ErrBoardForbidden
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/boards/jonsnow/wall/invitations", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestInvitationHandler_CreateInvitation(t *testing.T) {
+	t.Run("OK", testInvitationHandler_CreateInvitation_OK)
+	t.Run("ErrInvalidJSON", testInvitationHandler_CreateInvitation_ErrInvalidJSON)
+	t.Run("ValidationError", testInvitationHandler_CreateInvitation_ValidationError)
+	t.Run("Invalid role", testInvitationHandler_CreateInvitation_WithResponse(t, http.StatusBadRequest, pulpe.ErrBoardInvalidRole))
+	t.Run("No mailer", testInvitationHandler_CreateInvitation_WithResponse(t, http.StatusBadRequest, pulpe.ErrInvitationNoMailer))
+	t.Run("Not found", testInvitationHandler_CreateInvitation_WithResponse(t, http.StatusNotFound, pulpe.ErrBoardNotFound))
+	t.Run("Forbidden", testInvitationHandler_CreateInvitation_WithResponse(t, http.StatusForbidden, pulpe.ErrBoardForbidden))
+	t.Run("Auth failed", testInvitationHandler_CreateInvitation_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testInvitationHandler_CreateInvitation_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testInvitationHandler_CreateInvitation_OK(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.InvitationService.CreateInvitationFn = func(boardID string, ic *pulpe.InvitationCreation) (*pulpe.Invitation, error) {
+		require.Equal(t, "XXX", boardID)
+		require.Equal(t, &pulpe.InvitationCreation{
+			Email:     "arya@winterfell.com",
+			Role:      pulpe.BoardRoleMember,
+			ExpiresIn: time.Hour,
+		}, ic)
+		return newInvitation(), nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/boards/XXX/invitations", bytes.NewReader([]byte(`{
+		"email": " arya@winterfell.com ",
+		"role": "member",
+		"expiresIn": 3600
+	}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.True(t, c.InvitationService.CreateInvitationInvoked)
+}
+
+func testInvitationHandler_CreateInvitation_ErrInvalidJSON(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/boards/XXX/invitations", bytes.NewReader([]byte(`{"role": "memb`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "invalid_json"}`, w.Body.String())
+}
+
+func testInvitationHandler_CreateInvitation_ValidationError(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/boards/XXX/invitations", bytes.NewReader([]byte(`{"maxUses": -1}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "validation error", "fields": {"role": ["non zero value required"], "maxUses": ["should not be negative"]}}`, w.Body.String())
+	require.False(t, c.InvitationService.CreateInvitationInvoked)
+}
+
+func testInvitationHandler_CreateInvitation_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.InvitationService.CreateInvitationFn = func(boardID string, ic *pulpe.InvitationCreation) (*pulpe.Invitation, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/boards/XXX/invitations", bytes.NewReader([]byte(`{"role": "member"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.True(t, c.InvitationService.CreateInvitationInvoked)
+	}
+}
+
+func TestInvitationHandler_RevokeInvitation(t *testing.T) {
+	t.Run("OK", testInvitationHandler_RevokeInvitation_WithResponse(t, http.StatusNoContent, nil))
+	t.Run("Board not found", testInvitationHandler_RevokeInvitation_WithResponse(t, http.StatusNotFound, pulpe.ErrBoardNotFound))
+	t.Run("Invitation not found", testInvitationHandler_RevokeInvitation_WithResponse(t, http.StatusNotFound, pulpe.ErrInvitationNotFound))
+	t.Run("Forbidden", testInvitationHandler_RevokeInvitation_WithResponse(t, http.StatusForbidden, pulpe.ErrOrganizationForbidden))
+	t.Run("ErrInternal", testInvitationHandler_RevokeInvitation_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testInvitationHandler_RevokeInvitation_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.InvitationService.RevokeInvitationFn = func(boardID, id string) error {
+			require.Equal(t, "XXX", boardID)
+			require.Equal(t, "123", id)
+			return err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/api/boards/XXX/invitations/123", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.True(t, c.InvitationService.RevokeInvitationInvoked)
+	}
+}
+
+func TestInvitationHandler_AcceptInvitation(t *testing.T) {
+	t.Run("OK", testInvitationHandler_AcceptInvitation_WithResponse(t, http.StatusOK, nil))
+	t.Run("Not found", testInvitationHandler_AcceptInvitation_WithResponse(t, http.StatusNotFound, pulpe.ErrInvitationNotFound))
+	t.Run("Expired", testInvitationHandler_AcceptInvitation_WithResponse(t, http.StatusGone, pulpe.ErrInvitationExpired))
+	t.Run("Auth failed", testInvitationHandler_AcceptInvitation_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+
+	t.Run("ValidationError", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/invitations/accept", bytes.NewReader([]byte(`{}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.JSONEq(t, `{"err": "validation error", "fields": {"token": ["non zero value required"]}}`, w.Body.String())
+		require.False(t, c.InvitationService.AcceptInvitationInvoked)
+	})
+}
+
+func testInvitationHandler_AcceptInvitation_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.InvitationService.AcceptInvitationFn = func(token string) (*pulpe.Board, error) {
+			require.Equal(t, "secret", token)
+			if err != nil {
+				return nil, err
+			}
+			return &pulpe.Board{ID: "XXX"}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/invitations/accept", bytes.NewReader([]byte(`{"token": "secret"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.True(t, c.InvitationService.AcceptInvitationInvoked)
+	}
+}
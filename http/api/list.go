@@ -24,6 +24,7 @@ func registerListHandler(router *httprouter.Router, c pulpeHttp.Connector) {
 	router.POST("/api/boards/:boardID/lists", h.handlePostList)
 	router.DELETE("/api/lists/:id", h.handleDeleteList)
 	router.PATCH("/api/lists/:id", h.handlePatchList)
+	router.POST("/api/lists/:listID/copy", h.handleCopyList)
 }
 
 // listHandler represents an HTTP API handler for lists.
@@ -51,7 +52,7 @@ func (h *listHandler) handlePostList(w http.ResponseWriter, r *http.Request, ps
 		return
 	}
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	// create the list
@@ -72,7 +73,7 @@ func (h *listHandler) handlePostList(w http.ResponseWriter, r *http.Request, ps
 func (h *listHandler) handleDeleteList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	id := ps.ByName("id")
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	err := session.ListService().DeleteList(id)
@@ -106,7 +107,7 @@ func (h *listHandler) handlePatchList(w http.ResponseWriter, r *http.Request, ps
 		return
 	}
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	card, err := session.ListService().UpdateList(id, lu)
@@ -115,6 +116,43 @@ func (h *listHandler) handlePatchList(w http.ResponseWriter, r *http.Request, ps
 		encodeJSON(w, card, http.StatusOK, h.logger)
 	case pulpe.ErrListNotFound:
 		http.NotFound(w, r)
+	case pulpe.ErrBoardNotFound:
+		Error(w, validation.AddError(nil, "boardID", err), http.StatusBadRequest, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleCopyList handles requests to copy a list and its cards to a board.
+func (h *listHandler) handleCopyList(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("listID")
+
+	var req ListCopyRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	lc, err := req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	list, err := session.ListService().CopyList(id, lc)
+	switch err {
+	case nil:
+		encodeJSON(w, list, http.StatusCreated, h.logger)
+	case pulpe.ErrListNotFound:
+		Error(w, err, http.StatusNotFound, h.logger)
+	case pulpe.ErrBoardNotFound:
+		Error(w, validation.AddError(nil, "boardID", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
 	default:
 		Error(w, err, http.StatusInternalServerError, h.logger)
 	}
@@ -145,6 +183,7 @@ func (l *ListCreateRequest) Validate() (*pulpe.ListCreation, error) {
 type ListUpdateRequest struct {
 	Name     *string  `json:"name" valid:"stringlength(1|64)"`
 	Position *float64 `json:"position"`
+	BoardID  *string  `json:"boardID"`
 }
 
 // Validate list update payload.
@@ -166,5 +205,25 @@ func (l *ListUpdateRequest) Validate() (*pulpe.ListUpdate, error) {
 	return &pulpe.ListUpdate{
 		Name:     l.Name,
 		Position: l.Position,
+		BoardID:  l.BoardID,
+	}, nil
+}
+
+// ListCopyRequest is used to copy a List.
+type ListCopyRequest struct {
+	BoardID  string   `json:"boardID" valid:"required"`
+	Position *float64 `json:"position"`
+}
+
+// Validate list copy payload.
+func (l *ListCopyRequest) Validate() (*pulpe.ListCopy, error) {
+	err := validation.Validate(l)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pulpe.ListCopy{
+		BoardID:  l.BoardID,
+		Position: l.Position,
 	}, nil
 }
@@ -198,6 +198,7 @@ func TestListHandler_UpdateList(t *testing.T) {
 	t.Run("OK", testListHandler_UpdateList_OK)
 	t.Run("ErrInvalidJSON", testListHandler_UpdateList_ErrInvalidJSON)
 	t.Run("Not found", testListHandler_UpdateList_NotFound)
+	t.Run("Board not found", testListHandler_UpdateList_BoardNotFound)
 	t.Run("Internal error", testListHandler_UpdateList_InternalError)
 	t.Run("Validation error", testListHandler_UpdateList_ValidationError)
 	t.Run("Auth failed", testListHandler_UpdateList_AuthenticationFailed)
@@ -274,6 +275,24 @@ func testListHandler_UpdateList_NotFound(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func testListHandler_UpdateList_BoardNotFound(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.ListService.UpdateListFn = func(id string, u *pulpe.ListUpdate) (*pulpe.List, error) {
+		require.Equal(t, "YYY", *u.BoardID)
+		return nil, pulpe.ErrBoardNotFound
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PATCH", "/api/lists/XXX", bytes.NewReader([]byte(`{
+    "boardID": "YYY"
+  }`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "validation error", "fields": {"boardID": ["board not found"]}}`, w.Body.String())
+}
+
 func testListHandler_UpdateList_InternalError(t *testing.T) {
 	c := mock.NewClient()
 	h := newHandler(c)
@@ -324,6 +343,97 @@ func testListHandler_UpdateList_AuthenticationFailed(t *testing.T) {
 	require.False(t, c.ListService.UpdateListInvoked)
 }
 
+func TestListHandler_CopyList(t *testing.T) {
+	t.Run("OK", testListHandler_CopyList_OK)
+	t.Run("ErrInvalidJSON", testListHandler_CopyList_ErrInvalidJSON)
+	t.Run("ErrValidation", testListHandler_CopyList_ErrValidation)
+	t.Run("Not found", testListHandler_CopyList_WithResponse(t, http.StatusNotFound, pulpe.ErrListNotFound))
+	t.Run("Board not found", testListHandler_CopyList_WithResponse(t, http.StatusBadRequest, pulpe.ErrBoardNotFound))
+	t.Run("Internal error", testListHandler_CopyList_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+	t.Run("Auth failed", testListHandler_CopyList_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+}
+
+func testListHandler_CopyList_OK(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.ListService.CopyListFn = func(id string, lc *pulpe.ListCopy) (*pulpe.List, error) {
+		require.Equal(t, "XXX", id)
+		require.Equal(t, "YYY", lc.BoardID)
+		require.Equal(t, 3.0, *lc.Position)
+		return &pulpe.List{
+			ID:        "ZZZ",
+			Name:      "name",
+			Slug:      "name",
+			Position:  *lc.Position,
+			BoardID:   lc.BoardID,
+			OwnerID:   "456",
+			CreatedAt: mock.Now,
+		}, nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/lists/XXX/copy", bytes.NewReader([]byte(`{
+		"boardID": "YYY",
+		"position": 3
+	}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.True(t, c.ListService.CopyListInvoked)
+
+	date, _ := mock.Now.MarshalJSON()
+	require.JSONEq(t, `{
+		"id": "ZZZ",
+		"name": "name",
+		"slug": "name",
+		"position": 3,
+		"boardID": "YYY",
+		"ownerID": "456",
+		"createdAt": `+string(date)+`
+	}`, w.Body.String())
+}
+
+func testListHandler_CopyList_ErrInvalidJSON(t *testing.T) {
+	h := newHandler(mock.NewClient())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/lists/XXX/copy", bytes.NewReader([]byte(`{
+		"boardID": "YY
+	}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "invalid_json"}`, w.Body.String())
+}
+
+func testListHandler_CopyList_ErrValidation(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/lists/XXX/copy", bytes.NewReader([]byte(`{}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.False(t, c.ListService.CopyListInvoked)
+}
+
+func testListHandler_CopyList_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.ListService.CopyListFn = func(id string, lc *pulpe.ListCopy) (*pulpe.List, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/lists/XXX/copy", bytes.NewReader([]byte(`{
+			"boardID": "YYY"
+		}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
+
 func TestListCreateRequest_Validate(t *testing.T) {
 	t.Run("Empty", func(t *testing.T) {
 		var l api.ListCreateRequest
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/julienschmidt/httprouter"
+)
+
+// registerOrganizationHandler register the organizationHandler routes.
+func registerOrganizationHandler(router *httprouter.Router, c pulpeHttp.Connector) {
+	h := organizationHandler{
+		connect: c,
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	router.HandlerFunc("GET", "/api/orgs", h.handleGetOrganizations)
+	router.HandlerFunc("POST", "/api/orgs", h.handlePostOrganization)
+	router.GET("/api/orgs/:org", h.handleGetOrganization)
+	router.PUT("/api/orgs/:org/members/:login", h.handlePutMember)
+	router.DELETE("/api/orgs/:org/members/:login", h.handleDeleteMember)
+}
+
+// organizationHandler represents an HTTP API handler for organizations.
+type organizationHandler struct {
+	connect pulpeHttp.Connector
+	logger  *log.Logger
+}
+
+// handleGetOrganizations handles requests to list the organizations of the authenticated user.
+func (h *organizationHandler) handleGetOrganizations(w http.ResponseWriter, r *http.Request) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	orgs, err := session.OrganizationService().Organizations()
+	switch err {
+	case nil:
+		encodeJSON(w, orgs, http.StatusOK, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handlePostOrganization handles requests to create a new organization.
+func (h *organizationHandler) handlePostOrganization(w http.ResponseWriter, r *http.Request) {
+	var req OrganizationCreateRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	oc, err := req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	org, err := session.OrganizationService().CreateOrganization(oc)
+	switch err {
+	case nil:
+		encodeJSON(w, org, http.StatusCreated, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleGetOrganization handles requests to fetch an organization and its members.
+func (h *organizationHandler) handleGetOrganization(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	org, err := session.OrganizationService().Organization(ps.ByName("org"))
+	switch err {
+	case nil:
+		encodeJSON(w, org, http.StatusOK, h.logger)
+	case pulpe.ErrOrganizationNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handlePutMember handles requests to add a member to an organization or to change its role.
+func (h *organizationHandler) handlePutMember(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req OrganizationMemberRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	org, err := session.OrganizationService().SetMember(ps.ByName("org"), ps.ByName("login"), req.Role)
+	switch err {
+	case nil:
+		encodeJSON(w, org, http.StatusOK, h.logger)
+	case pulpe.ErrOrganizationInvalidRole:
+		Error(w, validation.AddError(nil, "role", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrOrganizationNotFound, pulpe.ErrUserNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrOrganizationForbidden:
+		Error(w, err, http.StatusForbidden, h.logger)
+	case pulpe.ErrOrganizationLastAdmin:
+		Error(w, err, http.StatusConflict, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleDeleteMember handles requests to remove a member from an organization.
+func (h *organizationHandler) handleDeleteMember(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	_, err := session.OrganizationService().RemoveMember(ps.ByName("org"), ps.ByName("login"))
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case pulpe.ErrOrganizationNotFound, pulpe.ErrOrganizationMemberNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrOrganizationForbidden:
+		Error(w, err, http.StatusForbidden, h.logger)
+	case pulpe.ErrOrganizationLastAdmin:
+		Error(w, err, http.StatusConflict, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// OrganizationCreateRequest is used to create an organization.
+type OrganizationCreateRequest struct {
+	Name string `json:"name" valid:"required,stringlength(1|64)"`
+}
+
+// Validate organization creation payload.
+func (o *OrganizationCreateRequest) Validate() (*pulpe.OrganizationCreation, error) {
+	o.Name = strings.TrimSpace(o.Name)
+	err := validation.Validate(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pulpe.OrganizationCreation{
+		Name: o.Name,
+	}, nil
+}
+
+// OrganizationMemberRequest is used to set the role of an organization member.
+type OrganizationMemberRequest struct {
+	Role string `json:"role" valid:"required"`
+}
+
+// Validate organization member payload.
+func (o *OrganizationMemberRequest) Validate() error {
+	o.Role = strings.TrimSpace(o.Role)
+	return validation.Validate(o)
+}
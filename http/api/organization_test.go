@@ -0,0 +1,263 @@
+package api_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newOrganization() *pulpe.Organization {
+	return &pulpe.Organization{
+		ID:        "123",
+		Slug:      "winterfell",
+		Name:      "Winterfell",
+		CreatedAt: mock.Now,
+		Members: []*pulpe.OrganizationMember{
+			{UserID: "456", Login: "jonsnow", FullName: "Jon Snow", Role: pulpe.OrganizationRoleAdmin},
+		},
+	}
+}
+
+func TestOrganizationHandler_Organizations(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.OrganizationService.OrganizationsFn = func() ([]*pulpe.Organization, error) {
+			return []*pulpe.Organization{
+				{ID: "123", Slug: "winterfell", Name: "Winterfell", CreatedAt: mock.Now},
+			}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/orgs", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		date, _ := mock.Now.MarshalJSON()
+		require.JSONEq(t, `[{"id": "123", "slug": "winterfell", "name": "Winterfell", "createdAt": `+string(date)+`}]`, w.Body.String())
+	})
+
+	t.Run("Auth failed", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.OrganizationService.OrganizationsFn = func() ([]*pulpe.Organization, error) {
+			return nil, pulpe.ErrUserAuthenticationFailed
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/orgs", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestOrganizationHandler_CreateOrganization(t *testing.T) {
+	t.Run("OK", testOrganizationHandler_CreateOrganization_OK)
+	t.Run("ErrInvalidJSON", testOrganizationHandler_CreateOrganization_ErrInvalidJSON)
+	t.Run("ValidationError", testOrganizationHandler_CreateOrganization_ValidationError)
+	t.Run("ErrInternal", testOrganizationHandler_CreateOrganization_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+	t.Run("Authfailed", testOrganizationHandler_CreateOrganization_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+}
+
+func testOrganizationHandler_CreateOrganization_OK(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.OrganizationService.CreateOrganizationFn = func(oc *pulpe.OrganizationCreation) (*pulpe.Organization, error) {
+		require.Equal(t, "Winterfell", oc.Name)
+		return newOrganization(), nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/orgs", bytes.NewReader([]byte(`{"name": " Winterfell "}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	date, _ := mock.Now.MarshalJSON()
+	require.JSONEq(t, `{
+		"id": "123",
+		"slug": "winterfell",
+		"name": "Winterfell",
+		"createdAt": `+string(date)+`,
+		"members": [{"userID": "456", "login": "jonsnow", "fullName": "Jon Snow", "role": "admin"}]
+	}`, w.Body.String())
+}
+
+func testOrganizationHandler_CreateOrganization_ErrInvalidJSON(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/orgs", bytes.NewReader([]byte(`{"name": "Wint`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "invalid_json"}`, w.Body.String())
+}
+
+func testOrganizationHandler_CreateOrganization_ValidationError(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/orgs", bytes.NewReader([]byte(`{"name": "  "}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "validation error", "fields": {"name": ["non zero value required"]}}`, w.Body.String())
+	require.False(t, c.OrganizationService.CreateOrganizationInvoked)
+}
+
+func testOrganizationHandler_CreateOrganization_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.OrganizationService.CreateOrganizationFn = func(oc *pulpe.OrganizationCreation) (*pulpe.Organization, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/orgs", bytes.NewReader([]byte(`{"name": "Winterfell"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.True(t, c.OrganizationService.CreateOrganizationInvoked)
+	}
+}
+
+func TestOrganizationHandler_Organization(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.OrganizationService.OrganizationFn = func(slug string) (*pulpe.Organization, error) {
+			require.Equal(t, "winterfell", slug)
+			return newOrganization(), nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/orgs/winterfell", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, c.OrganizationService.OrganizationInvoked)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.OrganizationService.OrganizationFn = func(slug string) (*pulpe.Organization, error) {
+			return nil, pulpe.ErrOrganizationNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/orgs/winterfell", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestOrganizationHandler_SetMember(t *testing.T) {
+	t.Run("OK", testOrganizationHandler_SetMember_OK)
+	t.Run("ValidationError", testOrganizationHandler_SetMember_ValidationError)
+	t.Run("Not found", testOrganizationHandler_SetMember_WithResponse(t, http.StatusNotFound, pulpe.ErrOrganizationNotFound))
+	t.Run("Unknown user", testOrganizationHandler_SetMember_WithResponse(t, http.StatusNotFound, pulpe.ErrUserNotFound))
+	t.Run("Forbidden", testOrganizationHandler_SetMember_WithResponse(t, http.StatusForbidden, pulpe.ErrOrganizationForbidden))
+	t.Run("Last admin", testOrganizationHandler_SetMember_WithResponse(t, http.StatusConflict, pulpe.ErrOrganizationLastAdmin))
+	t.Run("Authfailed", testOrganizationHandler_SetMember_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+}
+
+func testOrganizationHandler_SetMember_OK(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	c.OrganizationService.SetMemberFn = func(slug, login, role string) (*pulpe.Organization, error) {
+		require.Equal(t, "winterfell", slug)
+		require.Equal(t, "aryastark", login)
+		require.Equal(t, pulpe.OrganizationRoleMember, role)
+
+		org := newOrganization()
+		org.Members = append(org.Members, &pulpe.OrganizationMember{UserID: "789", Login: login, Role: role})
+		return org, nil
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PUT", "/api/orgs/winterfell/members/aryastark", bytes.NewReader([]byte(`{"role": "member"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.True(t, c.OrganizationService.SetMemberInvoked)
+}
+
+func testOrganizationHandler_SetMember_ValidationError(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PUT", "/api/orgs/winterfell/members/aryastark", bytes.NewReader([]byte(`{}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "validation error", "fields": {"role": ["non zero value required"]}}`, w.Body.String())
+	require.False(t, c.OrganizationService.SetMemberInvoked)
+
+	c.OrganizationService.SetMemberFn = func(slug, login, role string) (*pulpe.Organization, error) {
+		return nil, pulpe.ErrOrganizationInvalidRole
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PUT", "/api/orgs/winterfell/members/aryastark", bytes.NewReader([]byte(`{"role": "king"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "validation error", "fields": {"role": ["invalid organization role"]}}`, w.Body.String())
+}
+
+func testOrganizationHandler_SetMember_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.OrganizationService.SetMemberFn = func(slug, login, role string) (*pulpe.Organization, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PUT", "/api/orgs/winterfell/members/aryastark", bytes.NewReader([]byte(`{"role": "admin"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.True(t, c.OrganizationService.SetMemberInvoked)
+	}
+}
+
+func TestOrganizationHandler_RemoveMember(t *testing.T) {
+	t.Run("OK", testOrganizationHandler_RemoveMember_WithResponse(t, http.StatusNoContent, nil))
+	t.Run("Not found", testOrganizationHandler_RemoveMember_WithResponse(t, http.StatusNotFound, pulpe.ErrOrganizationNotFound))
+	t.Run("Not a member", testOrganizationHandler_RemoveMember_WithResponse(t, http.StatusNotFound, pulpe.ErrOrganizationMemberNotFound))
+	t.Run("Forbidden", testOrganizationHandler_RemoveMember_WithResponse(t, http.StatusForbidden, pulpe.ErrOrganizationForbidden))
+	t.Run("Last admin", testOrganizationHandler_RemoveMember_WithResponse(t, http.StatusConflict, pulpe.ErrOrganizationLastAdmin))
+	t.Run("ErrInternal", testOrganizationHandler_RemoveMember_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testOrganizationHandler_RemoveMember_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.OrganizationService.RemoveMemberFn = func(slug, login string) (*pulpe.Organization, error) {
+			require.Equal(t, "winterfell", slug)
+			require.Equal(t, "aryastark", login)
+			if err != nil {
+				return nil, err
+			}
+			return newOrganization(), nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("DELETE", "/api/orgs/winterfell/members/aryastark", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.True(t, c.OrganizationService.RemoveMemberInvoked)
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/julienschmidt/httprouter"
+)
+
+// registerRevisionHandler register the revisionHandler routes.
+func registerRevisionHandler(router *httprouter.Router, c pulpeHttp.Connector) {
+	h := revisionHandler{
+		connect: c,
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	router.GET("/api/cards/:id/revisions", h.handleGetRevisions)
+	router.GET("/api/cards/:id/revisions/:revisionID/diff", h.handleGetRevisionDiff)
+	router.POST("/api/cards/:id/revisions/:revisionID/restore", h.handleRestoreRevision)
+}
+
+// revisionHandler represents an HTTP API handler for card revisions.
+type revisionHandler struct {
+	connect pulpeHttp.Connector
+	logger  *log.Logger
+}
+
+// handleGetRevisions handles requests to list the revisions of a card.
+func (h *revisionHandler) handleGetRevisions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	revisions, err := session.RevisionService().Revisions(ps.ByName("id"))
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	encodeJSON(w, revisions, http.StatusOK, h.logger)
+}
+
+// handleGetRevisionDiff handles requests to compare a revision of a card
+// with the one given by the to parameter.
+func (h *revisionHandler) handleGetRevisionDiff(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		Error(w, validation.AddError(nil, "to", errors.New("to is required")), http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	d, err := session.RevisionService().DiffRevisions(ps.ByName("id"), ps.ByName("revisionID"), to)
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	encodeJSON(w, d, http.StatusOK, h.logger)
+}
+
+// handleRestoreRevision handles requests to restore a revision of a card.
+func (h *revisionHandler) handleRestoreRevision(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	card, err := session.RevisionService().RestoreRevision(ps.ByName("id"), ps.ByName("revisionID"))
+	if err != nil {
+		h.error(w, err)
+		return
+	}
+
+	encodeJSON(w, card, http.StatusOK, h.logger)
+}
+
+func (h *revisionHandler) error(w http.ResponseWriter, err error) {
+	switch err {
+	case pulpe.ErrCardNotFound, pulpe.ErrRevisionNotFound:
+		Error(w, err, http.StatusNotFound, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
@@ -0,0 +1,151 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevisionHandler_Revisions(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.RevisionService.RevisionsFn = func(cardID string) ([]*pulpe.Revision, error) {
+			require.Equal(t, "CCC", cardID)
+			return []*pulpe.Revision{
+				{ID: "R2", CardID: "CCC", CreatedAt: mock.Now, AuthorID: "UUU", Name: "name", Description: "new"},
+				{ID: "R1", CardID: "CCC", CreatedAt: mock.Now, Name: "name", Description: "old"},
+			}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/cards/CCC/revisions", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `[
+			{"id": "R2", "cardID": "CCC", "createdAt": "2000-01-01T00:00:00Z", "authorID": "UUU", "name": "name", "description": "new"},
+			{"id": "R1", "cardID": "CCC", "createdAt": "2000-01-01T00:00:00Z", "name": "name", "description": "old"}
+		]`, w.Body.String())
+	})
+
+	for _, test := range []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"Not found", pulpe.ErrCardNotFound, http.StatusNotFound},
+		{"Auth failed", pulpe.ErrUserAuthenticationFailed, http.StatusUnauthorized},
+		{"Internal error", errors.New("unexpected error"), http.StatusInternalServerError},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := mock.NewClient()
+			h := newHandler(c)
+
+			c.RevisionService.RevisionsFn = func(cardID string) ([]*pulpe.Revision, error) {
+				return nil, test.err
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/api/cards/CCC/revisions", nil)
+			h.ServeHTTP(w, r)
+			require.Equal(t, test.status, w.Code)
+		})
+	}
+}
+
+func TestRevisionHandler_DiffRevisions(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.RevisionService.DiffRevisionsFn = func(cardID, fromID, toID string) (*pulpe.RevisionDiff, error) {
+			require.Equal(t, "CCC", cardID)
+			require.Equal(t, "R1", fromID)
+			require.Equal(t, "R2", toID)
+			return &pulpe.RevisionDiff{
+				From: &pulpe.Revision{ID: "R1", CardID: "CCC", CreatedAt: mock.Now, Name: "name", Description: "old"},
+				To:   &pulpe.Revision{ID: "R2", CardID: "CCC", CreatedAt: mock.Now, Name: "name", Description: "new"},
+				Name: []*pulpe.DiffLine{{Op: pulpe.DiffEqual, Text: "name"}},
+				Description: []*pulpe.DiffLine{
+					{Op: pulpe.DiffDelete, Text: "old"},
+					{Op: pulpe.DiffInsert, Text: "new"},
+				},
+			}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/cards/CCC/revisions/R1/diff?to=R2", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{
+			"from": {"id": "R1", "cardID": "CCC", "createdAt": "2000-01-01T00:00:00Z", "name": "name", "description": "old"},
+			"to": {"id": "R2", "cardID": "CCC", "createdAt": "2000-01-01T00:00:00Z", "name": "name", "description": "new"},
+			"name": [{"op": "=", "text": "name"}],
+			"description": [{"op": "-", "text": "old"}, {"op": "+", "text": "new"}]
+		}`, w.Body.String())
+	})
+
+	t.Run("Missing to", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/cards/CCC/revisions/R1/diff", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.False(t, c.RevisionService.DiffRevisionsInvoked)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.RevisionService.DiffRevisionsFn = func(cardID, fromID, toID string) (*pulpe.RevisionDiff, error) {
+			return nil, pulpe.ErrRevisionNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/api/cards/CCC/revisions/R1/diff?to=R2", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestRevisionHandler_RestoreRevision(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.RevisionService.RestoreRevisionFn = func(cardID, revisionID string) (*pulpe.Card, error) {
+			require.Equal(t, "CCC", cardID)
+			require.Equal(t, "R1", revisionID)
+			return &pulpe.Card{ID: "CCC", CreatedAt: mock.Now, Name: "name", Description: "old"}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/cards/CCC/revisions/R1/restore", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, c.RevisionService.RestoreRevisionInvoked)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.RevisionService.RestoreRevisionFn = func(cardID, revisionID string) (*pulpe.Card, error) {
+			return nil, pulpe.ErrRevisionNotFound
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/cards/CCC/revisions/R1/restore", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/julienschmidt/httprouter"
+)
+
+// maxSyncOperations is the maximum number of operations sent in a single sync.
+const maxSyncOperations = 500
+
+// registerSyncHandler register the syncHandler routes.
+// Changes are downloaded with the since parameter of the board endpoints.
+func registerSyncHandler(router *httprouter.Router, c pulpeHttp.Connector) {
+	h := syncHandler{
+		connect: c,
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	router.POST("/api/boards/:boardID/sync", h.handlePostSync)
+}
+
+// syncHandler represents an HTTP API handler for client synchronization.
+type syncHandler struct {
+	connect pulpeHttp.Connector
+	logger  *log.Logger
+}
+
+// handlePostSync handles requests to apply the operations of a client.
+// Malformed operations are rejected along with the ones the service refuses.
+func (h *syncHandler) handlePostSync(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req SyncRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	ops, rejected, err := req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	res, err := session.SyncService().Sync(ps.ByName("boardID"), req.Token, ops)
+	switch err {
+	case nil:
+		res.Rejected = append(rejected, res.Rejected...)
+		encodeJSON(w, res, http.StatusOK, h.logger)
+	case pulpe.ErrSyncTokenInvalid:
+		Error(w, validation.AddError(nil, "token", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrSyncTokenExpired:
+		Error(w, err, http.StatusGone, h.logger)
+	case pulpe.ErrSyncInProgress:
+		Error(w, err, http.StatusConflict, h.logger)
+	case pulpe.ErrBoardNotFound:
+		http.NotFound(w, r)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// SyncRequest is the payload sent to apply the operations of a client.
+type SyncRequest struct {
+	// Token returned by the previous sync, empty to get the whole board back.
+	Token      string                  `json:"token"`
+	Operations []*SyncOperationRequest `json:"operations"`
+}
+
+// Validate sync payload. Malformed operations are returned as rejections,
+// so that they don't prevent the client from sending the other ones.
+func (s *SyncRequest) Validate() ([]*pulpe.SyncOperation, []*pulpe.SyncRejection, error) {
+	if len(s.Operations) > maxSyncOperations {
+		err := validation.AddError(nil, "operations", fmt.Errorf("should not contain more than %d operations", maxSyncOperations))
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool, len(s.Operations))
+	for _, o := range s.Operations {
+		if o == nil || o.ID == "" {
+			return nil, nil, validation.AddError(nil, "operations", errors.New("operations should have an id"))
+		}
+
+		if seen[o.ID] {
+			return nil, nil, validation.AddError(nil, "operations", errors.New("operation ids should be unique"))
+		}
+		seen[o.ID] = true
+	}
+
+	var ops []*pulpe.SyncOperation
+	var rejected []*pulpe.SyncRejection
+	for _, o := range s.Operations {
+		op, err := o.Validate()
+		if err != nil {
+			rejected = append(rejected, &pulpe.SyncRejection{
+				OperationID: o.ID,
+				Reason:      pulpe.SyncRejectedInvalid,
+			})
+			continue
+		}
+
+		ops = append(ops, op)
+	}
+
+	return ops, rejected, nil
+}
+
+// SyncOperationRequest is a change made by a client, possibly while offline.
+type SyncOperationRequest struct {
+	ID       string    `json:"id"`
+	Kind     string    `json:"kind"`
+	Entity   string    `json:"entity"`
+	EntityID string    `json:"entityID"`
+	Time     time.Time `json:"time"`
+
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Position    *float64 `json:"position"`
+	Labels      []string `json:"labels"`
+	// Due is removed if empty.
+	Due     *string `json:"due"`
+	ListID  *string `json:"listID"`
+	BoardID *string `json:"boardID"`
+}
+
+// Validate sync operation. The fields are validated like the ones of regular updates.
+func (o *SyncOperationRequest) Validate() (*pulpe.SyncOperation, error) {
+	var err error
+
+	switch o.Kind {
+	case pulpe.SyncCreate, pulpe.SyncUpdate, pulpe.SyncMove, pulpe.SyncDelete:
+	default:
+		err = validation.AddError(err, "kind", errors.New("unsupported operation"))
+	}
+
+	if o.EntityID == "" {
+		err = validation.AddError(err, "entityID", errors.New("entityID should not be empty"))
+	}
+
+	if o.Time.IsZero() {
+		err = validation.AddError(err, "time", errors.New("time should not be empty"))
+	}
+
+	op := pulpe.SyncOperation{
+		ID:       o.ID,
+		Kind:     o.Kind,
+		Entity:   o.Entity,
+		EntityID: o.EntityID,
+		Time:     o.Time,
+	}
+
+	content := o.Name != nil || o.Description != nil || o.Labels != nil || o.Due != nil
+	move := o.Position != nil || o.ListID != nil || o.BoardID != nil
+
+	switch o.Kind {
+	case pulpe.SyncCreate:
+		if o.Name == nil {
+			err = validation.AddError(err, "name", errors.New("name is required"))
+		}
+	case pulpe.SyncUpdate:
+		if o.ListID != nil || o.BoardID != nil {
+			err = validation.AddError(err, "kind", errors.New("updates can't move"))
+		}
+	case pulpe.SyncMove:
+		if content {
+			err = validation.AddError(err, "kind", errors.New("moves can only change the position"))
+		}
+	case pulpe.SyncDelete:
+		if content || move {
+			err = validation.AddError(err, "kind", errors.New("deletions can't change fields"))
+		}
+	}
+
+	if o.Kind != pulpe.SyncCreate && o.Kind != pulpe.SyncDelete && !content && !move {
+		err = validation.AddError(err, "kind", errors.New("nothing to change"))
+	}
+
+	switch o.Entity {
+	case pulpe.SyncList:
+		if o.Description != nil || o.Labels != nil || o.Due != nil || o.ListID != nil {
+			err = validation.AddError(err, "entity", errors.New("unsupported list field"))
+		}
+
+		req := ListUpdateRequest{Name: o.Name, Position: o.Position, BoardID: o.BoardID}
+		u, verr := req.Validate()
+		if verr != nil {
+			return nil, verr
+		}
+
+		op.Name, op.Position, op.BoardID = u.Name, u.Position, u.BoardID
+	case pulpe.SyncCard:
+		if o.BoardID != nil {
+			err = validation.AddError(err, "entity", errors.New("cards are moved with listID"))
+		}
+
+		if o.Kind == pulpe.SyncCreate && o.ListID == nil {
+			err = validation.AddError(err, "listID", errors.New("listID is required"))
+		}
+
+		req := CardUpdateRequest{
+			Name:        o.Name,
+			Description: o.Description,
+			Position:    o.Position,
+			ListID:      o.ListID,
+			Labels:      o.Labels,
+			Due:         o.Due,
+		}
+		u, verr := req.Validate()
+		if verr != nil {
+			return nil, verr
+		}
+
+		op.Name, op.Description, op.Position = u.Name, u.Description, u.Position
+		op.Labels, op.Due, op.ListID = u.Labels, u.Due, u.ListID
+	default:
+		err = validation.AddError(err, "entity", errors.New("unsupported entity"))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &op, nil
+}
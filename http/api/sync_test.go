@@ -0,0 +1,138 @@
+package api_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncHandler_Sync(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.SyncService.SyncFn = func(boardID, token string, ops []*pulpe.SyncOperation) (*pulpe.SyncResult, error) {
+			require.Equal(t, "XXX", boardID)
+			require.Equal(t, "TTT", token)
+			require.Len(t, ops, 2)
+			require.Equal(t, pulpe.SyncCreate, ops[0].Kind)
+			require.Equal(t, pulpe.SyncCard, ops[0].Entity)
+			require.Equal(t, "tmp", ops[0].EntityID)
+			require.Equal(t, "name", *ops[0].Name)
+			require.Equal(t, "LLL", *ops[0].ListID)
+			require.Equal(t, time.Date(2017, 5, 1, 10, 0, 0, 0, time.UTC), ops[0].Time.UTC())
+			require.Equal(t, pulpe.SyncDelete, ops[1].Kind)
+
+			return &pulpe.SyncResult{
+				Changes:  &pulpe.Changes{Token: "UUU"},
+				IDs:      map[string]string{"tmp": "CCC"},
+				Rejected: []*pulpe.SyncRejection{{OperationID: "3", Reason: pulpe.SyncRejectedConflict}},
+			}, nil
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/boards/XXX/sync", bytes.NewReader([]byte(`{
+			"token": "TTT",
+			"operations": [
+				{"id": "1", "kind": "create", "entity": "card", "entityID": "tmp", "time": "2017-05-01T12:00:00+02:00", "name": " name ", "listID": "LLL"},
+				{"id": "2", "kind": "update", "entity": "card", "entityID": "tmp", "time": "2017-05-01T12:00:00+02:00", "name": ""},
+				{"id": "3", "kind": "delete", "entity": "list", "entityID": "LLL", "time": "2017-05-01T12:00:00+02:00"}
+			]
+		}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{
+			"changes": {"token": "UUU"},
+			"ids": {"tmp": "CCC"},
+			"rejected": [
+				{"operationID": "2", "reason": "invalid"},
+				{"operationID": "3", "reason": "conflict"}
+			]
+		}`, w.Body.String())
+	})
+
+	t.Run("Invalid operations", func(t *testing.T) {
+		for _, op := range []string{
+			`{"id": "1", "kind": "rename", "entity": "card", "entityID": "CCC", "time": "2017-05-01T12:00:00Z", "name": "name"}`,
+			`{"id": "1", "kind": "update", "entity": "board", "entityID": "CCC", "time": "2017-05-01T12:00:00Z", "name": "name"}`,
+			`{"id": "1", "kind": "update", "entity": "card", "entityID": "CCC", "name": "name"}`,
+			`{"id": "1", "kind": "update", "entity": "card", "entityID": "CCC", "time": "2017-05-01T12:00:00Z"}`,
+			`{"id": "1", "kind": "update", "entity": "card", "entityID": "CCC", "time": "2017-05-01T12:00:00Z", "listID": "LLL"}`,
+			`{"id": "1", "kind": "move", "entity": "card", "entityID": "CCC", "time": "2017-05-01T12:00:00Z", "name": "name"}`,
+			`{"id": "1", "kind": "delete", "entity": "card", "entityID": "CCC", "time": "2017-05-01T12:00:00Z", "position": 1}`,
+			`{"id": "1", "kind": "create", "entity": "card", "entityID": "tmp", "time": "2017-05-01T12:00:00Z", "name": "name"}`,
+			`{"id": "1", "kind": "create", "entity": "list", "entityID": "tmp", "time": "2017-05-01T12:00:00Z", "name": "name", "labels": ["bug"]}`,
+			`{"id": "1", "kind": "update", "entity": "card", "entityID": "CCC", "time": "2017-05-01T12:00:00Z", "due": "tomorrow"}`,
+		} {
+			c := mock.NewClient()
+			h := newHandler(c)
+
+			c.SyncService.SyncFn = func(boardID, token string, ops []*pulpe.SyncOperation) (*pulpe.SyncResult, error) {
+				require.Empty(t, ops)
+				return &pulpe.SyncResult{Changes: &pulpe.Changes{Token: "UUU"}}, nil
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/api/boards/XXX/sync", bytes.NewReader([]byte(`{"operations": [`+op+`]}`)))
+			h.ServeHTTP(w, r)
+			require.Equal(t, http.StatusOK, w.Code, op)
+			require.JSONEq(t, `{
+				"changes": {"token": "UUU"},
+				"rejected": [{"operationID": "1", "reason": "invalid"}]
+			}`, w.Body.String(), op)
+		}
+	})
+
+	t.Run("Invalid batch", func(t *testing.T) {
+		for _, body := range []string{
+			`{"operations": [{"kind": "delete", "entity": "card", "entityID": "CCC", "time": "2017-05-01T12:00:00Z"}]}`,
+			`{"operations": [
+				{"id": "1", "kind": "delete", "entity": "card", "entityID": "CCC", "time": "2017-05-01T12:00:00Z"},
+				{"id": "1", "kind": "delete", "entity": "card", "entityID": "DDD", "time": "2017-05-01T12:00:00Z"}
+			]}`,
+			`{"operations": {}}`,
+		} {
+			c := mock.NewClient()
+			h := newHandler(c)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/api/boards/XXX/sync", bytes.NewReader([]byte(body)))
+			h.ServeHTTP(w, r)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+			require.False(t, c.SyncService.SyncInvoked)
+		}
+	})
+
+	for _, test := range []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"Invalid token", pulpe.ErrSyncTokenInvalid, http.StatusBadRequest},
+		{"Expired token", pulpe.ErrSyncTokenExpired, http.StatusGone},
+		{"Not found", pulpe.ErrBoardNotFound, http.StatusNotFound},
+		{"Auth failed", pulpe.ErrUserAuthenticationFailed, http.StatusUnauthorized},
+		{"Internal error", errors.New("unexpected error"), http.StatusInternalServerError},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := mock.NewClient()
+			h := newHandler(c)
+
+			c.SyncService.SyncFn = func(boardID, token string, ops []*pulpe.SyncOperation) (*pulpe.SyncResult, error) {
+				return nil, test.err
+			}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/api/boards/XXX/sync", bytes.NewReader([]byte(`{"token": "TTT", "operations": []}`)))
+			h.ServeHTTP(w, r)
+			require.Equal(t, test.status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/julienschmidt/httprouter"
+)
+
+// registerTwoFactorHandler register the twoFactorHandler routes.
+func registerTwoFactorHandler(router *httprouter.Router, c pulpeHttp.Connector) {
+	h := twoFactorHandler{
+		connect: c,
+		logger:  log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	router.HandlerFunc("POST", "/api/me/2fa", h.handleEnroll)
+	router.HandlerFunc("POST", "/api/me/2fa/confirm", h.handleConfirm)
+	router.HandlerFunc("POST", "/api/me/2fa/disable", h.handleDisable)
+}
+
+// twoFactorHandler represents an HTTP API handler for two-factor authentication.
+type twoFactorHandler struct {
+	connect pulpeHttp.Connector
+	logger  *log.Logger
+}
+
+// handleEnroll handles requests to generate a new two-factor authentication secret.
+func (h *twoFactorHandler) handleEnroll(w http.ResponseWriter, r *http.Request) {
+	session := h.connect(w, r)
+	defer session.Close()
+
+	enrollment, err := session.TwoFactorService().EnrollTwoFactor()
+	switch err {
+	case nil:
+		encodeJSON(w, enrollment, http.StatusOK, h.logger)
+	case pulpe.ErrTwoFactorAlreadyEnabled:
+		Error(w, err, http.StatusConflict, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleConfirm handles requests to enable two-factor authentication.
+func (h *twoFactorHandler) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorCodeRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	codes, err := session.TwoFactorService().ConfirmTwoFactor(req.Code)
+	switch err {
+	case nil:
+		encodeJSON(w, &recoveryCodesResponse{RecoveryCodes: codes}, http.StatusOK, h.logger)
+	case pulpe.ErrTwoFactorInvalidCode:
+		Error(w, validation.AddError(nil, "code", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrTwoFactorNotEnrolled:
+		Error(w, err, http.StatusBadRequest, h.logger)
+	case pulpe.ErrTwoFactorAlreadyEnabled:
+		Error(w, err, http.StatusConflict, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// handleDisable handles requests to disable two-factor authentication.
+func (h *twoFactorHandler) handleDisable(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorCodeRequest
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	err = req.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	err = session.TwoFactorService().DisableTwoFactor(req.Code)
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case pulpe.ErrTwoFactorInvalidCode:
+		Error(w, validation.AddError(nil, "code", err), http.StatusBadRequest, h.logger)
+	case pulpe.ErrTwoFactorNotEnabled:
+		Error(w, err, http.StatusBadRequest, h.logger)
+	case pulpe.ErrUserAuthenticationFailed:
+		Error(w, err, http.StatusUnauthorized, h.logger)
+	default:
+		Error(w, err, http.StatusInternalServerError, h.logger)
+	}
+}
+
+// recoveryCodesResponse contains the recovery codes generated when enabling two-factor authentication.
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// TwoFactorCodeRequest is used to send a two-factor authentication code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" valid:"required,stringlength(6|32)"`
+}
+
+// Validate two-factor code payload.
+func (t *TwoFactorCodeRequest) Validate() error {
+	t.Code = strings.TrimSpace(t.Code)
+	return validation.Validate(t)
+}
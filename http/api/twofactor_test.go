@@ -0,0 +1,187 @@
+package api_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwoFactorHandler_Enroll(t *testing.T) {
+	t.Run("OK", testTwoFactorHandler_Enroll_OK)
+	t.Run("AlreadyEnabled", testTwoFactorHandler_Enroll_WithResponse(t, http.StatusConflict, pulpe.ErrTwoFactorAlreadyEnabled))
+	t.Run("Unauthorized", testTwoFactorHandler_Enroll_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testTwoFactorHandler_Enroll_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testTwoFactorHandler_Enroll_OK(t *testing.T) {
+	c := mock.NewClient()
+
+	c.TwoFactorService.EnrollTwoFactorFn = func() (*pulpe.TwoFactorEnrollment, error) {
+		return &pulpe.TwoFactorEnrollment{
+			Secret: "SECRET",
+			URI:    "otpauth://totp/Pulpe:jon.snow@wall.com?secret=SECRET",
+		}, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/me/2fa", nil)
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{
+		"secret": "SECRET",
+		"uri": "otpauth://totp/Pulpe:jon.snow@wall.com?secret=SECRET"
+	}`, w.Body.String())
+}
+
+func testTwoFactorHandler_Enroll_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.TwoFactorService.EnrollTwoFactorFn = func() (*pulpe.TwoFactorEnrollment, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/me/2fa", nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
+
+func TestTwoFactorHandler_Confirm(t *testing.T) {
+	t.Run("OK", testTwoFactorHandler_Confirm_OK)
+	t.Run("ErrInvalidJSON", testTwoFactorHandler_Confirm_ErrInvalidJSON)
+	t.Run("ErrValidation", testTwoFactorHandler_Confirm_ErrValidation)
+	t.Run("InvalidCode", testTwoFactorHandler_Confirm_InvalidCode)
+	t.Run("NotEnrolled", testTwoFactorHandler_Confirm_WithResponse(t, http.StatusBadRequest, pulpe.ErrTwoFactorNotEnrolled))
+	t.Run("AlreadyEnabled", testTwoFactorHandler_Confirm_WithResponse(t, http.StatusConflict, pulpe.ErrTwoFactorAlreadyEnabled))
+	t.Run("ErrInternal", testTwoFactorHandler_Confirm_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testTwoFactorHandler_Confirm_OK(t *testing.T) {
+	c := mock.NewClient()
+
+	c.TwoFactorService.ConfirmTwoFactorFn = func(code string) ([]string, error) {
+		require.Equal(t, "123456", code)
+		return []string{"abcde-fghij", "klmno-pqrst"}, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/me/2fa/confirm", bytes.NewReader([]byte(`{"code": "123456"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"recoveryCodes": ["abcde-fghij", "klmno-pqrst"]}`, w.Body.String())
+}
+
+func testTwoFactorHandler_Confirm_ErrInvalidJSON(t *testing.T) {
+	h := newHandler(mock.NewClient())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/me/2fa/confirm", bytes.NewReader([]byte(`{"code": "12`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "invalid_json"}`, w.Body.String())
+}
+
+func testTwoFactorHandler_Confirm_ErrValidation(t *testing.T) {
+	h := newHandler(mock.NewClient())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/me/2fa/confirm", bytes.NewReader([]byte(`{}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func testTwoFactorHandler_Confirm_InvalidCode(t *testing.T) {
+	c := mock.NewClient()
+
+	c.TwoFactorService.ConfirmTwoFactorFn = func(code string) ([]string, error) {
+		return nil, pulpe.ErrTwoFactorInvalidCode
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/me/2fa/confirm", bytes.NewReader([]byte(`{"code": "123456"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.JSONEq(t, `{"err": "validation error", "fields": {"code": ["invalid two-factor authentication code"]}}`, w.Body.String())
+}
+
+func testTwoFactorHandler_Confirm_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.TwoFactorService.ConfirmTwoFactorFn = func(code string) ([]string, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/me/2fa/confirm", bytes.NewReader([]byte(`{"code": "123456"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
+
+func TestTwoFactorHandler_Disable(t *testing.T) {
+	t.Run("OK", testTwoFactorHandler_Disable_OK)
+	t.Run("ErrValidation", testTwoFactorHandler_Disable_ErrValidation)
+	t.Run("InvalidCode", testTwoFactorHandler_Disable_WithResponse(t, http.StatusBadRequest, pulpe.ErrTwoFactorInvalidCode))
+	t.Run("NotEnabled", testTwoFactorHandler_Disable_WithResponse(t, http.StatusBadRequest, pulpe.ErrTwoFactorNotEnabled))
+	t.Run("Unauthorized", testTwoFactorHandler_Disable_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserAuthenticationFailed))
+	t.Run("ErrInternal", testTwoFactorHandler_Disable_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+func testTwoFactorHandler_Disable_OK(t *testing.T) {
+	c := mock.NewClient()
+
+	c.TwoFactorService.DisableTwoFactorFn = func(code string) error {
+		require.Equal(t, "abcde-fghij", code)
+		return nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/me/2fa/disable", bytes.NewReader([]byte(`{"code": " abcde-fghij "}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.True(t, c.TwoFactorService.DisableTwoFactorInvoked)
+}
+
+func testTwoFactorHandler_Disable_ErrValidation(t *testing.T) {
+	h := newHandler(mock.NewClient())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/me/2fa/disable", bytes.NewReader([]byte(`{"code": "1"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func testTwoFactorHandler_Disable_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.TwoFactorService.DisableTwoFactorFn = func(code string) error {
+			return err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/me/2fa/disable", bytes.NewReader([]byte(`{"code": "123456"}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blankrobot/pulpe"
 	pulpeHttp "github.com/blankrobot/pulpe/http"
@@ -22,6 +24,7 @@ func registerUserHandler(router *httprouter.Router, c pulpeHttp.Connector) {
 
 	router.HandlerFunc("POST", "/api/register", h.handleUserRegistration)
 	router.HandlerFunc("POST", "/api/login", h.handleUserLogin)
+	router.HandlerFunc("POST", "/api/login/2fa", h.handleUserLoginTwoFactor)
 	router.HandlerFunc("GET", "/api/me", h.handleUserMe)
 }
 
@@ -47,7 +50,7 @@ func (h *userHandler) handleUserRegistration(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	user, err := session.UserService().Register(ur)
@@ -67,12 +70,16 @@ func (h *userHandler) handleUserRegistration(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:    "pulpesid",
-		Value:   us.ID,
-		Expires: us.ExpiresAt,
-		Path:    "/",
-	})
+	pulpeHttp.SetSessionCookie(w, us)
+
+	// the user is registered even if the invitation can't be accepted
+	if payload.Invitation != "" {
+		session.SetAuthToken(us.ID)
+		_, err = session.InvitationService().AcceptInvitation(payload.Invitation)
+		if err != nil {
+			h.logger.Printf("invitation not accepted on registration: %s", err)
+		}
+	}
 
 	encodeJSON(w, user, http.StatusCreated, h.logger)
 }
@@ -93,33 +100,176 @@ func (h *userHandler) handleUserLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
-	us, err := session.UserSessionService().Login(payload.EmailOrLogin, payload.Password)
+	var opts []pulpe.SessionOption
+	if payload.Remember {
+		opts = append(opts, pulpe.RememberMe())
+	}
+
+	ip := pulpeHttp.ClientIP(r)
+	attempts := session.LoginAttemptService()
+
+	account, err := attempts.Account(payload.EmailOrLogin)
+	if err != nil {
+		Error(w, err, http.StatusInternalServerError, h.logger)
+		return
+	}
+
+	wait, err := attempts.Check(account, ip)
+	if err != nil {
+		Error(w, err, http.StatusInternalServerError, h.logger)
+		return
+	}
+
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+		Error(w, pulpe.ErrLoginThrottled, http.StatusTooManyRequests, h.logger)
+		return
+	}
+
+	us, err := session.UserSessionService().Login(payload.EmailOrLogin, payload.Password, opts...)
 	if err != nil {
 		switch err {
 		case pulpe.ErrUserAuthenticationFailed:
+			if err := attempts.RecordFailure(account, ip); err != nil {
+				Error(w, err, http.StatusInternalServerError, h.logger)
+				return
+			}
 			Error(w, err, http.StatusUnauthorized, h.logger)
+		case pulpe.ErrUserIdentityNoEmail:
+			// the directory entry can't be turned into a user
+			Error(w, err, http.StatusForbidden, h.logger)
+		case pulpe.ErrUserEmailConflict:
+			Error(w, err, http.StatusConflict, h.logger)
 		default:
 			Error(w, err, http.StatusInternalServerError, h.logger)
 		}
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:    "pulpesid",
-		Value:   us.ID,
-		Expires: us.ExpiresAt,
-		Path:    "/",
-	})
+	if us.Pending {
+		encodeJSON(w, &twoFactorChallengeResponse{
+			Challenge: us.ID,
+			ExpiresAt: us.ExpiresAt,
+		}, http.StatusAccepted, h.logger)
+		return
+	}
+
+	err = attempts.RecordSuccess(account, ip)
+	if err != nil {
+		Error(w, err, http.StatusInternalServerError, h.logger)
+		return
+	}
+
+	pulpeHttp.SetSessionCookie(w, us)
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleUserLoginTwoFactor handles requests to complete a two-factor authentication challenge.
+func (h *userHandler) handleUserLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var payload TwoFactorLoginRequest
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		Error(w, ErrInvalidJSON, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	err = payload.Validate()
+	if err != nil {
+		Error(w, err, http.StatusBadRequest, h.logger)
+		return
+	}
+
+	// challenges of sign ins through an identity provider are kept in a cookie
+	var fromCookie bool
+	if payload.Challenge == "" {
+		cookie, err := r.Cookie(pulpeHttp.TwoFactorCookieName)
+		if err != nil {
+			Error(w, pulpe.ErrUserAuthenticationFailed, http.StatusUnauthorized, h.logger)
+			return
+		}
+
+		payload.Challenge = cookie.Value
+		fromCookie = true
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	sessions := session.UserSessionService()
+
+	challenge, err := sessions.Challenge(payload.Challenge)
+	if err != nil {
+		switch err {
+		case pulpe.ErrUserSessionUnknownID:
+			if fromCookie {
+				pulpeHttp.ClearTwoFactorCookie(w)
+			}
+			Error(w, pulpe.ErrUserAuthenticationFailed, http.StatusUnauthorized, h.logger)
+		default:
+			Error(w, err, http.StatusInternalServerError, h.logger)
+		}
+		return
+	}
+
+	// new challenges can be started with the password,
+	// codes are throttled across challenges like passwords.
+	ip := pulpeHttp.ClientIP(r)
+	attempts := session.LoginAttemptService()
+
+	wait, err := attempts.Check(challenge.UserID, ip)
+	if err != nil {
+		Error(w, err, http.StatusInternalServerError, h.logger)
+		return
+	}
+
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((wait+time.Second-1)/time.Second)))
+		Error(w, pulpe.ErrLoginThrottled, http.StatusTooManyRequests, h.logger)
+		return
+	}
+
+	us, err := sessions.VerifyTwoFactor(payload.Challenge, payload.Code)
+	if err != nil {
+		switch err {
+		case pulpe.ErrTwoFactorInvalidCode:
+			if err := attempts.RecordFailure(challenge.UserID, ip); err != nil {
+				Error(w, err, http.StatusInternalServerError, h.logger)
+				return
+			}
+			Error(w, pulpe.ErrUserAuthenticationFailed, http.StatusUnauthorized, h.logger)
+		case pulpe.ErrUserSessionUnknownID:
+			if fromCookie {
+				pulpeHttp.ClearTwoFactorCookie(w)
+			}
+			Error(w, pulpe.ErrUserAuthenticationFailed, http.StatusUnauthorized, h.logger)
+		default:
+			Error(w, err, http.StatusInternalServerError, h.logger)
+		}
+		return
+	}
+
+	err = attempts.RecordSuccess(challenge.UserID, ip)
+	if err != nil {
+		Error(w, err, http.StatusInternalServerError, h.logger)
+		return
+	}
+
+	if fromCookie {
+		pulpeHttp.ClearTwoFactorCookie(w)
+	}
+	pulpeHttp.SetSessionCookie(w, us)
 
 	w.WriteHeader(http.StatusCreated)
 }
 
 // handleUserMe returns the current user information.
 func (h *userHandler) handleUserMe(w http.ResponseWriter, r *http.Request) {
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	user, err := session.Authenticate()
@@ -138,6 +288,8 @@ type UserRegistrationRequest struct {
 	FullName string `json:"fullName" valid:"required,stringlength(1|64)"`
 	Email    string `json:"email" valid:"required,email"`
 	Password string `json:"password" valid:"required,stringlength(6|64)"`
+	// Token of a board invitation accepted once registered.
+	Invitation string `json:"invitation"`
 }
 
 // Validate user registration payload.
@@ -159,9 +311,29 @@ func (u *UserRegistrationRequest) Validate() (*pulpe.UserRegistration, error) {
 type UserLoginRequest struct {
 	EmailOrLogin string `json:"login" valid:"required,stringlength(1|64)"`
 	Password     string `json:"password" valid:"required,stringlength(1|64)"`
+	Remember     bool   `json:"remember"`
 }
 
 // Validate user login payload.
 func (u *UserLoginRequest) Validate() error {
 	return validation.Validate(u)
 }
+
+// twoFactorChallengeResponse is sent when the user must complete a two-factor authentication challenge.
+type twoFactorChallengeResponse struct {
+	Challenge string    `json:"challenge"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// TwoFactorLoginRequest is used to complete a two-factor authentication challenge.
+// The challenge is read from the challenge cookie if empty.
+type TwoFactorLoginRequest struct {
+	Challenge string `json:"challenge" valid:"stringlength(1|64)"`
+	Code      string `json:"code" valid:"required,stringlength(6|32)"`
+}
+
+// Validate two-factor login payload.
+func (t *TwoFactorLoginRequest) Validate() error {
+	t.Code = strings.TrimSpace(t.Code)
+	return validation.Validate(t)
+}
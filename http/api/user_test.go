@@ -17,6 +17,7 @@ func TestUserHandler_Registration(t *testing.T) {
 	t.Run("OK", testUserHandler_Registration_OK)
 	t.Run("ErrInvalidJSON", testUserHandler_Registration_ErrInvalidJSON)
 	t.Run("ErrValidation", testUserHandler_Registration_ErrValidation)
+	t.Run("Invitation", testUserHandler_Registration_Invitation)
 	t.Run("NotFound", testUserHandler_Registration_EmailConflict)
 	t.Run("ErrInternal", testUserHandler_Registration_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
 }
@@ -40,7 +41,7 @@ func testUserHandler_Registration_OK(t *testing.T) {
 		}, nil
 	}
 
-	c.UserSessionService.CreateSessionFn = func(u *pulpe.User) (*pulpe.UserSession, error) {
+	c.UserSessionService.CreateSessionFn = func(u *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
 		require.Equal(t, &pulpe.User{
 			ID:        "123",
 			CreatedAt: mock.Now,
@@ -78,6 +79,38 @@ func testUserHandler_Registration_OK(t *testing.T) {
 	}`, w.Body.String())
 }
 
+func testUserHandler_Registration_Invitation(t *testing.T) {
+	c := mock.NewClient()
+
+	c.UserService.RegisterFn = func(u *pulpe.UserRegistration) (*pulpe.User, error) {
+		return &pulpe.User{ID: "123", Login: "login"}, nil
+	}
+
+	c.UserSessionService.CreateSessionFn = func(u *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+		return &pulpe.UserSession{ID: "456", ExpiresAt: mock.Now.Add(10 * time.Minute)}, nil
+	}
+
+	c.InvitationService.AcceptInvitationFn = func(token string) (*pulpe.Board, error) {
+		require.Equal(t, "456", c.Session.AuthToken)
+		require.Equal(t, "secret", token)
+		return nil, pulpe.ErrInvitationExpired
+	}
+
+	h := newHandler(c)
+
+	// the registration succeeds even if the invitation expired
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/register", bytes.NewReader([]byte(`{
+    "fullName": "Jon Snow",
+    "email": "jon.snow@wall.com",
+    "password": "password",
+    "invitation": "secret"
+  }`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.True(t, c.InvitationService.AcceptInvitationInvoked)
+}
+
 func testUserHandler_Registration_ErrInvalidJSON(t *testing.T) {
 	h := newHandler(mock.NewClient())
 
@@ -140,16 +173,22 @@ func testUserHandler_Registration_ErrValidation(t *testing.T) {
 
 func TestUserHandler_Login(t *testing.T) {
 	t.Run("OK", testUserHandler_Login_OK)
+	t.Run("RememberMe", testUserHandler_Login_RememberMe)
+	t.Run("TwoFactorChallenge", testUserHandler_Login_TwoFactorChallenge)
+	t.Run("Throttled", testUserHandler_Login_Throttled)
+	t.Run("RecordFailure", testUserHandler_Login_RecordFailure)
 	t.Run("ErrInvalidJSON", testUserHandler_Login_ErrInvalidJSON)
 	t.Run("ErrValidation", testUserHandler_Login_ErrValidation)
 	t.Run("NotFound", testUserHandler_Login_UserAuthenticationFailed)
+	t.Run("NoEmail", testUserHandler_Login_WithResponse(t, http.StatusForbidden, pulpe.ErrUserIdentityNoEmail))
+	t.Run("EmailConflict", testUserHandler_Login_WithResponse(t, http.StatusConflict, pulpe.ErrUserEmailConflict))
 	t.Run("ErrInternal", testUserHandler_Login_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
 }
 
 func testUserHandler_Login_OK(t *testing.T) {
 	c := mock.NewClient()
 
-	c.UserSessionService.LoginFn = func(loginOrEmail, password string) (*pulpe.UserSession, error) {
+	c.UserSessionService.LoginFn = func(loginOrEmail, password string, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
 		require.Equal(t, "jonsnow", loginOrEmail)
 		require.Equal(t, "password", password)
 
@@ -167,9 +206,120 @@ func testUserHandler_Login_OK(t *testing.T) {
     "login": "jonsnow",
 		"password": "password"
   }`)))
+	r.RemoteAddr = "10.0.0.1:1234"
 	h.ServeHTTP(w, r)
 	require.Equal(t, http.StatusCreated, w.Code)
 	require.Equal(t, "pulpesid=456; Path=/; Expires=Sat, 01 Jan 2000 00:10:00 GMT", w.HeaderMap.Get("Set-Cookie"))
+	require.True(t, c.LoginAttemptService.CheckInvoked)
+	require.False(t, c.LoginAttemptService.RecordFailureInvoked)
+	require.True(t, c.LoginAttemptService.RecordSuccessInvoked)
+}
+
+func testUserHandler_Login_Throttled(t *testing.T) {
+	c := mock.NewClient()
+
+	c.LoginAttemptService.CheckFn = func(login, ip string) (time.Duration, error) {
+		require.Equal(t, "jonsnow", login)
+		require.Equal(t, "10.0.0.1", ip)
+		return 1500 * time.Millisecond, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/login", bytes.NewReader([]byte(`{
+    "login": "jonsnow",
+		"password": "password"
+  }`)))
+	r.RemoteAddr = "10.0.0.1:1234"
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "2", w.Header().Get("Retry-After"))
+	require.JSONEq(t, `{"err": "too many login attempts"}`, w.Body.String())
+	require.False(t, c.UserSessionService.LoginInvoked)
+}
+
+func testUserHandler_Login_RecordFailure(t *testing.T) {
+	c := mock.NewClient()
+
+	c.UserSessionService.LoginFn = func(loginOrEmail, password string, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+		return nil, pulpe.ErrUserAuthenticationFailed
+	}
+
+	c.LoginAttemptService.RecordFailureFn = func(login, ip string) error {
+		require.Equal(t, "jonsnow", login)
+		require.Equal(t, "10.0.0.1", ip)
+		return nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/login", bytes.NewReader([]byte(`{
+    "login": "jonsnow",
+		"password": "password"
+  }`)))
+	r.RemoteAddr = "10.0.0.1:1234"
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.True(t, c.LoginAttemptService.RecordFailureInvoked)
+	require.False(t, c.LoginAttemptService.RecordSuccessInvoked)
+}
+
+func testUserHandler_Login_RememberMe(t *testing.T) {
+	c := mock.NewClient()
+
+	c.UserSessionService.LoginFn = func(loginOrEmail, password string, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+		var opts pulpe.SessionOptions
+		for i := range options {
+			options[i](&opts)
+		}
+		require.True(t, opts.Remember)
+
+		return &pulpe.UserSession{
+			ID:        "456",
+			UpdatedAt: mock.Now,
+			ExpiresAt: mock.Now.Add(30 * 24 * time.Hour),
+			Remember:  true,
+		}, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/login", bytes.NewReader([]byte(`{
+    "login": "jonsnow",
+		"password": "password",
+		"remember": true
+  }`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, "pulpesid=456; Path=/; Expires=Mon, 31 Jan 2000 00:00:00 GMT", w.HeaderMap.Get("Set-Cookie"))
+}
+
+func testUserHandler_Login_TwoFactorChallenge(t *testing.T) {
+	c := mock.NewClient()
+
+	c.UserSessionService.LoginFn = func(loginOrEmail, password string, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+		return &pulpe.UserSession{
+			ID:        "456",
+			UpdatedAt: mock.Now,
+			ExpiresAt: mock.Now.Add(5 * time.Minute),
+			Pending:   true,
+		}, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/login", bytes.NewReader([]byte(`{
+    "login": "jonsnow",
+		"password": "password"
+  }`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Empty(t, w.HeaderMap.Get("Set-Cookie"))
+	require.JSONEq(t, `{"challenge": "456", "expiresAt": "2000-01-01T00:05:00Z"}`, w.Body.String())
 }
 
 func testUserHandler_Login_ErrInvalidJSON(t *testing.T) {
@@ -188,7 +338,7 @@ func testUserHandler_Login_UserAuthenticationFailed(t *testing.T) {
 	c := mock.NewClient()
 	h := newHandler(c)
 
-	c.UserSessionService.LoginFn = func(loginOrEmail, password string) (*pulpe.UserSession, error) {
+	c.UserSessionService.LoginFn = func(loginOrEmail, password string, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
 		return nil, pulpe.ErrUserAuthenticationFailed
 	}
 
@@ -206,7 +356,7 @@ func testUserHandler_Login_WithResponse(t *testing.T, status int, err error) fun
 		c := mock.NewClient()
 		h := newHandler(c)
 
-		c.UserSessionService.LoginFn = func(loginOrEmail, password string) (*pulpe.UserSession, error) {
+		c.UserSessionService.LoginFn = func(loginOrEmail, password string, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
 			return nil, err
 		}
 
@@ -230,6 +380,173 @@ func testUserHandler_Login_ErrValidation(t *testing.T) {
 	require.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestUserHandler_LoginTwoFactor(t *testing.T) {
+	t.Run("OK", testUserHandler_LoginTwoFactor_OK)
+	t.Run("ErrValidation", testUserHandler_LoginTwoFactor_ErrValidation)
+	t.Run("InvalidCode", testUserHandler_LoginTwoFactor_WithResponse(t, http.StatusUnauthorized, pulpe.ErrTwoFactorInvalidCode))
+	t.Run("UnknownChallenge", testUserHandler_LoginTwoFactor_WithResponse(t, http.StatusUnauthorized, pulpe.ErrUserSessionUnknownID))
+	t.Run("ExpiredChallenge", testUserHandler_LoginTwoFactor_ExpiredChallenge)
+	t.Run("NoChallenge", testUserHandler_LoginTwoFactor_NoChallenge)
+	t.Run("Lockout", testUserHandler_LoginTwoFactor_Lockout)
+	t.Run("ErrInternal", testUserHandler_LoginTwoFactor_WithResponse(t, http.StatusInternalServerError, errors.New("unexpected error")))
+}
+
+// challengeOf returns pending sessions of the user with the given id.
+func challengeOf(userID string) func(id string) (*pulpe.UserSession, error) {
+	return func(id string) (*pulpe.UserSession, error) {
+		return &pulpe.UserSession{
+			ID:      id,
+			UserID:  userID,
+			Pending: true,
+		}, nil
+	}
+}
+
+func testUserHandler_LoginTwoFactor_OK(t *testing.T) {
+	c := mock.NewClient()
+
+	c.UserSessionService.ChallengeFn = challengeOf("123")
+	c.UserSessionService.VerifyTwoFactorFn = func(id, code string) (*pulpe.UserSession, error) {
+		require.Equal(t, "456", id)
+		require.Equal(t, "123456", code)
+
+		return &pulpe.UserSession{
+			ID:        "789",
+			UpdatedAt: mock.Now,
+			ExpiresAt: mock.Now.Add(10 * time.Minute),
+		}, nil
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/login/2fa", bytes.NewReader([]byte(`{
+		"challenge": "456",
+		"code": "123456"
+	}`)))
+	r.RemoteAddr = "10.0.0.1:1234"
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.True(t, c.UserSessionService.VerifyTwoFactorInvoked)
+	require.Equal(t, "pulpesid=789; Path=/; Expires=Sat, 01 Jan 2000 00:10:00 GMT", w.HeaderMap.Get("Set-Cookie"))
+	require.True(t, c.LoginAttemptService.CheckInvoked)
+	require.False(t, c.LoginAttemptService.RecordFailureInvoked)
+	require.True(t, c.LoginAttemptService.RecordSuccessInvoked)
+}
+
+func testUserHandler_LoginTwoFactor_ExpiredChallenge(t *testing.T) {
+	c := mock.NewClient()
+
+	c.UserSessionService.ChallengeFn = func(id string) (*pulpe.UserSession, error) {
+		return nil, pulpe.ErrUserSessionUnknownID
+	}
+
+	h := newHandler(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/login/2fa", bytes.NewReader([]byte(`{
+		"challenge": "456",
+		"code": "123456"
+	}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.False(t, c.UserSessionService.VerifyTwoFactorInvoked)
+	require.False(t, c.LoginAttemptService.RecordFailureInvoked)
+}
+
+func testUserHandler_LoginTwoFactor_NoChallenge(t *testing.T) {
+	c := mock.NewClient()
+	h := newHandler(c)
+
+	// neither in the payload nor in a cookie
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/login/2fa", bytes.NewReader([]byte(`{"code": "123456"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.False(t, c.UserSessionService.ChallengeInvoked)
+}
+
+func testUserHandler_LoginTwoFactor_Lockout(t *testing.T) {
+	c := mock.NewClient()
+
+	c.UserSessionService.ChallengeFn = challengeOf("123")
+	c.UserSessionService.VerifyTwoFactorFn = func(id, code string) (*pulpe.UserSession, error) {
+		return nil, pulpe.ErrTwoFactorInvalidCode
+	}
+
+	failures := make(map[string]int)
+	c.LoginAttemptService.CheckFn = func(account, ip string) (time.Duration, error) {
+		require.Equal(t, "123", account)
+		if failures[account+" "+ip] >= 3 {
+			return time.Minute, nil
+		}
+		return 0, nil
+	}
+	c.LoginAttemptService.RecordFailureFn = func(account, ip string) error {
+		failures[account+" "+ip]++
+		return nil
+	}
+
+	h := newHandler(c)
+
+	post := func(challenge string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/login/2fa", bytes.NewReader([]byte(`{
+			"challenge": "`+challenge+`",
+			"code": "123456"
+		}`)))
+		r.RemoteAddr = "10.0.0.1:1234"
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	// a new challenge is started with the password after each bad code
+	for _, challenge := range []string{"c1", "c2", "c3"} {
+		w := post(challenge)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	require.Equal(t, 3, failures["123 10.0.0.1"])
+
+	c.UserSessionService.VerifyTwoFactorInvoked = false
+	w := post("c4")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "60", w.Header().Get("Retry-After"))
+	require.False(t, c.UserSessionService.VerifyTwoFactorInvoked)
+	require.False(t, c.LoginAttemptService.RecordSuccessInvoked)
+}
+
+func testUserHandler_LoginTwoFactor_ErrValidation(t *testing.T) {
+	h := newHandler(mock.NewClient())
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/api/login/2fa", bytes.NewReader([]byte(`{"challenge": "456"}`)))
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func testUserHandler_LoginTwoFactor_WithResponse(t *testing.T, status int, err error) func(*testing.T) {
+	return func(t *testing.T) {
+		c := mock.NewClient()
+		h := newHandler(c)
+
+		c.UserSessionService.ChallengeFn = challengeOf("123")
+		c.UserSessionService.VerifyTwoFactorFn = func(id, code string) (*pulpe.UserSession, error) {
+			return nil, err
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/login/2fa", bytes.NewReader([]byte(`{
+			"challenge": "456",
+			"code": "123456"
+		}`)))
+		h.ServeHTTP(w, r)
+		require.Equal(t, status, w.Code)
+		require.Empty(t, w.HeaderMap.Get("Set-Cookie"))
+		require.Equal(t, err == pulpe.ErrTwoFactorInvalidCode, c.LoginAttemptService.RecordFailureInvoked)
+		require.False(t, c.LoginAttemptService.RecordSuccessInvoked)
+	}
+}
+
 func TestUserHandler_Me(t *testing.T) {
 	t.Run("OK", testUserHandler_Me_OK)
 	t.Run("NotFound", testUserHandler_Me_UserAuthenticationFailed)
@@ -0,0 +1,196 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/oidc"
+)
+
+const (
+	oidcCookieName = "pulpeoidc"
+	oidcCookiePath = "/auth/oidc/"
+
+	// maximum duration of the authentication on the identity provider.
+	oidcFlowTimeout = 10 * time.Minute
+)
+
+// RegisterOIDCHandler register the routes for OpenID Connect single sign-on.
+func RegisterOIDCHandler(mux *ServeMux, connect Connector, provider *oidc.Provider) {
+	h := oidcHandler{
+		connect:  connect,
+		provider: provider,
+	}
+
+	mux.HandleFunc("/auth/oidc/login", h.handleLogin)
+	mux.HandleFunc("/auth/oidc/callback", h.handleCallback)
+}
+
+type oidcHandler struct {
+	connect  Connector
+	provider *oidc.Provider
+}
+
+// oidcFlow is stored in a cookie during the authentication on the identity provider.
+type oidcFlow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// handleLogin redirects the user to the identity provider.
+func (h *oidcHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var flow oidcFlow
+	var err error
+
+	for _, v := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		*v, err = oidc.RandomString()
+		if err != nil {
+			log.Print(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	data, err := json.Marshal(&flow)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(data),
+		Path:     oidcCookiePath,
+		Expires:  time.Now().UTC().Add(oidcFlowTimeout),
+		HttpOnly: true,
+	})
+
+	http.Redirect(w, r, h.provider.AuthCodeURL(flow.State, flow.Nonce, flow.Verifier), http.StatusFound)
+}
+
+// handleCallback completes the authentication and creates a user session.
+func (h *oidcHandler) handleCallback(w http.ResponseWriter, r *http.Request) {
+	flow, err := readOIDCFlow(r)
+	if err != nil {
+		http.Error(w, "authentication expired, please try again", http.StatusBadRequest)
+		return
+	}
+
+	// the flow can only be completed once
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Path:     oidcCookiePath,
+		Expires:  time.Now().UTC(),
+		HttpOnly: true,
+	})
+
+	q := r.URL.Query()
+	if q.Get("state") == "" || q.Get("state") != flow.State {
+		http.Error(w, "invalid state", http.StatusBadRequest)
+		return
+	}
+
+	if e := q.Get("error"); e != "" {
+		log.Printf("oidc: authentication failed: %s %s", e, q.Get("error_description"))
+		http.Error(w, "authentication failed", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := h.provider.Exchange(q.Get("code"), flow.Verifier)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "authentication failed", http.StatusUnauthorized)
+		return
+	}
+
+	if token.Nonce != flow.Nonce {
+		http.Error(w, "authentication failed", http.StatusUnauthorized)
+		return
+	}
+
+	session := h.connect(w, r)
+	defer session.Close()
+
+	current, err := session.Authenticate()
+	if err != nil && err != pulpe.ErrUserAuthenticationFailed {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	user, err := session.UserService().ResolveIdentity(&pulpe.ExternalIdentity{
+		Issuer:        token.Issuer,
+		Subject:       token.Subject,
+		Email:         token.Email,
+		EmailVerified: token.EmailVerified,
+		FullName:      token.Name,
+	})
+	if err != nil {
+		switch err {
+		case pulpe.ErrUserEmailConflict:
+			http.Error(w, "an account with this email already exists, sign in to link it", http.StatusConflict)
+		case pulpe.ErrUserIdentityNoEmail:
+			http.Error(w, "the identity provider didn't share your email", http.StatusForbidden)
+		default:
+			log.Print(err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// the identity was linked to the signed in user
+	if current != nil && current.ID == user.ID {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	us, err := session.UserSessionService().StartSession(user)
+	if err != nil {
+		switch err {
+		case pulpe.ErrUserAuthenticationFailed:
+			http.Error(w, "authentication failed", http.StatusUnauthorized)
+		default:
+			log.Print(err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// the two-factor authentication challenge is completed on the login page,
+	// the challenge is kept out of the URL.
+	if us.Pending {
+		SetTwoFactorCookie(w, us)
+		http.Redirect(w, r, "/login?step=two-factor", http.StatusFound)
+		return
+	}
+
+	SetSessionCookie(w, us)
+
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+func readOIDCFlow(r *http.Request) (*oidcFlow, error) {
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var flow oidcFlow
+	err = json.Unmarshal(data, &flow)
+	if err != nil {
+		return nil, err
+	}
+
+	return &flow, nil
+}
@@ -0,0 +1,309 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/http/api"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/blankrobot/pulpe/oidc"
+	"github.com/blankrobot/pulpe/oidc/oidctest"
+	"github.com/stretchr/testify/require"
+)
+
+func newOIDCHandler(t *testing.T, c *mock.Client) (http.Handler, *oidctest.Server) {
+	srv := oidctest.NewServer("pulpe", "secret")
+	srv.Identity = oidctest.Identity{
+		Subject:       "1234",
+		Email:         "jon.snow@wall.com",
+		EmailVerified: true,
+		Name:          "Jon Snow",
+	}
+
+	provider, err := oidc.NewProvider(oidc.Config{
+		Issuer:       srv.Issuer(),
+		ClientID:     "pulpe",
+		ClientSecret: "secret",
+		RedirectURL:  "http://pulpe.local/auth/oidc/callback",
+	})
+	require.NoError(t, err)
+
+	c.Session.AuthenticateFn = func() (*pulpe.User, error) {
+		return nil, pulpe.ErrUserAuthenticationFailed
+	}
+
+	mux := pulpeHttp.NewServeMux()
+	pulpeHttp.RegisterOIDCHandler(mux, pulpeHttp.NewCookieConnector(c), provider)
+
+	return mux, srv
+}
+
+// oidcLogin starts the flow and returns the callback request sent by the browser.
+func oidcLogin(t *testing.T, h http.Handler) *http.Request {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/auth/oidc/login", nil)
+	h.ServeHTTP(w, r)
+	require.Equal(t, http.StatusFound, w.Code)
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.True(t, cookies[0].HttpOnly)
+
+	client := http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(w.Header().Get("Location"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	u, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "/auth/oidc/callback", u.Path)
+
+	r, _ = http.NewRequest("GET", u.RequestURI(), nil)
+	r.AddCookie(cookies[0])
+	return r
+}
+
+func TestOIDCHandler(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		h, srv := newOIDCHandler(t, c)
+		defer srv.Close()
+
+		c.UserService.ResolveIdentityFn = func(identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+			require.Equal(t, &pulpe.ExternalIdentity{
+				Issuer:        srv.Issuer(),
+				Subject:       "1234",
+				Email:         "jon.snow@wall.com",
+				EmailVerified: true,
+				FullName:      "Jon Snow",
+			}, identity)
+
+			return &pulpe.User{ID: "123"}, nil
+		}
+
+		c.UserSessionService.StartSessionFn = func(user *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+			require.Equal(t, "123", user.ID)
+
+			return &pulpe.UserSession{
+				ID:        "456",
+				UserID:    "123",
+				UpdatedAt: mock.Now,
+				ExpiresAt: mock.Now.Add(10 * time.Minute),
+			}, nil
+		}
+
+		r := oidcLogin(t, h)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusFound, w.Code)
+		require.Equal(t, "/", w.Header().Get("Location"))
+		require.Contains(t, w.HeaderMap["Set-Cookie"], "pulpesid=456; Path=/; Expires=Sat, 01 Jan 2000 00:10:00 GMT")
+
+		// the flow can't be replayed
+		c.UserService.ResolveIdentityInvoked = false
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusUnauthorized, w.Code)
+		require.False(t, c.UserService.ResolveIdentityInvoked)
+	})
+
+	t.Run("InvalidState", func(t *testing.T) {
+		c := mock.NewClient()
+		h, srv := newOIDCHandler(t, c)
+		defer srv.Close()
+
+		r := oidcLogin(t, h)
+		q := r.URL.Query()
+		q.Set("state", "other")
+		r.URL.RawQuery = q.Encode()
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.False(t, c.UserService.ResolveIdentityInvoked)
+	})
+
+	t.Run("NoCookie", func(t *testing.T) {
+		c := mock.NewClient()
+		h, srv := newOIDCHandler(t, c)
+		defer srv.Close()
+
+		r := oidcLogin(t, h)
+		r.Header.Del("Cookie")
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.False(t, c.UserService.ResolveIdentityInvoked)
+	})
+
+	t.Run("EmailConflict", func(t *testing.T) {
+		c := mock.NewClient()
+		h, srv := newOIDCHandler(t, c)
+		defer srv.Close()
+
+		c.UserService.ResolveIdentityFn = func(identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+			return nil, pulpe.ErrUserEmailConflict
+		}
+
+		r := oidcLogin(t, h)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusConflict, w.Code)
+		require.False(t, c.UserSessionService.StartSessionInvoked)
+	})
+
+	t.Run("TwoFactor", func(t *testing.T) {
+		c := mock.NewClient()
+		h, srv := newOIDCHandler(t, c)
+		defer srv.Close()
+
+		c.UserService.ResolveIdentityFn = func(identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+			return &pulpe.User{ID: "123", TwoFactor: true}, nil
+		}
+
+		c.UserSessionService.StartSessionFn = func(user *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+			return &pulpe.UserSession{
+				ID:        "456",
+				UserID:    "123",
+				UpdatedAt: mock.Now,
+				ExpiresAt: mock.Now.Add(10 * time.Minute),
+				Pending:   true,
+			}, nil
+		}
+
+		r := oidcLogin(t, h)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusFound, w.Code)
+		require.Equal(t, "/login?step=two-factor", w.Header().Get("Location"))
+
+		// the challenge is only readable by the endpoint completing it
+		var challenge *http.Cookie
+		for _, cookie := range w.Result().Cookies() {
+			require.NotEqual(t, "pulpesid", cookie.Name)
+			if cookie.Name == pulpeHttp.TwoFactorCookieName {
+				challenge = cookie
+			}
+		}
+		require.NotNil(t, challenge)
+		require.Equal(t, "456", challenge.Value)
+		require.Equal(t, "/api/login/2fa", challenge.Path)
+		require.True(t, challenge.HttpOnly)
+	})
+
+	t.Run("Linked", func(t *testing.T) {
+		c := mock.NewClient()
+		h, srv := newOIDCHandler(t, c)
+		defer srv.Close()
+
+		c.Session.AuthenticateFn = func() (*pulpe.User, error) {
+			return &pulpe.User{ID: "123"}, nil
+		}
+
+		c.UserService.ResolveIdentityFn = func(identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+			return &pulpe.User{ID: "123"}, nil
+		}
+
+		r := oidcLogin(t, h)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		require.Equal(t, http.StatusFound, w.Code)
+		require.Equal(t, "/", w.Header().Get("Location"))
+		require.False(t, c.UserSessionService.StartSessionInvoked)
+	})
+}
+
+func TestOIDCHandler_TwoFactorFlow(t *testing.T) {
+	c := mock.NewClient()
+	h, srv := newOIDCHandler(t, c)
+	defer srv.Close()
+
+	mux := h.(*pulpeHttp.ServeMux)
+	api.Register(mux, pulpeHttp.NewCookieConnector(c))
+
+	c.UserService.ResolveIdentityFn = func(identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+		return &pulpe.User{ID: "123", TwoFactor: true}, nil
+	}
+
+	c.UserSessionService.StartSessionFn = func(user *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+		return &pulpe.UserSession{
+			ID:        "456",
+			UserID:    "123",
+			ExpiresAt: mock.Now.Add(5 * time.Minute),
+			Pending:   true,
+		}, nil
+	}
+
+	c.UserSessionService.ChallengeFn = func(id string) (*pulpe.UserSession, error) {
+		require.Equal(t, "456", id)
+		return &pulpe.UserSession{ID: id, UserID: "123", Pending: true}, nil
+	}
+
+	c.UserSessionService.VerifyTwoFactorFn = func(id, code string) (*pulpe.UserSession, error) {
+		require.Equal(t, "456", id)
+		if code != "123456" {
+			return nil, pulpe.ErrTwoFactorInvalidCode
+		}
+
+		return &pulpe.UserSession{
+			ID:        "789",
+			UserID:    "123",
+			UpdatedAt: mock.Now,
+			ExpiresAt: mock.Now.Add(10 * time.Minute),
+		}, nil
+	}
+
+	// the provider authenticates the user, who is sent to the login page
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, oidcLogin(t, mux))
+	require.Equal(t, http.StatusFound, w.Code)
+	require.Equal(t, "/login?step=two-factor", w.Header().Get("Location"))
+
+	var challenge *http.Cookie
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == pulpeHttp.TwoFactorCookieName {
+			challenge = cookie
+		}
+	}
+	require.NotNil(t, challenge)
+
+	verify := func(code string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/api/login/2fa", strings.NewReader(`{"code": "`+code+`"}`))
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.AddCookie(challenge)
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	// the login page sends the code, the challenge is read from the cookie
+	w = verify("000000")
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.True(t, c.LoginAttemptService.RecordFailureInvoked)
+	require.Empty(t, w.HeaderMap.Get("Set-Cookie"))
+
+	w = verify("123456")
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.True(t, c.LoginAttemptService.RecordSuccessInvoked)
+
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range w.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	require.Equal(t, "789", cookies[pulpeHttp.SessionCookieName].Value)
+	require.Empty(t, cookies[pulpeHttp.TwoFactorCookieName].Value)
+	require.Equal(t, "/api/login/2fa", cookies[pulpeHttp.TwoFactorCookieName].Path)
+}
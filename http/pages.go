@@ -7,7 +7,6 @@ import (
 	"log"
 	"net/http"
 	"path/filepath"
-	"time"
 
 	"github.com/blankrobot/pulpe"
 	"github.com/julienschmidt/httprouter"
@@ -61,7 +60,7 @@ func (h *pageHandler) render(wr io.Writer, name string, data interface{}) {
 }
 
 func (h *pageHandler) handleIndex(w http.ResponseWriter, r *http.Request) {
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	user, err := session.Authenticate()
@@ -74,6 +73,28 @@ func (h *pageHandler) handleIndex(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *pageHandler) handleRegister(w http.ResponseWriter, r *http.Request) {
+	// signed in users accept invitations directly, the others when registering
+	if token := r.URL.Query().Get("invitation"); token != "" {
+		session := h.connect(w, r)
+		defer session.Close()
+
+		if _, err := session.Authenticate(); err == nil {
+			board, err := session.InvitationService().AcceptInvitation(token)
+			switch err {
+			case nil:
+				http.Redirect(w, r, fmt.Sprintf("/%s/%s", board.Namespace(), board.Slug), http.StatusFound)
+			case pulpe.ErrInvitationNotFound, pulpe.ErrBoardNotFound:
+				http.NotFound(w, r)
+			case pulpe.ErrInvitationExpired:
+				http.Error(w, err.Error(), http.StatusGone)
+			default:
+				log.Print(err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
 	h.render(w, "register.tmpl.html", map[string]interface{}{
 		"Title": "Join",
 		"Dev":   h.dev,
@@ -88,13 +109,13 @@ func (h *pageHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *pageHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
-	cookie, err := r.Cookie("pulpesid")
+	cookie, err := r.Cookie(SessionCookieName)
 	if err != nil {
 		http.Redirect(w, r, "/login", http.StatusFound)
 		return
 	}
 
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
 	err = session.UserSessionService().DeleteSession(cookie.Value)
@@ -104,35 +125,55 @@ func (h *pageHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:    "pulpesid",
-		Expires: time.Now().UTC(),
-		Path:    "/",
-	})
+	ClearSessionCookie(w)
 
 	http.Redirect(w, r, "/login", http.StatusFound)
 }
 
 func (h *pageHandler) handleBoardPage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	session := h.connect(r)
+	session := h.connect(w, r)
 	defer session.Close()
 
-	user, err := session.Authenticate()
-	if err != nil {
-		http.Redirect(w, r, "/login", http.StatusFound)
+	owner := ps.ByName("owner")
+
+	// public and shared boards can be seen without being authenticated
+	if slug := ps.ByName("board"); slug != "" {
+		_, err := session.BoardService().BoardByOwnerAndSlug(owner, slug, pulpe.WithShareToken(r.URL.Query().Get("token")))
+		switch err {
+		case nil:
+			h.render(w, "board.tmpl.html", map[string]interface{}{
+				"Dev": h.dev,
+			})
+		case pulpe.ErrUserAuthenticationFailed:
+			http.Redirect(w, r, "/login", http.StatusFound)
+		case pulpe.ErrBoardNotFound:
+			http.NotFound(w, r)
+		default:
+			log.Print(err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
 		return
 	}
 
-	if user.Login != ps.ByName("owner") {
-		http.NotFound(w, r)
+	user, err := session.Authenticate()
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusFound)
 		return
 	}
 
-	if ps.ByName("board") != "" {
-		h.render(w, "board.tmpl.html", map[string]interface{}{
-			"Dev": h.dev,
-		})
-		return
+	// the owner is either the user or one of its organizations
+	if user.Login != owner {
+		_, err = session.OrganizationService().Organization(owner)
+		switch err {
+		case nil:
+		case pulpe.ErrOrganizationNotFound:
+			http.NotFound(w, r)
+			return
+		default:
+			log.Print(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 	}
 
 	boards, err := session.BoardService().Boards()
@@ -142,12 +183,14 @@ func (h *pageHandler) handleBoardPage(w http.ResponseWriter, r *http.Request, ps
 		return
 	}
 
-	if len(boards) == 0 {
-		h.render(w, "board.tmpl.html", map[string]interface{}{
-			"Dev": h.dev,
-		})
-		return
+	for _, b := range boards {
+		if b.Namespace() == owner {
+			http.Redirect(w, r, fmt.Sprintf("/%s/%s", owner, b.Slug), http.StatusFound)
+			return
+		}
 	}
 
-	http.Redirect(w, r, fmt.Sprintf("/%s/%s", user.Login, boards[0].Slug), http.StatusFound)
+	h.render(w, "board.tmpl.html", map[string]interface{}{
+		"Dev": h.dev,
+	})
 }
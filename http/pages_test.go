@@ -0,0 +1,183 @@
+package http_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	pulpeHttp "github.com/blankrobot/pulpe/http"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newPageHandler(t *testing.T, c *mock.Client) (http.Handler, func()) {
+	dir, err := ioutil.TempDir("", "pulpe")
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "board.tmpl.html"), []byte("board"), 0600)
+	require.NoError(t, err)
+
+	mux := pulpeHttp.NewServeMux()
+	pulpeHttp.RegisterPageHandler(mux, func(w http.ResponseWriter, r *http.Request) pulpe.Session {
+		return c.Connect()
+	}, dir, true)
+
+	return mux, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPageHandler_Board(t *testing.T) {
+	c := mock.NewClient()
+	h, cleanup := newPageHandler(t, c)
+	defer cleanup()
+
+	c.Session.AuthenticateFn = func() (*pulpe.User, error) {
+		return &pulpe.User{ID: "1", Login: "jonsnow"}, nil
+	}
+
+	c.OrganizationService.OrganizationFn = func(slug string) (*pulpe.Organization, error) {
+		if slug == "winterfell" {
+			return &pulpe.Organization{ID: "2", Slug: slug}, nil
+		}
+
+		return nil, pulpe.ErrOrganizationNotFound
+	}
+
+	c.BoardService.BoardsFn = func(...pulpe.BoardListOption) ([]*pulpe.Board, error) {
+		return []*pulpe.Board{
+			{Slug: "wall", Owner: &pulpe.User{Login: "jonsnow"}},
+			{Slug: "crypt", Organization: &pulpe.Organization{Slug: "winterfell"}},
+		}, nil
+	}
+
+	c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+		if (owner == "jonsnow" && slug == "wall") || (owner == "winterfell" && slug == "crypt") {
+			return &pulpe.Board{Slug: slug}, nil
+		}
+
+		return nil, pulpe.ErrBoardNotFound
+	}
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/jonsnow", http.StatusFound, "/jonsnow/wall"},
+		{"/winterfell", http.StatusFound, "/winterfell/crypt"},
+		{"/jonsnow/wall", http.StatusOK, ""},
+		{"/winterfell/crypt", http.StatusOK, ""},
+		{"/kingslanding", http.StatusNotFound, ""},
+		{"/kingslanding/throne", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.path, nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, test.status, w.Code, test.path)
+		require.Equal(t, test.location, w.Header().Get("Location"), test.path)
+	}
+}
+
+func TestPageHandler_PublicBoard(t *testing.T) {
+	c := mock.NewClient()
+	h, cleanup := newPageHandler(t, c)
+	defer cleanup()
+
+	c.Session.AuthenticateFn = func() (*pulpe.User, error) {
+		return nil, pulpe.ErrUserAuthenticationFailed
+	}
+
+	c.BoardService.BoardByOwnerAndSlugFn = func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+		var opts pulpe.BoardGetOptions
+		for _, o := range options {
+			o(&opts)
+		}
+
+		switch {
+		case slug == "wall":
+			return &pulpe.Board{Slug: slug, Visibility: pulpe.BoardVisibilityPublic, ReadOnly: true}, nil
+		case slug == "crypt" && opts.ShareToken == "secret":
+			return &pulpe.Board{Slug: slug, ReadOnly: true}, nil
+		}
+
+		return nil, pulpe.ErrUserAuthenticationFailed
+	}
+
+	tests := []struct {
+		path     string
+		status   int
+		location string
+	}{
+		{"/jonsnow/wall", http.StatusOK, ""},
+		{"/winterfell/crypt?token=secret", http.StatusOK, ""},
+		{"/winterfell/crypt", http.StatusFound, "/login"},
+		{"/jonsnow", http.StatusFound, "/login"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.path, nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, test.status, w.Code, test.path)
+		require.Equal(t, test.location, w.Header().Get("Location"), test.path)
+	}
+}
+
+func TestPageHandler_Join(t *testing.T) {
+	c := mock.NewClient()
+	dir, err := ioutil.TempDir("", "pulpe")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "register.tmpl.html"), []byte("register"), 0600)
+	require.NoError(t, err)
+
+	mux := pulpeHttp.NewServeMux()
+	pulpeHttp.RegisterPageHandler(mux, func(w http.ResponseWriter, r *http.Request) pulpe.Session {
+		return c.Connect()
+	}, dir, true)
+
+	c.InvitationService.AcceptInvitationFn = func(token string) (*pulpe.Board, error) {
+		if token == "secret" {
+			return &pulpe.Board{Slug: "wall", Owner: &pulpe.User{Login: "jonsnow"}}, nil
+		}
+
+		return nil, pulpe.ErrInvitationExpired
+	}
+
+	tests := []struct {
+		path     string
+		auth     bool
+		status   int
+		location string
+	}{
+		{"/join", false, http.StatusOK, ""},
+		{"/join?invitation=secret", false, http.StatusOK, ""},
+		{"/join?invitation=secret", true, http.StatusFound, "/jonsnow/wall"},
+		{"/join?invitation=old", true, http.StatusGone, ""},
+	}
+
+	for _, test := range tests {
+		auth := test.auth
+		c.Session.AuthenticateFn = func() (*pulpe.User, error) {
+			if auth {
+				return &pulpe.User{ID: "1", Login: "aryastark"}, nil
+			}
+
+			return nil, pulpe.ErrUserAuthenticationFailed
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.path, nil)
+		mux.ServeHTTP(w, r)
+		require.Equal(t, test.status, w.Code, test.path)
+		require.Equal(t, test.location, w.Header().Get("Location"), test.path)
+	}
+}
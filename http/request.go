@@ -6,27 +6,29 @@ import (
 	"strings"
 )
 
-// returns the client real ip address.
-// inspired by https://github.com/gin-gonic/gin/blob/32cab500ecc71d2975f5699c8a65c6debb29cfbe/context.go#L341
-func clientIP(r *http.Request) string {
-	clientIP := strings.TrimSpace(r.Header.Get("X-Real-Ip"))
-	if len(clientIP) > 0 {
-		return clientIP
+// ClientIP returns the IP address of the client connected to the server.
+// Behind a reverse proxy, ServeMux.TrustProxy replaces it by the address sent by the proxy.
+func ClientIP(r *http.Request) string {
+	if ip, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr)); err == nil {
+		return ip
 	}
 
-	clientIP = r.Header.Get("X-Forwarded-For")
-	if index := strings.IndexByte(clientIP, ','); index >= 0 {
-		clientIP = clientIP[0:index]
-	}
+	return ""
+}
 
-	clientIP = strings.TrimSpace(clientIP)
+// proxiedIP returns the client IP address sent by a reverse proxy.
+// Only the last address of X-Forwarded-For is used, since it is the one appended by the proxy,
+// the previous ones being sent by the client.
+func proxiedIP(r *http.Request) string {
+	clientIP := strings.TrimSpace(r.Header.Get("X-Real-Ip"))
 	if len(clientIP) > 0 {
 		return clientIP
 	}
 
-	if ip, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr)); err == nil {
-		return ip
+	clientIP = r.Header.Get("X-Forwarded-For")
+	if index := strings.LastIndexByte(clientIP, ','); index >= 0 {
+		clientIP = clientIP[index+1:]
 	}
 
-	return ""
+	return strings.TrimSpace(clientIP)
 }
@@ -67,18 +67,30 @@ func NewServeMux() *ServeMux {
 // ServeMux is a wrapper around a http.Handler.
 type ServeMux struct {
 	*http.ServeMux
+
+	// TrustProxy uses the client IP address sent by a reverse proxy in the X-Real-Ip
+	// or X-Forwarded-For headers. It must only be set when the server can't be reached directly,
+	// otherwise clients can send any address.
+	TrustProxy bool
 }
 
 // ServeHTTP delegates a request to the underlying ServeMux.
 func (s *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 
+	if s.TrustProxy {
+		if ip := proxiedIP(r); ip != "" {
+			r = r.WithContext(r.Context())
+			r.RemoteAddr = net.JoinHostPort(ip, "0")
+		}
+	}
+
 	rw := NewResponseWriter(w)
 	s.ServeMux.ServeHTTP(rw, r)
 
 	log.Printf(
 		"%s %s %s %d %d %s",
-		clientIP(r),
+		ClientIP(r),
 		r.Method,
 		r.URL,
 		rw.status,
@@ -116,19 +128,86 @@ func (w *ResponseWriter) Write(data []byte) (int, error) {
 	return w.ResponseWriter.Write(data)
 }
 
-// Connector creates a session from and a request.
-type Connector func(*http.Request) pulpe.Session
+// Connector creates a session from a request.
+// It might write headers to the response.
+type Connector func(http.ResponseWriter, *http.Request) pulpe.Session
 
 // NewCookieConnector returns a connector that creates a session and loads the session token from a cookie.
+// The user session is renewed and its new expiration date is sent to the client.
+// Sessions resolve the user session once, authenticating the request doesn't read it again.
 func NewCookieConnector(client pulpe.Client) Connector {
-	return func(r *http.Request) pulpe.Session {
+	return func(w http.ResponseWriter, r *http.Request) pulpe.Session {
 		session := client.Connect()
 
-		cookie, err := r.Cookie("pulpesid")
-		if err == nil {
-			session.SetAuthToken(cookie.Value)
+		cookie, err := r.Cookie(SessionCookieName)
+		if err != nil {
+			return session
 		}
 
+		us, err := session.UserSessionService().GetSession(cookie.Value)
+		switch err {
+		case nil:
+			SetSessionCookie(w, us)
+		case pulpe.ErrUserSessionUnknownID:
+			ClearSessionCookie(w)
+			return session
+		default:
+			log.Print(err)
+		}
+
+		session.SetAuthToken(cookie.Value)
 		return session
 	}
 }
+
+// SessionCookieName is the name of the cookie that holds the session id.
+const SessionCookieName = "pulpesid"
+
+// SetSessionCookie sends the session cookie to the client.
+func SetSessionCookie(w http.ResponseWriter, us *pulpe.UserSession) {
+	http.SetCookie(w, &http.Cookie{
+		Name:    SessionCookieName,
+		Value:   us.ID,
+		Expires: us.ExpiresAt,
+		Path:    "/",
+	})
+}
+
+// ClearSessionCookie tells the client to remove the session cookie.
+func ClearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:    SessionCookieName,
+		Expires: time.Now().UTC(),
+		Path:    "/",
+	})
+}
+
+// TwoFactorCookieName is the name of the cookie that holds the two-factor authentication challenge
+// of a sign in that didn't return it to the client, like the identity provider ones.
+// It is only sent to the endpoint completing the challenge and is not readable by scripts.
+const TwoFactorCookieName = "pulpe2fa"
+
+// twoFactorCookiePath is the path of the endpoint completing the challenge.
+const twoFactorCookiePath = "/api/login/2fa"
+
+// SetTwoFactorCookie sends the challenge of the pending session to the client.
+func SetTwoFactorCookie(w http.ResponseWriter, us *pulpe.UserSession) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     TwoFactorCookieName,
+		Value:    us.ID,
+		Expires:  us.ExpiresAt,
+		Path:     twoFactorCookiePath,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ClearTwoFactorCookie tells the client to remove the two-factor authentication challenge cookie.
+func ClearTwoFactorCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     TwoFactorCookieName,
+		Expires:  time.Now().UTC(),
+		Path:     twoFactorCookiePath,
+		HttpOnly: true,
+	})
+}
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/blankrobot/pulpe"
 	pulpeHttp "github.com/blankrobot/pulpe/http"
 	"github.com/blankrobot/pulpe/mock"
 	"github.com/stretchr/testify/require"
@@ -19,16 +21,103 @@ func TestServeMux(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestServeMux_TrustProxy(t *testing.T) {
+	var ip string
+	mux := pulpeHttp.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		ip = pulpeHttp.ClientIP(r)
+	})
+
+	t.Run("Direct", func(t *testing.T) {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("X-Real-Ip", "10.0.0.2")
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+		require.Equal(t, "10.0.0.1", ip)
+	})
+
+	mux.TrustProxy = true
+
+	t.Run("Real IP", func(t *testing.T) {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("X-Real-Ip", "10.0.0.2")
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+		require.Equal(t, "10.0.0.2", ip)
+	})
+
+	t.Run("Forwarded", func(t *testing.T) {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("X-Forwarded-For", "10.0.0.3, 10.0.0.2")
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+		require.Equal(t, "10.0.0.2", ip)
+	})
+
+	t.Run("No header", func(t *testing.T) {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		mux.ServeHTTP(httptest.NewRecorder(), r)
+		require.Equal(t, "10.0.0.1", ip)
+	})
+}
+
 func TestCookieConnector(t *testing.T) {
-	c := mock.NewClient()
-	connect := pulpeHttp.NewCookieConnector(c)
-	r, err := http.NewRequest("GET", "/", nil)
-	require.NoError(t, err)
-	r.AddCookie(&http.Cookie{
-		Name:  "pulpesid",
-		Value: "token",
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		c.UserSessionService.GetSessionFn = func(id string) (*pulpe.UserSession, error) {
+			require.Equal(t, "token", id)
+			return &pulpe.UserSession{
+				ID:        "token",
+				UpdatedAt: mock.Now,
+				ExpiresAt: mock.Now.Add(24 * time.Hour),
+			}, nil
+		}
+
+		connect := pulpeHttp.NewCookieConnector(c)
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", "/", nil)
+		require.NoError(t, err)
+		r.AddCookie(&http.Cookie{
+			Name:  "pulpesid",
+			Value: "token",
+		})
+
+		session := connect(w, r)
+		require.Equal(t, "token", session.(*mock.Session).AuthToken)
+		require.Equal(t, "pulpesid=token; Path=/; Expires=Sun, 02 Jan 2000 00:00:00 GMT", w.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("UnknownSession", func(t *testing.T) {
+		c := mock.NewClient()
+		c.UserSessionService.GetSessionFn = func(id string) (*pulpe.UserSession, error) {
+			return nil, pulpe.ErrUserSessionUnknownID
+		}
+
+		connect := pulpeHttp.NewCookieConnector(c)
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", "/", nil)
+		require.NoError(t, err)
+		r.AddCookie(&http.Cookie{
+			Name:  "pulpesid",
+			Value: "token",
+		})
+
+		session := connect(w, r)
+		require.False(t, session.(*mock.Session).SetAuthTokenInvoked)
+		require.Contains(t, w.Header().Get("Set-Cookie"), "pulpesid=;")
 	})
 
-	session := connect(r)
-	require.Equal(t, "token", session.(*mock.Session).AuthToken)
+	t.Run("NoCookie", func(t *testing.T) {
+		c := mock.NewClient()
+		connect := pulpeHttp.NewCookieConnector(c)
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", "/", nil)
+		require.NoError(t, err)
+
+		session := connect(w, r)
+		require.False(t, session.(*mock.Session).SetAuthTokenInvoked)
+		require.False(t, c.UserSessionService.GetSessionInvoked)
+		require.Empty(t, w.Header().Get("Set-Cookie"))
+	})
 }
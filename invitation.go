@@ -0,0 +1,59 @@
+package pulpe
+
+import "time"
+
+// Invitation errors
+const (
+	ErrInvitationNotFound = Error("invitation not found")
+	ErrInvitationExpired  = Error("invitation expired")
+	ErrInvitationNoMailer = Error("no mailer configured")
+)
+
+// An Invitation adds the users accepting it to a board with the given role.
+// It is either sent by email or shared as a link.
+type Invitation struct {
+	ID          string                  `json:"id"`
+	BoardID     string                  `json:"boardID"`
+	CreatedAt   time.Time               `json:"createdAt"`
+	ExpiresAt   time.Time               `json:"expiresAt"`
+	Email       string                  `json:"email,omitempty"`
+	Token       string                  `json:"token"`
+	Role        string                  `json:"role"`
+	MaxUses     int                     `json:"maxUses"`
+	Uses        int                     `json:"uses"`
+	Acceptances []*InvitationAcceptance `json:"acceptances,omitempty"`
+}
+
+// InvitationAcceptance records a user who accepted an invitation.
+type InvitationAcceptance struct {
+	UserID     string    `json:"userID"`
+	Login      string    `json:"login"`
+	AcceptedAt time.Time `json:"acceptedAt"`
+}
+
+// InvitationCreation is used to create an invitation.
+type InvitationCreation struct {
+	// Email of the invitee. If empty, the invitation is only returned as a link.
+	Email string
+	Role  string
+	// Lifetime of the invitation. Zero means the default lifetime.
+	ExpiresIn time.Duration
+	// Number of times the invitation can be accepted. Zero means one use
+	// for email invitations and no limit for links.
+	MaxUses int
+}
+
+// InvitationService represents a service for managing board invitations.
+// Only board admins can create, list and revoke invitations.
+type InvitationService interface {
+	CreateInvitation(boardID string, ic *InvitationCreation) (*Invitation, error)
+	Invitations(boardID string) ([]*Invitation, error)
+	RevokeInvitation(boardID, id string) error
+	// AcceptInvitation adds the authenticated user to the board of the invitation.
+	AcceptInvitation(token string) (*Board, error)
+}
+
+// Mailer sends emails to users.
+type Mailer interface {
+	SendInvitation(inv *Invitation, board *Board, inviter *User) error
+}
@@ -0,0 +1,253 @@
+// Package ldap implements a pulpe.CredentialVerifier that checks
+// credentials against an LDAP directory.
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	goldap "gopkg.in/ldap.v2"
+)
+
+// Defaults
+const (
+	DefaultUserFilter     = "(uid=%s)"
+	DefaultEmailAttribute = "mail"
+	DefaultNameAttribute  = "cn"
+	DefaultGroupAttribute = "memberOf"
+
+	timeout = 10 * time.Second
+)
+
+// Config of an LDAP directory.
+type Config struct {
+	// URL of the server, ldap:// or ldaps://
+	URL string
+
+	// Upgrade ldap:// connections with StartTLS.
+	StartTLS bool
+
+	// Credentials used to search users. Anonymous if empty.
+	BindDN       string
+	BindPassword string
+
+	// Base DN of the user search.
+	BaseDN string
+
+	// Filter used to find the user, %s is replaced by the escaped login.
+	UserFilter string
+
+	// Attributes of the user entries.
+	EmailAttribute string
+	NameAttribute  string
+	GroupAttribute string
+
+	// GroupRoles maps group DNs to pulpe roles. If nil, user roles are not managed by the directory.
+	GroupRoles map[string]string
+}
+
+// Ensure Verifier implements pulpe.CredentialVerifier.
+var _ pulpe.CredentialVerifier = new(Verifier)
+
+// Verifier binds against an LDAP directory to check user credentials.
+// Local users are created or updated from the directory attributes.
+type Verifier struct {
+	config Config
+}
+
+// NewVerifier returns a Verifier. Missing attributes are set to their default values.
+func NewVerifier(config Config) *Verifier {
+	if config.UserFilter == "" {
+		config.UserFilter = DefaultUserFilter
+	}
+	if config.EmailAttribute == "" {
+		config.EmailAttribute = DefaultEmailAttribute
+	}
+	if config.NameAttribute == "" {
+		config.NameAttribute = DefaultNameAttribute
+	}
+	if config.GroupAttribute == "" {
+		config.GroupAttribute = DefaultGroupAttribute
+	}
+
+	return &Verifier{config: config}
+}
+
+// VerifyCredentials binds as the user and returns the id of the matching local user.
+func (v *Verifier) VerifyCredentials(session pulpe.Session, loginOrEmail, password string) (string, error) {
+	// an empty password would be an unauthenticated bind, which always succeeds
+	if loginOrEmail == "" || password == "" {
+		return "", pulpe.ErrUserAuthenticationFailed
+	}
+
+	conn, err := v.connect()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	entry, err := v.search(conn, loginOrEmail)
+	if err != nil {
+		return "", err
+	}
+
+	if entry == nil {
+		return "", pulpe.ErrUserAuthenticationFailed
+	}
+
+	err = conn.Bind(entry.DN, password)
+	if err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			return "", pulpe.ErrUserAuthenticationFailed
+		}
+		return "", err
+	}
+
+	user, err := session.UserService().ResolveIdentity(v.identity(entry))
+	if err != nil {
+		return "", err
+	}
+
+	return user.ID, nil
+}
+
+// AccountKey returns the DN of the directory entry the login or email refers to.
+// The directory decides which spellings match the same entry.
+func (v *Verifier) AccountKey(session pulpe.Session, loginOrEmail string) (string, error) {
+	if loginOrEmail == "" {
+		return "", nil
+	}
+
+	conn, err := v.connect()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	entry, err := v.search(conn, loginOrEmail)
+	if err != nil || entry == nil {
+		return "", err
+	}
+
+	return strings.ToLower(entry.DN), nil
+}
+
+// connect dials the directory and binds with the search credentials.
+func (v *Verifier) connect() (*goldap.Conn, error) {
+	conn, err := v.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	if v.config.BindDN != "" {
+		err = conn.Bind(v.config.BindDN, v.config.BindPassword)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+// search returns the entry matching the login or email, or nil if there is not exactly one.
+func (v *Verifier) search(conn *goldap.Conn, loginOrEmail string) (*goldap.Entry, error) {
+	res, err := conn.Search(goldap.NewSearchRequest(
+		v.config.BaseDN,
+		goldap.ScopeWholeSubtree,
+		goldap.NeverDerefAliases,
+		2,
+		int(timeout/time.Second),
+		false,
+		strings.Replace(v.config.UserFilter, "%s", goldap.EscapeFilter(loginOrEmail), -1),
+		[]string{v.config.EmailAttribute, v.config.NameAttribute, v.config.GroupAttribute},
+		nil,
+	))
+	if err != nil && !goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded) {
+		return nil, err
+	}
+
+	if res == nil || len(res.Entries) != 1 {
+		return nil, nil
+	}
+
+	return res.Entries[0], nil
+}
+
+// identity returns the identity asserted by the directory entry.
+func (v *Verifier) identity(entry *goldap.Entry) *pulpe.ExternalIdentity {
+	identity := pulpe.ExternalIdentity{
+		Issuer:        v.config.URL,
+		Subject:       strings.ToLower(entry.DN),
+		Email:         entry.GetAttributeValue(v.config.EmailAttribute),
+		EmailVerified: true,
+		FullName:      entry.GetAttributeValue(v.config.NameAttribute),
+		Authoritative: true,
+	}
+
+	if v.config.GroupRoles == nil {
+		return &identity
+	}
+
+	groups := make(map[string]string)
+	for dn, role := range v.config.GroupRoles {
+		groups[strings.ToLower(dn)] = role
+	}
+
+	identity.Roles = []string{}
+	seen := make(map[string]bool)
+	for _, group := range entry.GetAttributeValues(v.config.GroupAttribute) {
+		role, ok := groups[strings.ToLower(group)]
+		if ok && !seen[role] {
+			seen[role] = true
+			identity.Roles = append(identity.Roles, role)
+		}
+	}
+
+	return &identity
+}
+
+func (v *Verifier) dial() (*goldap.Conn, error) {
+	u, err := url.Parse(v.config.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	host := u.Host
+	var conn *goldap.Conn
+
+	switch u.Scheme {
+	case "ldap":
+		if u.Port() == "" {
+			host = net.JoinHostPort(host, "389")
+		}
+		conn, err = goldap.Dial("tcp", host)
+	case "ldaps":
+		if u.Port() == "" {
+			host = net.JoinHostPort(host, "636")
+		}
+		conn, err = goldap.DialTLS("tcp", host, &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("ldap: unsupported scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetTimeout(timeout)
+
+	if v.config.StartTLS && u.Scheme == "ldap" {
+		err = conn.StartTLS(&tls.Config{ServerName: u.Hostname()})
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,167 @@
+package ldap_test
+
+import (
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/ldap"
+	"github.com/blankrobot/pulpe/ldap/ldaptest"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer() *ldaptest.Server {
+	return ldaptest.NewServer(
+		ldaptest.Entry{
+			DN:       "cn=pulpe,ou=services,dc=wall,dc=com",
+			Password: "service",
+		},
+		ldaptest.Entry{
+			DN:       "uid=jsnow,ou=people,dc=wall,dc=com",
+			Password: "ygritte",
+			Attributes: map[string][]string{
+				"uid":      {"jsnow"},
+				"cn":       {"Jon Snow"},
+				"mail":     {"jon.snow@wall.com"},
+				"memberOf": {"cn=Watch,ou=groups,dc=wall,dc=com", "cn=stark,ou=groups,dc=wall,dc=com"},
+			},
+		},
+		ldaptest.Entry{
+			DN:       "uid=sam,ou=people,dc=wall,dc=com",
+			Password: "gilly",
+			Attributes: map[string][]string{
+				"uid":  {"sam"},
+				"mail": {"sam@wall.com"},
+			},
+		},
+	)
+}
+
+func newVerifier(srv *ldaptest.Server, groupRoles map[string]string) *ldap.Verifier {
+	return ldap.NewVerifier(ldap.Config{
+		URL:          srv.URL(),
+		BindDN:       "cn=pulpe,ou=services,dc=wall,dc=com",
+		BindPassword: "service",
+		BaseDN:       "ou=people,dc=wall,dc=com",
+		GroupRoles:   groupRoles,
+	})
+}
+
+func TestVerifier(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	t.Run("OK", func(t *testing.T) {
+		c := mock.NewClient()
+		c.UserService.ResolveIdentityFn = func(identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+			require.Equal(t, &pulpe.ExternalIdentity{
+				Issuer:        srv.URL(),
+				Subject:       "uid=jsnow,ou=people,dc=wall,dc=com",
+				Email:         "jon.snow@wall.com",
+				EmailVerified: true,
+				FullName:      "Jon Snow",
+				Authoritative: true,
+			}, identity)
+
+			return &pulpe.User{ID: "123"}, nil
+		}
+
+		id, err := newVerifier(srv, nil).VerifyCredentials(c.Connect(), "jsnow", "ygritte")
+		require.NoError(t, err)
+		require.Equal(t, "123", id)
+	})
+
+	t.Run("GroupRoles", func(t *testing.T) {
+		c := mock.NewClient()
+		c.UserService.ResolveIdentityFn = func(identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+			require.Equal(t, []string{"member"}, identity.Roles)
+			return &pulpe.User{ID: "123"}, nil
+		}
+
+		v := newVerifier(srv, map[string]string{
+			"cn=watch,ou=groups,dc=wall,dc=com":    "member",
+			"cn=admins,ou=groups,dc=wall,dc=com":   "admin",
+			"cn=brothers,ou=groups,dc=wall,dc=com": "member",
+		})
+
+		_, err := v.VerifyCredentials(c.Connect(), "jsnow", "ygritte")
+		require.NoError(t, err)
+
+		// users without mapped groups have no roles
+		c.UserService.ResolveIdentityFn = func(identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+			require.NotNil(t, identity.Roles)
+			require.Empty(t, identity.Roles)
+			require.Empty(t, identity.FullName)
+			return &pulpe.User{ID: "456"}, nil
+		}
+
+		id, err := v.VerifyCredentials(c.Connect(), "sam", "gilly")
+		require.NoError(t, err)
+		require.Equal(t, "456", id)
+	})
+
+	t.Run("BadCredentials", func(t *testing.T) {
+		c := mock.NewClient()
+		v := newVerifier(srv, nil)
+
+		tests := []struct {
+			login, password string
+		}{
+			{"jsnow", "wrong"},
+			{"jsnow", ""},
+			{"unknown", "ygritte"},
+			{"*", "ygritte"},
+			{"", ""},
+		}
+
+		for _, test := range tests {
+			_, err := v.VerifyCredentials(c.Connect(), test.login, test.password)
+			require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+		}
+
+		require.False(t, c.UserService.ResolveIdentityInvoked)
+	})
+
+	t.Run("BadServiceAccount", func(t *testing.T) {
+		v := ldap.NewVerifier(ldap.Config{
+			URL:          srv.URL(),
+			BindDN:       "cn=pulpe,ou=services,dc=wall,dc=com",
+			BindPassword: "wrong",
+			BaseDN:       "ou=people,dc=wall,dc=com",
+		})
+
+		_, err := v.VerifyCredentials(mock.NewClient().Connect(), "jsnow", "ygritte")
+		require.Error(t, err)
+		require.NotEqual(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		v := ldap.NewVerifier(ldap.Config{
+			URL: "ldap://127.0.0.1:1",
+		})
+
+		_, err := v.VerifyCredentials(mock.NewClient().Connect(), "jsnow", "ygritte")
+		require.Error(t, err)
+	})
+}
+
+func TestVerifier_AccountKey(t *testing.T) {
+	srv := newServer()
+	defer srv.Close()
+
+	v := newVerifier(srv, nil)
+	session := mock.NewClient().Connect()
+
+	// every spelling the directory matches refers to the same account
+	for _, login := range []string{"jsnow", "JSnow"} {
+		key, err := v.AccountKey(session, login)
+		require.NoError(t, err)
+		require.Equal(t, "uid=jsnow,ou=people,dc=wall,dc=com", key)
+	}
+
+	for _, login := range []string{"unknown", "*", ""} {
+		key, err := v.AccountKey(session, login)
+		require.NoError(t, err)
+		require.Empty(t, key)
+	}
+}
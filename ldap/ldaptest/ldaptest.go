@@ -0,0 +1,355 @@
+// Package ldaptest provides an in-process LDAP server for testing.
+// It only supports simple binds and searches with equality, presence,
+// and, or and not filters.
+package ldaptest
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	ber "gopkg.in/asn1-ber.v1"
+)
+
+// LDAP protocol operations and result codes.
+const (
+	appBindRequest       = 0
+	appBindResponse      = 1
+	appUnbindRequest     = 2
+	appSearchRequest     = 3
+	appSearchResultEntry = 4
+	appSearchResultDone  = 5
+
+	resultSuccess                  = 0
+	resultProtocolError            = 2
+	resultInvalidCredentials       = 49
+	resultInsufficientAccessRights = 50
+
+	filterAnd           = 0
+	filterOr            = 1
+	filterNot           = 2
+	filterEqualityMatch = 3
+	filterPresent       = 7
+
+	scopeBaseObject   = 0
+	scopeSingleLevel  = 1
+	scopeWholeSubtree = 2
+)
+
+// Entry of the directory.
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// Server is an in-process LDAP server.
+type Server struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	entries []Entry
+	binds   int
+	conns   map[net.Conn]bool
+
+	wg sync.WaitGroup
+}
+
+// NewServer starts a server listening on a random local port.
+func NewServer(entries ...Entry) *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	s := Server{
+		listener: l,
+		entries:  entries,
+		conns:    make(map[net.Conn]bool),
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return &s
+}
+
+// URL returns the ldap:// URL of the server.
+func (s *Server) URL() string {
+	return "ldap://" + s.listener.Addr().String()
+}
+
+// AddEntry adds an entry to the directory.
+func (s *Server) AddEntry(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, e)
+}
+
+// Binds returns the number of successful binds.
+func (s *Server) Binds() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.binds
+}
+
+// Close stops the server and closes the open connections.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	var bound bool
+
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		id, ok := packet.Children[0].Value.(int64)
+		if !ok {
+			return
+		}
+
+		op := packet.Children[1]
+		switch op.Tag {
+		case appBindRequest:
+			var code int64
+			code, bound = s.bind(op)
+			err = write(conn, id, result(appBindResponse, code))
+		case appSearchRequest:
+			if !bound {
+				err = write(conn, id, result(appSearchResultDone, resultInsufficientAccessRights))
+				break
+			}
+			err = s.search(conn, id, op)
+		case appUnbindRequest:
+			return
+		default:
+			err = write(conn, id, result(appSearchResultDone, resultProtocolError))
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// bind checks the simple bind credentials and reports if the connection is authenticated.
+// Binds with an empty password are unauthenticated binds: they succeed,
+// as with most servers, but can't be used to search.
+func (s *Server) bind(op *ber.Packet) (int64, bool) {
+	if len(op.Children) < 3 {
+		return resultProtocolError, false
+	}
+
+	dn, _ := op.Children[1].Value.(string)
+	password := op.Children[2].Data.String()
+
+	if password == "" {
+		return resultSuccess, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		if strings.EqualFold(e.DN, dn) && e.Password != "" && e.Password == password {
+			s.binds++
+			return resultSuccess, true
+		}
+	}
+
+	return resultInvalidCredentials, false
+}
+
+func (s *Server) search(conn net.Conn, id int64, op *ber.Packet) error {
+	if len(op.Children) < 8 {
+		return write(conn, id, result(appSearchResultDone, resultProtocolError))
+	}
+
+	base, _ := op.Children[0].Value.(string)
+	scope, _ := op.Children[1].Value.(int64)
+	filter := op.Children[6]
+
+	var attrs []string
+	for _, a := range op.Children[7].Children {
+		if name, ok := a.Value.(string); ok {
+			attrs = append(attrs, name)
+		}
+	}
+
+	s.mu.Lock()
+	var found []Entry
+	for _, e := range s.entries {
+		if inScope(e.DN, base, scope) && match(e, filter) {
+			found = append(found, e)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, e := range found {
+		err := write(conn, id, entryPacket(e, attrs))
+		if err != nil {
+			return err
+		}
+	}
+
+	return write(conn, id, result(appSearchResultDone, resultSuccess))
+}
+
+func inScope(dn, base string, scope int64) bool {
+	dn, base = strings.ToLower(dn), strings.ToLower(base)
+
+	switch scope {
+	case scopeBaseObject:
+		return dn == base
+	case scopeSingleLevel:
+		return strings.HasSuffix(dn, ","+base) && !strings.Contains(strings.TrimSuffix(dn, ","+base), ",")
+	case scopeWholeSubtree:
+		return base == "" || dn == base || strings.HasSuffix(dn, ","+base)
+	}
+
+	return false
+}
+
+func match(e Entry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case filterAnd:
+		for _, f := range filter.Children {
+			if !match(e, f) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, f := range filter.Children {
+			if match(e, f) {
+				return true
+			}
+		}
+		return false
+	case filterNot:
+		return len(filter.Children) == 1 && !match(e, filter.Children[0])
+	case filterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		name, _ := filter.Children[0].Value.(string)
+		value, _ := filter.Children[1].Value.(string)
+		for _, v := range values(e, name) {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+		return false
+	case filterPresent:
+		return len(values(e, filter.Data.String())) > 0
+	}
+
+	return false
+}
+
+// values returns the values of an attribute, ignoring the case of its name.
+func values(e Entry, name string) []string {
+	for k, v := range e.Attributes {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func entryPacket(e Entry, attrs []string) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, appSearchResultEntry, nil, "Search Result Entry")
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, "DN"))
+
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, vals := range e.Attributes {
+		if !selected(name, attrs) {
+			continue
+		}
+
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, v := range vals {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "Value"))
+		}
+		attr.AppendChild(set)
+		list.AppendChild(attr)
+	}
+	p.AppendChild(list)
+
+	return p
+}
+
+func selected(name string, attrs []string) bool {
+	if len(attrs) == 0 {
+		return true
+	}
+
+	for _, a := range attrs {
+		if a == "*" || strings.EqualFold(a, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func result(tag ber.Tag, code int64) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return p
+}
+
+func write(conn net.Conn, id int64, op *ber.Packet) error {
+	p := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "Message ID"))
+	p.AppendChild(op)
+
+	_, err := conn.Write(p.Bytes())
+	return err
+}
@@ -17,18 +17,30 @@ type List struct {
 	Name      string     `json:"name"`
 	Slug      string     `json:"slug"`
 	Position  float64    `json:"position"`
+	ImportID  string     `json:"importID,omitempty"`
 }
 
 // ListCreation is used to create a List.
 type ListCreation struct {
 	Name     string
 	Position float64
+	// ImportID identifies the list in the tool it was imported from.
+	ImportID string
 }
 
 // ListUpdate is used to update a List.
 type ListUpdate struct {
 	Name     *string
 	Position *float64
+	// BoardID moves the list and its cards to another board.
+	BoardID *string
+}
+
+// ListCopy is used to copy a List and its cards to a board.
+type ListCopy struct {
+	BoardID string
+	// Position of the copy. The position of the list is kept if nil.
+	Position *float64
 }
 
 // ListService represents a service for managing lists.
@@ -37,6 +49,7 @@ type ListService interface {
 	List(id string) (*List, error)
 	DeleteList(id string) error
 	UpdateList(id string, u *ListUpdate) (*List, error)
+	CopyList(id string, c *ListCopy) (*List, error)
 	DeleteListsByBoardID(boardID string) error
 	ListsByBoard(boardID string) ([]*List, error)
 }
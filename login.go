@@ -0,0 +1,47 @@
+package pulpe
+
+import "time"
+
+// Login throttling errors
+const (
+	ErrLoginThrottled = Error("too many login attempts")
+)
+
+// Lockout scopes
+const (
+	// Failures on an account from a client IP.
+	LockoutScopeClient = "client"
+	// Failures from a client IP, whatever the account.
+	LockoutScopeIP = "ip"
+)
+
+// Lockout is recorded when too many failed login attempts
+// were made on the same account from the same client IP, or from the same client IP.
+type Lockout struct {
+	ID        string    `json:"id"`
+	Scope     string    `json:"scope"`
+	Account   string    `json:"account"`
+	IP        string    `json:"ip"`
+	Failures  int       `json:"failures"`
+	CreatedAt time.Time `json:"createdAt"`
+	Until     time.Time `json:"until"`
+}
+
+// LoginAttemptService tracks failed login attempts to slow down brute force attacks.
+// Failures on an account from any client only delay the next attempts,
+// so that no client can lock a user out of their account.
+// Failures on an account from a client IP, and from a client IP on any account, lock them out.
+type LoginAttemptService interface {
+	// Account returns the account a login or email refers to,
+	// the same for every spelling the credential verifier accepts.
+	Account(loginOrEmail string) (string, error)
+	// Check returns how long the client must wait before trying to log in to the account again.
+	Check(account, ip string) (time.Duration, error)
+	// RecordFailure records a failed login attempt and locks out the client IP
+	// if there were too many failures.
+	RecordFailure(account, ip string) error
+	// RecordSuccess resets the failed login attempts on the account.
+	RecordSuccess(account, ip string) error
+	// Lockouts returns the most recent lockouts.
+	Lockouts() ([]Lockout, error)
+}
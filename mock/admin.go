@@ -0,0 +1,54 @@
+package mock
+
+import "github.com/blankrobot/pulpe"
+
+// Ensure AdminService implements pulpe.AdminService.
+var _ pulpe.AdminService = new(AdminService)
+
+// AdminService is a mock service that runs provided functions. Useful for testing.
+type AdminService struct {
+	UsersFn      func(search string) ([]*pulpe.User, error)
+	UsersInvoked bool
+
+	SetUserDisabledFn      func(id string, disabled bool) (*pulpe.User, error)
+	SetUserDisabledInvoked bool
+
+	ResetPasswordFn      func(id string) (string, error)
+	ResetPasswordInvoked bool
+
+	RevokeSessionsFn      func(id string) error
+	RevokeSessionsInvoked bool
+
+	StatsFn      func() (*pulpe.InstanceStats, error)
+	StatsInvoked bool
+}
+
+// Users runs UsersFn and sets UsersInvoked to true when invoked.
+func (s *AdminService) Users(search string) ([]*pulpe.User, error) {
+	s.UsersInvoked = true
+	return s.UsersFn(search)
+}
+
+// SetUserDisabled runs SetUserDisabledFn and sets SetUserDisabledInvoked to true when invoked.
+func (s *AdminService) SetUserDisabled(id string, disabled bool) (*pulpe.User, error) {
+	s.SetUserDisabledInvoked = true
+	return s.SetUserDisabledFn(id, disabled)
+}
+
+// ResetPassword runs ResetPasswordFn and sets ResetPasswordInvoked to true when invoked.
+func (s *AdminService) ResetPassword(id string) (string, error) {
+	s.ResetPasswordInvoked = true
+	return s.ResetPasswordFn(id)
+}
+
+// RevokeSessions runs RevokeSessionsFn and sets RevokeSessionsInvoked to true when invoked.
+func (s *AdminService) RevokeSessions(id string) error {
+	s.RevokeSessionsInvoked = true
+	return s.RevokeSessionsFn(id)
+}
+
+// Stats runs StatsFn and sets StatsInvoked to true when invoked.
+func (s *AdminService) Stats() (*pulpe.InstanceStats, error) {
+	s.StatsInvoked = true
+	return s.StatsFn()
+}
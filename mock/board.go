@@ -16,7 +16,7 @@ type BoardService struct {
 	BoardByOwnerAndSlugFn      func(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error)
 	BoardByOwnerAndSlugInvoked bool
 
-	BoardsFn      func() ([]*pulpe.Board, error)
+	BoardsFn      func(options ...pulpe.BoardListOption) ([]*pulpe.Board, error)
 	BoardsInvoked bool
 
 	DeleteBoardFn      func(id string) error
@@ -24,6 +24,48 @@ type BoardService struct {
 
 	UpdateBoardFn      func(id string, u *pulpe.BoardUpdate) (*pulpe.Board, error)
 	UpdateBoardInvoked bool
+
+	CopyBoardFn      func(id string, c *pulpe.BoardCopy) (*pulpe.Board, error)
+	CopyBoardInvoked bool
+
+	TemplatesFn      func() ([]*pulpe.Board, error)
+	TemplatesInvoked bool
+
+	CreateShareLinkFn      func(boardID string) (*pulpe.BoardShareLink, error)
+	CreateShareLinkInvoked bool
+
+	ShareLinksFn      func(boardID string) ([]*pulpe.BoardShareLink, error)
+	ShareLinksInvoked bool
+
+	RevokeShareLinkFn      func(boardID, linkID string) error
+	RevokeShareLinkInvoked bool
+
+	CreateCardTemplateFn      func(boardID string, t *pulpe.CardTemplateCreation) (*pulpe.CardTemplate, error)
+	CreateCardTemplateInvoked bool
+
+	CardTemplatesFn      func(boardID string) ([]*pulpe.CardTemplate, error)
+	CardTemplatesInvoked bool
+
+	UpdateCardTemplateFn      func(boardID, templateID string, u *pulpe.CardTemplateUpdate) (*pulpe.CardTemplate, error)
+	UpdateCardTemplateInvoked bool
+
+	DeleteCardTemplateFn      func(boardID, templateID string) error
+	DeleteCardTemplateInvoked bool
+
+	CreateCustomFieldFn      func(boardID string, f *pulpe.CustomFieldCreation) (*pulpe.CustomField, error)
+	CreateCustomFieldInvoked bool
+
+	UpdateCustomFieldFn      func(boardID, fieldID string, u *pulpe.CustomFieldUpdate) (*pulpe.CustomField, error)
+	UpdateCustomFieldInvoked bool
+
+	DeleteCustomFieldFn      func(boardID, fieldID string) error
+	DeleteCustomFieldInvoked bool
+
+	ExportBoardFn      func(id string) (*pulpe.BoardArchive, error)
+	ExportBoardInvoked bool
+
+	ImportBoardFn      func(a *pulpe.BoardArchive, organization string) (*pulpe.Board, error)
+	ImportBoardInvoked bool
 }
 
 // CreateBoard runs CreateBoardFn and sets CreateBoardInvoked to true when invoked.
@@ -45,9 +87,9 @@ func (s *BoardService) BoardByOwnerAndSlug(owner, slug string, options ...pulpe.
 }
 
 // Boards runs BoardsFn and sets BoardsInvoked to true when invoked.
-func (s *BoardService) Boards() ([]*pulpe.Board, error) {
+func (s *BoardService) Boards(options ...pulpe.BoardListOption) ([]*pulpe.Board, error) {
 	s.BoardsInvoked = true
-	return s.BoardsFn()
+	return s.BoardsFn(options...)
 }
 
 // DeleteBoard runs DeleteBoardFn and sets DeleteBoardInvoked to true when invoked.
@@ -61,3 +103,87 @@ func (s *BoardService) UpdateBoard(id string, u *pulpe.BoardUpdate) (*pulpe.Boar
 	s.UpdateBoardInvoked = true
 	return s.UpdateBoardFn(id, u)
 }
+
+// CopyBoard runs CopyBoardFn and sets CopyBoardInvoked to true when invoked.
+func (s *BoardService) CopyBoard(id string, c *pulpe.BoardCopy) (*pulpe.Board, error) {
+	s.CopyBoardInvoked = true
+	return s.CopyBoardFn(id, c)
+}
+
+// Templates runs TemplatesFn and sets TemplatesInvoked to true when invoked.
+func (s *BoardService) Templates() ([]*pulpe.Board, error) {
+	s.TemplatesInvoked = true
+	return s.TemplatesFn()
+}
+
+// CreateShareLink runs CreateShareLinkFn and sets CreateShareLinkInvoked to true when invoked.
+func (s *BoardService) CreateShareLink(boardID string) (*pulpe.BoardShareLink, error) {
+	s.CreateShareLinkInvoked = true
+	return s.CreateShareLinkFn(boardID)
+}
+
+// ShareLinks runs ShareLinksFn and sets ShareLinksInvoked to true when invoked.
+func (s *BoardService) ShareLinks(boardID string) ([]*pulpe.BoardShareLink, error) {
+	s.ShareLinksInvoked = true
+	return s.ShareLinksFn(boardID)
+}
+
+// RevokeShareLink runs RevokeShareLinkFn and sets RevokeShareLinkInvoked to true when invoked.
+func (s *BoardService) RevokeShareLink(boardID, linkID string) error {
+	s.RevokeShareLinkInvoked = true
+	return s.RevokeShareLinkFn(boardID, linkID)
+}
+
+// CreateCardTemplate runs CreateCardTemplateFn and sets CreateCardTemplateInvoked to true when invoked.
+func (s *BoardService) CreateCardTemplate(boardID string, t *pulpe.CardTemplateCreation) (*pulpe.CardTemplate, error) {
+	s.CreateCardTemplateInvoked = true
+	return s.CreateCardTemplateFn(boardID, t)
+}
+
+// CardTemplates runs CardTemplatesFn and sets CardTemplatesInvoked to true when invoked.
+func (s *BoardService) CardTemplates(boardID string) ([]*pulpe.CardTemplate, error) {
+	s.CardTemplatesInvoked = true
+	return s.CardTemplatesFn(boardID)
+}
+
+// UpdateCardTemplate runs UpdateCardTemplateFn and sets UpdateCardTemplateInvoked to true when invoked.
+func (s *BoardService) UpdateCardTemplate(boardID, templateID string, u *pulpe.CardTemplateUpdate) (*pulpe.CardTemplate, error) {
+	s.UpdateCardTemplateInvoked = true
+	return s.UpdateCardTemplateFn(boardID, templateID, u)
+}
+
+// DeleteCardTemplate runs DeleteCardTemplateFn and sets DeleteCardTemplateInvoked to true when invoked.
+func (s *BoardService) DeleteCardTemplate(boardID, templateID string) error {
+	s.DeleteCardTemplateInvoked = true
+	return s.DeleteCardTemplateFn(boardID, templateID)
+}
+
+// CreateCustomField runs CreateCustomFieldFn and sets CreateCustomFieldInvoked to true when invoked.
+func (s *BoardService) CreateCustomField(boardID string, f *pulpe.CustomFieldCreation) (*pulpe.CustomField, error) {
+	s.CreateCustomFieldInvoked = true
+	return s.CreateCustomFieldFn(boardID, f)
+}
+
+// UpdateCustomField runs UpdateCustomFieldFn and sets UpdateCustomFieldInvoked to true when invoked.
+func (s *BoardService) UpdateCustomField(boardID, fieldID string, u *pulpe.CustomFieldUpdate) (*pulpe.CustomField, error) {
+	s.UpdateCustomFieldInvoked = true
+	return s.UpdateCustomFieldFn(boardID, fieldID, u)
+}
+
+// DeleteCustomField runs DeleteCustomFieldFn and sets DeleteCustomFieldInvoked to true when invoked.
+func (s *BoardService) DeleteCustomField(boardID, fieldID string) error {
+	s.DeleteCustomFieldInvoked = true
+	return s.DeleteCustomFieldFn(boardID, fieldID)
+}
+
+// ExportBoard runs ExportBoardFn and sets ExportBoardInvoked to true when invoked.
+func (s *BoardService) ExportBoard(id string) (*pulpe.BoardArchive, error) {
+	s.ExportBoardInvoked = true
+	return s.ExportBoardFn(id)
+}
+
+// ImportBoard runs ImportBoardFn and sets ImportBoardInvoked to true when invoked.
+func (s *BoardService) ImportBoard(a *pulpe.BoardArchive, organization string) (*pulpe.Board, error) {
+	s.ImportBoardInvoked = true
+	return s.ImportBoardFn(a, organization)
+}
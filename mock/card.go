@@ -25,7 +25,10 @@ type CardService struct {
 	UpdateCardFn      func(id string, u *pulpe.CardUpdate) (*pulpe.Card, error)
 	UpdateCardInvoked bool
 
-	CardsByBoardFn      func(boardID string) ([]*pulpe.Card, error)
+	CopyCardFn      func(id string, c *pulpe.CardCopy) (*pulpe.Card, error)
+	CopyCardInvoked bool
+
+	CardsByBoardFn      func(boardID string, options ...pulpe.CardListOption) ([]*pulpe.Card, error)
 	CardsByBoardInvoked bool
 }
 
@@ -65,8 +68,14 @@ func (s *CardService) UpdateCard(id string, u *pulpe.CardUpdate) (*pulpe.Card, e
 	return s.UpdateCardFn(id, u)
 }
 
+// CopyCard runs CopyCardFn and sets CopyCardInvoked to true when invoked.
+func (s *CardService) CopyCard(id string, c *pulpe.CardCopy) (*pulpe.Card, error) {
+	s.CopyCardInvoked = true
+	return s.CopyCardFn(id, c)
+}
+
 // CardsByBoard runs CardsByBoardFn and sets CardsByBoardInvoked to true when invoked.
-func (s *CardService) CardsByBoard(boardID string) ([]*pulpe.Card, error) {
+func (s *CardService) CardsByBoard(boardID string, options ...pulpe.CardListOption) ([]*pulpe.Card, error) {
 	s.CardsByBoardInvoked = true
-	return s.CardsByBoardFn(boardID)
+	return s.CardsByBoardFn(boardID, options...)
 }
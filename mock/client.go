@@ -17,12 +17,20 @@ func NewClient() *Client {
 // Client represents a mock client.
 type Client struct {
 	// Services
-	CardService        CardService
-	ListService        ListService
-	BoardService       BoardService
-	UserService        UserService
-	UserSessionService UserSessionService
-	Session            Session
+	CardService         CardService
+	ListService         ListService
+	BoardService        BoardService
+	UserService         UserService
+	OrganizationService OrganizationService
+	InvitationService   InvitationService
+	UserSessionService  UserSessionService
+	TwoFactorService    TwoFactorService
+	LoginAttemptService LoginAttemptService
+	AdminService        AdminService
+	SyncService         SyncService
+	HistoryService      HistoryService
+	RevisionService     RevisionService
+	Session             Session
 }
 
 // Connect creates a mock Session.
@@ -33,7 +41,15 @@ func (c *Client) Connect() pulpe.Session {
 	c.Session.listService = &c.ListService
 	c.Session.boardService = &c.BoardService
 	c.Session.userService = &c.UserService
+	c.Session.organizationService = &c.OrganizationService
+	c.Session.invitationService = &c.InvitationService
 	c.Session.userSessionService = &c.UserSessionService
+	c.Session.twoFactorService = &c.TwoFactorService
+	c.Session.loginAttemptService = &c.LoginAttemptService
+	c.Session.adminService = &c.AdminService
+	c.Session.syncService = &c.SyncService
+	c.Session.historyService = &c.HistoryService
+	c.Session.revisionService = &c.RevisionService
 	return &c.Session
 }
 
@@ -42,11 +58,19 @@ type Session struct {
 	now time.Time
 
 	// Services
-	cardService        *CardService
-	listService        *ListService
-	boardService       *BoardService
-	userService        *UserService
-	userSessionService *UserSessionService
+	cardService         *CardService
+	listService         *ListService
+	boardService        *BoardService
+	userService         *UserService
+	organizationService *OrganizationService
+	invitationService   *InvitationService
+	userSessionService  *UserSessionService
+	twoFactorService    *TwoFactorService
+	loginAttemptService *LoginAttemptService
+	adminService        *AdminService
+	syncService         *SyncService
+	historyService      *HistoryService
+	revisionService     *RevisionService
 
 	AuthenticateFn      func() (*pulpe.User, error)
 	AuthenticateInvoked bool
@@ -76,11 +100,51 @@ func (s *Session) UserService() pulpe.UserService {
 	return s.userService
 }
 
+// OrganizationService returns the session OrganizationService
+func (s *Session) OrganizationService() pulpe.OrganizationService {
+	return s.organizationService
+}
+
+// InvitationService returns the session InvitationService
+func (s *Session) InvitationService() pulpe.InvitationService {
+	return s.invitationService
+}
+
 // UserSessionService returns the session UserSessionService
 func (s *Session) UserSessionService() pulpe.UserSessionService {
 	return s.userSessionService
 }
 
+// TwoFactorService returns the session TwoFactorService
+func (s *Session) TwoFactorService() pulpe.TwoFactorService {
+	return s.twoFactorService
+}
+
+// LoginAttemptService returns the session LoginAttemptService
+func (s *Session) LoginAttemptService() pulpe.LoginAttemptService {
+	return s.loginAttemptService
+}
+
+// AdminService returns the session AdminService
+func (s *Session) AdminService() pulpe.AdminService {
+	return s.adminService
+}
+
+// SyncService returns the session SyncService
+func (s *Session) SyncService() pulpe.SyncService {
+	return s.syncService
+}
+
+// HistoryService returns the session HistoryService
+func (s *Session) HistoryService() pulpe.HistoryService {
+	return s.historyService
+}
+
+// RevisionService returns the session RevisionService
+func (s *Session) RevisionService() pulpe.RevisionService {
+	return s.revisionService
+}
+
 // Authenticate runs AuthenticateFn and sets AuthenticateInvoked to true when invoked.
 func (s *Session) Authenticate() (*pulpe.User, error) {
 	s.AuthenticateInvoked = true
@@ -0,0 +1,27 @@
+package mock
+
+import "github.com/blankrobot/pulpe"
+
+// Ensure HistoryService implements pulpe.HistoryService.
+var _ pulpe.HistoryService = new(HistoryService)
+
+// HistoryService is a mock service that runs provided functions. Useful for testing.
+type HistoryService struct {
+	UndoFn      func() (*pulpe.HistoryEntry, error)
+	UndoInvoked bool
+
+	RedoFn      func() (*pulpe.HistoryEntry, error)
+	RedoInvoked bool
+}
+
+// Undo runs UndoFn and sets UndoInvoked to true when invoked.
+func (s *HistoryService) Undo() (*pulpe.HistoryEntry, error) {
+	s.UndoInvoked = true
+	return s.UndoFn()
+}
+
+// Redo runs RedoFn and sets RedoInvoked to true when invoked.
+func (s *HistoryService) Redo() (*pulpe.HistoryEntry, error) {
+	s.RedoInvoked = true
+	return s.RedoFn()
+}
@@ -0,0 +1,57 @@
+package mock
+
+import "github.com/blankrobot/pulpe"
+
+// Ensure InvitationService implements pulpe.InvitationService.
+var _ pulpe.InvitationService = new(InvitationService)
+
+// InvitationService is a mock service that runs provided functions. Useful for testing.
+type InvitationService struct {
+	CreateInvitationFn      func(boardID string, ic *pulpe.InvitationCreation) (*pulpe.Invitation, error)
+	CreateInvitationInvoked bool
+
+	InvitationsFn      func(boardID string) ([]*pulpe.Invitation, error)
+	InvitationsInvoked bool
+
+	RevokeInvitationFn      func(boardID, id string) error
+	RevokeInvitationInvoked bool
+
+	AcceptInvitationFn      func(token string) (*pulpe.Board, error)
+	AcceptInvitationInvoked bool
+}
+
+// CreateInvitation runs CreateInvitationFn and sets CreateInvitationInvoked to true when invoked.
+func (s *InvitationService) CreateInvitation(boardID string, ic *pulpe.InvitationCreation) (*pulpe.Invitation, error) {
+	s.CreateInvitationInvoked = true
+	return s.CreateInvitationFn(boardID, ic)
+}
+
+// Invitations runs InvitationsFn and sets InvitationsInvoked to true when invoked.
+func (s *InvitationService) Invitations(boardID string) ([]*pulpe.Invitation, error) {
+	s.InvitationsInvoked = true
+	return s.InvitationsFn(boardID)
+}
+
+// RevokeInvitation runs RevokeInvitationFn and sets RevokeInvitationInvoked to true when invoked.
+func (s *InvitationService) RevokeInvitation(boardID, id string) error {
+	s.RevokeInvitationInvoked = true
+	return s.RevokeInvitationFn(boardID, id)
+}
+
+// AcceptInvitation runs AcceptInvitationFn and sets AcceptInvitationInvoked to true when invoked.
+func (s *InvitationService) AcceptInvitation(token string) (*pulpe.Board, error) {
+	s.AcceptInvitationInvoked = true
+	return s.AcceptInvitationFn(token)
+}
+
+// Mailer is a mock pulpe.Mailer.
+type Mailer struct {
+	SendInvitationFn      func(inv *pulpe.Invitation, board *pulpe.Board, inviter *pulpe.User) error
+	SendInvitationInvoked bool
+}
+
+// SendInvitation runs SendInvitationFn and sets SendInvitationInvoked to true when invoked.
+func (m *Mailer) SendInvitation(inv *pulpe.Invitation, board *pulpe.Board, inviter *pulpe.User) error {
+	m.SendInvitationInvoked = true
+	return m.SendInvitationFn(inv, board, inviter)
+}
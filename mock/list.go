@@ -22,6 +22,9 @@ type ListService struct {
 	UpdateListFn      func(id string, u *pulpe.ListUpdate) (*pulpe.List, error)
 	UpdateListInvoked bool
 
+	CopyListFn      func(id string, c *pulpe.ListCopy) (*pulpe.List, error)
+	CopyListInvoked bool
+
 	ListsByBoardFn      func(boardID string) ([]*pulpe.List, error)
 	ListsByBoardInvoked bool
 }
@@ -56,6 +59,12 @@ func (s *ListService) UpdateList(id string, u *pulpe.ListUpdate) (*pulpe.List, e
 	return s.UpdateListFn(id, u)
 }
 
+// CopyList runs CopyListFn and sets CopyListInvoked to true when invoked.
+func (s *ListService) CopyList(id string, c *pulpe.ListCopy) (*pulpe.List, error) {
+	s.CopyListInvoked = true
+	return s.CopyListFn(id, c)
+}
+
 // ListsByBoard runs ListsByBoardFn and sets ListsByBoardInvoked to true when invoked.
 func (s *ListService) ListsByBoard(boardID string) ([]*pulpe.List, error) {
 	s.ListsByBoardInvoked = true
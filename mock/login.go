@@ -0,0 +1,76 @@
+package mock
+
+import (
+	"time"
+
+	"github.com/blankrobot/pulpe"
+)
+
+// Ensure LoginAttemptService implements pulpe.LoginAttemptService.
+var _ pulpe.LoginAttemptService = new(LoginAttemptService)
+
+// LoginAttemptService is a mock service that runs provided functions. Useful for testing.
+// Unlike other mock services, functions are optional: attempts are never throttled by default.
+type LoginAttemptService struct {
+	AccountFn      func(loginOrEmail string) (string, error)
+	AccountInvoked bool
+
+	CheckFn      func(account, ip string) (time.Duration, error)
+	CheckInvoked bool
+
+	RecordFailureFn      func(account, ip string) error
+	RecordFailureInvoked bool
+
+	RecordSuccessFn      func(account, ip string) error
+	RecordSuccessInvoked bool
+
+	LockoutsFn      func() ([]pulpe.Lockout, error)
+	LockoutsInvoked bool
+}
+
+// Account runs AccountFn if it exists and sets AccountInvoked to true when invoked.
+// The login or email is the account by default.
+func (s *LoginAttemptService) Account(loginOrEmail string) (string, error) {
+	s.AccountInvoked = true
+	if s.AccountFn == nil {
+		return loginOrEmail, nil
+	}
+
+	return s.AccountFn(loginOrEmail)
+}
+
+// Check runs CheckFn if it exists and sets CheckInvoked to true when invoked.
+func (s *LoginAttemptService) Check(account, ip string) (time.Duration, error) {
+	s.CheckInvoked = true
+	if s.CheckFn == nil {
+		return 0, nil
+	}
+
+	return s.CheckFn(account, ip)
+}
+
+// RecordFailure runs RecordFailureFn if it exists and sets RecordFailureInvoked to true when invoked.
+func (s *LoginAttemptService) RecordFailure(account, ip string) error {
+	s.RecordFailureInvoked = true
+	if s.RecordFailureFn == nil {
+		return nil
+	}
+
+	return s.RecordFailureFn(account, ip)
+}
+
+// RecordSuccess runs RecordSuccessFn if it exists and sets RecordSuccessInvoked to true when invoked.
+func (s *LoginAttemptService) RecordSuccess(account, ip string) error {
+	s.RecordSuccessInvoked = true
+	if s.RecordSuccessFn == nil {
+		return nil
+	}
+
+	return s.RecordSuccessFn(account, ip)
+}
+
+// Lockouts runs LockoutsFn and sets LockoutsInvoked to true when invoked.
+func (s *LoginAttemptService) Lockouts() ([]pulpe.Lockout, error) {
+	s.LockoutsInvoked = true
+	return s.LockoutsFn()
+}
@@ -0,0 +1,54 @@
+package mock
+
+import "github.com/blankrobot/pulpe"
+
+// Ensure OrganizationService implements pulpe.OrganizationService.
+var _ pulpe.OrganizationService = new(OrganizationService)
+
+// OrganizationService is a mock service that runs provided functions. Useful for testing.
+type OrganizationService struct {
+	CreateOrganizationFn      func(oc *pulpe.OrganizationCreation) (*pulpe.Organization, error)
+	CreateOrganizationInvoked bool
+
+	OrganizationFn      func(slug string) (*pulpe.Organization, error)
+	OrganizationInvoked bool
+
+	OrganizationsFn      func() ([]*pulpe.Organization, error)
+	OrganizationsInvoked bool
+
+	SetMemberFn      func(slug, login, role string) (*pulpe.Organization, error)
+	SetMemberInvoked bool
+
+	RemoveMemberFn      func(slug, login string) (*pulpe.Organization, error)
+	RemoveMemberInvoked bool
+}
+
+// CreateOrganization runs CreateOrganizationFn and sets CreateOrganizationInvoked to true when invoked.
+func (s *OrganizationService) CreateOrganization(oc *pulpe.OrganizationCreation) (*pulpe.Organization, error) {
+	s.CreateOrganizationInvoked = true
+	return s.CreateOrganizationFn(oc)
+}
+
+// Organization runs OrganizationFn and sets OrganizationInvoked to true when invoked.
+func (s *OrganizationService) Organization(slug string) (*pulpe.Organization, error) {
+	s.OrganizationInvoked = true
+	return s.OrganizationFn(slug)
+}
+
+// Organizations runs OrganizationsFn and sets OrganizationsInvoked to true when invoked.
+func (s *OrganizationService) Organizations() ([]*pulpe.Organization, error) {
+	s.OrganizationsInvoked = true
+	return s.OrganizationsFn()
+}
+
+// SetMember runs SetMemberFn and sets SetMemberInvoked to true when invoked.
+func (s *OrganizationService) SetMember(slug, login, role string) (*pulpe.Organization, error) {
+	s.SetMemberInvoked = true
+	return s.SetMemberFn(slug, login, role)
+}
+
+// RemoveMember runs RemoveMemberFn and sets RemoveMemberInvoked to true when invoked.
+func (s *OrganizationService) RemoveMember(slug, login string) (*pulpe.Organization, error) {
+	s.RemoveMemberInvoked = true
+	return s.RemoveMemberFn(slug, login)
+}
@@ -0,0 +1,36 @@
+package mock
+
+import "github.com/blankrobot/pulpe"
+
+// Ensure RevisionService implements pulpe.RevisionService.
+var _ pulpe.RevisionService = new(RevisionService)
+
+// RevisionService is a mock service that runs provided functions. Useful for testing.
+type RevisionService struct {
+	RevisionsFn      func(cardID string) ([]*pulpe.Revision, error)
+	RevisionsInvoked bool
+
+	DiffRevisionsFn      func(cardID, fromID, toID string) (*pulpe.RevisionDiff, error)
+	DiffRevisionsInvoked bool
+
+	RestoreRevisionFn      func(cardID, revisionID string) (*pulpe.Card, error)
+	RestoreRevisionInvoked bool
+}
+
+// Revisions runs RevisionsFn and sets RevisionsInvoked to true when invoked.
+func (s *RevisionService) Revisions(cardID string) ([]*pulpe.Revision, error) {
+	s.RevisionsInvoked = true
+	return s.RevisionsFn(cardID)
+}
+
+// DiffRevisions runs DiffRevisionsFn and sets DiffRevisionsInvoked to true when invoked.
+func (s *RevisionService) DiffRevisions(cardID, fromID, toID string) (*pulpe.RevisionDiff, error) {
+	s.DiffRevisionsInvoked = true
+	return s.DiffRevisionsFn(cardID, fromID, toID)
+}
+
+// RestoreRevision runs RestoreRevisionFn and sets RestoreRevisionInvoked to true when invoked.
+func (s *RevisionService) RestoreRevision(cardID, revisionID string) (*pulpe.Card, error) {
+	s.RestoreRevisionInvoked = true
+	return s.RestoreRevisionFn(cardID, revisionID)
+}
@@ -0,0 +1,36 @@
+package mock
+
+import "github.com/blankrobot/pulpe"
+
+// Ensure SyncService implements pulpe.SyncService.
+var _ pulpe.SyncService = new(SyncService)
+
+// SyncService is a mock service that runs provided functions. Useful for testing.
+type SyncService struct {
+	BoardsChangesFn      func(token string) (*pulpe.Changes, error)
+	BoardsChangesInvoked bool
+
+	BoardChangesFn      func(boardID, token string, options ...pulpe.BoardGetOption) (*pulpe.Changes, error)
+	BoardChangesInvoked bool
+
+	SyncFn      func(boardID, token string, ops []*pulpe.SyncOperation) (*pulpe.SyncResult, error)
+	SyncInvoked bool
+}
+
+// BoardsChanges runs BoardsChangesFn and sets BoardsChangesInvoked to true when invoked.
+func (s *SyncService) BoardsChanges(token string) (*pulpe.Changes, error) {
+	s.BoardsChangesInvoked = true
+	return s.BoardsChangesFn(token)
+}
+
+// BoardChanges runs BoardChangesFn and sets BoardChangesInvoked to true when invoked.
+func (s *SyncService) BoardChanges(boardID, token string, options ...pulpe.BoardGetOption) (*pulpe.Changes, error) {
+	s.BoardChangesInvoked = true
+	return s.BoardChangesFn(boardID, token, options...)
+}
+
+// Sync runs SyncFn and sets SyncInvoked to true when invoked.
+func (s *SyncService) Sync(boardID, token string, ops []*pulpe.SyncOperation) (*pulpe.SyncResult, error) {
+	s.SyncInvoked = true
+	return s.SyncFn(boardID, token, ops)
+}
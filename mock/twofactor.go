@@ -0,0 +1,36 @@
+package mock
+
+import "github.com/blankrobot/pulpe"
+
+// Ensure TwoFactorService implements pulpe.TwoFactorService.
+var _ pulpe.TwoFactorService = new(TwoFactorService)
+
+// TwoFactorService is a mock service that runs provided functions. Useful for testing.
+type TwoFactorService struct {
+	EnrollTwoFactorFn      func() (*pulpe.TwoFactorEnrollment, error)
+	EnrollTwoFactorInvoked bool
+
+	ConfirmTwoFactorFn      func(code string) ([]string, error)
+	ConfirmTwoFactorInvoked bool
+
+	DisableTwoFactorFn      func(code string) error
+	DisableTwoFactorInvoked bool
+}
+
+// EnrollTwoFactor runs EnrollTwoFactorFn and sets EnrollTwoFactorInvoked to true when invoked.
+func (s *TwoFactorService) EnrollTwoFactor() (*pulpe.TwoFactorEnrollment, error) {
+	s.EnrollTwoFactorInvoked = true
+	return s.EnrollTwoFactorFn()
+}
+
+// ConfirmTwoFactor runs ConfirmTwoFactorFn and sets ConfirmTwoFactorInvoked to true when invoked.
+func (s *TwoFactorService) ConfirmTwoFactor(code string) ([]string, error) {
+	s.ConfirmTwoFactorInvoked = true
+	return s.ConfirmTwoFactorFn(code)
+}
+
+// DisableTwoFactor runs DisableTwoFactorFn and sets DisableTwoFactorInvoked to true when invoked.
+func (s *TwoFactorService) DisableTwoFactor(code string) error {
+	s.DisableTwoFactorInvoked = true
+	return s.DisableTwoFactorFn(code)
+}
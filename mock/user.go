@@ -13,8 +13,17 @@ type UserService struct {
 	UserFn      func(id string) (*pulpe.User, error)
 	UserInvoked bool
 
+	UserByLoginFn      func(login string) (*pulpe.User, error)
+	UserByLoginInvoked bool
+
 	MatchPasswordFn      func(login, passwd string) (string, error)
 	MatchPasswordInvoked bool
+
+	MatchLoginFn      func(login string) (string, error)
+	MatchLoginInvoked bool
+
+	ResolveIdentityFn      func(identity *pulpe.ExternalIdentity) (*pulpe.User, error)
+	ResolveIdentityInvoked bool
 }
 
 // Register runs RegisterFn and sets RegisterInvoked to true when invoked.
@@ -29,34 +38,67 @@ func (s *UserService) User(id string) (*pulpe.User, error) {
 	return s.UserFn(id)
 }
 
+// UserByLogin runs UserByLoginFn and sets UserByLoginInvoked to true when invoked.
+func (s *UserService) UserByLogin(login string) (*pulpe.User, error) {
+	s.UserByLoginInvoked = true
+	return s.UserByLoginFn(login)
+}
+
 // MatchPassword runs MatchPasswordFn and sets MatchPasswordnInvoked to true when invoked.
 func (s *UserService) MatchPassword(login, passwd string) (string, error) {
 	s.MatchPasswordInvoked = true
 	return s.MatchPasswordFn(login, passwd)
 }
 
+// MatchLogin runs MatchLoginFn and sets MatchLoginInvoked to true when invoked.
+func (s *UserService) MatchLogin(login string) (string, error) {
+	s.MatchLoginInvoked = true
+	return s.MatchLoginFn(login)
+}
+
+// ResolveIdentity runs ResolveIdentityFn and sets ResolveIdentityInvoked to true when invoked.
+func (s *UserService) ResolveIdentity(identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+	s.ResolveIdentityInvoked = true
+	return s.ResolveIdentityFn(identity)
+}
+
 // Ensure UserSessionService implements pulpe.UserSessionService.
 var _ pulpe.UserSessionService = new(UserSessionService)
 
 // UserSessionService is a mock service that runs provided functions. Useful for testing.
 type UserSessionService struct {
-	CreateSessionFn      func(user *pulpe.User) (*pulpe.UserSession, error)
+	CreateSessionFn      func(user *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error)
 	CreateSessionInvoked bool
 
+	StartSessionFn      func(user *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error)
+	StartSessionInvoked bool
+
 	GetSessionFn      func(sid string) (*pulpe.UserSession, error)
 	GetSessionInvoked bool
 
-	LoginFn      func(login, passwd string) (*pulpe.UserSession, error)
+	LoginFn      func(login, passwd string, options ...pulpe.SessionOption) (*pulpe.UserSession, error)
 	LoginInvoked bool
 
+	ChallengeFn      func(id string) (*pulpe.UserSession, error)
+	ChallengeInvoked bool
+
+	VerifyTwoFactorFn      func(id, code string) (*pulpe.UserSession, error)
+	VerifyTwoFactorInvoked bool
+
 	DeleteSessionFn      func(id string) error
 	DeleteSessionInvoked bool
 }
 
 // CreateSession runs CreateSessionFn and sets CreateSessionInvoked to true when invoked.
-func (s *UserSessionService) CreateSession(user *pulpe.User) (*pulpe.UserSession, error) {
+func (s *UserSessionService) CreateSession(user *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
 	s.CreateSessionInvoked = true
-	return s.CreateSessionFn(user)
+	return s.CreateSessionFn(user, options...)
+}
+
+// StartSession runs StartSessionFn and sets StartSessionInvoked to true when invoked.
+func (s *UserSessionService) StartSession(user *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+	s.StartSessionInvoked = true
+	return s.StartSessionFn(user, options...)
 }
 
 // GetSession runs GetSessionFn and sets GetSessionInvoked to true when invoked.
@@ -66,9 +108,21 @@ func (s *UserSessionService) GetSession(sid string) (*pulpe.UserSession, error)
 }
 
 // Login runs LoginFn and sets LoginInvoked to true when invoked.
-func (s *UserSessionService) Login(login, passwd string) (*pulpe.UserSession, error) {
+func (s *UserSessionService) Login(login, passwd string, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
 	s.LoginInvoked = true
-	return s.LoginFn(login, passwd)
+	return s.LoginFn(login, passwd, options...)
+}
+
+// Challenge runs ChallengeFn and sets ChallengeInvoked to true when invoked.
+func (s *UserSessionService) Challenge(id string) (*pulpe.UserSession, error) {
+	s.ChallengeInvoked = true
+	return s.ChallengeFn(id)
+}
+
+// VerifyTwoFactor runs VerifyTwoFactorFn and sets VerifyTwoFactorInvoked to true when invoked.
+func (s *UserSessionService) VerifyTwoFactor(id, code string) (*pulpe.UserSession, error) {
+	s.VerifyTwoFactorInvoked = true
+	return s.VerifyTwoFactorFn(id, code)
 }
 
 // DeleteSession runs DeleteSessionFn and sets DeleteSessionInvoked to true when invoked.
@@ -0,0 +1,70 @@
+package mongo
+
+import "gopkg.in/mgo.v2/bson"
+
+// access describes the content a user can reach: everything owned by the
+// user or by its organizations, and the boards the user was invited to.
+type access struct {
+	userID   string
+	ownerIDs []string
+	boardIDs []string
+}
+
+// access returns the content the user can reach.
+func (s *Session) access(userID string) (*access, error) {
+	ownerIDs, err := s.organizationService.ownerIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var bs []board
+	err = s.db.C(boardCol).Find(bson.M{"members.userID": userID}).Select(bson.M{"_id": 1}).All(&bs)
+	if err != nil {
+		return nil, err
+	}
+
+	boardIDs := make([]string, len(bs))
+	for i := range bs {
+		boardIDs[i] = bs[i].ID.Hex()
+	}
+
+	return &access{
+		userID:   userID,
+		ownerIDs: ownerIDs,
+		boardIDs: boardIDs,
+	}, nil
+}
+
+// owns returns true if the owner is the user or one of its organizations.
+func (a *access) owns(ownerID string) bool {
+	for _, id := range a.ownerIDs {
+		if id == ownerID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// boards returns the $or clauses matching the boards the user can reach.
+func (a *access) boards() []bson.M {
+	return []bson.M{
+		{"ownerID": bson.M{"$in": a.ownerIDs}},
+		{"members.userID": a.userID},
+	}
+}
+
+// content returns the $or clauses matching the lists and cards the user can reach.
+func (a *access) content() []bson.M {
+	return []bson.M{
+		{"ownerID": bson.M{"$in": a.ownerIDs}},
+		{"boardID": bson.M{"$in": a.boardIDs}},
+	}
+}
+
+// ownedBy returns the $or clauses matching the documents of a single owner.
+func ownedBy(ownerID string) []bson.M {
+	return []bson.M{
+		{"ownerID": ownerID},
+	}
+}
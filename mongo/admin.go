@@ -0,0 +1,218 @@
+package mongo
+
+import (
+	"regexp"
+
+	"github.com/blankrobot/pulpe"
+	"golang.org/x/crypto/bcrypt"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// maximum number of users returned by Users.
+const adminUsersLimit = 100
+
+// Ensure AdminService implements pulpe.AdminService.
+var _ pulpe.AdminService = new(AdminService)
+
+// AdminService represents a service for administrating the instance.
+type AdminService struct {
+	session *Session
+}
+
+// authenticate returns the authenticated user if it is an instance administrator.
+func (s *AdminService) authenticate() (*pulpe.User, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Admin {
+		return nil, pulpe.ErrAdminRequired
+	}
+
+	return user, nil
+}
+
+// Users returns the users whose login, email or full name contain search, sorted by login.
+func (s *AdminService) Users(search string) ([]*pulpe.User, error) {
+	_, err := s.authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{}
+	if search != "" {
+		re := bson.RegEx{Pattern: regexp.QuoteMeta(search), Options: "i"}
+		query["$or"] = []bson.M{
+			{"login": re},
+			{"email": re},
+			{"fullName": re},
+		}
+	}
+
+	var list []user
+	err = s.session.db.C(userCol).Find(query).Sort("login").Limit(adminUsersLimit).All(&list)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*pulpe.User, len(list))
+	for i := range list {
+		users[i] = list[i].toPulpeUser()
+	}
+
+	return users, nil
+}
+
+// SetUserDisabled disables or enables an account.
+// Disabling an account revokes its sessions.
+func (s *AdminService) SetUserDisabled(id string, disabled bool) (*pulpe.User, error) {
+	admin, err := s.authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	if !bson.IsObjectIdHex(id) {
+		return nil, pulpe.ErrUserNotFound
+	}
+
+	if disabled && id == admin.ID {
+		return nil, pulpe.ErrAdminSelfDisabled
+	}
+
+	var u user
+	_, err = s.session.db.C(userCol).FindId(bson.ObjectIdHex(id)).Apply(mgo.Change{
+		Update: bson.M{
+			"$set":         bson.M{"disabled": disabled},
+			"$currentDate": bson.M{"updatedAt": true},
+		},
+		ReturnNew: true,
+	}, &u)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if disabled {
+		err = s.deleteSessions(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return u.toPulpeUser(), nil
+}
+
+// ResetPassword replaces the password of a user by a random one,
+// revokes its sessions and returns the new password.
+func (s *AdminService) ResetPassword(id string) (string, error) {
+	_, err := s.authenticate()
+	if err != nil {
+		return "", err
+	}
+
+	if !bson.IsObjectIdHex(id) {
+		return "", pulpe.ErrUserNotFound
+	}
+
+	passwd, err := generateRandomString(12)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(passwd), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.session.db.C(userCol).UpdateId(bson.ObjectIdHex(id), bson.M{
+		"$set":         bson.M{"password": string(hash)},
+		"$currentDate": bson.M{"updatedAt": true},
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return "", pulpe.ErrUserNotFound
+		}
+		return "", err
+	}
+
+	err = s.deleteSessions(id)
+	if err != nil {
+		return "", err
+	}
+
+	return passwd, nil
+}
+
+// RevokeSessions logs a user out of every device.
+func (s *AdminService) RevokeSessions(id string) error {
+	_, err := s.authenticate()
+	if err != nil {
+		return err
+	}
+
+	// make sure the user exists
+	_, err = s.session.userService.User(id)
+	if err != nil {
+		return err
+	}
+
+	return s.deleteSessions(id)
+}
+
+// deleteSessions removes every session of the given user, including pending ones.
+func (s *AdminService) deleteSessions(userID string) error {
+	if us := s.session.userSession; us != nil && us.UserID == userID {
+		s.session.userSession = nil
+	}
+
+	_, err := s.session.db.C(userSessionCol).RemoveAll(bson.M{"userID": userID})
+	return err
+}
+
+// Stats returns instance-wide counts.
+func (s *AdminService) Stats() (*pulpe.InstanceStats, error) {
+	_, err := s.authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	var stats pulpe.InstanceStats
+
+	counts := []struct {
+		col   string
+		count *int
+	}{
+		{userCol, &stats.Users},
+		{boardCol, &stats.Boards},
+		{listCol, &stats.Lists},
+		{cardCol, &stats.Cards},
+	}
+
+	for _, c := range counts {
+		*c.count, err = s.session.db.C(c.col).Count()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &stats, nil
+}
+
+// GrantAdmin makes the user with the given login an instance administrator.
+// It doesn't require authentication and is meant to be used by operators
+// with access to the database, to bootstrap the first administrator.
+func (s *AdminService) GrantAdmin(login string) error {
+	err := s.session.db.C(userCol).Update(bson.M{"login": login}, bson.M{
+		"$set":         bson.M{"admin": true},
+		"$currentDate": bson.M{"updatedAt": true},
+	})
+	if err == mgo.ErrNotFound {
+		return pulpe.ErrUserNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,244 @@
+package mongo_test
+
+import (
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mongo"
+	"github.com/stretchr/testify/require"
+)
+
+// newAdmin creates an instance administrator and returns a session authenticated as it.
+func newAdmin(t *testing.T) (*mongo.Session, *pulpe.User) {
+	session := client.Connect()
+	user := createUser(t, session, "Admin")
+
+	err := session.AdminService().(*mongo.AdminService).GrantAdmin(user.Login)
+	require.NoError(t, err)
+
+	us, err := session.UserSessionService().CreateSession(user)
+	require.NoError(t, err)
+	session.SetAuthToken(us.ID)
+
+	return session, user
+}
+
+// loginAs creates a session for the given user and returns a new connection authenticated with it.
+func loginAs(t *testing.T, user *pulpe.User) (*mongo.Session, string) {
+	session := client.Connect()
+	us, err := session.UserSessionService().CreateSession(user)
+	require.NoError(t, err)
+	session.SetAuthToken(us.ID)
+	return session, us.ID
+}
+
+func TestAdminService_GrantAdmin(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("OK", func(t *testing.T) {
+		session, user := newAdmin(t)
+		defer session.Close()
+
+		me, err := session.Authenticate()
+		require.NoError(t, err)
+		require.Equal(t, user.ID, me.ID)
+		require.True(t, me.Admin)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		err := sessions.NoAuth.AdminService().(*mongo.AdminService).GrantAdmin("someone")
+		require.Equal(t, pulpe.ErrUserNotFound, err)
+	})
+}
+
+func TestAdminService_Users(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	session, _ := newAdmin(t)
+	defer session.Close()
+
+	t.Run("All", func(t *testing.T) {
+		users, err := session.AdminService().Users("")
+		require.NoError(t, err)
+		require.True(t, len(users) >= 4)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		red, err := sessions.Red.Authenticate()
+		require.NoError(t, err)
+
+		users, err := session.AdminService().Users("RE")
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		require.Equal(t, red.ID, users[1].ID)
+		require.Equal(t, "green", users[0].Login)
+	})
+
+	t.Run("Regexp", func(t *testing.T) {
+		users, err := session.AdminService().Users(".*")
+		require.NoError(t, err)
+		require.Len(t, users, 0)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		_, err := sessions.Red.AdminService().Users("")
+		require.Equal(t, pulpe.ErrAdminRequired, err)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := sessions.NoAuth.AdminService().Users("")
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+}
+
+func TestAdminService_SetUserDisabled(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	session, admin := newAdmin(t)
+	defer session.Close()
+
+	t.Run("OK", func(t *testing.T) {
+		user := createUser(t, session, "Disabled")
+		target, _ := loginAs(t, user)
+		defer target.Close()
+
+		u, err := session.AdminService().SetUserDisabled(user.ID, true)
+		require.NoError(t, err)
+		require.True(t, u.Disabled)
+
+		// sessions are revoked
+		_, err = target.Authenticate()
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+
+		// new sessions are rejected
+		disabled, _ := loginAs(t, user)
+		defer disabled.Close()
+		_, err = disabled.Authenticate()
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+
+		_, err = sessions.NoAuth.UserSessionService().Login(user.Login, userPassword)
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+
+		u, err = session.AdminService().SetUserDisabled(user.ID, false)
+		require.NoError(t, err)
+		require.False(t, u.Disabled)
+
+		_, err = sessions.NoAuth.UserSessionService().Login(user.Login, userPassword)
+		require.NoError(t, err)
+	})
+
+	t.Run("Self", func(t *testing.T) {
+		_, err := session.AdminService().SetUserDisabled(admin.ID, true)
+		require.Equal(t, pulpe.ErrAdminSelfDisabled, err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := session.AdminService().SetUserDisabled(newUserID(), true)
+		require.Equal(t, pulpe.ErrUserNotFound, err)
+
+		_, err = session.AdminService().SetUserDisabled("some id", true)
+		require.Equal(t, pulpe.ErrUserNotFound, err)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		_, err := sessions.Red.AdminService().SetUserDisabled(admin.ID, true)
+		require.Equal(t, pulpe.ErrAdminRequired, err)
+	})
+}
+
+func TestAdminService_ResetPassword(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	session, _ := newAdmin(t)
+	defer session.Close()
+
+	t.Run("OK", func(t *testing.T) {
+		user := createUser(t, session, "Reset")
+		target, _ := loginAs(t, user)
+		defer target.Close()
+
+		passwd, err := session.AdminService().ResetPassword(user.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, passwd)
+
+		_, err = target.Authenticate()
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+
+		_, err = sessions.NoAuth.UserService().MatchPassword(user.Login, userPassword)
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+
+		id, err := sessions.NoAuth.UserService().MatchPassword(user.Login, passwd)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, id)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := session.AdminService().ResetPassword(newUserID())
+		require.Equal(t, pulpe.ErrUserNotFound, err)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		_, err := sessions.Red.AdminService().ResetPassword(newUserID())
+		require.Equal(t, pulpe.ErrAdminRequired, err)
+	})
+}
+
+func TestAdminService_RevokeSessions(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	session, _ := newAdmin(t)
+	defer session.Close()
+
+	t.Run("OK", func(t *testing.T) {
+		user := createUser(t, session, "Revoked")
+		first, _ := loginAs(t, user)
+		defer first.Close()
+		second, _ := loginAs(t, user)
+		defer second.Close()
+
+		err := session.AdminService().RevokeSessions(user.ID)
+		require.NoError(t, err)
+
+		_, err = first.Authenticate()
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+		_, err = second.Authenticate()
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		err := session.AdminService().RevokeSessions(newUserID())
+		require.Equal(t, pulpe.ErrUserNotFound, err)
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		err := sessions.Red.AdminService().RevokeSessions(newUserID())
+		require.Equal(t, pulpe.ErrAdminRequired, err)
+	})
+}
+
+func TestAdminService_Stats(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	session, _ := newAdmin(t)
+	defer session.Close()
+
+	board := newBoard(t, sessions.Red)
+	newListWithBoardID(t, sessions.Red, board.ID)
+	newListWithBoardID(t, sessions.Red, board.ID)
+
+	stats, err := session.AdminService().Stats()
+	require.NoError(t, err)
+	require.Equal(t, 4, stats.Users)
+	require.Equal(t, 1, stats.Boards)
+	require.Equal(t, 2, stats.Lists)
+	require.Equal(t, 0, stats.Cards)
+
+	_, err = sessions.Red.AdminService().Stats()
+	require.Equal(t, pulpe.ErrAdminRequired, err)
+}
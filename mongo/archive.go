@@ -0,0 +1,275 @@
+package mongo
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/Machiel/slugify"
+	"github.com/blankrobot/pulpe"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// newObjectIDWithTime returns a unique ObjectId whose creation time is t,
+// so that imported documents keep their original creation date.
+// The current time is used if t is zero.
+func newObjectIDWithTime(t time.Time) bson.ObjectId {
+	if t.IsZero() {
+		return bson.NewObjectId()
+	}
+
+	id := []byte(string(bson.NewObjectId()))
+	binary.BigEndian.PutUint32(id[:4], uint32(t.Unix()))
+	return bson.ObjectId(id)
+}
+
+// utcTime returns a copy of t in UTC, or nil.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	u := t.UTC()
+	return &u
+}
+
+// ExportBoard returns an archive of a board the authenticated user is a member of.
+func (s *BoardService) ExportBoard(id string) (*pulpe.BoardArchive, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.boardByID(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	a := pulpe.BoardArchive{
+		Version:    pulpe.BoardArchiveVersion,
+		ExportedAt: s.session.now,
+		Board: &pulpe.ArchivedBoard{
+			ID:         b.ID.Hex(),
+			CreatedAt:  b.ID.Time().UTC(),
+			UpdatedAt:  utcTime(b.UpdatedAt),
+			Name:       b.Name,
+			Slug:       b.Slug,
+			Visibility: b.Visibility,
+		},
+		Lists: []*pulpe.ArchivedList{},
+		Cards: []*pulpe.ArchivedCard{},
+	}
+
+	for _, t := range b.CardTemplates {
+		a.CardTemplates = append(a.CardTemplates, &pulpe.ArchivedCardTemplate{
+			ID:          t.ID.Hex(),
+			CreatedAt:   t.ID.Time().UTC(),
+			Name:        t.Name,
+			Description: t.Description,
+			Labels:      t.Labels,
+			Checklist:   toPulpeChecklist(t.Checklist),
+		})
+	}
+
+	for _, f := range b.CustomFields {
+		a.CustomFields = append(a.CustomFields, &pulpe.ArchivedCustomField{
+			ID:        f.ID.Hex(),
+			CreatedAt: f.ID.Time().UTC(),
+			Name:      f.Name,
+			Type:      f.Type,
+			Options:   f.Options,
+		})
+	}
+
+	ls, err := s.session.listService.store.listsByBoardID(b.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range ls {
+		a.Lists = append(a.Lists, &pulpe.ArchivedList{
+			ID:        l.ID.Hex(),
+			CreatedAt: l.ID.Time().UTC(),
+			UpdatedAt: utcTime(l.UpdatedAt),
+			Name:      l.Name,
+			Slug:      l.Slug,
+			Position:  l.Position,
+		})
+	}
+
+	cs, err := s.session.cardService.store.cardsByBoardID(b.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range cs {
+		a.Cards = append(a.Cards, &pulpe.ArchivedCard{
+			ID:          c.ID.Hex(),
+			CreatedAt:   c.ID.Time().UTC(),
+			UpdatedAt:   utcTime(c.UpdatedAt),
+			ListID:      c.ListID,
+			Name:        c.Name,
+			Slug:        c.Slug,
+			Description: c.Description,
+			Position:    c.Position,
+			Labels:      c.Labels,
+			Checklist:   toPulpeChecklist(c.Checklist),
+			Due:         utcTime(c.Due),
+			Fields:      toPulpeFieldValues(c.Fields),
+		})
+	}
+
+	return &a, nil
+}
+
+// ImportBoard recreates an archived board, owned by the authenticated user
+// or by the organization with the given slug if not empty.
+// Creation and update dates are kept, slugs already taken by the owner are suffixed
+// and the board is removed if any of its lists or cards can't be created.
+func (s *BoardService) ImportBoard(a *pulpe.BoardArchive, organization string) (*pulpe.Board, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	visibility := a.Board.Visibility
+	if visibility != "" && visibility != pulpe.BoardVisibilityPrivate && visibility != pulpe.BoardVisibilityPublic {
+		return nil, pulpe.ErrBoardInvalidVisibility
+	}
+
+	b := board{
+		ID:         newObjectIDWithTime(a.Board.CreatedAt),
+		UpdatedAt:  utcTime(a.Board.UpdatedAt),
+		Name:       a.Board.Name,
+		Slug:       archivedSlug(a.Board.Slug, a.Board.Name),
+		OwnerID:    user.ID,
+		Visibility: visibility,
+	}
+
+	for _, t := range a.CardTemplates {
+		b.CardTemplates = append(b.CardTemplates, cardTemplate{
+			ID:          newObjectIDWithTime(t.CreatedAt),
+			Name:        t.Name,
+			Description: t.Description,
+			Labels:      t.Labels,
+			Checklist:   toChecklist(t.Checklist),
+		})
+	}
+
+	fieldIDs := make(map[string]string, len(a.CustomFields))
+	for _, af := range a.CustomFields {
+		if !validCustomFieldType(af.Type) {
+			return nil, pulpe.ErrBoardArchiveInvalid
+		}
+
+		f := customField{
+			ID:   newObjectIDWithTime(af.CreatedAt),
+			Name: af.Name,
+			Type: af.Type,
+		}
+
+		if f.Type == pulpe.CustomFieldDropdown {
+			f.Options = af.Options
+		}
+
+		b.CustomFields = append(b.CustomFields, f)
+		fieldIDs[af.ID] = f.ID.Hex()
+	}
+
+	if organization != "" {
+		o, err := s.session.organizationService.organizationBySlug(user.ID, organization)
+		if err != nil {
+			return nil, err
+		}
+
+		b.OwnerID = o.ID.Hex()
+		b.OrganizationID = o.ID.Hex()
+	}
+
+	err = s.store.createBoard(&b)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.importContent(&b, a, fieldIDs)
+	if err != nil {
+		s.deleteImported(&b)
+		return nil, err
+	}
+
+	return s.toPulpeBoard(user, &b)
+}
+
+// importContent creates the lists and cards of the archive in the imported board.
+// Custom field values are keyed by the ids of the imported fields.
+func (s *BoardService) importContent(b *board, a *pulpe.BoardArchive, fieldIDs map[string]string) error {
+	listIDs := make(map[string]string, len(a.Lists))
+
+	for _, al := range a.Lists {
+		l := list{
+			ID:        newObjectIDWithTime(al.CreatedAt),
+			UpdatedAt: utcTime(al.UpdatedAt),
+			OwnerID:   b.OwnerID,
+			BoardID:   b.ID.Hex(),
+			Name:      al.Name,
+			Slug:      archivedSlug(al.Slug, al.Name),
+			Position:  al.Position,
+		}
+
+		err := s.session.listService.store.createList(&l)
+		if err != nil {
+			return err
+		}
+
+		listIDs[al.ID] = l.ID.Hex()
+	}
+
+	for _, ac := range a.Cards {
+		values, err := b.customFieldValues(remapFieldValues(ac.Fields, fieldIDs))
+		if err != nil {
+			return pulpe.ErrBoardArchiveInvalid
+		}
+
+		c := card{
+			ID:          newObjectIDWithTime(ac.CreatedAt),
+			UpdatedAt:   utcTime(ac.UpdatedAt),
+			OwnerID:     b.OwnerID,
+			BoardID:     b.ID.Hex(),
+			ListID:      listIDs[ac.ListID],
+			Name:        ac.Name,
+			Slug:        archivedSlug(ac.Slug, ac.Name),
+			Description: ac.Description,
+			Position:    ac.Position,
+			Labels:      ac.Labels,
+			Checklist:   toChecklist(ac.Checklist),
+			Due:         utcTime(ac.Due),
+			Fields:      toFieldValues(values),
+		}
+
+		err = s.session.cardService.store.createCard(&c)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteImported removes a partially imported board.
+// Errors are ignored since the import already failed.
+func (s *BoardService) deleteImported(b *board) {
+	s.session.cascadeDelete(deletionBoard, b.ID)
+}
+
+// archivedSlug returns the slug of an archived item, generating it from its name if missing.
+func archivedSlug(slug, name string) string {
+	if slug != "" {
+		return slug
+	}
+
+	return slugify.Slugify(name)
+}
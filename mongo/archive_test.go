@@ -0,0 +1,166 @@
+package mongo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/stretchr/testify/require"
+)
+
+// newArchivedBoard creates a board with a list and a card and returns its archive.
+func newArchivedBoard(t *testing.T, session *Session) *pulpe.BoardArchive {
+	board := newBoard(t, session)
+	list := newListWithBoardID(t, session, board.ID)
+
+	_, err := session.CardService().CreateCard(list.ID, &pulpe.CardCreation{
+		Name:        "Write docs",
+		Description: "In **markdown**",
+		Position:    2,
+	})
+	require.NoError(t, err)
+
+	a, err := session.BoardService().ExportBoard(board.ID)
+	require.NoError(t, err)
+
+	return a
+}
+
+func TestBoardService_ExportBoard(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("OK", func(t *testing.T) {
+		board := newBoard(t, sessions.Red)
+		list := newListWithBoardID(t, sessions.Red, board.ID)
+		card, err := sessions.Red.CardService().CreateCard(list.ID, &pulpe.CardCreation{
+			Name:        "Write docs",
+			Description: "In **markdown**",
+			Position:    2,
+		})
+		require.NoError(t, err)
+
+		a, err := sessions.Red.BoardService().ExportBoard(board.ID)
+		require.NoError(t, err)
+		require.Equal(t, pulpe.BoardArchiveVersion, a.Version)
+		require.Equal(t, board.ID, a.Board.ID)
+		require.Equal(t, board.Name, a.Board.Name)
+		require.Equal(t, board.Slug, a.Board.Slug)
+		require.Equal(t, board.CreatedAt, a.Board.CreatedAt)
+		require.Len(t, a.Lists, 1)
+		require.Equal(t, list.ID, a.Lists[0].ID)
+		require.Equal(t, list.Slug, a.Lists[0].Slug)
+		require.Len(t, a.Cards, 1)
+		require.Equal(t, &pulpe.ArchivedCard{
+			ID:          card.ID,
+			CreatedAt:   card.CreatedAt,
+			ListID:      list.ID,
+			Name:        "Write docs",
+			Slug:        card.Slug,
+			Description: "In **markdown**",
+			Position:    2,
+		}, a.Cards[0])
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		board := newBoard(t, sessions.Red)
+
+		_, err := sessions.Blue.BoardService().ExportBoard(board.ID)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+		_, err = sessions.NoAuth.BoardService().ExportBoard(board.ID)
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+}
+
+func TestBoardService_ImportBoard(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("OK", func(t *testing.T) {
+		a := newArchivedBoard(t, sessions.Red)
+		updatedAt := time.Date(2010, time.March, 1, 10, 0, 0, 0, time.UTC)
+		a.Board.CreatedAt = time.Date(2010, time.January, 1, 10, 0, 0, 0, time.UTC)
+		a.Board.UpdatedAt = &updatedAt
+
+		board, err := sessions.Blue.BoardService().ImportBoard(a, "")
+		require.NoError(t, err)
+		require.NotEqual(t, a.Board.ID, board.ID)
+		require.Equal(t, a.Board.Name, board.Name)
+		require.Equal(t, a.Board.Slug, board.Slug)
+		require.Equal(t, a.Board.CreatedAt, board.CreatedAt)
+		require.Equal(t, &updatedAt, board.UpdatedAt)
+
+		blue, err := sessions.Blue.Authenticate()
+		require.NoError(t, err)
+		require.Equal(t, blue.ID, board.Owner.ID)
+
+		other, err := sessions.Blue.BoardService().Board(board.ID, pulpe.WithLists(), pulpe.WithCards())
+		require.NoError(t, err)
+		require.Len(t, other.Lists, 1)
+		require.Equal(t, a.Lists[0].Name, other.Lists[0].Name)
+		require.Equal(t, a.Lists[0].CreatedAt, other.Lists[0].CreatedAt)
+		require.Equal(t, blue.ID, other.Lists[0].OwnerID)
+		require.Len(t, other.Cards, 1)
+		require.Equal(t, other.Lists[0].ID, other.Cards[0].ListID)
+		require.Equal(t, "In **markdown**", other.Cards[0].Description)
+		require.Equal(t, 2.0, other.Cards[0].Position)
+
+		// the original board is untouched
+		_, err = sessions.Red.BoardService().Board(a.Board.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("SlugConflict", func(t *testing.T) {
+		a := newArchivedBoard(t, sessions.Red)
+
+		board, err := sessions.Red.BoardService().ImportBoard(a, "")
+		require.NoError(t, err)
+		require.Equal(t, a.Board.Slug+"-1", board.Slug)
+
+		other, err := sessions.Red.BoardService().Board(board.ID, pulpe.WithLists(), pulpe.WithCards())
+		require.NoError(t, err)
+		require.Equal(t, a.Lists[0].Slug+"-1", other.Lists[0].Slug)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		org := newOrganization(t, sessions.Red, "Archives")
+		a := newArchivedBoard(t, sessions.Red)
+
+		board, err := sessions.Red.BoardService().ImportBoard(a, org.Slug)
+		require.NoError(t, err)
+		require.Equal(t, org.ID, board.Organization.ID)
+
+		_, err = sessions.Blue.BoardService().ImportBoard(a, org.Slug)
+		require.Equal(t, pulpe.ErrOrganizationNotFound, err)
+	})
+
+	t.Run("VersionMismatch", func(t *testing.T) {
+		a := newArchivedBoard(t, sessions.Red)
+		a.Version = pulpe.BoardArchiveVersion + 1
+
+		_, err := sessions.Red.BoardService().ImportBoard(a, "")
+		require.Equal(t, pulpe.ErrBoardArchiveVersion, err)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		a := newArchivedBoard(t, sessions.Red)
+		a.Cards[0].ListID = newListID()
+
+		_, err := sessions.Red.BoardService().ImportBoard(a, "")
+		require.Equal(t, pulpe.ErrBoardArchiveInvalid, err)
+
+		a = newArchivedBoard(t, sessions.Red)
+		a.Board.Visibility = "secret"
+
+		_, err = sessions.Red.BoardService().ImportBoard(a, "")
+		require.Equal(t, pulpe.ErrBoardInvalidVisibility, err)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		a := newArchivedBoard(t, sessions.Red)
+
+		_, err := sessions.NoAuth.BoardService().ImportBoard(a, "")
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+}
@@ -0,0 +1,306 @@
+package mongo
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/blankrobot/pulpe"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	// number of documents inserted at once by Restore.
+	restoreBatchSize = 1000
+
+	// prefix of the collections loaded by Restore before replacing the originals.
+	restorePrefix = "restore."
+)
+
+// Ensure BackupStore implements pulpe.MigrationStore.
+var _ pulpe.MigrationStore = new(BackupStore)
+
+// BackupStore dumps and restores the collections of the database.
+// Documents are raw BSON.
+type BackupStore struct {
+	session *mgo.Session
+}
+
+// BackupStore returns a BackupStore using the client connection.
+func (c *Client) BackupStore() *BackupStore {
+	return &BackupStore{session: c.Session}
+}
+
+// Backend returns "mongo".
+func (s *BackupStore) Backend() string {
+	return "mongo"
+}
+
+// Collections returns every collection used by pulpe.
+func (s *BackupStore) Collections() []string {
+	return []string{
+		userCol,
+		userSessionCol,
+		organizationCol,
+		namespaceCol,
+		boardCol,
+		listCol,
+		cardCol,
+		invitationCol,
+		loginAttemptCol,
+		lockoutCol,
+		migrationCol,
+		deletionCol,
+		tombstoneCol,
+		syncOperationCol,
+		historyCol,
+		revisionCol,
+	}
+}
+
+// Snapshot calls fn while the server is locked against writes.
+// The lock applies to the whole server, including the other databases it hosts:
+// writes sent by other clients meanwhile wait until fn returns.
+// If the process dies before unlocking, the server stays locked until db.fsyncUnlock()
+// is run from the mongo shell.
+func (s *BackupStore) Snapshot(fn func() error) (err error) {
+	err = s.session.FsyncLock()
+	if err != nil {
+		return err
+	}
+
+	// the server is unlocked even if fn panics
+	defer func() {
+		uerr := s.session.FsyncUnlock()
+		if err == nil {
+			err = uerr
+		}
+	}()
+
+	return fn()
+}
+
+// Dump calls fn with every document of a collection, in _id order.
+func (s *BackupStore) Dump(collection string, fn func(doc []byte) error) error {
+	iter := s.session.DB("").C(collection).Find(nil).Sort("_id").Iter()
+
+	var raw bson.Raw
+	for iter.Next(&raw) {
+		err := fn(raw.Data)
+		if err != nil {
+			iter.Close()
+			return err
+		}
+	}
+
+	return iter.Close()
+}
+
+// Validate checks that doc is a BSON document with an _id.
+func (s *BackupStore) Validate(collection string, doc []byte) error {
+	var d bson.M
+
+	err := bson.Unmarshal(doc, &d)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := d["_id"]; !ok {
+		return errors.New("missing _id")
+	}
+
+	return nil
+}
+
+// Restore loads every collection in a temporary collection, then renames them over the originals.
+// Indexes of the originals are kept. If a collection fails to load, the temporary collections
+// are dropped and nothing is replaced.
+func (s *BackupStore) Restore(collections map[string][][]byte) error {
+	db := s.session.DB("")
+
+	for name, docs := range collections {
+		err := s.load(db.C(restorePrefix+name), db.C(name), docs)
+		if err != nil {
+			for name := range collections {
+				db.C(restorePrefix + name).DropCollection()
+			}
+
+			return err
+		}
+	}
+
+	for name := range collections {
+		err := s.session.Run(bson.D{
+			{Name: "renameCollection", Value: db.Name + "." + restorePrefix + name},
+			{Name: "to", Value: db.Name + "." + name},
+			{Name: "dropTarget", Value: true},
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// load creates tmp with the indexes of col and inserts docs.
+func (s *BackupStore) load(tmp, col *mgo.Collection, docs [][]byte) error {
+	// left by a failed restore
+	err := tmp.DropCollection()
+	if err != nil && !isNamespaceNotFound(err) {
+		return err
+	}
+
+	err = tmp.Create(new(mgo.CollectionInfo))
+	if err != nil {
+		return err
+	}
+
+	indexes, err := col.Indexes()
+	if err != nil && !isNamespaceNotFound(err) {
+		return err
+	}
+
+	for _, idx := range indexes {
+		if idx.Name == "_id_" {
+			continue
+		}
+
+		err = tmp.EnsureIndex(idx)
+		if err != nil {
+			return err
+		}
+	}
+
+	for len(docs) > 0 {
+		n := restoreBatchSize
+		if n > len(docs) {
+			n = len(docs)
+		}
+
+		batch := make([]interface{}, n)
+		for i, doc := range docs[:n] {
+			batch[i] = bson.Raw{Kind: 0x03, Data: doc}
+		}
+
+		err = tmp.Insert(batch...)
+		if err != nil {
+			return err
+		}
+
+		docs = docs[n:]
+	}
+
+	return nil
+}
+
+// isNamespaceNotFound returns true if err is returned for a collection that doesn't exist.
+func isNamespaceNotFound(err error) bool {
+	qerr, ok := err.(*mgo.QueryError)
+	return ok && (qerr.Code == 26 || qerr.Message == "ns not found")
+}
+
+// Scan returns at most limit documents of a collection whose _id is greater than after,
+// in _id order. Keys are hex encoded BSON documents holding the _id.
+func (s *BackupStore) Scan(collection, after string, limit int) ([][]byte, string, error) {
+	query := bson.M{}
+	if after != "" {
+		id, err := decodeKey(after)
+		if err != nil {
+			return nil, "", err
+		}
+		query["_id"] = bson.M{"$gt": id}
+	}
+
+	iter := s.session.DB("").C(collection).Find(query).Sort("_id").Limit(limit).Iter()
+
+	var docs [][]byte
+	var raw bson.Raw
+	for iter.Next(&raw) {
+		doc := make([]byte, len(raw.Data))
+		copy(doc, raw.Data)
+		docs = append(docs, doc)
+	}
+
+	err := iter.Close()
+	if err != nil || len(docs) == 0 {
+		return nil, "", err
+	}
+
+	last, err := encodeKey(docs[len(docs)-1])
+	if err != nil {
+		return nil, "", err
+	}
+
+	return docs, last, nil
+}
+
+// Upsert writes documents, replacing those with the same _id.
+func (s *BackupStore) Upsert(collection string, docs [][]byte) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	bulk := s.session.DB("").C(collection).Bulk()
+	bulk.Unordered()
+
+	for _, doc := range docs {
+		var d struct {
+			ID interface{} `bson:"_id"`
+		}
+
+		err := bson.Unmarshal(doc, &d)
+		if err != nil {
+			return err
+		}
+
+		bulk.Upsert(bson.M{"_id": d.ID}, bson.Raw{Kind: 0x03, Data: doc})
+	}
+
+	_, err := bulk.Run()
+	return err
+}
+
+// Clear removes every document of a collection. Indexes are kept.
+func (s *BackupStore) Clear(collection string) error {
+	_, err := s.session.DB("").C(collection).RemoveAll(nil)
+	return err
+}
+
+// encodeKey returns the key of a document.
+func encodeKey(doc []byte) (string, error) {
+	var d struct {
+		ID interface{} `bson:"_id"`
+	}
+
+	err := bson.Unmarshal(doc, &d)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := bson.Marshal(bson.M{"_id": d.ID})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(data), nil
+}
+
+// decodeKey returns the _id encoded in a key.
+func decodeKey(key string) (interface{}, error) {
+	data, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var d struct {
+		ID interface{} `bson:"_id"`
+	}
+
+	err = bson.Unmarshal(data, &d)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.ID, nil
+}
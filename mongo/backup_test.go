@@ -0,0 +1,112 @@
+package mongo_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/backup"
+	"github.com/blankrobot/pulpe/mongo"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestBackupStore(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	store := client.BackupStore()
+
+	board := newBoard(t, sessions.Red)
+	list := newListWithBoardID(t, sessions.Red, board.ID)
+
+	var buf bytes.Buffer
+	m, err := backup.Write(&buf, store, Now())
+	require.NoError(t, err)
+	require.Equal(t, "mongo", m.Backend)
+	require.Len(t, m.Collections, len(store.Collections()))
+
+	// changes made after the backup are lost on restore
+	err = sessions.Red.BoardService().DeleteBoard(board.ID)
+	require.NoError(t, err)
+	other := newBoard(t, sessions.Red)
+
+	a, err := backup.Read(bytes.NewReader(buf.Bytes()), store)
+	require.NoError(t, err)
+
+	// reading doesn't modify the database
+	_, err = sessions.Red.BoardService().Board(board.ID)
+	require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+	err = a.Restore(store)
+	require.NoError(t, err)
+
+	b, err := sessions.Red.BoardService().Board(board.ID, pulpe.WithLists())
+	require.NoError(t, err)
+	require.Equal(t, board.Name, b.Name)
+	require.Len(t, b.Lists, 1)
+	require.Equal(t, list.ID, b.Lists[0].ID)
+
+	_, err = sessions.Red.BoardService().Board(other.ID)
+	require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+	// nothing is replaced if a collection fails to load
+	doc, err := bson.Marshal(bson.M{"_id": "same"})
+	require.NoError(t, err)
+	err = store.Restore(map[string][][]byte{
+		"boards": {},
+		"lists":  {doc, doc},
+	})
+	require.Error(t, err)
+
+	_, err = sessions.Red.BoardService().Board(board.ID)
+	require.NoError(t, err)
+}
+
+func TestBackupStore_Migrate(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	newBoard(t, sessions.Red)
+
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+
+	target := mongo.NewClient(uri + "/pulpe-tests-migrate")
+	require.NoError(t, target.Open())
+	defer func() {
+		target.Session.DB("").DropDatabase()
+		target.Close()
+	}()
+
+	from, to := client.BackupStore(), target.BackupStore()
+
+	var state backup.MigrationState
+	var saves int
+	err := backup.Migrate(from, to, &state, 2, func(*backup.MigrationState) error {
+		saves++
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotZero(t, saves)
+
+	cmps, err := backup.Verify(from, to)
+	require.NoError(t, err)
+	for _, c := range cmps {
+		require.True(t, c.Match(), c.Name)
+	}
+
+	// resuming copies the new documents only
+	board := newBoard(t, sessions.Red)
+	copied := state.Collections["boards"].Copied
+	err = backup.Migrate(from, to, &state, 2, func(*backup.MigrationState) error { return nil })
+	require.NoError(t, err)
+	require.Equal(t, copied+1, state.Collections["boards"].Copied)
+
+	docs, _, err := to.Scan("boards", "", 1000)
+	require.NoError(t, err)
+	require.Contains(t, string(docs[len(docs)-1]), board.Name)
+}
@@ -1,10 +1,12 @@
 package mongo
 
 import (
+	"crypto/subtle"
 	"time"
 
 	"github.com/Machiel/slugify"
 	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/validation"
 	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -15,22 +17,57 @@ const boardCol = "boards"
 var _ pulpe.BoardService = new(BoardService)
 
 // board representation stored in MongoDB.
+// The owner of an organization board is the organization.
 type board struct {
-	ID        bson.ObjectId `bson:"_id"`
-	UpdatedAt *time.Time    `bson:"updatedAt,omitempty"`
-	Name      string        `bson:"name"`
-	Slug      string        `bson:"slug"`
-	OwnerID   string        `bson:"ownerID"`
+	ID             bson.ObjectId    `bson:"_id"`
+	UpdatedAt      *time.Time       `bson:"updatedAt,omitempty"`
+	Name           string           `bson:"name"`
+	Slug           string           `bson:"slug"`
+	OwnerID        string           `bson:"ownerID"`
+	OrganizationID string           `bson:"organizationID,omitempty"`
+	Visibility     string           `bson:"visibility,omitempty"`
+	ShareLinks     []boardShareLink `bson:"shareLinks,omitempty"`
+	Members        []boardMember    `bson:"members,omitempty"`
+	ImportID       string           `bson:"importID,omitempty"`
+	Template       bool             `bson:"template,omitempty"`
+	CardTemplates  []cardTemplate   `bson:"cardTemplates,omitempty"`
+	CustomFields   []customField    `bson:"customFields,omitempty"`
+}
+
+// boardMember is a user invited to a board.
+type boardMember struct {
+	UserID string `bson:"userID"`
+	Role   string `bson:"role"`
+}
+
+// boardShareLink gives read-only access to a board.
+type boardShareLink struct {
+	ID    bson.ObjectId `bson:"id"`
+	Token string        `bson:"token"`
 }
 
 // toPulpeBoard creates a pulpe board from a mongo board.
-func (b *board) toPulpeBoard(user *pulpe.User) *pulpe.Board {
+// Either the user or the organization is set as the owner.
+func (b *board) toPulpeBoard(user *pulpe.User, org *pulpe.Organization) *pulpe.Board {
 	p := pulpe.Board{
-		ID:        b.ID.Hex(),
-		CreatedAt: b.ID.Time().UTC(),
-		Name:      b.Name,
-		Slug:      b.Slug,
-		Owner:     user,
+		ID:           b.ID.Hex(),
+		CreatedAt:    b.ID.Time().UTC(),
+		Name:         b.Name,
+		Slug:         b.Slug,
+		Visibility:   b.Visibility,
+		ImportID:     b.ImportID,
+		Template:     b.Template,
+		CustomFields: b.pulpeCustomFields(),
+	}
+
+	if p.Visibility == "" {
+		p.Visibility = pulpe.BoardVisibilityPrivate
+	}
+
+	if org != nil {
+		p.Organization = org
+	} else {
+		p.Owner = user
 	}
 
 	if b.UpdatedAt != nil {
@@ -41,6 +78,40 @@ func (b *board) toPulpeBoard(user *pulpe.User) *pulpe.Board {
 	return &p
 }
 
+// memberRole returns the role of the user if it was invited to the board.
+func (b *board) memberRole(userID string) string {
+	for _, m := range b.Members {
+		if m.UserID == userID {
+			return m.Role
+		}
+	}
+
+	return ""
+}
+
+// hasShareToken returns true if the token matches one of the board share links.
+func (b *board) hasShareToken(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	for _, l := range b.ShareLinks {
+		if subtle.ConstantTimeCompare([]byte(l.Token), []byte(token)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *boardShareLink) toPulpeBoardShareLink() *pulpe.BoardShareLink {
+	return &pulpe.BoardShareLink{
+		ID:        l.ID.Hex(),
+		Token:     l.Token,
+		CreatedAt: l.ID.Time().UTC(),
+	}
+}
+
 // BoardService represents a service for managing boards.
 type BoardService struct {
 	session *Session
@@ -57,97 +128,265 @@ func (s *BoardService) ensureIndexes() error {
 		Sparse: true,
 	}
 
-	return col.EnsureIndex(index)
+	err := col.EnsureIndex(index)
+	if err != nil {
+		return err
+	}
+
+	// Invited members
+	return col.EnsureIndexKey("members.userID")
 }
 
-// CreateBoard creates a new Board.
+// CreateBoard creates a new Board. Organization boards can be created by any member.
+// If a template is given, its lists and cards are copied in the new board.
 func (s *BoardService) CreateBoard(bc *pulpe.BoardCreation) (*pulpe.Board, error) {
 	user, err := s.session.Authenticate()
 	if err != nil {
 		return nil, err
 	}
 
-	b := board{
-		ID:      bson.NewObjectId(),
-		Name:    bc.Name,
-		Slug:    slugify.Slugify(bc.Name),
-		OwnerID: user.ID,
+	var tpl *board
+	if bc.TemplateID != "" {
+		tpl, err = s.boardByID(user, bc.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !tpl.Template {
+			return nil, pulpe.ErrBoardNotFound
+		}
 	}
 
-	err = s.store.createBoard(&b)
+	b, org, err := s.createBoard(user, bc)
 	if err != nil {
 		return nil, err
 	}
 
-	return b.toPulpeBoard(user), nil
+	if tpl != nil {
+		err = s.copyContent(tpl, b, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b.toPulpeBoard(user, org), nil
 }
 
-// Board returns a Board by id.
-func (s *BoardService) Board(id string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+// CopyBoard creates a new board with the lists, and optionally the cards, of a board.
+// The copy is private and has no members nor share links.
+func (s *BoardService) CopyBoard(id string, bc *pulpe.BoardCopy) (*pulpe.Board, error) {
 	user, err := s.session.Authenticate()
 	if err != nil {
 		return nil, err
 	}
 
-	b, err := s.store.boardByOwnerIDAndID(user.ID, id)
+	src, err := s.boardByID(user, id)
 	if err != nil {
-		if err == mgo.ErrNotFound {
-			return nil, pulpe.ErrBoardNotFound
-		}
+		return nil, err
+	}
 
+	name := bc.Name
+	if name == "" {
+		name = src.Name
+	}
+
+	b, org, err := s.createBoard(user, &pulpe.BoardCreation{
+		Name:         name,
+		Organization: bc.Organization,
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	board := b.toPulpeBoard(user)
+	err = s.copyContent(src, b, bc.WithCards)
+	if err != nil {
+		return nil, err
+	}
 
-	var opts pulpe.BoardGetOptions
+	return b.toPulpeBoard(user, org), nil
+}
 
-	for i := range options {
-		options[i](&opts)
+// Templates returns the template boards the authenticated user is a member of.
+func (s *BoardService) Templates() ([]*pulpe.Board, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
 	}
 
-	if opts.WithLists {
-		board.Lists, err = s.session.ListService().ListsByBoard(board.ID)
+	acc, err := s.session.access(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := s.store.templatesByAccess(acc.boards())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toPulpeBoards(user, bs)
+}
+
+// createBoard creates a board owned by the user or by the organization
+// of the board creation, which is returned if any.
+func (s *BoardService) createBoard(user *pulpe.User, bc *pulpe.BoardCreation) (*board, *pulpe.Organization, error) {
+	b := board{
+		ID:       bson.NewObjectId(),
+		Name:     bc.Name,
+		Slug:     slugify.Slugify(bc.Name),
+		OwnerID:  user.ID,
+		ImportID: bc.ImportID,
+	}
+
+	var org *pulpe.Organization
+	if bc.Organization != "" {
+		o, err := s.session.organizationService.organizationBySlug(user.ID, bc.Organization)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+
+		b.OwnerID = o.ID.Hex()
+		b.OrganizationID = o.ID.Hex()
+		org = o.toPulpeOrganization()
 	}
 
-	if opts.WithCards {
-		board.Cards, err = s.session.CardService().CardsByBoard(board.ID)
+	err := s.store.createBoard(&b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &b, org, nil
+}
+
+// copyContent copies the card templates, the custom fields, the lists and optionally
+// the cards of a board in a new board. The new board is removed if the copy fails.
+func (s *BoardService) copyContent(src, dst *board, withCards bool) error {
+	fieldIDs, err := s.copyBoardSettings(src, dst)
+	if err == nil {
+		err = s.copyLists(src, dst, withCards, fieldIDs)
+	}
+	if err != nil {
+		// errors are ignored since the copy already failed
+		s.session.cascadeDelete(deletionBoard, dst.ID)
+	}
+
+	return err
+}
+
+// copyBoardSettings copies the card templates and the custom fields of a board
+// and returns the new id of each custom field, keyed by the old one.
+func (s *BoardService) copyBoardSettings(src, dst *board) (map[string]string, error) {
+	var fieldIDs map[string]string
+
+	patch := make(bson.M)
+	if len(src.CardTemplates) > 0 {
+		dst.CardTemplates = copyCardTemplates(src.CardTemplates)
+		patch["cardTemplates"] = dst.CardTemplates
+	}
+
+	if len(src.CustomFields) > 0 {
+		dst.CustomFields, fieldIDs = copyCustomFields(src.CustomFields)
+		patch["customFields"] = dst.CustomFields
+	}
+
+	if len(patch) == 0 {
+		return fieldIDs, nil
+	}
+
+	return fieldIDs, s.session.db.C(boardCol).UpdateId(dst.ID, bson.M{"$set": patch})
+}
+
+func (s *BoardService) copyLists(src, dst *board, withCards bool, fieldIDs map[string]string) error {
+	ls, err := s.session.listService.store.listsByBoardID(src.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	lists := make(map[string]*list, len(ls))
+	for i := range ls {
+		l := list{
+			ID:       bson.NewObjectId(),
+			OwnerID:  dst.OwnerID,
+			BoardID:  dst.ID.Hex(),
+			Name:     ls[i].Name,
+			Slug:     ls[i].Slug,
+			Position: ls[i].Position,
+		}
+
+		err = s.session.listService.store.createList(&l)
 		if err != nil {
-			return nil, err
+			return err
 		}
+
+		lists[ls[i].ID.Hex()] = &l
 	}
 
-	return board, nil
+	if !withCards {
+		return nil
+	}
+
+	cs, err := s.session.cardService.store.cardsByBoardID(src.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	for i := range cs {
+		l, ok := lists[cs[i].ListID]
+		if !ok {
+			// the card was left behind by an interrupted deletion
+			continue
+		}
+
+		fields := remapFieldValues(cs[i].Fields, fieldIDs)
+		_, err = s.session.cardService.copyCard(&cs[i], l, cs[i].Position, fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-// BoardByOwnerAndSlug returns a Board by owner and slug.
-func (s *BoardService) BoardByOwnerAndSlug(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+// Board returns a Board by id.
+func (s *BoardService) Board(id string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
 	user, err := s.session.Authenticate()
 	if err != nil {
 		return nil, err
 	}
 
-	if user.Login != owner {
-		return nil, pulpe.ErrBoardNotFound
+	b, err := s.boardByID(user, id)
+	if err != nil {
+		return nil, err
 	}
 
-	b, err := s.store.boardByOwnerIDAndSlug(user.ID, slug)
+	board, err := s.toPulpeBoard(user, b)
 	if err != nil {
-		if err == mgo.ErrNotFound {
-			return nil, pulpe.ErrBoardNotFound
-		}
-
 		return nil, err
 	}
 
-	if b.OwnerID != user.ID {
-		return nil, pulpe.ErrBoardNotFound
+	var opts pulpe.BoardGetOptions
+
+	for i := range options {
+		options[i](&opts)
+	}
+
+	return s.withContent(board, &opts)
+}
+
+// BoardByOwnerAndSlug returns a Board by owner and slug.
+// The owner is either a user login or an organization slug.
+// Boards are visible to their members and, read-only, to anyone
+// if they are public or opened with a valid share token.
+func (s *BoardService) BoardByOwnerAndSlug(owner, slug string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+	user, err := s.session.Authenticate()
+	if err != nil && err != pulpe.ErrUserAuthenticationFailed {
+		return nil, err
 	}
 
-	board := b.toPulpeBoard(user)
+	// anonymous users can't know if a private board exists
+	notFound := pulpe.ErrBoardNotFound
+	if user == nil {
+		notFound = pulpe.ErrUserAuthenticationFailed
+	}
 
 	var opts pulpe.BoardGetOptions
 
@@ -155,51 +394,90 @@ func (s *BoardService) BoardByOwnerAndSlug(owner, slug string, options ...pulpe.
 		options[i](&opts)
 	}
 
-	if opts.WithLists {
-		board.Lists, err = s.session.ListService().ListsByBoard(board.ID)
-		if err != nil {
-			return nil, err
+	b, err := s.boardByNamespaceAndSlug(owner, slug)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, notFound
 		}
+
+		return nil, err
 	}
 
-	if opts.WithCards {
-		board.Cards, err = s.session.CardService().CardsByBoard(board.ID)
-		if err != nil {
-			return nil, err
+	member, err := s.readAccess(user, b, opts.ShareToken)
+	if err != nil {
+		if err == pulpe.ErrBoardNotFound {
+			return nil, notFound
 		}
+
+		return nil, err
 	}
 
-	return board, nil
+	board, err := s.toPulpeBoard(user, b)
+	if err != nil {
+		return nil, err
+	}
+
+	board.ReadOnly = !member
+
+	return s.withContent(board, &opts)
 }
 
-// Boards returns all the boards of the authenticated user.
-func (s *BoardService) Boards() ([]*pulpe.Board, error) {
+// Boards returns all the boards of the authenticated user, of its organizations
+// and the ones it was invited to. An invalid cursor is reported as a validation error.
+func (s *BoardService) Boards(options ...pulpe.BoardListOption) ([]*pulpe.Board, error) {
 	user, err := s.session.Authenticate()
 	if err != nil {
 		return nil, err
 	}
 
-	bs, err := s.store.boardsByOwnerID(user.ID)
+	var opts pulpe.BoardListOptions
+
+	for i := range options {
+		options[i](&opts)
+	}
+
+	query := make(bson.M)
+	if opts.After != "" {
+		if !bson.IsObjectIdHex(opts.After) {
+			return nil, validation.AddError(nil, "after", pulpe.ErrBoardInvalidCursor)
+		}
+
+		query["_id"] = bson.M{"$gt": bson.ObjectIdHex(opts.After)}
+	}
+
+	acc, err := s.session.access(user.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	boards := make([]*pulpe.Board, len(bs))
-	for i := range bs {
-		boards[i] = bs[i].toPulpeBoard(user)
+	query["$or"] = acc.boards()
+	bs, err := s.store.findBoards(query, opts.Limit)
+	if err != nil {
+		return nil, err
 	}
 
-	return boards, nil
+	return s.toPulpeBoards(user, bs)
 }
 
 // DeleteBoard deletes a Board and its related cards and lists.
+// Organization boards can only be deleted by organization admins.
 func (s *BoardService) DeleteBoard(id string) error {
 	user, err := s.session.Authenticate()
 	if err != nil {
 		return err
 	}
 
-	b, err := s.store.boardByOwnerIDAndID(user.ID, id)
+	b, err := s.boardByID(user, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkAdmin(user, b)
+	if err != nil {
+		return err
+	}
+
+	err = s.session.cascadeDelete(deletionBoard, b.ID)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return pulpe.ErrBoardNotFound
@@ -208,20 +486,23 @@ func (s *BoardService) DeleteBoard(id string) error {
 		return err
 	}
 
-	err = s.store.deleteBoardByID(b.ID)
-	if err == mgo.ErrNotFound {
-		return pulpe.ErrBoardNotFound
+	t := tombstone{
+		Kind:     pulpe.TombstoneBoard,
+		TargetID: b.ID.Hex(),
+		BoardID:  b.ID.Hex(),
+		OwnerID:  b.OwnerID,
 	}
 
-	err = s.session.ListService().DeleteListsByBoardID(b.ID.Hex())
-	if err != nil {
-		return err
+	for _, m := range b.Members {
+		t.MemberIDs = append(t.MemberIDs, m.UserID)
 	}
 
-	return s.session.CardService().DeleteCardsByBoardID(b.ID.Hex())
+	return s.session.bury(&t)
 }
 
 // UpdateBoard updates a Board.
+// Only the board owner or the organization admins can change its visibility
+// and mark it as a template.
 func (s *BoardService) UpdateBoard(id string, u *pulpe.BoardUpdate) (*pulpe.Board, error) {
 	if !bson.IsObjectIdHex(id) {
 		return nil, pulpe.ErrBoardNotFound
@@ -232,6 +513,11 @@ func (s *BoardService) UpdateBoard(id string, u *pulpe.BoardUpdate) (*pulpe.Boar
 		return nil, err
 	}
 
+	b, err := s.boardByID(user, id)
+	if err != nil {
+		return nil, err
+	}
+
 	var newSlug string
 
 	patch := make(bson.M)
@@ -240,8 +526,30 @@ func (s *BoardService) UpdateBoard(id string, u *pulpe.BoardUpdate) (*pulpe.Boar
 		newSlug = slugify.Slugify(*u.Name)
 	}
 
+	if u.Visibility != nil {
+		if *u.Visibility != pulpe.BoardVisibilityPrivate && *u.Visibility != pulpe.BoardVisibilityPublic {
+			return nil, pulpe.ErrBoardInvalidVisibility
+		}
+
+		err = s.checkAdmin(user, b)
+		if err != nil {
+			return nil, err
+		}
+
+		patch["visibility"] = *u.Visibility
+	}
+
+	if u.Template != nil {
+		err = s.checkAdmin(user, b)
+		if err != nil {
+			return nil, err
+		}
+
+		patch["template"] = *u.Template
+	}
+
 	if len(patch) > 0 {
-		newSlug, err = s.store.updateBoardByID(bson.ObjectIdHex(id), user.ID, newSlug, patch)
+		newSlug, err = s.store.updateBoardByID(b.ID, b.OwnerID, newSlug, patch)
 		if err != nil {
 			if err == mgo.ErrNotFound {
 				return nil, pulpe.ErrBoardNotFound
@@ -251,7 +559,102 @@ func (s *BoardService) UpdateBoard(id string, u *pulpe.BoardUpdate) (*pulpe.Boar
 		}
 	}
 
-	b, err := s.store.boardByOwnerIDAndID(user.ID, id)
+	b, err = s.store.boardByAccessAndID(ownedBy(b.OwnerID), id)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		return nil, err
+	}
+
+	return s.toPulpeBoard(user, b)
+}
+
+// CreateShareLink creates a new share link for the board.
+func (s *BoardService) CreateShareLink(boardID string) (*pulpe.BoardShareLink, error) {
+	b, err := s.adminBoardByID(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	link := boardShareLink{
+		ID:    bson.NewObjectId(),
+		Token: token,
+	}
+
+	err = s.session.db.C(boardCol).UpdateId(b.ID, bson.M{
+		"$push":        bson.M{"shareLinks": &link},
+		"$currentDate": bson.M{"updatedAt": true},
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		return nil, err
+	}
+
+	return link.toPulpeBoardShareLink(), nil
+}
+
+// ShareLinks returns the share links of the board.
+func (s *BoardService) ShareLinks(boardID string) ([]*pulpe.BoardShareLink, error) {
+	b, err := s.adminBoardByID(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]*pulpe.BoardShareLink, len(b.ShareLinks))
+	for i := range b.ShareLinks {
+		links[i] = b.ShareLinks[i].toPulpeBoardShareLink()
+	}
+
+	return links, nil
+}
+
+// RevokeShareLink removes a share link from the board.
+func (s *BoardService) RevokeShareLink(boardID, linkID string) error {
+	b, err := s.adminBoardByID(boardID)
+	if err != nil {
+		return err
+	}
+
+	if !bson.IsObjectIdHex(linkID) {
+		return pulpe.ErrBoardShareLinkNotFound
+	}
+
+	id := bson.ObjectIdHex(linkID)
+	err = s.session.db.C(boardCol).Update(
+		bson.M{
+			"_id":           b.ID,
+			"shareLinks.id": id,
+		},
+		bson.M{
+			"$pull":        bson.M{"shareLinks": bson.M{"id": id}},
+			"$currentDate": bson.M{"updatedAt": true},
+		})
+	if err == mgo.ErrNotFound {
+		return pulpe.ErrBoardShareLinkNotFound
+	}
+
+	return err
+}
+
+// boardByID returns a board if the user owns it, is a member of the organization
+// owning it or was invited to it.
+func (s *BoardService) boardByID(user *pulpe.User, id string) (*board, error) {
+	acc, err := s.session.access(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.store.boardByAccessAndID(acc.boards(), id)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return nil, pulpe.ErrBoardNotFound
@@ -260,14 +663,249 @@ func (s *BoardService) UpdateBoard(id string, u *pulpe.BoardUpdate) (*pulpe.Boar
 		return nil, err
 	}
 
-	return b.toPulpeBoard(user), nil
+	return b, nil
+}
+
+// readAccess returns pulpe.ErrBoardNotFound if the user, which can be nil,
+// can't read the board, and whether the user is a member of it.
+func (s *BoardService) readAccess(user *pulpe.User, b *board, shareToken string) (bool, error) {
+	var member bool
+	if user != nil {
+		acc, err := s.session.access(user.ID)
+		if err != nil {
+			return false, err
+		}
+
+		member = acc.owns(b.OwnerID) || b.memberRole(user.ID) != ""
+	}
+
+	if !member && b.Visibility != pulpe.BoardVisibilityPublic && !b.hasShareToken(shareToken) {
+		return false, pulpe.ErrBoardNotFound
+	}
+
+	return member, nil
+}
+
+// adminBoardByID returns a board if the authenticated user can manage it.
+func (s *BoardService) adminBoardByID(id string) (*board, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.boardByID(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, s.checkAdmin(user, b)
+}
+
+// addMember invites a user to a board. Users already invited keep their role.
+func (s *BoardService) addMember(boardID, userID, role string) error {
+	if !bson.IsObjectIdHex(boardID) {
+		return pulpe.ErrBoardNotFound
+	}
+
+	err := s.session.db.C(boardCol).Update(
+		bson.M{
+			"_id":            bson.ObjectIdHex(boardID),
+			"members.userID": bson.M{"$ne": userID},
+		},
+		bson.M{
+			"$push":        bson.M{"members": &boardMember{UserID: userID, Role: role}},
+			"$currentDate": bson.M{"updatedAt": true},
+		})
+	if err == mgo.ErrNotFound {
+		// either already a member or the board was deleted
+		n, err := s.session.db.C(boardCol).FindId(bson.ObjectIdHex(boardID)).Count()
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return pulpe.ErrBoardNotFound
+		}
+
+		return nil
+	}
+
+	return err
+}
+
+// checkAdmin returns an error if the user can't manage the board.
+// Personal boards are managed by their owner, organization boards
+// by the organization admins, and both by the members invited as admins.
+func (s *BoardService) checkAdmin(user *pulpe.User, b *board) error {
+	if b.OwnerID == user.ID || b.memberRole(user.ID) == pulpe.BoardRoleAdmin {
+		return nil
+	}
+
+	if b.OrganizationID != "" {
+		role, err := s.session.organizationService.memberRole(b.OrganizationID, user.ID)
+		switch err {
+		case nil:
+			if role == pulpe.OrganizationRoleAdmin {
+				return nil
+			}
+			return pulpe.ErrOrganizationForbidden
+		case pulpe.ErrOrganizationNotFound:
+		default:
+			return err
+		}
+	}
+
+	if b.memberRole(user.ID) != "" {
+		return pulpe.ErrBoardForbidden
+	}
+
+	return pulpe.ErrBoardNotFound
+}
+
+// checkAssignees returns ErrCardInvalidAssignee if one of the users can't access the board.
+func (s *BoardService) checkAssignees(b *board, userIDs []string) error {
+	for _, id := range userIDs {
+		if b.memberRole(id) != "" {
+			continue
+		}
+
+		ownerIDs, err := s.session.organizationService.ownerIDs(id)
+		if err != nil {
+			return err
+		}
+
+		acc := access{userID: id, ownerIDs: ownerIDs}
+		if !acc.owns(b.OwnerID) {
+			return pulpe.ErrCardInvalidAssignee
+		}
+	}
+
+	return nil
+}
+
+// boardByNamespaceAndSlug returns a board by slug, owned by the user
+// or the organization with the given login or slug.
+func (s *BoardService) boardByNamespaceAndSlug(namespace, slug string) (*board, error) {
+	var ownerID string
+
+	var u user
+	err := s.session.db.C(userCol).Find(bson.M{"login": namespace}).Select(bson.M{"_id": 1}).One(&u)
+	switch err {
+	case nil:
+		ownerID = u.ID.Hex()
+	case mgo.ErrNotFound:
+		var o organization
+		err = s.session.db.C(organizationCol).Find(bson.M{"slug": namespace}).Select(bson.M{"_id": 1}).One(&o)
+		if err != nil {
+			return nil, err
+		}
+
+		ownerID = o.ID.Hex()
+	default:
+		return nil, err
+	}
+
+	return s.store.boardByOwnerIDAndSlug(ownerID, slug)
+}
+
+// withContent fetches the lists and cards of the board, depending on the options.
+// Access rights must be checked before.
+func (s *BoardService) withContent(board *pulpe.Board, opts *pulpe.BoardGetOptions) (*pulpe.Board, error) {
+	var err error
+
+	if opts.WithLists {
+		board.Lists, err = s.session.listService.listsByBoard(board.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.WithCards {
+		board.Cards, err = s.session.cardService.cardsByBoard(board.ID, new(pulpe.CardListOptions))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return board, nil
+}
+
+// toPulpeBoard converts a board for the given user.
+func (s *BoardService) toPulpeBoard(user *pulpe.User, b *board) (*pulpe.Board, error) {
+	boards, err := s.toPulpeBoards(user, []board{*b})
+	if err != nil {
+		return nil, err
+	}
+
+	return boards[0], nil
+}
+
+// toPulpeBoards converts boards for the given user, which can be nil,
+// and fetches their owners and invited members.
+func (s *BoardService) toPulpeBoards(user *pulpe.User, bs []board) ([]*pulpe.Board, error) {
+	var orgIDs, userIDs []string
+	for i := range bs {
+		switch {
+		case bs[i].OrganizationID != "":
+			orgIDs = append(orgIDs, bs[i].OrganizationID)
+		case user == nil || bs[i].OwnerID != user.ID:
+			userIDs = append(userIDs, bs[i].OwnerID)
+		}
+
+		for _, m := range bs[i].Members {
+			userIDs = append(userIDs, m.UserID)
+		}
+	}
+
+	var err error
+	var orgs map[string]*pulpe.Organization
+	if len(orgIDs) > 0 {
+		orgs, err = s.session.organizationService.organizationsByIDs(orgIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var profiles map[string]*pulpe.User
+	if len(userIDs) > 0 {
+		profiles, err = s.session.userService.publicProfiles(userIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	boards := make([]*pulpe.Board, len(bs))
+	for i := range bs {
+		owner := profiles[bs[i].OwnerID]
+		if user != nil && bs[i].OwnerID == user.ID {
+			owner = user
+		}
+
+		boards[i] = bs[i].toPulpeBoard(owner, orgs[bs[i].OrganizationID])
+
+		for _, m := range bs[i].Members {
+			member := pulpe.BoardMember{
+				UserID: m.UserID,
+				Role:   m.Role,
+			}
+
+			if p, ok := profiles[m.UserID]; ok {
+				member.Login = p.Login
+				member.FullName = p.FullName
+			}
+
+			boards[i].Members = append(boards[i].Members, &member)
+		}
+	}
+
+	return boards, nil
 }
 
 type boardStore struct {
 	session *Session
 }
 
-func (s *boardStore) boardByOwnerIDAndID(ownerID, id string) (*board, error) {
+func (s *boardStore) boardByAccessAndID(or []bson.M, id string) (*board, error) {
 	var b board
 
 	if !bson.IsObjectIdHex(id) {
@@ -275,8 +913,8 @@ func (s *boardStore) boardByOwnerIDAndID(ownerID, id string) (*board, error) {
 	}
 
 	query := bson.M{
-		"ownerID": ownerID,
-		"_id":     bson.ObjectIdHex(id),
+		"$or": or,
+		"_id": bson.ObjectIdHex(id),
 	}
 
 	return &b, s.session.db.C(boardCol).Find(query).One(&b)
@@ -305,14 +943,17 @@ func (s *boardStore) createBoard(b *board) error {
 	return err
 }
 
-func (s *boardStore) boardsByOwnerID(ownerID string) ([]board, error) {
+// findBoards returns the boards matching the query, sorted by id. Zero means no limit.
+func (s *boardStore) findBoards(query bson.M, limit int) ([]board, error) {
 	var bs []board
 
-	return bs, s.session.db.C(boardCol).Find(bson.M{"ownerID": ownerID}).Sort("_id").All(&bs)
+	return bs, s.session.db.C(boardCol).Find(query).Sort("_id").Limit(limit).All(&bs)
 }
 
-func (s *boardStore) deleteBoardByID(id bson.ObjectId) error {
-	return s.session.db.C(boardCol).RemoveId(id)
+func (s *boardStore) templatesByAccess(or []bson.M) ([]board, error) {
+	var bs []board
+
+	return bs, s.session.db.C(boardCol).Find(bson.M{"$or": or, "template": true}).Sort("name", "_id").All(&bs)
 }
 
 func (s *boardStore) updateBoardByID(id bson.ObjectId, ownerID, slug string, patch bson.M) (string, error) {
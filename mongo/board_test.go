@@ -59,6 +59,33 @@ func TestBoardService_CreateBoard(t *testing.T) {
 		require.Equal(t, board, other)
 	})
 
+	t.Run("ImportID", func(t *testing.T) {
+		board, err := sessions.Red.BoardService().CreateBoard(&pulpe.BoardCreation{
+			Name:     "Imported",
+			ImportID: "trello:b1",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "trello:b1", board.ImportID)
+
+		list, err := sessions.Red.ListService().CreateList(board.ID, &pulpe.ListCreation{
+			Name:     "Todo",
+			ImportID: "trello:l1",
+		})
+		require.NoError(t, err)
+
+		_, err = sessions.Red.CardService().CreateCard(list.ID, &pulpe.CardCreation{
+			Name:     "Card",
+			ImportID: "trello:c1",
+		})
+		require.NoError(t, err)
+
+		other, err := sessions.Red.BoardService().Board(board.ID, pulpe.WithLists(), pulpe.WithCards())
+		require.NoError(t, err)
+		require.Equal(t, "trello:b1", other.ImportID)
+		require.Equal(t, "trello:l1", other.Lists[0].ImportID)
+		require.Equal(t, "trello:c1", other.Cards[0].ImportID)
+	})
+
 	t.Run("Slug conflict", func(t *testing.T) {
 		s1 := sessions.Red.BoardService()
 		s2 := sessions.Blue.BoardService()
@@ -436,6 +463,228 @@ func TestBoardService_UpdateBoard(t *testing.T) {
 	})
 }
 
+func TestBoardService_Visibility(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	board := newBoard(t, sessions.Red)
+	require.Equal(t, pulpe.BoardVisibilityPrivate, board.Visibility)
+
+	_, err := sessions.Red.ListService().CreateList(board.ID, &pulpe.ListCreation{Name: "List1"})
+	require.NoError(t, err)
+
+	t.Run("Private", func(t *testing.T) {
+		_, err := sessions.NoAuth.BoardService().BoardByOwnerAndSlug(board.Owner.Login, board.Slug)
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+
+		_, err = sessions.Blue.BoardService().BoardByOwnerAndSlug(board.Owner.Login, board.Slug)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		v := "secret"
+		_, err := sessions.Red.BoardService().UpdateBoard(board.ID, &pulpe.BoardUpdate{Visibility: &v})
+		require.Equal(t, pulpe.ErrBoardInvalidVisibility, err)
+	})
+
+	t.Run("Public", func(t *testing.T) {
+		v := pulpe.BoardVisibilityPublic
+		updated, err := sessions.Red.BoardService().UpdateBoard(board.ID, &pulpe.BoardUpdate{Visibility: &v})
+		require.NoError(t, err)
+		require.Equal(t, pulpe.BoardVisibilityPublic, updated.Visibility)
+
+		other, err := sessions.NoAuth.BoardService().BoardByOwnerAndSlug(board.Owner.Login, board.Slug, pulpe.WithLists())
+		require.NoError(t, err)
+		require.True(t, other.ReadOnly)
+		require.Len(t, other.Lists, 1)
+		require.Equal(t, "red", other.Owner.Login)
+		require.Empty(t, other.Owner.Email)
+
+		other, err = sessions.Blue.BoardService().BoardByOwnerAndSlug(board.Owner.Login, board.Slug)
+		require.NoError(t, err)
+		require.True(t, other.ReadOnly)
+
+		other, err = sessions.Red.BoardService().BoardByOwnerAndSlug(board.Owner.Login, board.Slug)
+		require.NoError(t, err)
+		require.False(t, other.ReadOnly)
+
+		// mutations still require a member
+		_, err = sessions.Blue.ListService().CreateList(board.ID, &pulpe.ListCreation{Name: "List2"})
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+		err = sessions.Blue.BoardService().DeleteBoard(board.ID)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+}
+
+func TestBoardService_CopyBoard(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	board := newBoard(t, sessions.Red)
+	list := newListWithBoardID(t, sessions.Red, board.ID)
+	card := newCardWithListID(t, sessions.Red, list.ID)
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := sessions.NoAuth.BoardService().CopyBoard(board.ID, new(pulpe.BoardCopy))
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("Lists only", func(t *testing.T) {
+		s := sessions.Red.BoardService()
+
+		cp, err := s.CopyBoard(board.ID, new(pulpe.BoardCopy))
+		require.NoError(t, err)
+		require.NotEqual(t, board.ID, cp.ID)
+		require.Equal(t, board.Name, cp.Name)
+		require.Equal(t, board.Slug+"-1", cp.Slug)
+		require.False(t, cp.Template)
+
+		cp, err = s.Board(cp.ID, pulpe.WithLists(), pulpe.WithCards())
+		require.NoError(t, err)
+		require.Len(t, cp.Lists, 1)
+		require.Equal(t, list.Name, cp.Lists[0].Name)
+		require.Equal(t, list.Slug, cp.Lists[0].Slug)
+		require.Empty(t, cp.Cards)
+	})
+
+	t.Run("With cards", func(t *testing.T) {
+		s := sessions.Red.BoardService()
+
+		cp, err := s.CopyBoard(board.ID, &pulpe.BoardCopy{Name: "copy", WithCards: true})
+		require.NoError(t, err)
+		require.Equal(t, "copy", cp.Slug)
+
+		cp, err = s.Board(cp.ID, pulpe.WithLists(), pulpe.WithCards())
+		require.NoError(t, err)
+		require.Len(t, cp.Lists, 1)
+		require.Len(t, cp.Cards, 1)
+		require.Equal(t, cp.Lists[0].ID, cp.Cards[0].ListID)
+		require.Equal(t, card.Name, cp.Cards[0].Name)
+		require.Equal(t, card.Slug, cp.Cards[0].Slug)
+	})
+
+	t.Run("Bad user", func(t *testing.T) {
+		_, err := sessions.Blue.BoardService().CopyBoard(board.ID, new(pulpe.BoardCopy))
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+}
+
+func TestBoardService_Templates(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.BoardService()
+
+	board := newBoard(t, sessions.Red)
+	newCardWithListID(t, sessions.Red, newListWithBoardID(t, sessions.Red, board.ID).ID)
+	newBoard(t, sessions.Red)
+
+	// Not a template yet.
+	_, err := s.CreateBoard(&pulpe.BoardCreation{Name: "sprint", TemplateID: board.ID})
+	require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+	template := true
+	tpl, err := s.UpdateBoard(board.ID, &pulpe.BoardUpdate{Template: &template})
+	require.NoError(t, err)
+	require.True(t, tpl.Template)
+
+	templates, err := s.Templates()
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	require.Equal(t, board.ID, templates[0].ID)
+
+	// Other users don't see it.
+	templates, err = sessions.Blue.BoardService().Templates()
+	require.NoError(t, err)
+	require.Empty(t, templates)
+
+	_, err = sessions.Blue.BoardService().CreateBoard(&pulpe.BoardCreation{Name: "sprint", TemplateID: board.ID})
+	require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+	// Create a board from the template.
+	b, err := s.CreateBoard(&pulpe.BoardCreation{Name: "sprint", TemplateID: board.ID})
+	require.NoError(t, err)
+	require.Equal(t, "sprint", b.Slug)
+	require.False(t, b.Template)
+
+	b, err = s.Board(b.ID, pulpe.WithLists(), pulpe.WithCards())
+	require.NoError(t, err)
+	require.Len(t, b.Lists, 1)
+	require.Len(t, b.Cards, 1)
+}
+
+func TestBoardService_ShareLinks(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	board := newBoard(t, sessions.Red)
+
+	t.Run("Not a member", func(t *testing.T) {
+		_, err := sessions.Blue.BoardService().CreateShareLink(board.ID)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+		_, err = sessions.NoAuth.BoardService().CreateShareLink(board.ID)
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		s := sessions.Red.BoardService()
+
+		link, err := s.CreateShareLink(board.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, link.Token)
+
+		links, err := s.ShareLinks(board.ID)
+		require.NoError(t, err)
+		require.Len(t, links, 1)
+		require.Equal(t, link.ID, links[0].ID)
+
+		other, err := sessions.NoAuth.BoardService().BoardByOwnerAndSlug(board.Owner.Login, board.Slug, pulpe.WithShareToken(link.Token))
+		require.NoError(t, err)
+		require.True(t, other.ReadOnly)
+
+		_, err = sessions.NoAuth.BoardService().BoardByOwnerAndSlug(board.Owner.Login, board.Slug, pulpe.WithShareToken("wrong"))
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+
+		err = s.RevokeShareLink(board.ID, link.ID)
+		require.NoError(t, err)
+
+		err = s.RevokeShareLink(board.ID, link.ID)
+		require.Equal(t, pulpe.ErrBoardShareLinkNotFound, err)
+
+		_, err = sessions.NoAuth.BoardService().BoardByOwnerAndSlug(board.Owner.Login, board.Slug, pulpe.WithShareToken(link.Token))
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		org := newOrganization(t, sessions.Red, "Winterfell")
+
+		blue, err := sessions.Blue.Authenticate()
+		require.NoError(t, err)
+
+		_, err = sessions.Red.OrganizationService().SetMember(org.Slug, blue.Login, pulpe.OrganizationRoleMember)
+		require.NoError(t, err)
+
+		board, err := sessions.Red.BoardService().CreateBoard(&pulpe.BoardCreation{
+			Name:         "Crypt",
+			Organization: org.Slug,
+		})
+		require.NoError(t, err)
+
+		// only admins can share organization boards
+		_, err = sessions.Blue.BoardService().CreateShareLink(board.ID)
+		require.Equal(t, pulpe.ErrOrganizationForbidden, err)
+
+		v := pulpe.BoardVisibilityPublic
+		_, err = sessions.Blue.BoardService().UpdateBoard(board.ID, &pulpe.BoardUpdate{Visibility: &v})
+		require.Equal(t, pulpe.ErrOrganizationForbidden, err)
+
+		_, err = sessions.Red.BoardService().CreateShareLink(board.ID)
+		require.NoError(t, err)
+	})
+}
+
 func BenchmarkCreateBoard(b *testing.B) {
 	sessions, cleanup := MustGetSessions(b)
 	defer cleanup()
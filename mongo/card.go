@@ -1,10 +1,13 @@
 package mongo
 
 import (
+	"errors"
+	"regexp"
 	"time"
 
 	"github.com/Machiel/slugify"
 	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/validation"
 	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -16,15 +19,63 @@ var _ pulpe.CardService = new(CardService)
 
 // card representation stored in MongoDB.
 type card struct {
-	ID          bson.ObjectId `bson:"_id"`
-	UpdatedAt   *time.Time    `bson:"updatedAt,omitempty"`
-	OwnerID     string        `bson:"ownerID"`
-	ListID      string        `bson:"listID"`
-	BoardID     string        `bson:"boardID"`
-	Name        string        `bson:"name"`
-	Slug        string        `bson:"slug"`
-	Description string        `bson:"description"`
-	Position    float64       `bson:"position"`
+	ID          bson.ObjectId   `bson:"_id"`
+	UpdatedAt   *time.Time      `bson:"updatedAt,omitempty"`
+	OwnerID     string          `bson:"ownerID"`
+	ListID      string          `bson:"listID"`
+	BoardID     string          `bson:"boardID"`
+	Name        string          `bson:"name"`
+	Slug        string          `bson:"slug"`
+	Description string          `bson:"description"`
+	Position    float64         `bson:"position"`
+	ImportID    string          `bson:"importID,omitempty"`
+	Labels      []string        `bson:"labels,omitempty"`
+	Checklist   []checklistItem `bson:"checklist,omitempty"`
+	Due         *time.Time      `bson:"due,omitempty"`
+	Assignees   []string        `bson:"assignees,omitempty"`
+	// Fields contains the custom field values, keyed by field id.
+	Fields map[string]interface{} `bson:"fields,omitempty"`
+	// ModifiedAt contains the time of the last write of each field.
+	ModifiedAt map[string]time.Time `bson:"modifiedAt,omitempty"`
+	// RestoredAt is set when the card is recreated with its list by an undo or a redo.
+	RestoredAt *time.Time `bson:"restoredAt,omitempty"`
+}
+
+// syncedCardFields are the fields of a card written by sync operations.
+var syncedCardFields = []string{"name", "description", "position", "labels", "due", "listID"}
+
+// checklistItem is an item of the checklist of a card or a card template.
+type checklistItem struct {
+	Name string `bson:"name"`
+	Done bool   `bson:"done"`
+}
+
+// toChecklist converts a pulpe checklist.
+func toChecklist(items []*pulpe.ChecklistItem) []checklistItem {
+	if items == nil {
+		return nil
+	}
+
+	cl := make([]checklistItem, len(items))
+	for i, item := range items {
+		cl[i] = checklistItem{Name: item.Name, Done: item.Done}
+	}
+
+	return cl
+}
+
+// toPulpeChecklist converts a checklist to a pulpe checklist.
+func toPulpeChecklist(items []checklistItem) []*pulpe.ChecklistItem {
+	if items == nil {
+		return nil
+	}
+
+	cl := make([]*pulpe.ChecklistItem, len(items))
+	for i := range items {
+		cl[i] = &pulpe.ChecklistItem{Name: items[i].Name, Done: items[i].Done}
+	}
+
+	return cl
 }
 
 // toPulpeCard creates a pulpe card from a mongo card.
@@ -39,6 +90,12 @@ func (c *card) toPulpeCard() *pulpe.Card {
 		Slug:        c.Slug,
 		Description: c.Description,
 		Position:    c.Position,
+		ImportID:    c.ImportID,
+		Labels:      c.Labels,
+		Checklist:   toPulpeChecklist(c.Checklist),
+		Due:         utcTime(c.Due),
+		Assignees:   c.Assignees,
+		Fields:      toPulpeFieldValues(c.Fields),
 	}
 
 	if c.UpdatedAt != nil {
@@ -75,7 +132,27 @@ func (s *CardService) ensureIndexes() error {
 		Sparse: true,
 	}
 
-	return col.EnsureIndex(index)
+	err = col.EnsureIndex(index)
+	if err != nil {
+		return err
+	}
+
+	// filters and sort orders of the cards of a board
+	for _, key := range [][]string{
+		{"boardID", "position", "_id"},
+		{"boardID", "due", "_id"},
+		{"boardID", "updatedAt", "_id"},
+		{"boardID", "listID", "position", "_id"},
+		{"boardID", "labels"},
+		{"boardID", "assignees"},
+	} {
+		err = col.EnsureIndexKey(key...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // CreateCard creates a new Card.
@@ -85,24 +162,56 @@ func (s *CardService) CreateCard(listID string, cc *pulpe.CardCreation) (*pulpe.
 		return nil, err
 	}
 
-	list, err := s.session.ListService().List(listID)
+	list, err := s.session.listService.listByID(user, listID)
 	if err != nil {
 		return nil, err
 	}
 
-	if list.OwnerID != user.ID {
-		return nil, pulpe.ErrListNotFound
-	}
-
+	// cards share the owner of their list
 	c := card{
 		ID:          bson.NewObjectId(),
-		OwnerID:     user.ID,
+		OwnerID:     list.OwnerID,
 		BoardID:     list.BoardID,
 		ListID:      listID,
 		Name:        cc.Name,
 		Slug:        slugify.Slugify(cc.Name),
 		Description: cc.Description,
 		Position:    cc.Position,
+		ImportID:    cc.ImportID,
+		Labels:      cc.Labels,
+		Checklist:   toChecklist(cc.Checklist),
+		Due:         utcTime(cc.Due),
+		Assignees:   cc.Assignees,
+	}
+
+	if cc.TemplateID != "" || len(cc.Fields) > 0 || len(cc.Assignees) > 0 {
+		b, err := s.session.boardService.store.boardByAccessAndID(ownedBy(c.OwnerID), c.BoardID)
+		if err != nil {
+			if err == mgo.ErrNotFound {
+				return nil, pulpe.ErrListNotFound
+			}
+
+			return nil, err
+		}
+
+		if cc.TemplateID != "" {
+			err = c.applyTemplate(b, cc.TemplateID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = s.session.boardService.checkAssignees(b, cc.Assignees)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := b.customFieldValues(cc.Fields)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Fields = toFieldValues(values)
 	}
 
 	err = s.store.createCard(&c)
@@ -110,7 +219,35 @@ func (s *CardService) CreateCard(listID string, cc *pulpe.CardCreation) (*pulpe.
 		return nil, err
 	}
 
-	return c.toPulpeCard(), err
+	err = s.session.revisionService.record(user, nil, &c)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.toPulpeCard(), nil
+}
+
+// applyTemplate fills the description, labels and checklist of a new card
+// with the ones of a card template of its board, if they are missing.
+func (c *card) applyTemplate(b *board, templateID string) error {
+	t := b.cardTemplate(templateID)
+	if t == nil {
+		return pulpe.ErrCardTemplateNotFound
+	}
+
+	if c.Description == "" {
+		c.Description = t.Description
+	}
+
+	if c.Labels == nil {
+		c.Labels = t.Labels
+	}
+
+	if c.Checklist == nil {
+		c.Checklist = t.Checklist
+	}
+
+	return nil
 }
 
 // Card returns a Card.
@@ -124,7 +261,22 @@ func (s *CardService) Card(id string) (*pulpe.Card, error) {
 		return nil, pulpe.ErrCardNotFound
 	}
 
-	c, err := s.store.cardByOwnerIDAndID(user.ID, id)
+	c, err := s.cardByID(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.toPulpeCard(), nil
+}
+
+// cardByID returns a card if the user has access to its board.
+func (s *CardService) cardByID(user *pulpe.User, id string) (*card, error) {
+	acc, err := s.session.access(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.store.cardByAccessAndID(acc.content(), id)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return nil, pulpe.ErrCardNotFound
@@ -133,7 +285,7 @@ func (s *CardService) Card(id string) (*pulpe.Card, error) {
 		return nil, err
 	}
 
-	return c.toPulpeCard(), nil
+	return c, nil
 }
 
 // DeleteCard deletes a Card by ID.
@@ -147,12 +299,41 @@ func (s *CardService) DeleteCard(id string) error {
 		return pulpe.ErrCardNotFound
 	}
 
-	err = s.store.deleteCardByID(user.ID, bson.ObjectIdHex(id))
-	if err == mgo.ErrNotFound {
-		return pulpe.ErrCardNotFound
+	c, err := s.cardByID(user, id)
+	if err != nil {
+		return err
 	}
 
-	return err
+	err = s.deleteCard(c)
+	if err != nil {
+		return err
+	}
+
+	return s.session.historyService.record(user, &historyEntry{
+		Entity:   pulpe.HistoryCard,
+		Action:   pulpe.HistoryDelete,
+		EntityID: c.ID.Hex(),
+		BoardID:  c.BoardID,
+		Card:     c,
+	})
+}
+
+// deleteCard removes a card and records its tombstone.
+func (s *CardService) deleteCard(c *card) error {
+	err := s.store.deleteCardByID(ownedBy(c.OwnerID), c.ID)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return pulpe.ErrCardNotFound
+		}
+
+		return err
+	}
+
+	return s.session.bury(&tombstone{
+		Kind:     pulpe.TombstoneCard,
+		TargetID: c.ID.Hex(),
+		BoardID:  c.BoardID,
+	})
 }
 
 // UpdateCard updates a Card by ID.
@@ -162,10 +343,35 @@ func (s *CardService) UpdateCard(id string, u *pulpe.CardUpdate) (*pulpe.Card, e
 		return nil, err
 	}
 
+	before, err := s.cardByID(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.updateCard(user, id, u, s.session.now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.session.historyService.recordCardUpdate(user, before, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.toPulpeCard(), nil
+}
+
+// updateCard updates a card, recording at as the time of the write of the fields.
+func (s *CardService) updateCard(user *pulpe.User, id string, u *pulpe.CardUpdate, at time.Time) (*card, error) {
 	if !bson.IsObjectIdHex(id) {
 		return nil, pulpe.ErrCardNotFound
 	}
 
+	c, err := s.cardByID(user, id)
+	if err != nil {
+		return nil, err
+	}
+
 	var newSlug string
 
 	patch := make(bson.M)
@@ -182,20 +388,96 @@ func (s *CardService) UpdateCard(id string, u *pulpe.CardUpdate) (*pulpe.Card, e
 		patch["position"] = *u.Position
 	}
 
+	if u.Labels != nil {
+		patch["labels"] = u.Labels
+	}
+
+	if u.Checklist != nil {
+		patch["checklist"] = toChecklist(u.Checklist)
+	}
+
+	if u.Due != nil {
+		if u.Due.IsZero() {
+			patch["due"] = nil
+		} else {
+			patch["due"] = u.Due.UTC()
+		}
+	}
+
+	ownerID := c.OwnerID
+	boardID := c.BoardID
 	if u.ListID != nil {
-		list, err := s.session.ListService().List(*u.ListID)
+		// the user must have access to the board of the target list too
+		list, err := s.session.listService.listByID(user, *u.ListID)
 		if err != nil {
 			return nil, err
 		}
 
-		if list.OwnerID != user.ID {
-			return nil, pulpe.ErrListNotFound
+		patch["listID"] = list.ID.Hex()
+		if list.BoardID != c.BoardID {
+			// cards share the owner of their board and their slug is unique per board
+			ownerID = list.OwnerID
+			boardID = list.BoardID
+			patch["boardID"] = list.BoardID
+			patch["ownerID"] = list.OwnerID
+			if newSlug == "" {
+				newSlug = slugify.Slugify(c.Name)
+			}
+		}
+	}
+
+	if boardID != c.BoardID {
+		// assignees and custom fields belong to the board, the ones of the former board are removed
+		patch["assignees"] = nil
+		patch["fields"] = nil
+	}
+
+	var b *board
+	if len(u.Fields) > 0 || len(u.Assignees) > 0 {
+		b, err = s.session.boardService.store.boardByAccessAndID(ownedBy(ownerID), boardID)
+		if err != nil {
+			if err == mgo.ErrNotFound {
+				return nil, pulpe.ErrCardNotFound
+			}
+
+			return nil, err
 		}
-		patch["listID"] = *u.ListID
 	}
 
+	if u.Assignees != nil {
+		err = s.session.boardService.checkAssignees(b, u.Assignees)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(u.Assignees) > 0 {
+			patch["assignees"] = u.Assignees
+		} else {
+			patch["assignees"] = nil
+		}
+	}
+
+	if len(u.Fields) > 0 {
+		values, err := b.customFieldValues(u.Fields)
+		if err != nil {
+			return nil, err
+		}
+
+		if boardID != c.BoardID {
+			if m := toFieldValues(values); m != nil {
+				patch["fields"] = m
+			}
+		} else {
+			for id, v := range values {
+				patch["fields."+id] = v
+			}
+		}
+	}
+
+	stampPatch(patch, at)
+
 	if len(patch) > 0 {
-		newSlug, err = s.store.updateCardByID(bson.ObjectIdHex(id), user.ID, newSlug, patch)
+		newSlug, err = s.store.updateCardByID(c.ID, c.OwnerID, newSlug, patch)
 		if err != nil {
 			if err == mgo.ErrNotFound {
 				return nil, pulpe.ErrCardNotFound
@@ -205,7 +487,18 @@ func (s *CardService) UpdateCard(id string, u *pulpe.CardUpdate) (*pulpe.Card, e
 		}
 	}
 
-	c, err := s.store.cardByOwnerIDAndID(user.ID, id)
+	if boardID != c.BoardID {
+		err = s.session.bury(&tombstone{
+			Kind:     pulpe.TombstoneCard,
+			TargetID: c.ID.Hex(),
+			BoardID:  c.BoardID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := s.store.cardByAccessAndID(ownedBy(ownerID), id)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return nil, pulpe.ErrCardNotFound
@@ -214,17 +507,195 @@ func (s *CardService) UpdateCard(id string, u *pulpe.CardUpdate) (*pulpe.Card, e
 		return nil, err
 	}
 
-	return c.toPulpeCard(), nil
+	err = s.session.revisionService.record(user, c, updated)
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// CopyCard copies a Card to a list, possibly on another board.
+func (s *CardService) CopyCard(id string, cc *pulpe.CardCopy) (*pulpe.Card, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	if !bson.IsObjectIdHex(id) {
+		return nil, pulpe.ErrCardNotFound
+	}
+
+	c, err := s.cardByID(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.session.listService.listByID(user, cc.ListID)
+	if err != nil {
+		return nil, err
+	}
+
+	position := c.Position
+	if cc.Position != nil {
+		position = *cc.Position
+	}
+
+	// custom fields belong to the board, their values aren't copied to other boards
+	var fields map[string]interface{}
+	if list.BoardID == c.BoardID {
+		fields = c.Fields
+	}
+
+	cp, err := s.copyCard(c, list, position, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return cp.toPulpeCard(), nil
+}
+
+// copyCard creates a copy of a card in a list, with the given custom field values.
+// Assignees are only kept on the same board.
+func (s *CardService) copyCard(c *card, l *list, position float64, fields map[string]interface{}) (*card, error) {
+	cp := card{
+		ID:          bson.NewObjectId(),
+		OwnerID:     l.OwnerID,
+		BoardID:     l.BoardID,
+		ListID:      l.ID.Hex(),
+		Name:        c.Name,
+		Slug:        slugify.Slugify(c.Name),
+		Description: c.Description,
+		Position:    position,
+		Labels:      c.Labels,
+		Checklist:   c.Checklist,
+		Due:         c.Due,
+		Fields:      fields,
+	}
+
+	if l.BoardID == c.BoardID {
+		cp.Assignees = c.Assignees
+	}
+
+	return &cp, s.store.createCard(&cp)
+}
+
+// moveCardsToBoard moves the cards of a list that are not on the board of the list,
+// removing the assignees and the custom field values of their former board.
+// It can be called again to finish a move that was interrupted.
+func (s *CardService) moveCardsToBoard(l *list) error {
+	cs, err := s.store.cardsByListIDOutsideBoard(l.ID.Hex(), l.BoardID)
+	if err != nil {
+		return err
+	}
+
+	for i := range cs {
+		patch := bson.M{
+			"boardID":   l.BoardID,
+			"ownerID":   l.OwnerID,
+			"assignees": nil,
+			"fields":    nil,
+		}
+
+		_, err = s.store.updateCardByID(cs[i].ID, cs[i].OwnerID, slugify.Slugify(cs[i].Name), patch)
+		if err != nil && err != mgo.ErrNotFound {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // CardsByBoard returns Cards by board ID.
-func (s *CardService) CardsByBoard(boardID string) ([]*pulpe.Card, error) {
-	_, err := s.session.Authenticate()
+// Like the board, the cards of public and shared boards can be read without being authenticated.
+// Invalid filters, sort orders and cursors are reported as validation errors.
+func (s *CardService) CardsByBoard(boardID string, options ...pulpe.CardListOption) ([]*pulpe.Card, error) {
+	user, err := s.session.Authenticate()
+	if err != nil && err != pulpe.ErrUserAuthenticationFailed {
+		return nil, err
+	}
+
+	// anonymous users can't know if a private board exists
+	notFound := pulpe.ErrBoardNotFound
+	if user == nil {
+		notFound = pulpe.ErrUserAuthenticationFailed
+	}
+
+	var opts pulpe.CardListOptions
+
+	for i := range options {
+		options[i](&opts)
+	}
+
+	if !bson.IsObjectIdHex(boardID) {
+		return nil, notFound
+	}
+
+	var b board
+	err = s.session.db.C(boardCol).FindId(bson.ObjectIdHex(boardID)).One(&b)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, notFound
+		}
+
+		return nil, err
+	}
+
+	_, err = s.session.boardService.readAccess(user, &b, opts.ShareToken)
+	if err != nil {
+		if err == pulpe.ErrBoardNotFound {
+			return nil, notFound
+		}
+
+		return nil, err
+	}
+
+	return s.cardsByBoard(boardID, &opts)
+}
+
+// cardsByBoard returns the cards of a board without checking the access rights.
+func (s *CardService) cardsByBoard(boardID string, opts *pulpe.CardListOptions) ([]*pulpe.Card, error) {
+	// a board that doesn't exist has no custom fields
+	var b board
+	if (len(opts.Fields) > 0 || opts.SortField != "") && bson.IsObjectIdHex(boardID) {
+		err := s.session.db.C(boardCol).FindId(bson.ObjectIdHex(boardID)).One(&b)
+		if err != nil && err != mgo.ErrNotFound {
+			return nil, err
+		}
+	}
+
+	query, err := cardQuery(&b, boardID, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	cs, err := s.store.cardsByBoardID(boardID)
+	key, err := cardSortKey(&b, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.After != "" {
+		after, err := cardsAfter(&b, key, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		and, _ := query["$and"].([]bson.M)
+		query["$and"] = append(and, after)
+	}
+
+	sort := []string{key, "_id"}
+	if key == "_id" {
+		sort = sort[:1]
+	}
+
+	if opts.Descending {
+		for i := range sort {
+			sort[i] = "-" + sort[i]
+		}
+	}
+
+	cs, err := s.store.findCards(query, opts.Limit, sort...)
 	if err != nil {
 		return nil, err
 	}
@@ -237,6 +708,149 @@ func (s *CardService) CardsByBoard(boardID string) ([]*pulpe.Card, error) {
 	return cards, nil
 }
 
+// cardQuery returns the query matching the cards of a board filtered by the options.
+func cardQuery(b *board, boardID string, opts *pulpe.CardListOptions) (bson.M, error) {
+	query := bson.M{"boardID": boardID}
+
+	if opts.ListID != "" {
+		query["listID"] = opts.ListID
+	}
+
+	if opts.Label != "" {
+		query["labels"] = opts.Label
+	}
+
+	if opts.Assignee != "" {
+		query["assignees"] = opts.Assignee
+	}
+
+	if opts.DueAfter != nil || opts.DueBefore != nil {
+		due := make(bson.M)
+		if opts.DueAfter != nil {
+			due["$gte"] = *opts.DueAfter
+		}
+
+		if opts.DueBefore != nil {
+			due["$lt"] = *opts.DueBefore
+		}
+
+		query["due"] = due
+	}
+
+	var and []bson.M
+	if opts.Text != "" {
+		re := bson.RegEx{Pattern: regexp.QuoteMeta(opts.Text), Options: "i"}
+		and = append(and, bson.M{"$or": []bson.M{{"name": re}, {"description": re}}})
+	}
+
+	if opts.UpdatedSince != nil {
+		// cards that were never updated are matched by creation date
+		and = append(and, bson.M{"$or": []bson.M{
+			{"updatedAt": bson.M{"$gte": *opts.UpdatedSince}},
+			{"_id": bson.M{"$gte": bson.NewObjectIdWithTime(*opts.UpdatedSince)}},
+		}})
+	}
+
+	if len(and) > 0 {
+		query["$and"] = and
+	}
+
+	for id, v := range opts.Fields {
+		f := b.customField(id)
+		if f == nil {
+			return nil, validation.AddError(nil, "fields."+id, pulpe.ErrCustomFieldNotFound)
+		}
+
+		value, err := validation.ParseCustomFieldValue(f.toPulpeCustomField(), v)
+		if err != nil {
+			return nil, err
+		}
+
+		query["fields."+id] = value
+	}
+
+	return query, nil
+}
+
+// cardSortKey returns the key the cards are sorted by, before their id.
+func cardSortKey(b *board, opts *pulpe.CardListOptions) (string, error) {
+	if opts.SortField != "" {
+		if b.customField(opts.SortField) == nil {
+			return "", validation.AddError(nil, "sort", pulpe.ErrCustomFieldNotFound)
+		}
+
+		return "fields." + opts.SortField, nil
+	}
+
+	switch opts.Sort {
+	case "":
+		return "_id", nil
+	case pulpe.CardSortPosition:
+		return "position", nil
+	case pulpe.CardSortDue:
+		return "due", nil
+	case pulpe.CardSortUpdated:
+		return "updatedAt", nil
+	}
+
+	return "", validation.AddError(nil, "sort", errors.New("unsupported sort order"))
+}
+
+// cardsAfter returns the query matching the cards that follow the cursor of the options
+// in the sort order. Cards missing the sort key come first in ascending order.
+func cardsAfter(b *board, key string, opts *pulpe.CardListOptions) (bson.M, error) {
+	invalid := validation.AddError(nil, "after", pulpe.ErrCardInvalidCursor)
+
+	v, id, err := pulpe.ParseCardCursor(opts.After)
+	if err != nil || !bson.IsObjectIdHex(id) {
+		return nil, invalid
+	}
+
+	op := "$gt"
+	if opts.Descending {
+		op = "$lt"
+	}
+
+	next := bson.M{"_id": bson.M{op: bson.ObjectIdHex(id)}}
+	if key == "_id" {
+		return next, nil
+	}
+
+	if v != nil {
+		switch key {
+		case "position":
+			if _, ok := v.(float64); !ok {
+				return nil, invalid
+			}
+		case "due", "updatedAt":
+			s, _ := v.(string)
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, invalid
+			}
+			v = t
+		default:
+			values, err := b.customFieldValues(map[string]interface{}{opts.SortField: v})
+			if err != nil {
+				return nil, invalid
+			}
+			v = values[opts.SortField]
+		}
+	}
+
+	next[key] = v
+	switch {
+	case v == nil && opts.Descending:
+		return next, nil
+	case v == nil:
+		return bson.M{"$or": []bson.M{{key: bson.M{"$ne": nil}}, next}}, nil
+	case opts.Descending:
+		return bson.M{"$or": []bson.M{{key: bson.M{op: v}}, {key: nil}, next}}, nil
+	}
+
+	return bson.M{"$or": []bson.M{{key: bson.M{op: v}}, next}}, nil
+}
+
 // DeleteCardsByListID deletes all the cards of a list.
 func (s *CardService) DeleteCardsByListID(listID string) error {
 	user, err := s.session.Authenticate()
@@ -244,7 +858,12 @@ func (s *CardService) DeleteCardsByListID(listID string) error {
 		return err
 	}
 
-	return s.store.deleteCardsByListID(user.ID, listID)
+	acc, err := s.session.access(user.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.store.deleteCardsByListID(acc.content(), listID)
 }
 
 // DeleteCardsByBoardID deletes all the cards of a board.
@@ -254,7 +873,12 @@ func (s *CardService) DeleteCardsByBoardID(boardID string) error {
 		return err
 	}
 
-	return s.store.deleteCardsByBoardID(user.ID, boardID)
+	acc, err := s.session.access(user.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.store.deleteCardsByBoardID(acc.content(), boardID)
 }
 
 type cardStore struct {
@@ -273,7 +897,7 @@ func (s *cardStore) createCard(c *card) error {
 	return err
 }
 
-func (s *cardStore) cardByOwnerIDAndID(ownerID, id string) (*card, error) {
+func (s *cardStore) cardByAccessAndID(or []bson.M, id string) (*card, error) {
 	var c card
 
 	if !bson.IsObjectIdHex(id) {
@@ -281,55 +905,70 @@ func (s *cardStore) cardByOwnerIDAndID(ownerID, id string) (*card, error) {
 	}
 
 	query := bson.M{
-		"ownerID": ownerID,
-		"_id":     bson.ObjectIdHex(id),
+		"$or": or,
+		"_id": bson.ObjectIdHex(id),
 	}
 
 	return &c, s.session.db.C(cardCol).Find(query).One(&c)
 }
 
-func (s *cardStore) deleteCardByID(ownerID string, id bson.ObjectId) error {
+func (s *cardStore) deleteCardByID(or []bson.M, id bson.ObjectId) error {
 	return s.session.db.C(cardCol).Remove(bson.M{
-		"_id":     id,
-		"ownerID": ownerID,
+		"_id": id,
+		"$or": or,
 	})
 }
 
-func (s *cardStore) deleteCardsByBoardID(ownerID, boardID string) error {
+func (s *cardStore) deleteCardsByBoardID(or []bson.M, boardID string) error {
 	_, err := s.session.db.C(cardCol).RemoveAll(bson.M{
-		"ownerID": ownerID,
+		"$or":     or,
 		"boardID": boardID,
 	})
 
 	return err
 }
 
-func (s *cardStore) deleteCardsByListID(ownerID, listID string) error {
+func (s *cardStore) deleteCardsByListID(or []bson.M, listID string) error {
 	_, err := s.session.db.C(cardCol).RemoveAll(bson.M{
-		"ownerID": ownerID,
-		"listID":  listID,
+		"$or":    or,
+		"listID": listID,
 	})
 
 	return err
 }
 
+// updateCardByID applies the patch to a card. Nil values of the patch are removed.
 func (s *cardStore) updateCardByID(id bson.ObjectId, ownerID, slug string, patch bson.M) (string, error) {
 	col := s.session.db.C(cardCol)
 
+	unset := make(bson.M)
+	for k, v := range patch {
+		if v == nil {
+			unset[k] = ""
+			delete(patch, k)
+		}
+	}
+
 	newSlug, err := resolveSlugAndDo(col, ownerID, "slug", slug, "-", func(slug string) error {
 		if slug != "" {
 			patch["slug"] = slug
 		}
 
+		update := bson.M{"$currentDate": bson.M{"updatedAt": true}}
+		if len(patch) > 0 {
+			update["$set"] = patch
+		}
+
+		if len(unset) > 0 {
+			update["$unset"] = unset
+		}
+
 		return col.Update(
 			bson.M{
 				"_id":     id,
 				"ownerID": ownerID,
 			},
-			bson.M{
-				"$set":         patch,
-				"$currentDate": bson.M{"updatedAt": true},
-			})
+			update)
 	})
 
 	return newSlug, err
@@ -344,3 +983,34 @@ func (s *cardStore) cardsByBoardID(boardID string) ([]card, error) {
 	err := col.Find(bson.M{"boardID": boardID}).Sort("_id").All(&cards)
 	return cards, err
 }
+
+// findCards returns the cards matching the query. Zero means no limit.
+func (s *cardStore) findCards(query bson.M, limit int, sort ...string) ([]card, error) {
+	col := s.session.db.C(cardCol)
+
+	var cards []card
+
+	err := col.Find(query).Sort(sort...).Limit(limit).All(&cards)
+	return cards, err
+}
+
+func (s *cardStore) cardsByListID(listID string) ([]card, error) {
+	col := s.session.db.C(cardCol)
+
+	var cards []card
+
+	err := col.Find(bson.M{"listID": listID}).Sort("position", "_id").All(&cards)
+	return cards, err
+}
+
+func (s *cardStore) cardsByListIDOutsideBoard(listID, boardID string) ([]card, error) {
+	col := s.session.db.C(cardCol)
+
+	var cards []card
+
+	err := col.Find(bson.M{
+		"listID":  listID,
+		"boardID": bson.M{"$ne": boardID},
+	}).Sort("_id").All(&cards)
+	return cards, err
+}
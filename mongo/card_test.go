@@ -1,11 +1,14 @@
 package mongo_test
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"gopkg.in/mgo.v2/bson"
 
 	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/validation"
 	"github.com/stretchr/testify/require"
 )
 
@@ -354,6 +357,9 @@ func TestCardService_UpdateCard(t *testing.T) {
 		})
 		require.NoError(t, err)
 		require.Equal(t, list2, updatedCard.ListID)
+		list, err := sessions.Red.ListService().List(list2)
+		require.NoError(t, err)
+		require.Equal(t, list.BoardID, updatedCard.BoardID)
 
 		// Update the listID for an existing list that's not ours.
 		_, err = s.UpdateCard(card.ID, &pulpe.CardUpdate{
@@ -371,6 +377,28 @@ func TestCardService_UpdateCard(t *testing.T) {
 		require.Equal(t, pulpe.ErrListNotFound, err)
 	})
 
+	t.Run("Move to another board", func(t *testing.T) {
+		s := sessions.Red.CardService()
+
+		list1 := newList(t, sessions.Red)
+		list2 := newList(t, sessions.Red)
+
+		card, err := s.CreateCard(list1.ID, &pulpe.CardCreation{Name: "name"})
+		require.NoError(t, err)
+		_, err = s.CreateCard(list2.ID, &pulpe.CardCreation{Name: "name"})
+		require.NoError(t, err)
+
+		// the slug is recomputed against the target board
+		updatedCard, err := s.UpdateCard(card.ID, &pulpe.CardUpdate{
+			ListID: &list2.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, list2.ID, updatedCard.ListID)
+		require.Equal(t, list2.BoardID, updatedCard.BoardID)
+		require.Equal(t, "name-1", updatedCard.Slug)
+		require.Equal(t, card.Position, updatedCard.Position)
+	})
+
 	t.Run("Bad user", func(t *testing.T) {
 		s1 := sessions.Red.CardService()
 		s2 := sessions.Blue.CardService()
@@ -408,6 +436,66 @@ func TestCardService_UpdateCard(t *testing.T) {
 	})
 }
 
+func TestCardService_CopyCard(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		s := sessions.NoAuth.CardService()
+
+		_, err := s.CopyCard(newCardID(), &pulpe.CardCopy{ListID: newListID()})
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		s := sessions.Red.CardService()
+
+		list1 := newList(t, sessions.Red)
+		list2 := newList(t, sessions.Red)
+
+		card, err := s.CreateCard(list1.ID, &pulpe.CardCreation{
+			Name:        "name",
+			Description: "description",
+			Position:    4,
+		})
+		require.NoError(t, err)
+
+		// Copy to the same list.
+		cp, err := s.CopyCard(card.ID, &pulpe.CardCopy{ListID: list1.ID})
+		require.NoError(t, err)
+		require.NotEqual(t, card.ID, cp.ID)
+		require.Equal(t, "name-1", cp.Slug)
+		require.Equal(t, card.Description, cp.Description)
+		require.Equal(t, card.Position, cp.Position)
+
+		// Copy to another board.
+		position := 2.0
+		cp, err = s.CopyCard(card.ID, &pulpe.CardCopy{ListID: list2.ID, Position: &position})
+		require.NoError(t, err)
+		require.Equal(t, list2.ID, cp.ListID)
+		require.Equal(t, list2.BoardID, cp.BoardID)
+		require.Equal(t, "name", cp.Slug)
+		require.Equal(t, position, cp.Position)
+
+		// The original card is untouched.
+		c, err := s.Card(card.ID)
+		require.NoError(t, err)
+		require.Equal(t, card, c)
+	})
+
+	t.Run("Bad user", func(t *testing.T) {
+		card := newCardWithListID(t, sessions.Red, newList(t, sessions.Red).ID)
+
+		// Copy a card of red to a list of blue.
+		_, err := sessions.Blue.CardService().CopyCard(card.ID, &pulpe.CardCopy{ListID: newList(t, sessions.Blue).ID})
+		require.Equal(t, pulpe.ErrCardNotFound, err)
+
+		// Copy a card of red to a list of blue, as red.
+		_, err = sessions.Red.CardService().CopyCard(card.ID, &pulpe.CardCopy{ListID: newList(t, sessions.Blue).ID})
+		require.Equal(t, pulpe.ErrListNotFound, err)
+	})
+}
+
 func TestCardService_CardsByBoard(t *testing.T) {
 	sessions, cleanup := MustGetSessions(t)
 	defer cleanup()
@@ -454,8 +542,163 @@ func TestCardService_CardsByBoard(t *testing.T) {
 		s := sessions.Green.CardService()
 
 		// Trying to find cards of a board that doesn't exist.
-		cards, err := s.CardsByBoard(newBoardID())
+		_, err := s.CardsByBoard(newBoardID())
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+
+	t.Run("Access", func(t *testing.T) {
+		board := newBoard(t, sessions.Red)
+		newCardWithListID(t, sessions.Red, newListWithBoardID(t, sessions.Red, board.ID).ID)
+
+		_, err := sessions.Blue.CardService().CardsByBoard(board.ID)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+		_, err = sessions.NoAuth.CardService().CardsByBoard(board.ID)
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+
+		link, err := sessions.Red.BoardService().CreateShareLink(board.ID)
+		require.NoError(t, err)
+
+		cards, err := sessions.NoAuth.CardService().CardsByBoard(board.ID, pulpe.SharedWith(link.Token))
+		require.NoError(t, err)
+		require.Len(t, cards, 1)
+
+		public := pulpe.BoardVisibilityPublic
+		_, err = sessions.Red.BoardService().UpdateBoard(board.ID, &pulpe.BoardUpdate{Visibility: &public})
+		require.NoError(t, err)
+
+		cards, err = sessions.Blue.CardService().CardsByBoard(board.ID)
+		require.NoError(t, err)
+		require.Len(t, cards, 1)
+	})
+}
+
+func TestCardService_CardsByBoard_Filters(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.CardService()
+	red, err := sessions.Red.Authenticate()
+	require.NoError(t, err)
+
+	board := newBoard(t, sessions.Red)
+	list1 := newListWithBoardID(t, sessions.Red, board.ID)
+	list2 := newListWithBoardID(t, sessions.Red, board.ID)
+
+	day := time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		due := day.AddDate(0, 0, i)
+		cc := pulpe.CardCreation{
+			Name:     fmt.Sprintf("card %d", i),
+			Position: float64(6 - i),
+			Due:      &due,
+		}
+
+		listID := list1.ID
+		if i%2 == 1 {
+			listID = list2.ID
+			cc.Labels = []string{"bug"}
+			cc.Assignees = []string{red.ID}
+			cc.Description = "Crash on login"
+		}
+
+		_, err = s.CreateCard(listID, &cc)
+		require.NoError(t, err)
+	}
+
+	t.Run("Filters", func(t *testing.T) {
+		cards, err := s.CardsByBoard(board.ID, pulpe.InList(list1.ID))
+		require.NoError(t, err)
+		require.Len(t, cards, 3)
+
+		cards, err = s.CardsByBoard(board.ID, pulpe.WithLabel("bug"), pulpe.AssignedTo(red.ID))
+		require.NoError(t, err)
+		require.Len(t, cards, 3)
+
+		cards, err = s.CardsByBoard(board.ID, pulpe.MatchingText("CRASH"))
+		require.NoError(t, err)
+		require.Len(t, cards, 3)
+
+		after, before := day.AddDate(0, 0, 1), day.AddDate(0, 0, 3)
+		cards, err = s.CardsByBoard(board.ID, pulpe.DueBetween(&after, &before))
+		require.NoError(t, err)
+		require.Len(t, cards, 2)
+
+		cards, err = s.CardsByBoard(board.ID, pulpe.UpdatedSince(time.Now().Add(time.Hour)))
+		require.NoError(t, err)
+		require.Empty(t, cards)
+	})
+
+	t.Run("Sort and pages", func(t *testing.T) {
+		opts := pulpe.CardListOptions{Sort: pulpe.CardSortPosition}
+
+		var names []string
+		var after string
+		for {
+			cards, err := s.CardsByBoard(board.ID, pulpe.SortBy(pulpe.CardSortPosition, false), pulpe.Page(4, after))
+			require.NoError(t, err)
+			for _, c := range cards {
+				names = append(names, c.Name)
+			}
+
+			if len(cards) < 4 {
+				break
+			}
+			after = opts.Cursor(cards[len(cards)-1])
+		}
+
+		require.Equal(t, []string{"card 5", "card 4", "card 3", "card 2", "card 1", "card 0"}, names)
+
+		cards, err := s.CardsByBoard(board.ID, pulpe.SortBy(pulpe.CardSortDue, true), pulpe.Page(2, ""))
+		require.NoError(t, err)
+		require.Len(t, cards, 2)
+		require.Equal(t, "card 5", cards[0].Name)
+
+		opts = pulpe.CardListOptions{Sort: pulpe.CardSortDue}
+		cards, err = s.CardsByBoard(board.ID, pulpe.SortBy(pulpe.CardSortDue, true), pulpe.Page(2, opts.Cursor(cards[1])))
+		require.NoError(t, err)
+		require.Equal(t, "card 3", cards[0].Name)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := s.CardsByBoard(board.ID, pulpe.SortBy("name", false))
+		require.True(t, validation.IsError(err))
+
+		_, err = s.CardsByBoard(board.ID, pulpe.SortBy(pulpe.CardSortDue, false), pulpe.Page(2, "cursor"))
+		require.True(t, validation.IsError(err))
+	})
+}
+
+func TestCardService_Assignees(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.CardService()
+	red, err := sessions.Red.Authenticate()
+	require.NoError(t, err)
+	blue, err := sessions.Blue.Authenticate()
+	require.NoError(t, err)
+
+	list := newList(t, sessions.Red)
+
+	t.Run("Not a member", func(t *testing.T) {
+		_, err := s.CreateCard(list.ID, &pulpe.CardCreation{Name: "name", Assignees: []string{blue.ID}})
+		require.Equal(t, pulpe.ErrCardInvalidAssignee, err)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		card, err := s.CreateCard(list.ID, &pulpe.CardCreation{Name: "name", Assignees: []string{red.ID}})
+		require.NoError(t, err)
+		require.Equal(t, []string{red.ID}, card.Assignees)
+
+		due := time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC)
+		card, err = s.UpdateCard(card.ID, &pulpe.CardUpdate{Assignees: []string{}, Due: &due})
+		require.NoError(t, err)
+		require.Empty(t, card.Assignees)
+		require.Equal(t, &due, card.Due)
+
+		card, err = s.UpdateCard(card.ID, &pulpe.CardUpdate{Due: new(time.Time)})
 		require.NoError(t, err)
-		require.Len(t, cards, 0)
+		require.Nil(t, card.Due)
 	})
 }
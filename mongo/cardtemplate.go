@@ -0,0 +1,223 @@
+package mongo
+
+import (
+	"github.com/blankrobot/pulpe"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// cardTemplate pre-fills the cards created on a board.
+// Templates are stored in their board so that they follow it when it is copied or deleted.
+type cardTemplate struct {
+	ID          bson.ObjectId   `bson:"id"`
+	Name        string          `bson:"name"`
+	Description string          `bson:"description"`
+	Labels      []string        `bson:"labels,omitempty"`
+	Checklist   []checklistItem `bson:"checklist,omitempty"`
+}
+
+func (t *cardTemplate) toPulpeCardTemplate() *pulpe.CardTemplate {
+	p := pulpe.CardTemplate{
+		ID:          t.ID.Hex(),
+		CreatedAt:   t.ID.Time().UTC(),
+		Name:        t.Name,
+		Description: t.Description,
+		Labels:      t.Labels,
+		Checklist:   toPulpeChecklist(t.Checklist),
+	}
+
+	if p.Labels == nil {
+		p.Labels = []string{}
+	}
+
+	if p.Checklist == nil {
+		p.Checklist = []*pulpe.ChecklistItem{}
+	}
+
+	return &p
+}
+
+// cardTemplate returns a card template of the board, or nil.
+func (b *board) cardTemplate(id string) *cardTemplate {
+	if !bson.IsObjectIdHex(id) {
+		return nil
+	}
+
+	for i := range b.CardTemplates {
+		if b.CardTemplates[i].ID == bson.ObjectIdHex(id) {
+			return &b.CardTemplates[i]
+		}
+	}
+
+	return nil
+}
+
+// copyCardTemplates returns a copy of the card templates with new ids.
+func copyCardTemplates(ts []cardTemplate) []cardTemplate {
+	var cp []cardTemplate
+
+	for _, t := range ts {
+		t.ID = bson.NewObjectId()
+		cp = append(cp, t)
+	}
+
+	return cp
+}
+
+// CreateCardTemplate creates a card template on a board.
+func (s *BoardService) CreateCardTemplate(boardID string, tc *pulpe.CardTemplateCreation) (*pulpe.CardTemplate, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.boardByID(user, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	t := cardTemplate{
+		ID:          bson.NewObjectId(),
+		Name:        tc.Name,
+		Description: tc.Description,
+		Labels:      tc.Labels,
+		Checklist:   toChecklist(tc.Checklist),
+	}
+
+	err = s.session.db.C(boardCol).UpdateId(b.ID, bson.M{
+		"$push":        bson.M{"cardTemplates": &t},
+		"$currentDate": bson.M{"updatedAt": true},
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		return nil, err
+	}
+
+	return t.toPulpeCardTemplate(), nil
+}
+
+// CardTemplates returns the card templates of a board.
+func (s *BoardService) CardTemplates(boardID string) ([]*pulpe.CardTemplate, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.boardByID(user, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := make([]*pulpe.CardTemplate, len(b.CardTemplates))
+	for i := range b.CardTemplates {
+		ts[i] = b.CardTemplates[i].toPulpeCardTemplate()
+	}
+
+	return ts, nil
+}
+
+// UpdateCardTemplate updates a card template of a board.
+func (s *BoardService) UpdateCardTemplate(boardID, templateID string, u *pulpe.CardTemplateUpdate) (*pulpe.CardTemplate, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.boardByID(user, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.cardTemplate(templateID) == nil {
+		return nil, pulpe.ErrCardTemplateNotFound
+	}
+
+	patch := make(bson.M)
+	if u.Name != nil {
+		patch["cardTemplates.$.name"] = *u.Name
+	}
+
+	if u.Description != nil {
+		patch["cardTemplates.$.description"] = *u.Description
+	}
+
+	if u.Labels != nil {
+		patch["cardTemplates.$.labels"] = u.Labels
+	}
+
+	if u.Checklist != nil {
+		patch["cardTemplates.$.checklist"] = toChecklist(u.Checklist)
+	}
+
+	if len(patch) > 0 {
+		err = s.session.db.C(boardCol).Update(
+			bson.M{
+				"_id":              b.ID,
+				"cardTemplates.id": bson.ObjectIdHex(templateID),
+			},
+			bson.M{
+				"$set":         patch,
+				"$currentDate": bson.M{"updatedAt": true},
+			})
+		if err != nil {
+			if err == mgo.ErrNotFound {
+				return nil, pulpe.ErrCardTemplateNotFound
+			}
+
+			return nil, err
+		}
+	}
+
+	b, err = s.store.boardByAccessAndID(ownedBy(b.OwnerID), boardID)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		return nil, err
+	}
+
+	t := b.cardTemplate(templateID)
+	if t == nil {
+		return nil, pulpe.ErrCardTemplateNotFound
+	}
+
+	return t.toPulpeCardTemplate(), nil
+}
+
+// DeleteCardTemplate removes a card template from a board.
+// Cards created from the template are kept.
+func (s *BoardService) DeleteCardTemplate(boardID, templateID string) error {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return err
+	}
+
+	b, err := s.boardByID(user, boardID)
+	if err != nil {
+		return err
+	}
+
+	if !bson.IsObjectIdHex(templateID) {
+		return pulpe.ErrCardTemplateNotFound
+	}
+
+	id := bson.ObjectIdHex(templateID)
+	err = s.session.db.C(boardCol).Update(
+		bson.M{
+			"_id":              b.ID,
+			"cardTemplates.id": id,
+		},
+		bson.M{
+			"$pull":        bson.M{"cardTemplates": bson.M{"id": id}},
+			"$currentDate": bson.M{"updatedAt": true},
+		})
+	if err == mgo.ErrNotFound {
+		return pulpe.ErrCardTemplateNotFound
+	}
+
+	return err
+}
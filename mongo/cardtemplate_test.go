@@ -0,0 +1,160 @@
+package mongo_test
+
+import (
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/stretchr/testify/require"
+)
+
+func newCardTemplate(t *testing.T, session *Session, boardID string) *pulpe.CardTemplate {
+	template, err := session.BoardService().CreateCardTemplate(boardID, &pulpe.CardTemplateCreation{
+		Name:        "Bug report",
+		Description: "## Steps to reproduce",
+		Labels:      []string{"bug"},
+		Checklist:   []*pulpe.ChecklistItem{{Name: "Reproduce"}, {Name: "Fix"}},
+	})
+	require.NoError(t, err)
+	return template
+}
+
+func TestBoardService_CardTemplates(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := sessions.NoAuth.BoardService().CreateCardTemplate(newBoardID(), &pulpe.CardTemplateCreation{Name: "name"})
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		s := sessions.Red.BoardService()
+		board := newBoard(t, sessions.Red)
+
+		templates, err := s.CardTemplates(board.ID)
+		require.NoError(t, err)
+		require.Empty(t, templates)
+
+		template := newCardTemplate(t, sessions.Red, board.ID)
+		require.NotEmpty(t, template.ID)
+		require.Equal(t, []string{"bug"}, template.Labels)
+		require.Len(t, template.Checklist, 2)
+
+		templates, err = s.CardTemplates(board.ID)
+		require.NoError(t, err)
+		require.Equal(t, []*pulpe.CardTemplate{template}, templates)
+
+		name := "Feature request"
+		updated, err := s.UpdateCardTemplate(board.ID, template.ID, &pulpe.CardTemplateUpdate{
+			Name:   &name,
+			Labels: []string{},
+		})
+		require.NoError(t, err)
+		require.Equal(t, name, updated.Name)
+		require.Equal(t, template.Description, updated.Description)
+		require.Empty(t, updated.Labels)
+		require.Equal(t, template.Checklist, updated.Checklist)
+
+		err = s.DeleteCardTemplate(board.ID, template.ID)
+		require.NoError(t, err)
+
+		err = s.DeleteCardTemplate(board.ID, template.ID)
+		require.Equal(t, pulpe.ErrCardTemplateNotFound, err)
+
+		_, err = s.UpdateCardTemplate(board.ID, template.ID, &pulpe.CardTemplateUpdate{Name: &name})
+		require.Equal(t, pulpe.ErrCardTemplateNotFound, err)
+	})
+
+	t.Run("Bad user", func(t *testing.T) {
+		board := newBoard(t, sessions.Red)
+		template := newCardTemplate(t, sessions.Red, board.ID)
+
+		_, err := sessions.Blue.BoardService().CardTemplates(board.ID)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+		err = sessions.Blue.BoardService().DeleteCardTemplate(board.ID, template.ID)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+}
+
+func TestCardService_CreateCard_Template(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.CardService()
+	board := newBoard(t, sessions.Red)
+	list := newListWithBoardID(t, sessions.Red, board.ID)
+	template := newCardTemplate(t, sessions.Red, board.ID)
+
+	t.Run("Pre-filled", func(t *testing.T) {
+		card, err := s.CreateCard(list.ID, &pulpe.CardCreation{
+			Name:       "Crash on login",
+			TemplateID: template.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, template.Description, card.Description)
+		require.Equal(t, template.Labels, card.Labels)
+		require.Equal(t, template.Checklist, card.Checklist)
+
+		card, err = s.Card(card.ID)
+		require.NoError(t, err)
+		require.Equal(t, template.Checklist, card.Checklist)
+	})
+
+	t.Run("Overridden", func(t *testing.T) {
+		card, err := s.CreateCard(list.ID, &pulpe.CardCreation{
+			Name:        "Crash on login",
+			Description: "It crashes",
+			Labels:      []string{"urgent"},
+			TemplateID:  template.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "It crashes", card.Description)
+		require.Equal(t, []string{"urgent"}, card.Labels)
+		require.Equal(t, template.Checklist, card.Checklist)
+	})
+
+	t.Run("Template of another board", func(t *testing.T) {
+		other := newCardTemplate(t, sessions.Red, newBoard(t, sessions.Red).ID)
+
+		_, err := s.CreateCard(list.ID, &pulpe.CardCreation{Name: "name", TemplateID: other.ID})
+		require.Equal(t, pulpe.ErrCardTemplateNotFound, err)
+	})
+}
+
+func TestBoardService_CardTemplates_Copy(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.BoardService()
+	board := newBoard(t, sessions.Red)
+	template := newCardTemplate(t, sessions.Red, board.ID)
+
+	t.Run("CopyBoard", func(t *testing.T) {
+		cp, err := s.CopyBoard(board.ID, new(pulpe.BoardCopy))
+		require.NoError(t, err)
+
+		templates, err := s.CardTemplates(cp.ID)
+		require.NoError(t, err)
+		require.Len(t, templates, 1)
+		require.NotEqual(t, template.ID, templates[0].ID)
+		require.Equal(t, template.Name, templates[0].Name)
+		require.Equal(t, template.Checklist, templates[0].Checklist)
+	})
+
+	t.Run("Export and import", func(t *testing.T) {
+		a, err := s.ExportBoard(board.ID)
+		require.NoError(t, err)
+		require.Len(t, a.CardTemplates, 1)
+		require.Equal(t, template.Labels, a.CardTemplates[0].Labels)
+
+		imported, err := sessions.Blue.BoardService().ImportBoard(a, "")
+		require.NoError(t, err)
+
+		templates, err := sessions.Blue.BoardService().CardTemplates(imported.ID)
+		require.NoError(t, err)
+		require.Len(t, templates, 1)
+		require.Equal(t, template.Description, templates[0].Description)
+		require.Equal(t, template.CreatedAt, templates[0].CreatedAt)
+	})
+}
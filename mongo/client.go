@@ -11,11 +11,105 @@ import (
 // NewClient instantiates a new Client.
 func NewClient(uri string) *Client {
 	return &Client{
-		Now: time.Now,
-		URI: uri,
+		Now:                time.Now,
+		URI:                uri,
+		SessionTimeouts:    DefaultSessionTimeouts,
+		LoginLimits:        DefaultLoginLimits,
+		CredentialVerifier: new(PasswordVerifier),
+		InvitationLifetime: DefaultInvitationLifetime,
+		TombstoneLifetime:  DefaultTombstoneLifetime,
+		RevisionRetention:  DefaultRevisionRetention,
+		Migrations:         Migrations,
+		MigrationLockWait:  DefaultMigrationLockWait,
 	}
 }
 
+// DefaultInvitationLifetime is the default duration after which a board invitation expires.
+const DefaultInvitationLifetime = 7 * 24 * time.Hour
+
+// DefaultTombstoneLifetime is the default duration during which deletions are
+// reported to syncing clients.
+const DefaultTombstoneLifetime = 30 * 24 * time.Hour
+
+// DefaultRevisionRetention is the default retention policy of card revisions.
+var DefaultRevisionRetention = RevisionRetention{
+	MaxAge:   180 * 24 * time.Hour,
+	MaxCount: 100,
+}
+
+// RevisionRetention controls how long the revisions of cards are kept.
+type RevisionRetention struct {
+	// Duration after which a revision is removed. Zero keeps revisions forever.
+	MaxAge time.Duration
+
+	// Number of revisions kept per card, the oldest ones are removed first.
+	// Zero means no limit.
+	MaxCount int
+}
+
+// DefaultSessionTimeouts is the default configuration of user session lifetimes.
+var DefaultSessionTimeouts = SessionTimeouts{
+	Idle:     24 * time.Hour,
+	Remember: 30 * 24 * time.Hour,
+	Max:      90 * 24 * time.Hour,
+	Renew:    time.Minute,
+}
+
+// SessionTimeouts controls the lifetime of user sessions.
+type SessionTimeouts struct {
+	// Duration after which an unused session expires.
+	Idle time.Duration
+
+	// Duration after which an unused session created with the
+	// "remember me" option expires.
+	Remember time.Duration
+
+	// Duration after which a session expires, even if it is used.
+	// Zero means no limit.
+	Max time.Duration
+
+	// Minimum duration between two renewals of the same session.
+	Renew time.Duration
+}
+
+// DefaultLoginLimits is the default configuration of login throttling.
+var DefaultLoginLimits = LoginLimits{
+	FreeAttempts:    3,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	ClientLockout:   10,
+	IPLockout:       50,
+	LockoutDuration: 15 * time.Minute,
+	Window:          time.Hour,
+}
+
+// LoginLimits controls how failed login attempts are throttled.
+type LoginLimits struct {
+	// Number of failed attempts allowed before delaying the next ones.
+	FreeAttempts int
+
+	// Delay after the first throttled attempt. It doubles after each new failure.
+	BaseDelay time.Duration
+
+	// Maximum delay between two attempts.
+	MaxDelay time.Duration
+
+	// Number of failed attempts on an account from a client IP after which they are locked out.
+	// Failures on an account from every client only delay the next attempts.
+	// Zero disables client lockout.
+	ClientLockout int
+
+	// Number of failed attempts after which a client IP is locked.
+	// Zero disables IP lockout.
+	IPLockout int
+
+	// Duration of a lockout.
+	LockoutDuration time.Duration
+
+	// Failed attempts are forgotten after this duration without new failures.
+	Window time.Duration
+}
+
 // Client represents a client to the underlying MongoDB database.
 type Client struct {
 	// MongoDB database uri.
@@ -27,10 +121,44 @@ type Client struct {
 	// Authenticator
 	Authenticator pulpe.Authenticator
 
+	// Checks the credentials of users on login.
+	// Passwords stored in the database are used if nil.
+	CredentialVerifier pulpe.CredentialVerifier
+
+	// Lifetime of user sessions.
+	SessionTimeouts SessionTimeouts
+
+	// Throttling of failed login attempts.
+	LoginLimits LoginLimits
+
+	// Lifetime of board invitations created without an explicit expiry.
+	InvitationLifetime time.Duration
+
+	// Duration during which deletions are reported to syncing clients.
+	// Clients that didn't sync for longer must sync everything again.
+	// Zero keeps the deletions forever.
+	TombstoneLifetime time.Duration
+
+	// Retention of the revisions of the names and descriptions of cards.
+	RevisionRetention RevisionRetention
+
+	// Sends invitation emails. If nil, invitations can only be shared as links.
+	Mailer pulpe.Mailer
+
+	// Schema migrations applied by Open.
+	Migrations []Migration
+
+	// If true, Open doesn't apply pending migrations.
+	SkipMigrations bool
+
+	// Duration during which Migrate waits for another process migrating the database.
+	MigrationLockWait time.Duration
+
 	Session *mgo.Session
 }
 
-// Open opens and initializes the MongoDB database.
+// Open opens and initializes the MongoDB database,
+// applying pending migrations unless SkipMigrations is set.
 func (c *Client) Open() error {
 	var err error
 
@@ -39,6 +167,13 @@ func (c *Client) Open() error {
 		return err
 	}
 
+	if !c.SkipMigrations {
+		_, err = c.Migrate()
+		if err != nil {
+			return err
+		}
+	}
+
 	return c.EnsureIndexes()
 }
 
@@ -67,7 +202,37 @@ func (c *Client) EnsureIndexes() error {
 		return err
 	}
 
-	return session.UserSessionService().(*UserSessionService).ensureIndexes()
+	err = session.OrganizationService().(*OrganizationService).ensureIndexes()
+	if err != nil {
+		return err
+	}
+
+	err = session.InvitationService().(*InvitationService).ensureIndexes()
+	if err != nil {
+		return err
+	}
+
+	err = session.UserSessionService().(*UserSessionService).ensureIndexes()
+	if err != nil {
+		return err
+	}
+
+	err = session.LoginAttemptService().(*LoginAttemptService).ensureIndexes()
+	if err != nil {
+		return err
+	}
+
+	err = session.SyncService().(*SyncService).ensureIndexes()
+	if err != nil {
+		return err
+	}
+
+	err = session.HistoryService().(*HistoryService).ensureIndexes()
+	if err != nil {
+		return err
+	}
+
+	return session.RevisionService().(*RevisionService).ensureIndexes()
 }
 
 // Close closes then underlying MongoDB database.
@@ -82,5 +247,15 @@ func (c *Client) Connect() pulpe.Session {
 	s := newSession(c.Session.Copy())
 	s.now = c.Now().UTC()
 	s.authenticator = c.Authenticator
+	s.credentialVerifier = c.CredentialVerifier
+	if s.credentialVerifier == nil {
+		s.credentialVerifier = new(PasswordVerifier)
+	}
+	s.sessionTimeouts = c.SessionTimeouts
+	s.loginLimits = c.LoginLimits
+	s.invitationLifetime = c.InvitationLifetime
+	s.tombstoneLifetime = c.TombstoneLifetime
+	s.revisionRetention = c.RevisionRetention
+	s.mailer = c.Mailer
 	return s
 }
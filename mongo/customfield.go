@@ -0,0 +1,313 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/validation"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// customField is a typed value that can be set on the cards of a board.
+// Fields are stored in their board and the values in the cards, under fields.<id>.
+type customField struct {
+	ID      bson.ObjectId `bson:"id"`
+	Name    string        `bson:"name"`
+	Type    string        `bson:"type"`
+	Options []string      `bson:"options,omitempty"`
+}
+
+func (f *customField) toPulpeCustomField() *pulpe.CustomField {
+	return &pulpe.CustomField{
+		ID:        f.ID.Hex(),
+		CreatedAt: f.ID.Time().UTC(),
+		Name:      f.Name,
+		Type:      f.Type,
+		Options:   f.Options,
+	}
+}
+
+// customField returns a custom field of the board, or nil.
+func (b *board) customField(id string) *customField {
+	if !bson.IsObjectIdHex(id) {
+		return nil
+	}
+
+	for i := range b.CustomFields {
+		if b.CustomFields[i].ID == bson.ObjectIdHex(id) {
+			return &b.CustomFields[i]
+		}
+	}
+
+	return nil
+}
+
+// pulpeCustomFields returns the custom fields of the board, or nil if it has none.
+func (b *board) pulpeCustomFields() []*pulpe.CustomField {
+	if len(b.CustomFields) == 0 {
+		return nil
+	}
+
+	fs := make([]*pulpe.CustomField, len(b.CustomFields))
+	for i := range b.CustomFields {
+		fs[i] = b.CustomFields[i].toPulpeCustomField()
+	}
+
+	return fs
+}
+
+// customFieldValues validates and converts the custom field values of a card of the board.
+func (b *board) customFieldValues(values map[string]interface{}) (map[string]interface{}, error) {
+	return validation.CustomFieldValues(b.pulpeCustomFields(), values)
+}
+
+func validCustomFieldType(t string) bool {
+	switch t {
+	case pulpe.CustomFieldText, pulpe.CustomFieldNumber, pulpe.CustomFieldDate, pulpe.CustomFieldDropdown, pulpe.CustomFieldCheckbox:
+		return true
+	}
+
+	return false
+}
+
+// copyCustomFields returns a copy of the custom fields with new ids,
+// and the new id of each field, keyed by the old one.
+func copyCustomFields(fs []customField) ([]customField, map[string]string) {
+	var cp []customField
+	ids := make(map[string]string, len(fs))
+
+	for _, f := range fs {
+		id := bson.NewObjectId()
+		ids[f.ID.Hex()] = id.Hex()
+		f.ID = id
+		cp = append(cp, f)
+	}
+
+	return cp, ids
+}
+
+// remapFieldValues returns the custom field values keyed by the new ids of their fields.
+// Values of unknown fields are dropped.
+func remapFieldValues(values map[string]interface{}, ids map[string]string) map[string]interface{} {
+	var m map[string]interface{}
+
+	for id, v := range values {
+		newID, ok := ids[id]
+		if !ok {
+			continue
+		}
+
+		if m == nil {
+			m = make(map[string]interface{})
+		}
+		m[newID] = v
+	}
+
+	return m
+}
+
+// toFieldValues returns the card values to store, without the removed ones.
+func toFieldValues(values map[string]interface{}) map[string]interface{} {
+	var m map[string]interface{}
+
+	for id, v := range values {
+		if v == nil {
+			continue
+		}
+
+		if m == nil {
+			m = make(map[string]interface{})
+		}
+		m[id] = v
+	}
+
+	return m
+}
+
+// toPulpeFieldValues converts the custom field values of a card.
+func toPulpeFieldValues(values map[string]interface{}) map[string]interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+
+	m := make(map[string]interface{}, len(values))
+	for id, v := range values {
+		if t, ok := v.(time.Time); ok {
+			v = t.UTC()
+		}
+
+		m[id] = v
+	}
+
+	return m
+}
+
+// CreateCustomField creates a custom field on a board.
+func (s *BoardService) CreateCustomField(boardID string, fc *pulpe.CustomFieldCreation) (*pulpe.CustomField, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	if !validCustomFieldType(fc.Type) {
+		return nil, pulpe.ErrCustomFieldInvalidType
+	}
+
+	b, err := s.boardByID(user, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	f := customField{
+		ID:   bson.NewObjectId(),
+		Name: fc.Name,
+		Type: fc.Type,
+	}
+
+	if f.Type == pulpe.CustomFieldDropdown {
+		f.Options = fc.Options
+	}
+
+	err = s.session.db.C(boardCol).UpdateId(b.ID, bson.M{
+		"$push":        bson.M{"customFields": &f},
+		"$currentDate": bson.M{"updatedAt": true},
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		return nil, err
+	}
+
+	return f.toPulpeCustomField(), nil
+}
+
+// UpdateCustomField updates a custom field of a board.
+// Dropdown values that are no longer part of the options are removed from the cards.
+func (s *BoardService) UpdateCustomField(boardID, fieldID string, u *pulpe.CustomFieldUpdate) (*pulpe.CustomField, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.boardByID(user, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	f := b.customField(fieldID)
+	if f == nil {
+		return nil, pulpe.ErrCustomFieldNotFound
+	}
+
+	patch := make(bson.M)
+	if u.Name != nil {
+		patch["customFields.$.name"] = *u.Name
+	}
+
+	options := u.Options != nil && f.Type == pulpe.CustomFieldDropdown
+	if options {
+		patch["customFields.$.options"] = u.Options
+	}
+
+	if len(patch) > 0 {
+		err = s.session.db.C(boardCol).Update(
+			bson.M{
+				"_id":             b.ID,
+				"customFields.id": f.ID,
+			},
+			bson.M{
+				"$set":         patch,
+				"$currentDate": bson.M{"updatedAt": true},
+			})
+		if err != nil {
+			if err == mgo.ErrNotFound {
+				return nil, pulpe.ErrCustomFieldNotFound
+			}
+
+			return nil, err
+		}
+	}
+
+	if options {
+		key := "fields." + f.ID.Hex()
+		_, err = s.session.db.C(cardCol).UpdateAll(
+			bson.M{
+				"boardID": b.ID.Hex(),
+				key:       bson.M{"$exists": true, "$nin": u.Options},
+			},
+			bson.M{
+				"$unset":       bson.M{key: ""},
+				"$currentDate": bson.M{"updatedAt": true},
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b, err = s.store.boardByAccessAndID(ownedBy(b.OwnerID), boardID)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrBoardNotFound
+		}
+
+		return nil, err
+	}
+
+	f = b.customField(fieldID)
+	if f == nil {
+		return nil, pulpe.ErrCustomFieldNotFound
+	}
+
+	return f.toPulpeCustomField(), nil
+}
+
+// DeleteCustomField removes a custom field from a board and its values from the cards.
+func (s *BoardService) DeleteCustomField(boardID, fieldID string) error {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return err
+	}
+
+	b, err := s.boardByID(user, boardID)
+	if err != nil {
+		return err
+	}
+
+	if !bson.IsObjectIdHex(fieldID) {
+		return pulpe.ErrCustomFieldNotFound
+	}
+
+	id := bson.ObjectIdHex(fieldID)
+	err = s.session.db.C(boardCol).Update(
+		bson.M{
+			"_id":             b.ID,
+			"customFields.id": id,
+		},
+		bson.M{
+			"$pull":        bson.M{"customFields": bson.M{"id": id}},
+			"$currentDate": bson.M{"updatedAt": true},
+		})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return pulpe.ErrCustomFieldNotFound
+		}
+
+		return err
+	}
+
+	key := "fields." + fieldID
+	_, err = s.session.db.C(cardCol).UpdateAll(
+		bson.M{
+			"boardID": b.ID.Hex(),
+			key:       bson.M{"$exists": true},
+		},
+		bson.M{
+			"$unset":       bson.M{key: ""},
+			"$currentDate": bson.M{"updatedAt": true},
+		})
+
+	return err
+}
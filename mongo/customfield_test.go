@@ -0,0 +1,247 @@
+package mongo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/validation"
+	"github.com/stretchr/testify/require"
+)
+
+func newCustomField(t *testing.T, session *Session, boardID, typ string, options ...string) *pulpe.CustomField {
+	field, err := session.BoardService().CreateCustomField(boardID, &pulpe.CustomFieldCreation{
+		Name:    typ,
+		Type:    typ,
+		Options: options,
+	})
+	require.NoError(t, err)
+	return field
+}
+
+func TestBoardService_CustomFields(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := sessions.NoAuth.BoardService().CreateCustomField(newBoardID(), &pulpe.CustomFieldCreation{Name: "name", Type: pulpe.CustomFieldText})
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("Invalid type", func(t *testing.T) {
+		board := newBoard(t, sessions.Red)
+
+		_, err := sessions.Red.BoardService().CreateCustomField(board.ID, &pulpe.CustomFieldCreation{Name: "name", Type: "color"})
+		require.Equal(t, pulpe.ErrCustomFieldInvalidType, err)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		s := sessions.Red.BoardService()
+		board := newBoard(t, sessions.Red)
+
+		field := newCustomField(t, sessions.Red, board.ID, pulpe.CustomFieldDropdown, "low", "high")
+		require.NotEmpty(t, field.ID)
+		require.Equal(t, []string{"low", "high"}, field.Options)
+
+		b, err := s.Board(board.ID)
+		require.NoError(t, err)
+		require.Equal(t, []*pulpe.CustomField{field}, b.CustomFields)
+
+		name := "Severity"
+		updated, err := s.UpdateCustomField(board.ID, field.ID, &pulpe.CustomFieldUpdate{
+			Name:    &name,
+			Options: []string{"low", "medium", "high"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, name, updated.Name)
+		require.Equal(t, pulpe.CustomFieldDropdown, updated.Type)
+		require.Len(t, updated.Options, 3)
+
+		err = s.DeleteCustomField(board.ID, field.ID)
+		require.NoError(t, err)
+
+		err = s.DeleteCustomField(board.ID, field.ID)
+		require.Equal(t, pulpe.ErrCustomFieldNotFound, err)
+
+		_, err = s.UpdateCustomField(board.ID, field.ID, &pulpe.CustomFieldUpdate{Name: &name})
+		require.Equal(t, pulpe.ErrCustomFieldNotFound, err)
+	})
+
+	t.Run("Bad user", func(t *testing.T) {
+		board := newBoard(t, sessions.Red)
+		field := newCustomField(t, sessions.Red, board.ID, pulpe.CustomFieldText)
+
+		_, err := sessions.Blue.BoardService().CreateCustomField(board.ID, &pulpe.CustomFieldCreation{Name: "name", Type: pulpe.CustomFieldText})
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+		err = sessions.Blue.BoardService().DeleteCustomField(board.ID, field.ID)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+}
+
+func TestCardService_CustomFieldValues(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.CardService()
+	board := newBoard(t, sessions.Red)
+	list := newListWithBoardID(t, sessions.Red, board.ID)
+	points := newCustomField(t, sessions.Red, board.ID, pulpe.CustomFieldNumber)
+	due := newCustomField(t, sessions.Red, board.ID, pulpe.CustomFieldDate)
+	severity := newCustomField(t, sessions.Red, board.ID, pulpe.CustomFieldDropdown, "low", "high")
+
+	t.Run("Typed values", func(t *testing.T) {
+		card, err := s.CreateCard(list.ID, &pulpe.CardCreation{
+			Name: "name",
+			Fields: map[string]interface{}{
+				points.ID:   3.0,
+				due.ID:      "2017-05-01T10:00:00+02:00",
+				severity.ID: "high",
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3.0, card.Fields[points.ID])
+		require.Equal(t, time.Date(2017, 5, 1, 8, 0, 0, 0, time.UTC), card.Fields[due.ID])
+
+		card, err = s.Card(card.ID)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2017, 5, 1, 8, 0, 0, 0, time.UTC), card.Fields[due.ID])
+		require.Equal(t, "high", card.Fields[severity.ID])
+
+		card, err = s.UpdateCard(card.ID, &pulpe.CardUpdate{
+			Fields: map[string]interface{}{points.ID: 5.0, due.ID: nil},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{points.ID: 5.0, severity.ID: "high"}, card.Fields)
+
+		// removing an option removes the values
+		_, err = sessions.Red.BoardService().UpdateCustomField(board.ID, severity.ID, &pulpe.CustomFieldUpdate{Options: []string{"low"}})
+		require.NoError(t, err)
+
+		// deleting a field removes the values
+		err = sessions.Red.BoardService().DeleteCustomField(board.ID, points.ID)
+		require.NoError(t, err)
+
+		card, err = s.Card(card.ID)
+		require.NoError(t, err)
+		require.Empty(t, card.Fields)
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		other := newCustomField(t, sessions.Red, newBoard(t, sessions.Red).ID, pulpe.CustomFieldText)
+
+		for _, fields := range []map[string]interface{}{
+			{due.ID: "tomorrow"},
+			{severity.ID: "medium"},
+			{other.ID: "text"},
+		} {
+			_, err := s.CreateCard(list.ID, &pulpe.CardCreation{Name: "name", Fields: fields})
+			require.True(t, validation.IsError(err))
+		}
+	})
+
+	t.Run("Move to another board", func(t *testing.T) {
+		field := newCustomField(t, sessions.Red, board.ID, pulpe.CustomFieldCheckbox)
+		card, err := s.CreateCard(list.ID, &pulpe.CardCreation{
+			Name:   "name",
+			Fields: map[string]interface{}{field.ID: true},
+		})
+		require.NoError(t, err)
+
+		target := newList(t, sessions.Red)
+		card, err = s.UpdateCard(card.ID, &pulpe.CardUpdate{ListID: &target.ID})
+		require.NoError(t, err)
+		require.Empty(t, card.Fields)
+	})
+}
+
+func TestCardService_CardsByBoard_CustomFields(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.CardService()
+	board := newBoard(t, sessions.Red)
+	list := newListWithBoardID(t, sessions.Red, board.ID)
+	points := newCustomField(t, sessions.Red, board.ID, pulpe.CustomFieldNumber)
+	done := newCustomField(t, sessions.Red, board.ID, pulpe.CustomFieldCheckbox)
+
+	for i := 1; i <= 4; i++ {
+		_, err := s.CreateCard(list.ID, &pulpe.CardCreation{
+			Name: "name",
+			Fields: map[string]interface{}{
+				points.ID: float64(i),
+				done.ID:   i%2 == 0,
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("Filter", func(t *testing.T) {
+		cards, err := s.CardsByBoard(board.ID, pulpe.WithFieldValue(done.ID, "true"))
+		require.NoError(t, err)
+		require.Len(t, cards, 2)
+
+		cards, err = s.CardsByBoard(board.ID, pulpe.WithFieldValue(points.ID, "3"))
+		require.NoError(t, err)
+		require.Len(t, cards, 1)
+		require.Equal(t, 3.0, cards[0].Fields[points.ID])
+	})
+
+	t.Run("Sort", func(t *testing.T) {
+		cards, err := s.CardsByBoard(board.ID, pulpe.SortByField(points.ID, true))
+		require.NoError(t, err)
+		require.Len(t, cards, 4)
+		require.Equal(t, 4.0, cards[0].Fields[points.ID])
+		require.Equal(t, 1.0, cards[3].Fields[points.ID])
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := s.CardsByBoard(board.ID, pulpe.WithFieldValue(points.ID, "many"))
+		require.True(t, validation.IsError(err))
+
+		_, err = s.CardsByBoard(board.ID, pulpe.SortByField(newBoardID(), false))
+		require.True(t, validation.IsError(err))
+	})
+}
+
+func TestBoardService_CustomFields_Copy(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.BoardService()
+	board := newBoard(t, sessions.Red)
+	list := newListWithBoardID(t, sessions.Red, board.ID)
+	field := newCustomField(t, sessions.Red, board.ID, pulpe.CustomFieldText)
+	_, err := sessions.Red.CardService().CreateCard(list.ID, &pulpe.CardCreation{
+		Name:   "name",
+		Fields: map[string]interface{}{field.ID: "ACME"},
+	})
+	require.NoError(t, err)
+
+	t.Run("CopyBoard", func(t *testing.T) {
+		cp, err := s.CopyBoard(board.ID, &pulpe.BoardCopy{WithCards: true})
+		require.NoError(t, err)
+		require.Len(t, cp.CustomFields, 1)
+		require.NotEqual(t, field.ID, cp.CustomFields[0].ID)
+
+		cards, err := sessions.Red.CardService().CardsByBoard(cp.ID)
+		require.NoError(t, err)
+		require.Len(t, cards, 1)
+		require.Equal(t, map[string]interface{}{cp.CustomFields[0].ID: "ACME"}, cards[0].Fields)
+	})
+
+	t.Run("Export and import", func(t *testing.T) {
+		a, err := s.ExportBoard(board.ID)
+		require.NoError(t, err)
+		require.Len(t, a.CustomFields, 1)
+
+		imported, err := sessions.Blue.BoardService().ImportBoard(a, "")
+		require.NoError(t, err)
+		require.Len(t, imported.CustomFields, 1)
+
+		cards, err := sessions.Blue.CardService().CardsByBoard(imported.ID)
+		require.NoError(t, err)
+		require.Len(t, cards, 1)
+		require.Equal(t, map[string]interface{}{imported.CustomFields[0].ID: "ACME"}, cards[0].Fields)
+	})
+}
@@ -0,0 +1,221 @@
+package mongo
+
+import (
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const deletionCol = "deletions"
+
+// Deletion kinds
+const (
+	deletionBoard = "board"
+	deletionList  = "list"
+)
+
+// deletion is the intent to delete a board or a list with its content.
+// It is recorded before anything is removed and cleared once everything is,
+// so that CollectGarbage can finish deletions interrupted by a crash.
+type deletion struct {
+	ID        bson.ObjectId `bson:"_id"`
+	Kind      string        `bson:"kind"`
+	TargetID  bson.ObjectId `bson:"targetID"`
+	CreatedAt time.Time     `bson:"createdAt"`
+}
+
+// cascadeDelete removes a board or a list with its content.
+// It returns mgo.ErrNotFound if the target was already removed.
+func (s *Session) cascadeDelete(kind string, id bson.ObjectId) error {
+	d := deletion{
+		ID:        bson.NewObjectId(),
+		Kind:      kind,
+		TargetID:  id,
+		CreatedAt: s.now,
+	}
+
+	err := s.db.C(deletionCol).Insert(&d)
+	if err != nil {
+		return err
+	}
+
+	err = applyDeletion(s.db, &d)
+	if err != nil && err != mgo.ErrNotFound {
+		// the deletion stays journaled and will be finished by CollectGarbage
+		return err
+	}
+
+	rerr := s.db.C(deletionCol).RemoveId(d.ID)
+	if rerr != nil && rerr != mgo.ErrNotFound {
+		return rerr
+	}
+
+	return err
+}
+
+// applyDeletion removes the target of a deletion, then its content.
+// It can be applied several times and returns mgo.ErrNotFound
+// if the target was already removed.
+// Lists keep their id when restored by an undo, so a list and the cards
+// restored after the deletion was journaled are left untouched.
+func applyDeletion(db *mgo.Database, d *deletion) error {
+	var col, field string
+	switch d.Kind {
+	case deletionBoard:
+		col, field = boardCol, "boardID"
+	case deletionList:
+		col, field = listCol, "listID"
+	}
+
+	notRestored := bson.M{"$not": bson.M{"$gt": d.CreatedAt}}
+
+	// the target is removed first so that nothing can be added to it
+	notFound := db.C(col).Remove(bson.M{"_id": d.TargetID, "restoredAt": notRestored})
+	if notFound != nil && notFound != mgo.ErrNotFound {
+		return notFound
+	}
+
+	if notFound == mgo.ErrNotFound {
+		n, err := db.C(col).FindId(d.TargetID).Count()
+		if err != nil {
+			return err
+		}
+
+		if n > 0 {
+			// the target was restored, the deletion is obsolete
+			return nil
+		}
+	}
+
+	_, err := db.C(cardCol).RemoveAll(bson.M{field: d.TargetID.Hex(), "restoredAt": notRestored})
+	if err != nil {
+		return err
+	}
+
+	if d.Kind == deletionBoard {
+		_, err = db.C(listCol).RemoveAll(bson.M{"boardID": d.TargetID.Hex(), "restoredAt": notRestored})
+		if err != nil {
+			return err
+		}
+	}
+
+	return notFound
+}
+
+// GarbageReport describes what was removed by CollectGarbage.
+type GarbageReport struct {
+	// Interrupted deletions that were finished.
+	Deletions int
+
+	// Lists whose board doesn't exist.
+	Lists int
+
+	// Cards whose list or board doesn't exist.
+	Cards int
+}
+
+// CollectGarbage finishes the deletions interrupted by a crash,
+// then removes the lists and cards whose board or list doesn't exist anymore.
+func (c *Client) CollectGarbage() (*GarbageReport, error) {
+	var r GarbageReport
+	db := c.Session.DB("")
+	start := c.Now().UTC()
+
+	var ds []deletion
+	err := db.C(deletionCol).Find(nil).Sort("_id").All(&ds)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ds {
+		err = applyDeletion(db, &ds[i])
+		if err != nil && err != mgo.ErrNotFound {
+			return nil, err
+		}
+
+		err = db.C(deletionCol).RemoveId(ds[i].ID)
+		if err != nil && err != mgo.ErrNotFound {
+			return nil, err
+		}
+
+		r.Deletions++
+	}
+
+	r.Lists, err = removeOrphans(db, listCol, "boardID", boardCol, start)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := removeOrphans(db, cardCol, "boardID", boardCol, start)
+	if err != nil {
+		return nil, err
+	}
+	r.Cards += n
+
+	n, err = removeOrphans(db, cardCol, "listID", listCol, start)
+	if err != nil {
+		return nil, err
+	}
+	r.Cards += n
+
+	return &r, nil
+}
+
+// removeOrphans removes the documents of col whose field references a missing
+// document of parentCol, and returns how many were removed.
+// Parents are always created before their children, so a missing parent means
+// it was deleted. Lists restored by an undo keep their id: the documents restored
+// after since are left untouched, their parent may have been restored meanwhile.
+func removeOrphans(db *mgo.Database, col, field, parentCol string, since time.Time) (int, error) {
+	var refs []string
+	err := db.C(col).Find(nil).Distinct(field, &refs)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(refs) == 0 {
+		return 0, nil
+	}
+
+	ids := []bson.ObjectId{}
+	for _, ref := range refs {
+		if bson.IsObjectIdHex(ref) {
+			ids = append(ids, bson.ObjectIdHex(ref))
+		}
+	}
+
+	var parents []struct {
+		ID bson.ObjectId `bson:"_id"`
+	}
+	err = db.C(parentCol).Find(bson.M{"_id": bson.M{"$in": ids}}).Select(bson.M{"_id": 1}).All(&parents)
+	if err != nil {
+		return 0, err
+	}
+
+	exists := make(map[string]bool, len(parents))
+	for _, p := range parents {
+		exists[p.ID.Hex()] = true
+	}
+
+	var missing []string
+	for _, ref := range refs {
+		if !exists[ref] {
+			missing = append(missing, ref)
+		}
+	}
+
+	if len(missing) == 0 {
+		return 0, nil
+	}
+
+	info, err := db.C(col).RemoveAll(bson.M{
+		field:        bson.M{"$in": missing},
+		"restoredAt": bson.M{"$not": bson.M{"$gt": since}},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Removed, nil
+}
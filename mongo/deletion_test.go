@@ -0,0 +1,153 @@
+package mongo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mongo"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// newCardWithListID creates a card in a list.
+func newCardWithListID(t *testing.T, session *Session, listID string) *pulpe.Card {
+	card, err := session.CardService().CreateCard(listID, &pulpe.CardCreation{Name: "Card"})
+	require.NoError(t, err)
+	return card
+}
+
+func TestBoardService_DeleteBoard_Cascade(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	board := newBoard(t, sessions.Red)
+	list := newListWithBoardID(t, sessions.Red, board.ID)
+	card := newCardWithListID(t, sessions.Red, list.ID)
+
+	err := sessions.Red.BoardService().DeleteBoard(board.ID)
+	require.NoError(t, err)
+
+	_, err = sessions.Red.ListService().List(list.ID)
+	require.Equal(t, pulpe.ErrListNotFound, err)
+	_, err = sessions.Red.CardService().Card(card.ID)
+	require.Equal(t, pulpe.ErrCardNotFound, err)
+
+	// the journal is cleared
+	n, err := client.Session.DB("").C("deletions").Find(bson.M{"targetID": bson.ObjectIdHex(board.ID)}).Count()
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestListService_DeleteList_Cascade(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	list := newList(t, sessions.Red)
+	card := newCardWithListID(t, sessions.Red, list.ID)
+	other := newCardWithListID(t, sessions.Red, newListWithBoardID(t, sessions.Red, list.BoardID).ID)
+
+	err := sessions.Red.ListService().DeleteList(list.ID)
+	require.NoError(t, err)
+
+	_, err = sessions.Red.CardService().Card(card.ID)
+	require.Equal(t, pulpe.ErrCardNotFound, err)
+	_, err = sessions.Red.CardService().Card(other.ID)
+	require.NoError(t, err)
+}
+
+func TestClient_CollectGarbage(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	db := client.Session.DB("")
+
+	t.Run("InterruptedDeletion", func(t *testing.T) {
+		board := newBoard(t, sessions.Red)
+		list := newListWithBoardID(t, sessions.Red, board.ID)
+		card := newCardWithListID(t, sessions.Red, list.ID)
+
+		// a crash happened right after journaling the deletion
+		err := db.C("deletions").Insert(bson.M{
+			"_id":      bson.NewObjectId(),
+			"kind":     "board",
+			"targetID": bson.ObjectIdHex(board.ID),
+		})
+		require.NoError(t, err)
+
+		r, err := client.CollectGarbage()
+		require.NoError(t, err)
+		require.Equal(t, 1, r.Deletions)
+
+		_, err = sessions.Red.BoardService().Board(board.ID)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+		_, err = sessions.Red.CardService().Card(card.ID)
+		require.Equal(t, pulpe.ErrCardNotFound, err)
+
+		n, err := db.C("deletions").Count()
+		require.NoError(t, err)
+		require.Zero(t, n)
+	})
+
+	t.Run("RestoredList", func(t *testing.T) {
+		list := newList(t, sessions.Red)
+		card := newCardWithListID(t, sessions.Red, list.ID)
+
+		err := sessions.Red.ListService().DeleteList(list.ID)
+		require.NoError(t, err)
+
+		// the journal entry of the deletion was left by a crash
+		err = db.C("deletions").Insert(bson.M{
+			"_id":       bson.NewObjectId(),
+			"kind":      "list",
+			"targetID":  bson.ObjectIdHex(list.ID),
+			"createdAt": time.Now().Add(-time.Minute).UTC(),
+		})
+		require.NoError(t, err)
+
+		_, err = sessions.Red.HistoryService().Undo()
+		require.NoError(t, err)
+
+		r, err := client.CollectGarbage()
+		require.NoError(t, err)
+		require.Equal(t, 1, r.Deletions)
+		require.Zero(t, r.Cards)
+
+		_, err = sessions.Red.ListService().List(list.ID)
+		require.NoError(t, err)
+		_, err = sessions.Red.CardService().Card(card.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Orphans", func(t *testing.T) {
+		board := newBoard(t, sessions.Red)
+		list := newListWithBoardID(t, sessions.Red, board.ID)
+		newCardWithListID(t, sessions.Red, list.ID)
+
+		other := newList(t, sessions.Red)
+		card := newCardWithListID(t, sessions.Red, other.ID)
+		kept := newCardWithListID(t, sessions.Red, newList(t, sessions.Red).ID)
+
+		// the board and a list were removed without their content
+		require.NoError(t, db.C("boards").RemoveId(bson.ObjectIdHex(board.ID)))
+		require.NoError(t, db.C("lists").RemoveId(bson.ObjectIdHex(other.ID)))
+
+		r, err := client.CollectGarbage()
+		require.NoError(t, err)
+		require.Equal(t, 1, r.Lists)
+		require.Equal(t, 2, r.Cards)
+
+		n, err := db.C("lists").FindId(bson.ObjectIdHex(list.ID)).Count()
+		require.NoError(t, err)
+		require.Zero(t, n)
+		_, err = sessions.Red.CardService().Card(card.ID)
+		require.Equal(t, pulpe.ErrCardNotFound, err)
+		_, err = sessions.Red.CardService().Card(kept.ID)
+		require.NoError(t, err)
+
+		// nothing left to collect
+		r, err = client.CollectGarbage()
+		require.NoError(t, err)
+		require.Equal(t, &mongo.GarbageReport{}, r)
+	})
+}
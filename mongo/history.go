@@ -0,0 +1,642 @@
+package mongo
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/Machiel/slugify"
+	"github.com/blankrobot/pulpe"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const historyCol = "history"
+
+// maxHistory is the number of changes kept in the history of a user.
+const maxHistory = 50
+
+// historyClaimTimeout is the time after which a change that is still being undone or redone
+// is considered abandoned by the request that claimed it.
+const historyClaimTimeout = time.Minute
+
+// Ensure HistoryService implements pulpe.HistoryService.
+var _ pulpe.HistoryService = new(HistoryService)
+
+// historyEntry is a change of a list or a card made by a user.
+// Updates keep the list or the card before and after the change,
+// deletions keep what was removed.
+type historyEntry struct {
+	ID       bson.ObjectId `bson:"_id"`
+	UserID   string        `bson:"userID"`
+	Entity   string        `bson:"entity"`
+	Action   string        `bson:"action"`
+	EntityID string        `bson:"entityID"`
+	BoardID  string        `bson:"boardID"`
+	// Fields changed by an update.
+	Fields      []string `bson:"fields,omitempty"`
+	List        *list    `bson:"list,omitempty"`
+	Card        *card    `bson:"card,omitempty"`
+	UpdatedList *list    `bson:"updatedList,omitempty"`
+	UpdatedCard *card    `bson:"updatedCard,omitempty"`
+	// Cards of a deleted list, or of a list moved to another board.
+	Cards     []card    `bson:"cards,omitempty"`
+	Undone    bool      `bson:"undone"`
+	CreatedAt time.Time `bson:"createdAt"`
+	// PendingAt is set while the change is being undone or redone.
+	PendingAt *time.Time `bson:"pendingAt,omitempty"`
+}
+
+func (e *historyEntry) toPulpeHistoryEntry() *pulpe.HistoryEntry {
+	return &pulpe.HistoryEntry{
+		ID:        e.ID.Hex(),
+		CreatedAt: e.CreatedAt.UTC(),
+		Entity:    e.Entity,
+		Action:    e.Action,
+		EntityID:  e.EntityID,
+		BoardID:   e.BoardID,
+	}
+}
+
+// Fields restored by undo and redo.
+var (
+	listHistoryFields = []string{"name", "position", "boardID"}
+	cardHistoryFields = []string{"name", "description", "position", "labels", "checklist", "due", "listID", "assignees", "fields"}
+)
+
+// historyItem is a list or a card.
+type historyItem interface {
+	// field returns the value of a field, nil if it's empty.
+	field(name string) interface{}
+}
+
+func (l *list) field(name string) interface{} {
+	switch name {
+	case "name":
+		return l.Name
+	case "position":
+		return l.Position
+	case "boardID":
+		return l.BoardID
+	}
+
+	return nil
+}
+
+func (c *card) field(name string) interface{} {
+	switch name {
+	case "name":
+		return c.Name
+	case "description":
+		return c.Description
+	case "position":
+		return c.Position
+	case "listID":
+		return c.ListID
+	case "labels":
+		if len(c.Labels) > 0 {
+			return c.Labels
+		}
+	case "checklist":
+		if len(c.Checklist) > 0 {
+			return c.Checklist
+		}
+	case "due":
+		if c.Due != nil {
+			return c.Due.UTC()
+		}
+	case "assignees":
+		if len(c.Assignees) > 0 {
+			return c.Assignees
+		}
+	case "fields":
+		if len(c.Fields) > 0 {
+			return c.Fields
+		}
+	}
+
+	return nil
+}
+
+// diffFields returns the fields whose values differ.
+func diffFields(a, b historyItem, fields []string) []string {
+	var diff []string
+	for _, f := range fields {
+		if !reflect.DeepEqual(a.field(f), b.field(f)) {
+			diff = append(diff, f)
+		}
+	}
+
+	return diff
+}
+
+// HistoryService represents a service for undoing and redoing changes.
+type HistoryService struct {
+	session *Session
+}
+
+func (s *HistoryService) ensureIndexes() error {
+	col := s.session.db.C(historyCol)
+
+	// History of a user
+	return col.EnsureIndexKey("userID", "undone", "_id")
+}
+
+// record pushes a change on the history of a user and forgets the changes that were undone.
+func (s *HistoryService) record(user *pulpe.User, e *historyEntry) error {
+	col := s.session.db.C(historyCol)
+
+	_, err := col.RemoveAll(bson.M{"userID": user.ID, "undone": true})
+	if err != nil {
+		return err
+	}
+
+	e.ID = bson.NewObjectId()
+	e.UserID = user.ID
+	e.CreatedAt = s.session.now
+
+	err = col.Insert(e)
+	if err != nil {
+		return err
+	}
+
+	// only the last changes are kept
+	var last historyEntry
+	err = col.Find(bson.M{"userID": user.ID}).Sort("-_id").Skip(maxHistory).Select(bson.M{"_id": 1}).One(&last)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = col.RemoveAll(bson.M{"userID": user.ID, "_id": bson.M{"$lte": last.ID}})
+	return err
+}
+
+// recordListUpdate records the update of a list, if anything changed.
+// cards are the cards of the list before it was moved to another board.
+func (s *HistoryService) recordListUpdate(user *pulpe.User, before, after *list, cards []card) error {
+	fields := diffFields(before, after, listHistoryFields)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	action := pulpe.HistoryMove
+	if before.Name != after.Name {
+		action = pulpe.HistoryUpdate
+	}
+
+	e := historyEntry{
+		Entity:      pulpe.HistoryList,
+		Action:      action,
+		EntityID:    after.ID.Hex(),
+		BoardID:     after.BoardID,
+		Fields:      fields,
+		List:        before,
+		UpdatedList: after,
+	}
+
+	if before.BoardID != after.BoardID {
+		e.Cards = cards
+	}
+
+	return s.record(user, &e)
+}
+
+// recordCardUpdate records the update of a card, if anything changed.
+func (s *HistoryService) recordCardUpdate(user *pulpe.User, before, after *card) error {
+	fields := diffFields(before, after, cardHistoryFields)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	action := pulpe.HistoryMove
+	for _, f := range fields {
+		switch f {
+		case "position", "listID":
+		case "assignees", "fields":
+			// they are removed when a card is moved to another board
+			if before.BoardID == after.BoardID {
+				action = pulpe.HistoryUpdate
+			}
+		default:
+			action = pulpe.HistoryUpdate
+		}
+	}
+
+	return s.record(user, &historyEntry{
+		Entity:      pulpe.HistoryCard,
+		Action:      action,
+		EntityID:    after.ID.Hex(),
+		BoardID:     after.BoardID,
+		Fields:      fields,
+		Card:        before,
+		UpdatedCard: after,
+	})
+}
+
+// Undo reverts the last change of the authenticated user.
+func (s *HistoryService) Undo() (*pulpe.HistoryEntry, error) {
+	return s.pop(false)
+}
+
+// Redo applies again the last change undone by the authenticated user.
+func (s *HistoryService) Redo() (*pulpe.HistoryEntry, error) {
+	return s.pop(true)
+}
+
+// pop undoes the last change of the history, or redoes the last undone one.
+// Changes that can't be reverted anymore are removed from the history.
+func (s *HistoryService) pop(redo bool) (*pulpe.HistoryEntry, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	// the undone changes are always the last ones of the history
+	sort, notFound := "-_id", pulpe.ErrNothingToUndo
+	if redo {
+		sort, notFound = "_id", pulpe.ErrNothingToRedo
+	}
+
+	col := s.session.db.C(historyCol)
+
+	var e historyEntry
+	err = col.Find(bson.M{"userID": user.ID, "undone": redo}).Sort(sort).One(&e)
+	if err == mgo.ErrNotFound {
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the entry is claimed so that concurrent undos and redos don't revert it twice
+	now := s.session.now
+	_, err = col.Find(bson.M{
+		"_id":    e.ID,
+		"undone": redo,
+		"$or": []bson.M{
+			{"pendingAt": bson.M{"$exists": false}},
+			{"pendingAt": bson.M{"$lte": now.Add(-historyClaimTimeout)}},
+		},
+	}).Apply(mgo.Change{Update: bson.M{"$set": bson.M{"pendingAt": now}}}, nil)
+	if err == mgo.ErrNotFound {
+		return nil, pulpe.ErrHistoryBusy
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.revert(user, &e, redo)
+	switch err {
+	case nil:
+	case pulpe.ErrHistoryConflict, pulpe.ErrBoardNotFound, pulpe.ErrListNotFound, pulpe.ErrCardNotFound:
+		err = col.RemoveId(e.ID)
+		if err != nil && err != mgo.ErrNotFound {
+			return nil, err
+		}
+
+		return nil, pulpe.ErrHistoryConflict
+	default:
+		if uerr := col.UpdateId(e.ID, bson.M{"$unset": bson.M{"pendingAt": ""}}); uerr != nil && uerr != mgo.ErrNotFound {
+			return nil, uerr
+		}
+
+		return nil, err
+	}
+
+	patch := bson.M{"undone": !redo}
+	if e.Action == pulpe.HistoryDelete && e.Entity == pulpe.HistoryList {
+		// the cards of a list can change between an undo and a redo
+		patch["cards"] = e.Cards
+	}
+
+	// the entry may have been dropped by a new change meanwhile
+	err = col.UpdateId(e.ID, bson.M{"$set": patch, "$unset": bson.M{"pendingAt": ""}})
+	if err != nil && err != mgo.ErrNotFound {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// revert undoes a change, or redoes it.
+func (s *HistoryService) revert(user *pulpe.User, e *historyEntry, redo bool) (*pulpe.HistoryEntry, error) {
+	p := e.toPulpeHistoryEntry()
+
+	switch e.Entity {
+	case pulpe.HistoryList:
+		if e.Action == pulpe.HistoryDelete {
+			if redo {
+				return p, s.deleteList(user, e)
+			}
+
+			l, cards, err := s.restoreList(user, e)
+			if err != nil {
+				return nil, err
+			}
+
+			p.List = l.toPulpeList()
+			p.Cards = make([]*pulpe.Card, len(cards))
+			for i := range cards {
+				p.Cards[i] = cards[i].toPulpeCard()
+			}
+
+			return p, nil
+		}
+
+		from, to := e.UpdatedList, e.List
+		if redo {
+			from, to = e.List, e.UpdatedList
+		}
+
+		l, err := s.updateList(user, e, from, to, !redo)
+		if err != nil {
+			return nil, err
+		}
+
+		p.List = l.toPulpeList()
+	case pulpe.HistoryCard:
+		if e.Action == pulpe.HistoryDelete {
+			if redo {
+				return p, s.deleteCard(user, e)
+			}
+
+			c, err := s.restoreCard(user, e)
+			if err != nil {
+				return nil, err
+			}
+
+			p.Card = c.toPulpeCard()
+			return p, nil
+		}
+
+		from, to := e.UpdatedCard, e.Card
+		if redo {
+			from, to = e.Card, e.UpdatedCard
+		}
+
+		c, err := s.updateCard(user, e, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		p.Card = c.toPulpeCard()
+	}
+
+	return p, nil
+}
+
+// updateList sets the fields of a list back from their values in from to the ones in to.
+// If restoreCards is true, the assignees and the custom field values of the cards
+// removed by a move to another board are restored.
+func (s *HistoryService) updateList(user *pulpe.User, e *historyEntry, from, to *list, restoreCards bool) (*list, error) {
+	l, err := s.session.listService.listByID(user, e.EntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(diffFields(l, from, e.Fields)) > 0 {
+		return nil, pulpe.ErrHistoryConflict
+	}
+
+	var u pulpe.ListUpdate
+	for _, f := range e.Fields {
+		switch f {
+		case "name":
+			u.Name = &to.Name
+		case "position":
+			u.Position = &to.Position
+		case "boardID":
+			u.BoardID = &to.BoardID
+		}
+	}
+
+	l, err = s.session.listService.updateList(user, e.EntityID, &u, s.session.now)
+	if err != nil {
+		return nil, err
+	}
+
+	if !restoreCards || u.BoardID == nil {
+		return l, nil
+	}
+
+	for i := range e.Cards {
+		c := &e.Cards[i]
+		if len(c.Assignees) == 0 && len(c.Fields) == 0 {
+			continue
+		}
+
+		patch := bson.M{
+			"assignees": c.field("assignees"),
+			"fields":    c.field("fields"),
+		}
+
+		// cards deleted or moved away since are left alone
+		_, err = s.session.cardService.store.updateCardByID(c.ID, l.OwnerID, "", patch)
+		if err != nil && err != mgo.ErrNotFound {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// updateCard sets the fields of a card back from their values in from to the ones in to.
+func (s *HistoryService) updateCard(user *pulpe.User, e *historyEntry, from, to *card) (*card, error) {
+	c, err := s.session.cardService.cardByID(user, e.EntityID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(diffFields(c, from, e.Fields)) > 0 {
+		return nil, pulpe.ErrHistoryConflict
+	}
+
+	var u pulpe.CardUpdate
+	// assignees and custom field values are restored as they were,
+	// even if the members or the fields of the board changed since
+	patch := make(bson.M)
+	for _, f := range e.Fields {
+		switch f {
+		case "name":
+			u.Name = &to.Name
+		case "description":
+			u.Description = &to.Description
+		case "position":
+			u.Position = &to.Position
+		case "labels":
+			u.Labels = to.Labels
+			if u.Labels == nil {
+				u.Labels = []string{}
+			}
+		case "checklist":
+			u.Checklist = toPulpeChecklist(to.Checklist)
+			if u.Checklist == nil {
+				u.Checklist = []*pulpe.ChecklistItem{}
+			}
+		case "due":
+			u.Due = new(time.Time)
+			if to.Due != nil {
+				u.Due = to.Due
+			}
+		case "listID":
+			u.ListID = &to.ListID
+		case "assignees", "fields":
+			patch[f] = to.field(f)
+		}
+	}
+
+	c, err = s.session.cardService.updateCard(user, e.EntityID, &u, s.session.now)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(patch) == 0 {
+		return c, nil
+	}
+
+	_, err = s.session.cardService.store.updateCardByID(c.ID, c.OwnerID, "", patch)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrCardNotFound
+		}
+
+		return nil, err
+	}
+
+	return s.session.cardService.cardByID(user, e.EntityID)
+}
+
+// restoreList recreates a deleted list with its cards, on its board.
+func (s *HistoryService) restoreList(user *pulpe.User, e *historyEntry) (*list, []card, error) {
+	b, err := s.session.boardService.boardByID(user, e.List.BoardID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := s.session.now
+	l := *e.List
+	l.OwnerID = b.OwnerID
+	l.Slug = slugify.Slugify(l.Name)
+	// restored items are sent to the clients with the next changes
+	l.UpdatedAt = &now
+	// the list keeps its id, deletions journaled before now must not remove it again
+	l.RestoredAt = &now
+
+	err = s.session.listService.store.createList(&l)
+	if err != nil {
+		if mgo.IsDup(err) {
+			return nil, nil, pulpe.ErrHistoryConflict
+		}
+
+		return nil, nil, err
+	}
+
+	cards := make([]card, len(e.Cards))
+	for i := range e.Cards {
+		c := e.Cards[i]
+		c.OwnerID = b.OwnerID
+		c.BoardID = l.BoardID
+		c.Slug = slugify.Slugify(c.Name)
+		c.UpdatedAt = &now
+		c.RestoredAt = &now
+
+		err = s.session.cardService.store.createCard(&c)
+		if err != nil {
+			// the list is only restored with all its cards
+			if cerr := s.removeRestoredList(&l); cerr != nil {
+				return nil, nil, cerr
+			}
+
+			if mgo.IsDup(err) {
+				return nil, nil, pulpe.ErrHistoryConflict
+			}
+
+			return nil, nil, err
+		}
+
+		cards[i] = c
+	}
+
+	return &l, cards, nil
+}
+
+// removeRestoredList removes a list being restored and the cards restored with it.
+func (s *HistoryService) removeRestoredList(l *list) error {
+	err := s.session.cardService.store.deleteCardsByListID(ownedBy(l.OwnerID), l.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	err = s.session.db.C(listCol).RemoveId(l.ID)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+
+	return err
+}
+
+// deleteList deletes again a list restored by an undo.
+// The cards it contains are kept to be restored by the next undo.
+func (s *HistoryService) deleteList(user *pulpe.User, e *historyEntry) error {
+	l, err := s.session.listService.listByID(user, e.EntityID)
+	if err != nil {
+		return err
+	}
+
+	if len(diffFields(l, e.List, listHistoryFields)) > 0 {
+		return pulpe.ErrHistoryConflict
+	}
+
+	e.Cards, err = s.session.cardService.store.cardsByListID(l.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	return s.session.listService.deleteList(l)
+}
+
+// restoreCard recreates a deleted card, in its list.
+func (s *HistoryService) restoreCard(user *pulpe.User, e *historyEntry) (*card, error) {
+	l, err := s.session.listService.listByID(user, e.Card.ListID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the list was moved to another board since
+	if l.BoardID != e.Card.BoardID {
+		return nil, pulpe.ErrHistoryConflict
+	}
+
+	now := s.session.now
+	c := *e.Card
+	c.OwnerID = l.OwnerID
+	c.Slug = slugify.Slugify(c.Name)
+	c.UpdatedAt = &now
+
+	err = s.session.cardService.store.createCard(&c)
+	if err != nil {
+		if mgo.IsDup(err) {
+			return nil, pulpe.ErrHistoryConflict
+		}
+
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// deleteCard deletes again a card restored by an undo.
+func (s *HistoryService) deleteCard(user *pulpe.User, e *historyEntry) error {
+	c, err := s.session.cardService.cardByID(user, e.EntityID)
+	if err != nil {
+		return err
+	}
+
+	if len(diffFields(c, e.Card, cardHistoryFields)) > 0 {
+		return pulpe.ErrHistoryConflict
+	}
+
+	return s.session.cardService.deleteCard(c)
+}
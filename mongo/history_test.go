@@ -0,0 +1,178 @@
+package mongo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestHistoryService(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.HistoryService()
+	cards := sessions.Red.CardService()
+	board := newBoard(t, sessions.Red)
+	list := newListWithBoardID(t, sessions.Red, board.ID)
+
+	t.Run("Empty", func(t *testing.T) {
+		_, err := s.Undo()
+		require.Equal(t, pulpe.ErrNothingToUndo, err)
+
+		_, err = s.Redo()
+		require.Equal(t, pulpe.ErrNothingToRedo, err)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		card := newCardWithListID(t, sessions.Red, list.ID)
+
+		name := "new name"
+		_, err := cards.UpdateCard(card.ID, &pulpe.CardUpdate{Name: &name, Labels: []string{"bug"}})
+		require.NoError(t, err)
+
+		entry, err := s.Undo()
+		require.NoError(t, err)
+		require.Equal(t, pulpe.HistoryCard, entry.Entity)
+		require.Equal(t, pulpe.HistoryUpdate, entry.Action)
+		require.Equal(t, card.ID, entry.EntityID)
+		require.Equal(t, card.Name, entry.Card.Name)
+		require.Empty(t, entry.Card.Labels)
+
+		entry, err = s.Redo()
+		require.NoError(t, err)
+		require.Equal(t, name, entry.Card.Name)
+		require.Equal(t, []string{"bug"}, entry.Card.Labels)
+
+		_, err = s.Redo()
+		require.Equal(t, pulpe.ErrNothingToRedo, err)
+	})
+
+	t.Run("Move to another board", func(t *testing.T) {
+		field := newCustomField(t, sessions.Red, board.ID, pulpe.CustomFieldText)
+		card, err := cards.CreateCard(list.ID, &pulpe.CardCreation{
+			Name:   "name",
+			Fields: map[string]interface{}{field.ID: "ACME"},
+		})
+		require.NoError(t, err)
+
+		target := newList(t, sessions.Red)
+		_, err = cards.UpdateCard(card.ID, &pulpe.CardUpdate{ListID: &target.ID})
+		require.NoError(t, err)
+
+		entry, err := s.Undo()
+		require.NoError(t, err)
+		require.Equal(t, pulpe.HistoryMove, entry.Action)
+		require.Equal(t, list.ID, entry.Card.ListID)
+		require.Equal(t, board.ID, entry.Card.BoardID)
+		require.Equal(t, map[string]interface{}{field.ID: "ACME"}, entry.Card.Fields)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		card := newCardWithListID(t, sessions.Red, list.ID)
+		other := newListWithBoardID(t, sessions.Red, board.ID)
+		inner := newCardWithListID(t, sessions.Red, other.ID)
+
+		err := cards.DeleteCard(card.ID)
+		require.NoError(t, err)
+
+		err = sessions.Red.ListService().DeleteList(other.ID)
+		require.NoError(t, err)
+
+		entry, err := s.Undo()
+		require.NoError(t, err)
+		require.Equal(t, pulpe.HistoryList, entry.Entity)
+		require.Equal(t, pulpe.HistoryDelete, entry.Action)
+		require.Equal(t, other.ID, entry.List.ID)
+		require.Len(t, entry.Cards, 1)
+		require.Equal(t, inner.ID, entry.Cards[0].ID)
+
+		_, err = cards.Card(inner.ID)
+		require.NoError(t, err)
+
+		entry, err = s.Undo()
+		require.NoError(t, err)
+		require.Equal(t, card.ID, entry.Card.ID)
+
+		_, err = cards.Card(card.ID)
+		require.NoError(t, err)
+
+		entry, err = s.Redo()
+		require.NoError(t, err)
+		require.Nil(t, entry.Card)
+
+		_, err = cards.Card(card.ID)
+		require.Equal(t, pulpe.ErrCardNotFound, err)
+	})
+
+	t.Run("New change forgets undone ones", func(t *testing.T) {
+		card := newCardWithListID(t, sessions.Red, list.ID)
+
+		name := "first"
+		_, err := cards.UpdateCard(card.ID, &pulpe.CardUpdate{Name: &name})
+		require.NoError(t, err)
+
+		_, err = s.Undo()
+		require.NoError(t, err)
+
+		name = "second"
+		_, err = cards.UpdateCard(card.ID, &pulpe.CardUpdate{Name: &name})
+		require.NoError(t, err)
+
+		_, err = s.Redo()
+		require.Equal(t, pulpe.ErrNothingToRedo, err)
+	})
+
+	t.Run("Changed by someone else", func(t *testing.T) {
+		inv, err := sessions.Red.InvitationService().CreateInvitation(board.ID, &pulpe.InvitationCreation{Role: pulpe.BoardRoleMember})
+		require.NoError(t, err)
+
+		_, err = sessions.Blue.InvitationService().AcceptInvitation(inv.Token)
+		require.NoError(t, err)
+
+		card := newCardWithListID(t, sessions.Red, list.ID)
+
+		pos := 10.0
+		_, err = cards.UpdateCard(card.ID, &pulpe.CardUpdate{Position: &pos})
+		require.NoError(t, err)
+
+		red, blue := "red", "blue"
+		_, err = cards.UpdateCard(card.ID, &pulpe.CardUpdate{Name: &red})
+		require.NoError(t, err)
+
+		_, err = sessions.Blue.CardService().UpdateCard(card.ID, &pulpe.CardUpdate{Name: &blue})
+		require.NoError(t, err)
+
+		_, err = s.Undo()
+		require.Equal(t, pulpe.ErrHistoryConflict, err)
+
+		// the conflicting change is dropped, the previous one can still be undone
+		entry, err := s.Undo()
+		require.NoError(t, err)
+		require.Equal(t, card.Position, entry.Card.Position)
+		require.Equal(t, blue, entry.Card.Name)
+	})
+	t.Run("Being undone", func(t *testing.T) {
+		card := newCardWithListID(t, sessions.Red, list.ID)
+
+		name := "claimed"
+		_, err := cards.UpdateCard(card.ID, &pulpe.CardUpdate{Name: &name})
+		require.NoError(t, err)
+
+		// another request is undoing the change
+		_, err = client.Session.DB("").C("history").UpdateAll(
+			bson.M{"entityID": card.ID},
+			bson.M{"$set": bson.M{"pendingAt": time.Now()}},
+		)
+		require.NoError(t, err)
+
+		_, err = s.Undo()
+		require.Equal(t, pulpe.ErrHistoryBusy, err)
+
+		c, err := cards.Card(card.ID)
+		require.NoError(t, err)
+		require.Equal(t, name, c.Name)
+	})
+}
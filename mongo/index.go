@@ -0,0 +1,56 @@
+package mongo
+
+import (
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+)
+
+// ensureTTLIndex ensures documents are removed once the time stored in field is older than expireAfter.
+// An existing index created with another duration is dropped, since MongoDB refuses to change it,
+// and no index is kept if expireAfter is zero.
+func ensureTTLIndex(col *mgo.Collection, field string, expireAfter time.Duration) error {
+	expireAfter = ttlSeconds(expireAfter)
+
+	indexes, err := col.Indexes()
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range indexes {
+		if len(idx.Key) != 1 || idx.Key[0] != field {
+			continue
+		}
+
+		if expireAfter > 0 && idx.ExpireAfter == expireAfter {
+			return nil
+		}
+
+		err = col.DropIndexName(idx.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if expireAfter <= 0 {
+		return nil
+	}
+
+	return col.EnsureIndex(mgo.Index{
+		Key:         []string{field},
+		ExpireAfter: expireAfter,
+	})
+}
+
+// ttlSeconds rounds a duration the way MongoDB stores it in expireAfterSeconds.
+func ttlSeconds(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+
+	if d < time.Second {
+		return time.Second
+	}
+
+	return d / time.Second * time.Second
+}
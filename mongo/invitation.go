@@ -0,0 +1,293 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const invitationCol = "invitations"
+
+// Ensure InvitationService implements pulpe.InvitationService.
+var _ pulpe.InvitationService = new(InvitationService)
+
+// invitation representation stored in MongoDB.
+type invitation struct {
+	ID          bson.ObjectId          `bson:"_id"`
+	BoardID     string                 `bson:"boardID"`
+	InviterID   string                 `bson:"inviterID"`
+	Email       string                 `bson:"email,omitempty"`
+	Token       string                 `bson:"token"`
+	Role        string                 `bson:"role"`
+	ExpiresAt   time.Time              `bson:"expiresAt"`
+	MaxUses     int                    `bson:"maxUses"`
+	Uses        int                    `bson:"uses"`
+	Acceptances []invitationAcceptance `bson:"acceptances,omitempty"`
+}
+
+// invitationAcceptance records a user who accepted an invitation.
+type invitationAcceptance struct {
+	UserID     string    `bson:"userID"`
+	AcceptedAt time.Time `bson:"acceptedAt"`
+}
+
+// toPulpeInvitation creates a pulpe invitation from a mongo invitation.
+// The logins of the users who accepted it are not included.
+func (i *invitation) toPulpeInvitation() *pulpe.Invitation {
+	p := pulpe.Invitation{
+		ID:        i.ID.Hex(),
+		BoardID:   i.BoardID,
+		CreatedAt: i.ID.Time().UTC(),
+		ExpiresAt: i.ExpiresAt.UTC(),
+		Email:     i.Email,
+		Token:     i.Token,
+		Role:      i.Role,
+		MaxUses:   i.MaxUses,
+		Uses:      i.Uses,
+	}
+
+	for _, a := range i.Acceptances {
+		p.Acceptances = append(p.Acceptances, &pulpe.InvitationAcceptance{
+			UserID:     a.UserID,
+			AcceptedAt: a.AcceptedAt.UTC(),
+		})
+	}
+
+	return &p
+}
+
+// accepted returns true if the user already accepted the invitation.
+func (i *invitation) accepted(userID string) bool {
+	for _, a := range i.Acceptances {
+		if a.UserID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// InvitationService represents a service for managing board invitations.
+type InvitationService struct {
+	session *Session
+}
+
+func (s *InvitationService) ensureIndexes() error {
+	col := s.session.db.C(invitationCol)
+
+	// Unique token
+	index := mgo.Index{
+		Key:    []string{"token"},
+		Unique: true,
+	}
+
+	err := col.EnsureIndex(index)
+	if err != nil {
+		return err
+	}
+
+	return col.EnsureIndexKey("boardID")
+}
+
+// CreateInvitation creates an invitation to a board and sends it by email
+// if the invitee email is set.
+func (s *InvitationService) CreateInvitation(boardID string, ic *pulpe.InvitationCreation) (*pulpe.Invitation, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := s.session.boardService.adminBoardByID(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ic.Role != pulpe.BoardRoleAdmin && ic.Role != pulpe.BoardRoleMember {
+		return nil, pulpe.ErrBoardInvalidRole
+	}
+
+	if ic.Email != "" && s.session.mailer == nil {
+		return nil, pulpe.ErrInvitationNoMailer
+	}
+
+	token, err := generateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := ic.ExpiresIn
+	if lifetime <= 0 {
+		lifetime = s.session.invitationLifetime
+	}
+
+	maxUses := ic.MaxUses
+	if maxUses <= 0 && ic.Email != "" {
+		maxUses = 1
+	}
+
+	inv := invitation{
+		ID:        bson.NewObjectId(),
+		BoardID:   b.ID.Hex(),
+		InviterID: user.ID,
+		Email:     ic.Email,
+		Token:     token,
+		Role:      ic.Role,
+		ExpiresAt: s.session.now.Add(lifetime),
+		MaxUses:   maxUses,
+	}
+
+	col := s.session.db.C(invitationCol)
+	err = col.Insert(&inv)
+	if err != nil {
+		return nil, err
+	}
+
+	p := inv.toPulpeInvitation()
+
+	if inv.Email != "" {
+		board, err := s.session.boardService.toPulpeBoard(user, b)
+		if err == nil {
+			err = s.session.mailer.SendInvitation(p, board, user)
+		}
+
+		// an invitation that wasn't sent can't be accepted
+		if err != nil {
+			col.RemoveId(inv.ID)
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// Invitations returns the invitations of a board.
+func (s *InvitationService) Invitations(boardID string) ([]*pulpe.Invitation, error) {
+	b, err := s.session.boardService.adminBoardByID(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []invitation
+	err = s.session.db.C(invitationCol).Find(bson.M{"boardID": b.ID.Hex()}).Sort("_id").All(&docs)
+	if err != nil {
+		return nil, err
+	}
+
+	var userIDs []string
+	for i := range docs {
+		for _, a := range docs[i].Acceptances {
+			userIDs = append(userIDs, a.UserID)
+		}
+	}
+
+	var profiles map[string]*pulpe.User
+	if len(userIDs) > 0 {
+		profiles, err = s.session.userService.publicProfiles(userIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	invs := make([]*pulpe.Invitation, len(docs))
+	for i := range docs {
+		invs[i] = docs[i].toPulpeInvitation()
+		for _, a := range invs[i].Acceptances {
+			if p, ok := profiles[a.UserID]; ok {
+				a.Login = p.Login
+			}
+		}
+	}
+
+	return invs, nil
+}
+
+// RevokeInvitation deletes an invitation. Members who already accepted it stay on the board.
+func (s *InvitationService) RevokeInvitation(boardID, id string) error {
+	b, err := s.session.boardService.adminBoardByID(boardID)
+	if err != nil {
+		return err
+	}
+
+	if !bson.IsObjectIdHex(id) {
+		return pulpe.ErrInvitationNotFound
+	}
+
+	err = s.session.db.C(invitationCol).Remove(bson.M{
+		"_id":     bson.ObjectIdHex(id),
+		"boardID": b.ID.Hex(),
+	})
+	if err == mgo.ErrNotFound {
+		return pulpe.ErrInvitationNotFound
+	}
+
+	return err
+}
+
+// AcceptInvitation adds the authenticated user to the board of the invitation
+// and records the acceptance. Accepting twice the same invitation has no effect.
+func (s *InvitationService) AcceptInvitation(token string) (*pulpe.Board, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	if token == "" {
+		return nil, pulpe.ErrInvitationNotFound
+	}
+
+	col := s.session.db.C(invitationCol)
+
+	var inv invitation
+	err = col.Find(bson.M{"token": token}).One(&inv)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrInvitationNotFound
+		}
+
+		return nil, err
+	}
+
+	if !inv.accepted(user.ID) {
+		if !s.session.now.Before(inv.ExpiresAt) {
+			return nil, pulpe.ErrInvitationExpired
+		}
+
+		query := bson.M{
+			"_id":                inv.ID,
+			"acceptances.userID": bson.M{"$ne": user.ID},
+		}
+
+		if inv.MaxUses > 0 {
+			query["uses"] = bson.M{"$lt": inv.MaxUses}
+		}
+
+		err = col.Update(query, bson.M{
+			"$inc": bson.M{"uses": 1},
+			"$push": bson.M{"acceptances": &invitationAcceptance{
+				UserID:     user.ID,
+				AcceptedAt: s.session.now,
+			}},
+		})
+		if err != nil {
+			if err == mgo.ErrNotFound {
+				return nil, pulpe.ErrInvitationExpired
+			}
+
+			return nil, err
+		}
+
+		err = s.session.boardService.addMember(inv.BoardID, user.ID, inv.Role)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b, err := s.session.boardService.boardByID(user, inv.BoardID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.session.boardService.toPulpeBoard(user, b)
+}
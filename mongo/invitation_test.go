@@ -0,0 +1,166 @@
+package mongo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitationService_CreateInvitation(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	board := newBoard(t, sessions.Red)
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := sessions.NoAuth.InvitationService().CreateInvitation(board.ID, &pulpe.InvitationCreation{Role: pulpe.BoardRoleMember})
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("Not a member", func(t *testing.T) {
+		_, err := sessions.Blue.InvitationService().CreateInvitation(board.ID, &pulpe.InvitationCreation{Role: pulpe.BoardRoleMember})
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := sessions.Red.InvitationService().CreateInvitation(board.ID, &pulpe.InvitationCreation{Role: "king"})
+		require.Equal(t, pulpe.ErrBoardInvalidRole, err)
+	})
+
+	t.Run("No mailer", func(t *testing.T) {
+		_, err := sessions.Red.InvitationService().CreateInvitation(board.ID, &pulpe.InvitationCreation{
+			Email: "arya@winterfell.com",
+			Role:  pulpe.BoardRoleMember,
+		})
+		require.Equal(t, pulpe.ErrInvitationNoMailer, err)
+	})
+
+	t.Run("Link", func(t *testing.T) {
+		s := sessions.Red.InvitationService()
+
+		inv, err := s.CreateInvitation(board.ID, &pulpe.InvitationCreation{Role: pulpe.BoardRoleMember})
+		require.NoError(t, err)
+		require.NotEmpty(t, inv.Token)
+		require.Equal(t, board.ID, inv.BoardID)
+		require.Zero(t, inv.MaxUses)
+		require.True(t, inv.ExpiresAt.After(inv.CreatedAt.Add(6*24*time.Hour)))
+
+		invs, err := s.Invitations(board.ID)
+		require.NoError(t, err)
+		require.Len(t, invs, 1)
+		require.Equal(t, inv.ID, invs[0].ID)
+
+		err = s.RevokeInvitation(board.ID, inv.ID)
+		require.NoError(t, err)
+
+		err = s.RevokeInvitation(board.ID, inv.ID)
+		require.Equal(t, pulpe.ErrInvitationNotFound, err)
+	})
+
+	t.Run("Email", func(t *testing.T) {
+		var mailer mock.Mailer
+		mailer.SendInvitationFn = func(inv *pulpe.Invitation, b *pulpe.Board, inviter *pulpe.User) error {
+			require.Equal(t, "arya@winterfell.com", inv.Email)
+			require.Equal(t, "ned", inviter.Login)
+			require.Equal(t, inv.BoardID, b.ID)
+			return nil
+		}
+
+		client.Mailer = &mailer
+		defer func() { client.Mailer = nil }()
+
+		s := getSessionAs(t, "Ned")
+		board := newBoard(t, s)
+
+		inv, err := s.InvitationService().CreateInvitation(board.ID, &pulpe.InvitationCreation{
+			Email: "arya@winterfell.com",
+			Role:  pulpe.BoardRoleMember,
+		})
+		require.NoError(t, err)
+		require.True(t, mailer.SendInvitationInvoked)
+		require.Equal(t, 1, inv.MaxUses)
+	})
+}
+
+func TestInvitationService_AcceptInvitation(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	board := newBoard(t, sessions.Red)
+	list := newListWithBoardID(t, sessions.Red, board.ID)
+
+	inv, err := sessions.Red.InvitationService().CreateInvitation(board.ID, &pulpe.InvitationCreation{
+		Role:    pulpe.BoardRoleMember,
+		MaxUses: 1,
+	})
+	require.NoError(t, err)
+
+	t.Run("Unknown token", func(t *testing.T) {
+		_, err := sessions.Blue.InvitationService().AcceptInvitation("unknown")
+		require.Equal(t, pulpe.ErrInvitationNotFound, err)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		s := sessions.Blue
+
+		other, err := s.InvitationService().AcceptInvitation(inv.Token)
+		require.NoError(t, err)
+		require.Equal(t, board.ID, other.ID)
+		require.Len(t, other.Members, 1)
+		require.Equal(t, "blue", other.Members[0].Login)
+		require.Equal(t, pulpe.BoardRoleMember, other.Members[0].Role)
+
+		// accepting twice has no effect
+		_, err = s.InvitationService().AcceptInvitation(inv.Token)
+		require.NoError(t, err)
+
+		invs, err := sessions.Red.InvitationService().Invitations(board.ID)
+		require.NoError(t, err)
+		require.Equal(t, 1, invs[0].Uses)
+		require.Len(t, invs[0].Acceptances, 1)
+		require.Equal(t, "blue", invs[0].Acceptances[0].Login)
+
+		// members can work on the board
+		boards, err := s.BoardService().Boards()
+		require.NoError(t, err)
+		require.Len(t, boards, 1)
+
+		card, err := s.CardService().CreateCard(list.ID, &pulpe.CardCreation{Name: "Needle"})
+		require.NoError(t, err)
+		require.Equal(t, list.OwnerID, card.OwnerID)
+
+		_, err = s.BoardService().BoardByOwnerAndSlug(board.Owner.Login, board.Slug)
+		require.NoError(t, err)
+
+		// but can't manage it
+		_, err = s.InvitationService().CreateInvitation(board.ID, &pulpe.InvitationCreation{Role: pulpe.BoardRoleMember})
+		require.Equal(t, pulpe.ErrBoardForbidden, err)
+
+		err = s.BoardService().DeleteBoard(board.ID)
+		require.Equal(t, pulpe.ErrBoardForbidden, err)
+	})
+
+	t.Run("Used", func(t *testing.T) {
+		_, err := sessions.Green.InvitationService().AcceptInvitation(inv.Token)
+		require.Equal(t, pulpe.ErrInvitationExpired, err)
+
+		_, err = sessions.Green.BoardService().Board(board.ID)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		inv, err := sessions.Red.InvitationService().CreateInvitation(board.ID, &pulpe.InvitationCreation{
+			Role: pulpe.BoardRoleAdmin,
+		})
+		require.NoError(t, err)
+
+		_, err = sessions.Green.InvitationService().AcceptInvitation(inv.Token)
+		require.NoError(t, err)
+
+		_, err = sessions.Green.InvitationService().CreateInvitation(board.ID, &pulpe.InvitationCreation{Role: pulpe.BoardRoleMember})
+		require.NoError(t, err)
+	})
+}
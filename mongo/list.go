@@ -24,8 +24,16 @@ type list struct {
 	Name      string        `bson:"name"`
 	Slug      string        `bson:"slug"`
 	Position  float64       `bson:"position"`
+	ImportID  string        `bson:"importID,omitempty"`
+	// ModifiedAt contains the time of the last write of each field.
+	ModifiedAt map[string]time.Time `bson:"modifiedAt,omitempty"`
+	// RestoredAt is set when the list is recreated by an undo or a redo.
+	RestoredAt *time.Time `bson:"restoredAt,omitempty"`
 }
 
+// syncedListFields are the fields of a list written by sync operations.
+var syncedListFields = []string{"name", "position", "boardID"}
+
 // toPulpeList creates a pulpe list from a mongo list.
 func (l *list) toPulpeList() *pulpe.List {
 	p := pulpe.List{
@@ -36,6 +44,7 @@ func (l *list) toPulpeList() *pulpe.List {
 		Name:      l.Name,
 		Slug:      l.Slug,
 		Position:  l.Position,
+		ImportID:  l.ImportID,
 	}
 
 	if l.UpdatedAt != nil {
@@ -82,22 +91,20 @@ func (s *ListService) CreateList(boardID string, lc *pulpe.ListCreation) (*pulpe
 		return nil, err
 	}
 
-	board, err := s.session.BoardService().Board(boardID)
+	board, err := s.session.boardService.boardByID(user, boardID)
 	if err != nil {
 		return nil, err
 	}
 
-	if board.Owner.ID != user.ID {
-		return nil, pulpe.ErrBoardNotFound
-	}
-
+	// lists share the owner of their board
 	l := list{
 		ID:       bson.NewObjectId(),
-		OwnerID:  user.ID,
-		BoardID:  board.ID,
+		OwnerID:  board.OwnerID,
+		BoardID:  board.ID.Hex(),
 		Name:     lc.Name,
 		Slug:     slugify.Slugify(lc.Name),
 		Position: lc.Position,
+		ImportID: lc.ImportID,
 	}
 
 	err = s.store.createList(&l)
@@ -115,7 +122,22 @@ func (s *ListService) List(id string) (*pulpe.List, error) {
 		return nil, err
 	}
 
-	l, err := s.store.listByOwnerIDAndID(user.ID, id)
+	l, err := s.listByID(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.toPulpeList(), nil
+}
+
+// listByID returns a list if the user has access to its board.
+func (s *ListService) listByID(user *pulpe.User, id string) (*list, error) {
+	acc, err := s.session.access(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := s.store.listByAccessAndID(acc.content(), id)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return nil, pulpe.ErrListNotFound
@@ -124,7 +146,7 @@ func (s *ListService) List(id string) (*pulpe.List, error) {
 		return nil, err
 	}
 
-	return l.toPulpeList(), nil
+	return l, nil
 }
 
 // DeleteList deletes a List.
@@ -138,17 +160,48 @@ func (s *ListService) DeleteList(id string) error {
 		return pulpe.ErrListNotFound
 	}
 
-	err = s.store.deleteListByOwnerIDAndID(user.ID, bson.ObjectIdHex(id))
+	l, err := s.listByID(user, id)
+	if err != nil {
+		return err
+	}
+
+	// the cards are kept in the history to be restored with the list
+	cards, err := s.session.cardService.store.cardsByListID(l.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	err = s.deleteList(l)
+	if err != nil {
+		return err
+	}
+
+	return s.session.historyService.record(user, &historyEntry{
+		Entity:   pulpe.HistoryList,
+		Action:   pulpe.HistoryDelete,
+		EntityID: l.ID.Hex(),
+		BoardID:  l.BoardID,
+		List:     l,
+		Cards:    cards,
+	})
+}
+
+// deleteList removes a list with its cards and records its tombstone.
+func (s *ListService) deleteList(l *list) error {
+	err := s.session.cascadeDelete(deletionList, l.ID)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return pulpe.ErrListNotFound
-
 		}
 
 		return err
 	}
 
-	return s.session.CardService().DeleteCardsByListID(id)
+	return s.session.bury(&tombstone{
+		Kind:     pulpe.TombstoneList,
+		TargetID: l.ID.Hex(),
+		BoardID:  l.BoardID,
+	})
 }
 
 // DeleteListsByBoardID deletes all the lists of a board.
@@ -163,8 +216,39 @@ func (s *ListService) UpdateList(id string, u *pulpe.ListUpdate) (*pulpe.List, e
 		return nil, err
 	}
 
-	if !bson.IsObjectIdHex(id) {
-		return nil, pulpe.ErrListNotFound
+	before, err := s.listByID(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []card
+	if u.BoardID != nil && *u.BoardID != before.BoardID {
+		// the cards lose their assignees and custom field values on the other board,
+		// they are kept in the history to be restored if the move is undone
+		cards, err = s.session.cardService.store.cardsByListID(before.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := s.updateList(user, id, u, s.session.now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.session.historyService.recordListUpdate(user, before, l, cards)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.toPulpeList(), nil
+}
+
+// updateList updates a list, recording at as the time of the write of the fields.
+func (s *ListService) updateList(user *pulpe.User, id string, u *pulpe.ListUpdate, at time.Time) (*list, error) {
+	l, err := s.listByID(user, id)
+	if err != nil {
+		return nil, err
 	}
 
 	var newSlug string
@@ -179,8 +263,30 @@ func (s *ListService) UpdateList(id string, u *pulpe.ListUpdate) (*pulpe.List, e
 		patch["position"] = *u.Position
 	}
 
+	ownerID := l.OwnerID
+	from := l.BoardID
+	if u.BoardID != nil {
+		// the user must have access to the target board too
+		board, err := s.session.boardService.boardByID(user, *u.BoardID)
+		if err != nil {
+			return nil, err
+		}
+
+		if board.ID.Hex() != l.BoardID {
+			// lists share the owner of their board and their slug is unique per board
+			ownerID = board.OwnerID
+			patch["boardID"] = board.ID.Hex()
+			patch["ownerID"] = board.OwnerID
+			if newSlug == "" {
+				newSlug = slugify.Slugify(l.Name)
+			}
+		}
+	}
+
+	stampPatch(patch, at)
+
 	if len(patch) > 0 {
-		newSlug, err = s.store.updateListByID(bson.ObjectIdHex(id), user.ID, newSlug, patch)
+		newSlug, err = s.store.updateListByID(l.ID, l.OwnerID, newSlug, patch)
 		if err != nil {
 			if err == mgo.ErrNotFound {
 				return nil, pulpe.ErrListNotFound
@@ -190,7 +296,7 @@ func (s *ListService) UpdateList(id string, u *pulpe.ListUpdate) (*pulpe.List, e
 		}
 	}
 
-	l, err := s.store.listByOwnerIDAndID(user.ID, id)
+	l, err = s.store.listByAccessAndID(ownedBy(ownerID), id)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return nil, pulpe.ErrListNotFound
@@ -199,7 +305,85 @@ func (s *ListService) UpdateList(id string, u *pulpe.ListUpdate) (*pulpe.List, e
 		return nil, err
 	}
 
-	return l.toPulpeList(), nil
+	if u.BoardID != nil {
+		// cards follow their list, this also finishes previous moves that were interrupted
+		err = s.session.cardService.moveCardsToBoard(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if l.BoardID != from {
+		// for the clients of the former board, the list and its cards are gone
+		err = s.session.bury(&tombstone{
+			Kind:     pulpe.TombstoneList,
+			TargetID: l.ID.Hex(),
+			BoardID:  from,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// CopyList copies a List and its cards to a board, possibly another one.
+func (s *ListService) CopyList(id string, lc *pulpe.ListCopy) (*pulpe.List, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := s.listByID(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	board, err := s.session.boardService.boardByID(user, lc.BoardID)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := s.session.cardService.store.cardsByListID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cp := list{
+		ID:       bson.NewObjectId(),
+		OwnerID:  board.OwnerID,
+		BoardID:  board.ID.Hex(),
+		Name:     l.Name,
+		Slug:     slugify.Slugify(l.Name),
+		Position: l.Position,
+	}
+
+	if lc.Position != nil {
+		cp.Position = *lc.Position
+	}
+
+	err = s.store.createList(&cp)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cs {
+		// custom fields belong to the board, their values aren't copied to other boards
+		var fields map[string]interface{}
+		if cp.BoardID == l.BoardID {
+			fields = cs[i].Fields
+		}
+
+		_, err = s.session.cardService.copyCard(&cs[i], &cp, cs[i].Position, fields)
+		if err != nil {
+			// errors are ignored since the copy already failed
+			s.session.cascadeDelete(deletionList, cp.ID)
+			return nil, err
+		}
+	}
+
+	return cp.toPulpeList(), nil
 }
 
 // ListsByBoard returns all the lists of a given board.
@@ -209,6 +393,11 @@ func (s *ListService) ListsByBoard(boardID string) ([]*pulpe.List, error) {
 		return nil, err
 	}
 
+	return s.listsByBoard(boardID)
+}
+
+// listsByBoard returns the lists of a board without checking the access rights.
+func (s *ListService) listsByBoard(boardID string) ([]*pulpe.List, error) {
 	ls, err := s.store.listsByBoardID(boardID)
 	if err != nil {
 		return nil, err
@@ -226,7 +415,7 @@ type listStore struct {
 	session *Session
 }
 
-func (s *listStore) listByOwnerIDAndID(ownerID, id string) (*list, error) {
+func (s *listStore) listByAccessAndID(or []bson.M, id string) (*list, error) {
 	var b list
 
 	if !bson.IsObjectIdHex(id) {
@@ -234,8 +423,8 @@ func (s *listStore) listByOwnerIDAndID(ownerID, id string) (*list, error) {
 	}
 
 	query := bson.M{
-		"ownerID": ownerID,
-		"_id":     bson.ObjectIdHex(id),
+		"$or": or,
+		"_id": bson.ObjectIdHex(id),
 	}
 
 	return &b, s.session.db.C(listCol).Find(query).One(&b)
@@ -253,13 +442,6 @@ func (s *listStore) createList(l *list) error {
 	return err
 }
 
-func (s *listStore) deleteListByOwnerIDAndID(ownerID string, id bson.ObjectId) error {
-	return s.session.db.C(listCol).Remove(bson.M{
-		"_id":     id,
-		"ownerID": ownerID,
-	})
-}
-
 func (s *listStore) deleteListsByBoardID(boardID string) error {
 	_, err := s.session.db.C(listCol).RemoveAll(bson.M{
 		"boardID": boardID,
@@ -358,6 +358,44 @@ func TestListService_UpdateList(t *testing.T) {
 		require.Nil(t, updatedList)
 	})
 
+	t.Run("Move to another board", func(t *testing.T) {
+		s := sessions.Red.ListService()
+
+		board1 := newBoard(t, sessions.Red)
+		board2 := newBoard(t, sessions.Red)
+
+		list, err := s.CreateList(board1.ID, &pulpe.ListCreation{Name: "name", Position: 3})
+		require.NoError(t, err)
+		_, err = s.CreateList(board2.ID, &pulpe.ListCreation{Name: "name"})
+		require.NoError(t, err)
+
+		card := newCardWithListID(t, sessions.Red, list.ID)
+		// a card with the same slug on the target board
+		newCardWithListID(t, sessions.Red, newListWithBoardID(t, sessions.Red, board2.ID).ID)
+
+		updatedList, err := s.UpdateList(list.ID, &pulpe.ListUpdate{
+			BoardID: &board2.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, board2.ID, updatedList.BoardID)
+		require.Equal(t, "name-1", updatedList.Slug)
+		require.Equal(t, list.Position, updatedList.Position)
+
+		// the cards follow their list
+		movedCard, err := sessions.Red.CardService().Card(card.ID)
+		require.NoError(t, err)
+		require.Equal(t, list.ID, movedCard.ListID)
+		require.Equal(t, board2.ID, movedCard.BoardID)
+		require.Equal(t, "card-1", movedCard.Slug)
+
+		// to a board that's not ours
+		boardID := newBoard(t, sessions.Blue).ID
+		_, err = s.UpdateList(list.ID, &pulpe.ListUpdate{
+			BoardID: &boardID,
+		})
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+
 	t.Run("Slug conflict", func(t *testing.T) {
 		s := sessions.Green.ListService()
 
@@ -388,6 +426,60 @@ func TestListService_UpdateList(t *testing.T) {
 	})
 }
 
+func TestListService_CopyList(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		s := sessions.NoAuth.ListService()
+
+		_, err := s.CopyList(newListID(), &pulpe.ListCopy{BoardID: newBoardID()})
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		s := sessions.Red.ListService()
+
+		list := newList(t, sessions.Red)
+		board := newBoard(t, sessions.Red)
+
+		cs := sessions.Red.CardService()
+		for _, position := range []float64{2, 1} {
+			_, err := cs.CreateCard(list.ID, &pulpe.CardCreation{Name: "card", Position: position})
+			require.NoError(t, err)
+		}
+
+		cp, err := s.CopyList(list.ID, &pulpe.ListCopy{BoardID: board.ID})
+		require.NoError(t, err)
+		require.NotEqual(t, list.ID, cp.ID)
+		require.Equal(t, board.ID, cp.BoardID)
+		require.Equal(t, list.Slug, cp.Slug)
+		require.Equal(t, list.Position, cp.Position)
+
+		cards, err := cs.CardsByBoard(board.ID)
+		require.NoError(t, err)
+		require.Len(t, cards, 2)
+		for _, c := range cards {
+			require.Equal(t, cp.ID, c.ListID)
+		}
+
+		// the original cards are untouched
+		cards, err = cs.CardsByBoard(list.BoardID)
+		require.NoError(t, err)
+		require.Len(t, cards, 2)
+	})
+
+	t.Run("Bad user", func(t *testing.T) {
+		list := newList(t, sessions.Red)
+
+		_, err := sessions.Blue.ListService().CopyList(list.ID, &pulpe.ListCopy{BoardID: newBoard(t, sessions.Blue).ID})
+		require.Equal(t, pulpe.ErrListNotFound, err)
+
+		_, err = sessions.Red.ListService().CopyList(list.ID, &pulpe.ListCopy{BoardID: newBoard(t, sessions.Blue).ID})
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+}
+
 func TestListService_ListsByBoard(t *testing.T) {
 	sessions, cleanup := MustGetSessions(t)
 	defer cleanup()
@@ -0,0 +1,274 @@
+package mongo
+
+import (
+	"strings"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	loginAttemptCol = "loginAttempts"
+	lockoutCol      = "lockouts"
+
+	// maximum number of lockouts returned by Lockouts.
+	lockoutsLimit = 100
+
+	// scope of the failures on an account from every client.
+	// They only delay the next attempts, so that no client can lock a user out.
+	scopeAccount = "account"
+)
+
+// Ensure LoginAttemptService implements pulpe.LoginAttemptService.
+var _ pulpe.LoginAttemptService = new(LoginAttemptService)
+
+// loginAttempt counts the failed login attempts of an account, a client IP, or both.
+type loginAttempt struct {
+	ID            string     `bson:"_id"`
+	Failures      int        `bson:"failures"`
+	LastFailureAt time.Time  `bson:"lastFailureAt"`
+	LockedUntil   *time.Time `bson:"lockedUntil,omitempty"`
+	ExpiresAt     time.Time  `bson:"expiresAt"`
+}
+
+// wait returns how long a client must wait before trying again.
+func (a *loginAttempt) wait(now time.Time, limits LoginLimits) time.Duration {
+	var d time.Duration
+
+	if a.LockedUntil != nil {
+		d = a.LockedUntil.Sub(now)
+	}
+
+	if a.Failures > limits.FreeAttempts {
+		delay := limits.BaseDelay
+		for i := limits.FreeAttempts + 1; i < a.Failures && delay < limits.MaxDelay; i++ {
+			delay *= 2
+		}
+		if delay > limits.MaxDelay {
+			delay = limits.MaxDelay
+		}
+
+		if w := a.LastFailureAt.Add(delay).Sub(now); w > d {
+			d = w
+		}
+	}
+
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+// lockout representation stored in MongoDB.
+type lockout struct {
+	ID        bson.ObjectId `bson:"_id"`
+	Scope     string        `bson:"scope"`
+	Account   string        `bson:"account"`
+	IP        string        `bson:"ip"`
+	Failures  int           `bson:"failures"`
+	CreatedAt time.Time     `bson:"createdAt"`
+	Until     time.Time     `bson:"until"`
+}
+
+func (l *lockout) toPulpeLockout() *pulpe.Lockout {
+	return &pulpe.Lockout{
+		ID:        l.ID.Hex(),
+		Scope:     l.Scope,
+		Account:   l.Account,
+		IP:        l.IP,
+		Failures:  l.Failures,
+		CreatedAt: l.CreatedAt.UTC(),
+		Until:     l.Until.UTC(),
+	}
+}
+
+// LoginAttemptService represents a service for throttling failed login attempts.
+type LoginAttemptService struct {
+	session *Session
+}
+
+func (s *LoginAttemptService) ensureIndexes() error {
+	// Forget attempts once the window and the lockout have passed
+	err := s.session.db.C(loginAttemptCol).EnsureIndex(mgo.Index{
+		Key:         []string{"expiresAt"},
+		ExpireAfter: time.Second,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.session.db.C(lockoutCol).EnsureIndexKey("-createdAt")
+}
+
+// Account returns the key given by the credential verifier,
+// or the normalized login or email if there is no such account.
+func (s *LoginAttemptService) Account(loginOrEmail string) (string, error) {
+	key, err := s.session.credentialVerifier.AccountKey(s.session, loginOrEmail)
+	if err != nil || key != "" {
+		return key, err
+	}
+
+	return "login:" + strings.ToLower(strings.TrimSpace(loginOrEmail)), nil
+}
+
+// keys returns the identifiers used to count the attempts on the account,
+// on the account from the client IP and from the client IP.
+func keys(account, ip string) map[string]string {
+	keys := map[string]string{
+		scopeAccount: scopeAccount + ":" + account,
+	}
+
+	if ip != "" {
+		keys[pulpe.LockoutScopeClient] = pulpe.LockoutScopeClient + ":" + ip + " " + account
+		keys[pulpe.LockoutScopeIP] = pulpe.LockoutScopeIP + ":" + ip
+	}
+
+	return keys
+}
+
+// Check returns how long the client must wait before trying to log in to the account again.
+func (s *LoginAttemptService) Check(account, ip string) (time.Duration, error) {
+	var ids []string
+	for _, key := range keys(account, ip) {
+		ids = append(ids, key)
+	}
+
+	var attempts []loginAttempt
+	err := s.session.db.C(loginAttemptCol).Find(bson.M{"_id": bson.M{"$in": ids}}).All(&attempts)
+	if err != nil {
+		return 0, err
+	}
+
+	var wait time.Duration
+	for i := range attempts {
+		if d := attempts[i].wait(s.session.now, s.session.loginLimits); d > wait {
+			wait = d
+		}
+	}
+
+	return wait, nil
+}
+
+// RecordFailure records a failed login attempt on the account from the client IP.
+func (s *LoginAttemptService) RecordFailure(account, ip string) error {
+	limits := s.session.loginLimits
+
+	for scope, key := range keys(account, ip) {
+		var threshold int
+		switch scope {
+		case pulpe.LockoutScopeClient:
+			threshold = limits.ClientLockout
+		case pulpe.LockoutScopeIP:
+			threshold = limits.IPLockout
+		}
+
+		attempt, err := s.recordFailure(key)
+		if err != nil {
+			return err
+		}
+
+		if threshold <= 0 || attempt.Failures < threshold {
+			continue
+		}
+
+		err = s.lock(attempt, &lockout{
+			ID:        bson.NewObjectId(),
+			Scope:     scope,
+			Account:   account,
+			IP:        ip,
+			Failures:  attempt.Failures,
+			CreatedAt: s.session.now,
+			Until:     s.session.now.Add(limits.LockoutDuration),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordFailure increments the number of failures of the given key.
+func (s *LoginAttemptService) recordFailure(key string) (*loginAttempt, error) {
+	col := s.session.db.C(loginAttemptCol)
+	now := s.session.now
+
+	// start counting again if the last failure is outside the window
+	// and the key is not locked.
+	err := col.Remove(bson.M{
+		"_id":           key,
+		"lastFailureAt": bson.M{"$lte": now.Add(-s.session.loginLimits.Window)},
+		"$or": []bson.M{
+			{"lockedUntil": bson.M{"$exists": false}},
+			{"lockedUntil": bson.M{"$lte": now}},
+		},
+	})
+	if err != nil && err != mgo.ErrNotFound {
+		return nil, err
+	}
+
+	change := mgo.Change{
+		Update: bson.M{
+			"$inc": bson.M{"failures": 1},
+			"$set": bson.M{"lastFailureAt": now},
+			"$max": bson.M{"expiresAt": now.Add(s.session.loginLimits.Window)},
+		},
+		Upsert:    true,
+		ReturnNew: true,
+	}
+
+	var attempt loginAttempt
+	_, err = col.FindId(key).Apply(change, &attempt)
+	if mgo.IsDup(err) {
+		// concurrent upsert of the same key
+		_, err = col.FindId(key).Apply(change, &attempt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// lock locks the given key and records the lockout.
+func (s *LoginAttemptService) lock(attempt *loginAttempt, l *lockout) error {
+	err := s.session.db.C(loginAttemptCol).UpdateId(attempt.ID, bson.M{
+		"$set": bson.M{"lockedUntil": l.Until},
+		"$max": bson.M{"expiresAt": l.Until},
+	})
+	if err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+
+	return s.session.db.C(lockoutCol).Insert(l)
+}
+
+// RecordSuccess resets the failed login attempts on the account.
+func (s *LoginAttemptService) RecordSuccess(account, ip string) error {
+	k := keys(account, ip)
+
+	_, err := s.session.db.C(loginAttemptCol).RemoveAll(bson.M{
+		"_id": bson.M{"$in": []string{k[scopeAccount], k[pulpe.LockoutScopeClient]}},
+	})
+	return err
+}
+
+// Lockouts returns the most recent lockouts.
+func (s *LoginAttemptService) Lockouts() ([]pulpe.Lockout, error) {
+	var list []lockout
+
+	err := s.session.db.C(lockoutCol).Find(nil).Sort("-createdAt").Limit(lockoutsLimit).All(&list)
+	if err != nil {
+		return nil, err
+	}
+
+	lockouts := make([]pulpe.Lockout, len(list))
+	for i := range list {
+		lockouts[i] = *list[i].toPulpeLockout()
+	}
+
+	return lockouts, nil
+}
@@ -0,0 +1,206 @@
+package mongo_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mongo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginAttemptService(t *testing.T) {
+	limits := mongo.DefaultLoginLimits
+
+	t.Run("Backoff", func(t *testing.T) {
+		_, cleanup := MustGetSessions(t)
+		defer cleanup()
+
+		now := Now()
+		session := connectAt(now)
+		defer session.Close()
+		s := session.LoginAttemptService()
+
+		for i := 0; i < limits.FreeAttempts; i++ {
+			wait, err := s.Check("jonsnow", "10.0.0.1")
+			require.NoError(t, err)
+			require.Zero(t, wait)
+
+			err = s.RecordFailure("jonsnow", "10.0.0.1")
+			require.NoError(t, err)
+		}
+
+		wait, err := s.Check("jonsnow", "10.0.0.1")
+		require.NoError(t, err)
+		require.Zero(t, wait)
+
+		err = s.RecordFailure("jonsnow", "10.0.0.1")
+		require.NoError(t, err)
+
+		wait, err = s.Check("jonsnow", "10.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, limits.BaseDelay, wait)
+
+		// the delay doubles after each failure
+		err = s.RecordFailure("jonsnow", "10.0.0.1")
+		require.NoError(t, err)
+
+		wait, err = s.Check("jonsnow", "10.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, 2*limits.BaseDelay, wait)
+
+		// the client IP is throttled for other accounts
+		wait, err = s.Check("ygritte", "10.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, 2*limits.BaseDelay, wait)
+
+		// the account is throttled from other IPs
+		wait, err = s.Check("jonsnow", "10.0.0.2")
+		require.NoError(t, err)
+		require.Equal(t, 2*limits.BaseDelay, wait)
+
+		later := connectAt(now.Add(2 * limits.BaseDelay))
+		defer later.Close()
+		wait, err = later.LoginAttemptService().Check("jonsnow", "10.0.0.1")
+		require.NoError(t, err)
+		require.Zero(t, wait)
+	})
+
+	t.Run("Lockout", func(t *testing.T) {
+		_, cleanup := MustGetSessions(t)
+		defer cleanup()
+
+		now := Now()
+		session := connectAt(now)
+		defer session.Close()
+		s := session.LoginAttemptService()
+
+		for i := 0; i < limits.ClientLockout; i++ {
+			err := s.RecordFailure("jonsnow", "10.0.0.1")
+			require.NoError(t, err)
+		}
+
+		wait, err := s.Check("jonsnow", "10.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, limits.LockoutDuration, wait)
+
+		// other clients are only delayed, the user can't be locked out by anyone
+		wait, err = s.Check("jonsnow", "10.0.0.2")
+		require.NoError(t, err)
+		require.Equal(t, limits.MaxDelay, wait)
+
+		// the IP is below its own threshold
+		wait, err = s.Check("ygritte", "10.0.0.1")
+		require.NoError(t, err)
+		require.True(t, wait < limits.LockoutDuration)
+
+		lockouts, err := s.Lockouts()
+		require.NoError(t, err)
+		require.Len(t, lockouts, 1)
+		require.Equal(t, pulpe.LockoutScopeClient, lockouts[0].Scope)
+		require.Equal(t, "jonsnow", lockouts[0].Account)
+		require.Equal(t, "10.0.0.1", lockouts[0].IP)
+		require.Equal(t, limits.ClientLockout, lockouts[0].Failures)
+		require.Equal(t, now, lockouts[0].CreatedAt)
+		require.Equal(t, now.Add(limits.LockoutDuration), lockouts[0].Until)
+
+		later := connectAt(now.Add(limits.LockoutDuration))
+		defer later.Close()
+		wait, err = later.LoginAttemptService().Check("jonsnow", "10.0.0.1")
+		require.NoError(t, err)
+		require.Zero(t, wait)
+	})
+
+	t.Run("IP lockout", func(t *testing.T) {
+		_, cleanup := MustGetSessions(t)
+		defer cleanup()
+
+		now := Now()
+		session := connectAt(now)
+		defer session.Close()
+		s := session.LoginAttemptService()
+
+		for i := 0; i < limits.IPLockout; i++ {
+			err := s.RecordFailure(fmt.Sprintf("user%d", i), "10.0.0.1")
+			require.NoError(t, err)
+		}
+
+		wait, err := s.Check("ygritte", "10.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, limits.LockoutDuration, wait)
+
+		wait, err = s.Check("ygritte", "10.0.0.2")
+		require.NoError(t, err)
+		require.Zero(t, wait)
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		_, cleanup := MustGetSessions(t)
+		defer cleanup()
+
+		now := Now()
+		session := connectAt(now)
+		defer session.Close()
+
+		for i := 0; i < limits.FreeAttempts+1; i++ {
+			err := session.LoginAttemptService().RecordFailure("jonsnow", "")
+			require.NoError(t, err)
+		}
+
+		// failures are forgotten after the window
+		later := connectAt(now.Add(limits.Window + time.Second))
+		defer later.Close()
+		err := later.LoginAttemptService().RecordFailure("jonsnow", "")
+		require.NoError(t, err)
+
+		wait, err := later.LoginAttemptService().Check("jonsnow", "")
+		require.NoError(t, err)
+		require.Zero(t, wait)
+	})
+
+	t.Run("RecordSuccess", func(t *testing.T) {
+		_, cleanup := MustGetSessions(t)
+		defer cleanup()
+
+		session := connectAt(Now())
+		defer session.Close()
+		s := session.LoginAttemptService()
+
+		for i := 0; i < limits.FreeAttempts+1; i++ {
+			err := s.RecordFailure("jonsnow", "10.0.0.1")
+			require.NoError(t, err)
+		}
+
+		err := s.RecordSuccess("jonsnow", "10.0.0.1")
+		require.NoError(t, err)
+
+		wait, err := s.Check("jonsnow", "10.0.0.2")
+		require.NoError(t, err)
+		require.Zero(t, wait)
+	})
+
+	t.Run("Account", func(t *testing.T) {
+		_, cleanup := MustGetSessions(t)
+		defer cleanup()
+
+		session := connectAt(Now())
+		defer session.Close()
+		s := session.LoginAttemptService()
+
+		user := createUser(t, session, "Ygritte")
+
+		// the login and the email of an account share the same attempts
+		account, err := s.Account(user.Login)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, account)
+
+		account, err = s.Account(user.Email)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, account)
+
+		account, err = s.Account(" Unknown")
+		require.NoError(t, err)
+		require.Equal(t, "login:unknown", account)
+	})
+}
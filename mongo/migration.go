@@ -0,0 +1,264 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	migrationCol     = "migrations"
+	migrationLockCol = "migrationLock"
+
+	// id of the lock document.
+	migrationLockID = "migrations"
+
+	// duration after which the lock of a crashed process can be taken.
+	// The lock is renewed while migrations run.
+	migrationLockLifetime = 10 * time.Minute
+)
+
+// DefaultMigrationLockWait is the default duration during which a process
+// waits for another one to finish migrating the database.
+const DefaultMigrationLockWait = time.Minute
+
+// Migration lock errors
+var (
+	// ErrMigrationLocked is returned when another process is migrating the database for too long.
+	ErrMigrationLocked = errors.New("mongo: migrations locked by another process")
+	// ErrMigrationLockLost is returned when the lock couldn't be renewed in time
+	// and was taken by another process.
+	ErrMigrationLockLost = errors.New("mongo: migration lock taken by another process")
+)
+
+// Migration changes the shape of the documents stored in the database.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(db *mgo.Database) error
+}
+
+// Migrations are the schema migrations of the database.
+// A released migration must never be modified: changes are made by
+// appending a new one with the next version number.
+var Migrations = []Migration{
+	{Version: 1, Description: "Set the expiration date of sessions", Up: setSessionsExpiration},
+	{Version: 2, Description: "Claim the namespaces of users and organizations", Up: claimNamespaces},
+}
+
+// MigrationStatus describes a migration and when it was applied.
+type MigrationStatus struct {
+	Version     int
+	Description string
+
+	// Nil if the migration is pending.
+	AppliedAt *time.Time
+}
+
+// migration records an applied migration.
+type migration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// migrationLock prevents several processes from migrating the database at the same time.
+type migrationLock struct {
+	ID        string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// MigrationStatus returns the status of every migration, sorted by version.
+func (c *Client) MigrationStatus() ([]MigrationStatus, error) {
+	var applied []migration
+	err := c.Session.DB("").C(migrationCol).Find(nil).All(&applied)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for i := range applied {
+		byVersion[applied[i].Version] = &applied[i]
+	}
+
+	list := make([]MigrationStatus, len(c.Migrations))
+	for i, m := range c.Migrations {
+		list[i] = MigrationStatus{
+			Version:     m.Version,
+			Description: m.Description,
+		}
+
+		if a, ok := byVersion[m.Version]; ok {
+			appliedAt := a.AppliedAt.UTC()
+			list[i].AppliedAt = &appliedAt
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Migrate applies the pending migrations in version order and returns them.
+// If another process is migrating the database, Migrate waits for it to finish.
+func (c *Client) Migrate() ([]MigrationStatus, error) {
+	lock, err := c.lockMigrations()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.release()
+
+	// the status is read once locked, other processes may have applied migrations
+	list, err := c.MigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make(map[int]Migration)
+	for _, m := range c.Migrations {
+		migrations[m.Version] = m
+	}
+
+	var done []MigrationStatus
+	for _, s := range list {
+		if s.AppliedAt != nil {
+			continue
+		}
+
+		err = lock.check()
+		if err != nil {
+			return done, err
+		}
+
+		err = migrations[s.Version].Up(c.Session.DB(""))
+		if err != nil {
+			return done, fmt.Errorf("migration %d: %v", s.Version, err)
+		}
+
+		// the migration may have been applied again by the process that took the lock
+		err = lock.check()
+		if err != nil {
+			return done, fmt.Errorf("migration %d: %v", s.Version, err)
+		}
+
+		now := c.Now().UTC()
+		err = c.Session.DB("").C(migrationCol).Insert(&migration{
+			Version:     s.Version,
+			Description: s.Description,
+			AppliedAt:   now,
+		})
+		if err != nil {
+			return done, err
+		}
+
+		s.AppliedAt = &now
+		done = append(done, s)
+	}
+
+	return done, nil
+}
+
+// lockMigrations takes the migration lock, waiting for it if necessary.
+// The lock is renewed until it is released.
+func (c *Client) lockMigrations() (*heldMigrationLock, error) {
+	owner, err := generateRandomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	col := c.Session.DB("").C(migrationLockCol)
+	deadline := time.Now().Add(c.MigrationLockWait)
+
+	for {
+		now := c.Now().UTC()
+
+		// matches an expired lock or none, in which case the lock is inserted
+		_, err = col.Upsert(
+			bson.M{"_id": migrationLockID, "expiresAt": bson.M{"$lt": now}},
+			&migrationLock{ID: migrationLockID, Owner: owner, ExpiresAt: now.Add(migrationLockLifetime)},
+		)
+		if err == nil {
+			l := heldMigrationLock{
+				client: c,
+				owner:  owner,
+				stop:   make(chan struct{}),
+				done:   make(chan struct{}),
+			}
+			go l.renew()
+			return &l, nil
+		}
+
+		if !mgo.IsDup(err) {
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrMigrationLocked
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+// heldMigrationLock is the migration lock taken by this process.
+type heldMigrationLock struct {
+	client *Client
+	owner  string
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// renew extends the lifetime of the lock until it is released or taken by another process.
+func (l *heldMigrationLock) renew() {
+	defer close(l.done)
+
+	session := l.client.Session.Copy()
+	defer session.Close()
+	col := session.DB("").C(migrationLockCol)
+
+	ticker := time.NewTicker(migrationLockLifetime / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// other errors are retried on the next tick, which comes before the lock expires
+		err := col.Update(
+			bson.M{"_id": migrationLockID, "owner": l.owner},
+			bson.M{"$set": bson.M{"expiresAt": l.client.Now().UTC().Add(migrationLockLifetime)}},
+		)
+		if err == mgo.ErrNotFound {
+			return
+		}
+	}
+}
+
+// check returns ErrMigrationLockLost if another process took the lock.
+func (l *heldMigrationLock) check() error {
+	n, err := l.client.Session.DB("").C(migrationLockCol).Find(bson.M{"_id": migrationLockID, "owner": l.owner}).Count()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrMigrationLockLost
+	}
+
+	return nil
+}
+
+// release stops renewing the lock and releases it if this process still owns it.
+func (l *heldMigrationLock) release() {
+	close(l.stop)
+	<-l.done
+
+	l.client.Session.DB("").C(migrationLockCol).Remove(bson.M{"_id": migrationLockID, "owner": l.owner})
+}
@@ -0,0 +1,182 @@
+package mongo_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe/mongo"
+	"github.com/stretchr/testify/require"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// newMigrationClient returns an open client on an empty database, without migrations.
+func newMigrationClient(t *testing.T) (*mongo.Client, func()) {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+
+	c := mongo.NewClient(uri + "/pulpe-tests-migrations")
+	c.Now = Now
+	c.Migrations = nil
+	c.MigrationLockWait = time.Second
+	require.NoError(t, c.Open())
+
+	return c, func() {
+		c.Session.DB("").DropDatabase()
+		c.Close()
+	}
+}
+
+func TestClient_Migrate(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c, cleanup := newMigrationClient(t)
+		defer cleanup()
+
+		var calls []int
+		c.Migrations = []mongo.Migration{
+			{Version: 2, Description: "Second", Up: func(db *mgo.Database) error {
+				calls = append(calls, 2)
+				return db.C("boards").Insert(bson.M{"name": "migrated"})
+			}},
+			{Version: 1, Description: "First", Up: func(db *mgo.Database) error {
+				calls = append(calls, 1)
+				return nil
+			}},
+		}
+
+		list, err := c.MigrationStatus()
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		require.Equal(t, 1, list[0].Version)
+		require.Nil(t, list[0].AppliedAt)
+
+		done, err := c.Migrate()
+		require.NoError(t, err)
+		require.Len(t, done, 2)
+		require.Equal(t, []int{1, 2}, calls)
+		require.Equal(t, "Second", done[1].Description)
+		require.NotNil(t, done[1].AppliedAt)
+
+		n, err := c.Session.DB("").C("boards").Find(bson.M{"name": "migrated"}).Count()
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		// applied migrations are skipped
+		done, err = c.Migrate()
+		require.NoError(t, err)
+		require.Empty(t, done)
+		require.Equal(t, []int{1, 2}, calls)
+
+		list, err = c.MigrationStatus()
+		require.NoError(t, err)
+		require.NotNil(t, list[1].AppliedAt)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		c, cleanup := newMigrationClient(t)
+		defer cleanup()
+
+		c.Migrations = []mongo.Migration{
+			{Version: 1, Description: "First", Up: func(db *mgo.Database) error { return nil }},
+			{Version: 2, Description: "Broken", Up: func(db *mgo.Database) error { return errors.New("broken") }},
+		}
+
+		done, err := c.Migrate()
+		require.EqualError(t, err, "migration 2: broken")
+		require.Len(t, done, 1)
+
+		list, err := c.MigrationStatus()
+		require.NoError(t, err)
+		require.NotNil(t, list[0].AppliedAt)
+		require.Nil(t, list[1].AppliedAt)
+
+		// the lock was released
+		c.Migrations[1].Up = func(db *mgo.Database) error { return nil }
+		done, err = c.Migrate()
+		require.NoError(t, err)
+		require.Len(t, done, 1)
+	})
+
+	t.Run("Locked", func(t *testing.T) {
+		c, cleanup := newMigrationClient(t)
+		defer cleanup()
+
+		err := c.Session.DB("").C("migrationLock").Insert(bson.M{
+			"_id":       "migrations",
+			"owner":     "other",
+			"expiresAt": Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+
+		_, err = c.Migrate()
+		require.Equal(t, mongo.ErrMigrationLocked, err)
+
+		// an expired lock is taken over
+		err = c.Session.DB("").C("migrationLock").UpdateId("migrations", bson.M{
+			"$set": bson.M{"expiresAt": Now().Add(-time.Minute)},
+		})
+		require.NoError(t, err)
+
+		_, err = c.Migrate()
+		require.NoError(t, err)
+	})
+	t.Run("LockLost", func(t *testing.T) {
+		c, cleanup := newMigrationClient(t)
+		defer cleanup()
+
+		var calls []int
+		c.Migrations = []mongo.Migration{
+			{Version: 1, Description: "First", Up: func(db *mgo.Database) error {
+				calls = append(calls, 1)
+
+				// the lock expired and another process took it
+				return db.C("migrationLock").UpdateId("migrations", bson.M{
+					"$set": bson.M{"owner": "other"},
+				})
+			}},
+			{Version: 2, Description: "Second", Up: func(db *mgo.Database) error {
+				calls = append(calls, 2)
+				return nil
+			}},
+		}
+
+		done, err := c.Migrate()
+		require.EqualError(t, err, "migration 1: "+mongo.ErrMigrationLockLost.Error())
+		require.Empty(t, done)
+		require.Equal(t, []int{1}, calls)
+
+		// the lock of the other process is kept
+		n, err := c.Session.DB("").C("migrationLock").Find(bson.M{"owner": "other"}).Count()
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+	})
+}
+
+func TestMigrations_ClaimNamespaces(t *testing.T) {
+	c, cleanup := newMigrationClient(t)
+	defer cleanup()
+
+	db := c.Session.DB("")
+	userID, orgID := bson.NewObjectId(), bson.NewObjectId()
+	require.NoError(t, db.C("users").Insert(bson.M{"_id": userID, "login": "arya"}))
+	require.NoError(t, db.C("organizations").Insert(
+		bson.M{"_id": orgID, "slug": "winterfell"},
+		// created concurrently by a previous version
+		bson.M{"_id": bson.NewObjectId(), "slug": "arya"},
+	))
+
+	c.Migrations = mongo.Migrations
+	_, err := c.Migrate()
+	require.NoError(t, err)
+
+	var namespaces []bson.M
+	require.NoError(t, db.C("namespaces").Find(nil).Sort("_id").All(&namespaces))
+	require.Equal(t, []bson.M{
+		{"_id": "arya", "ownerID": userID},
+		{"_id": "winterfell", "ownerID": orgID},
+	}, namespaces)
+}
@@ -0,0 +1,395 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/Machiel/slugify"
+	"github.com/blankrobot/pulpe"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const organizationCol = "organizations"
+
+// Ensure OrganizationService implements pulpe.OrganizationService.
+var _ pulpe.OrganizationService = new(OrganizationService)
+
+// organization representation stored in MongoDB.
+type organization struct {
+	ID        bson.ObjectId        `bson:"_id"`
+	UpdatedAt *time.Time           `bson:"updatedAt,omitempty"`
+	Name      string               `bson:"name"`
+	Slug      string               `bson:"slug"`
+	Members   []organizationMember `bson:"members"`
+}
+
+// organizationMember links a user to an organization.
+type organizationMember struct {
+	UserID string `bson:"userID"`
+	Role   string `bson:"role"`
+}
+
+// toPulpeOrganization creates a pulpe organization from a mongo organization.
+// Members are not included.
+func (o *organization) toPulpeOrganization() *pulpe.Organization {
+	p := pulpe.Organization{
+		ID:        o.ID.Hex(),
+		CreatedAt: o.ID.Time().UTC(),
+		Name:      o.Name,
+		Slug:      o.Slug,
+	}
+
+	if o.UpdatedAt != nil {
+		t := (*o.UpdatedAt).UTC()
+		p.UpdatedAt = &t
+	}
+
+	return &p
+}
+
+// role returns the role of the given user, or an empty string if it's not a member.
+func (o *organization) role(userID string) string {
+	for _, m := range o.Members {
+		if m.UserID == userID {
+			return m.Role
+		}
+	}
+
+	return ""
+}
+
+// OrganizationService represents a service for managing organizations.
+type OrganizationService struct {
+	session *Session
+}
+
+func (s *OrganizationService) ensureIndexes() error {
+	col := s.session.db.C(organizationCol)
+
+	// Unique slug
+	index := mgo.Index{
+		Key:    []string{"slug"},
+		Unique: true,
+		Sparse: true,
+	}
+
+	err := col.EnsureIndex(index)
+	if err != nil {
+		return err
+	}
+
+	// Organizations of a user
+	index = mgo.Index{
+		Key: []string{"members.userID"},
+	}
+
+	return col.EnsureIndex(index)
+}
+
+// CreateOrganization creates an organization administrated by the authenticated user.
+func (s *OrganizationService) CreateOrganization(oc *pulpe.OrganizationCreation) (*pulpe.Organization, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	col := s.session.db.C(organizationCol)
+
+	o := organization{
+		ID:   bson.NewObjectId(),
+		Name: oc.Name,
+		Slug: slugify.Slugify(oc.Name),
+		Members: []organizationMember{
+			{UserID: user.ID, Role: pulpe.OrganizationRoleAdmin},
+		},
+	}
+
+	if o.Slug == "" {
+		o.Slug = "organization"
+	}
+
+	// organization slugs and user logins share the same namespace
+	_, err = resolveSlugAndDo(col, "", "slug", o.Slug, "-", func(slug string) error {
+		o.Slug = slug
+		return insertWithNamespace(s.session.db, slug, o.ID, func() error {
+			return col.Insert(&o)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withMembers(&o)
+}
+
+// Organization returns an organization of the authenticated user by slug.
+func (s *OrganizationService) Organization(slug string) (*pulpe.Organization, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := s.organizationBySlug(user.ID, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.withMembers(o)
+}
+
+// Organizations returns the organizations of the authenticated user.
+func (s *OrganizationService) Organizations() ([]*pulpe.Organization, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []organization
+	err = s.session.db.C(organizationCol).Find(bson.M{"members.userID": user.ID}).Sort("_id").All(&docs)
+	if err != nil {
+		return nil, err
+	}
+
+	orgs := make([]*pulpe.Organization, len(docs))
+	for i := range docs {
+		orgs[i] = docs[i].toPulpeOrganization()
+	}
+
+	return orgs, nil
+}
+
+// SetMember adds a user to the organization or changes its role.
+func (s *OrganizationService) SetMember(slug, login, role string) (*pulpe.Organization, error) {
+	if role != pulpe.OrganizationRoleAdmin && role != pulpe.OrganizationRoleMember {
+		return nil, pulpe.ErrOrganizationInvalidRole
+	}
+
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.session.userService.userBy(bson.M{"login": login})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateMembers(user.ID, slug, func(o *organization) error {
+		if o.role(user.ID) != pulpe.OrganizationRoleAdmin {
+			return pulpe.ErrOrganizationForbidden
+		}
+
+		for i := range o.Members {
+			if o.Members[i].UserID == member.ID {
+				o.Members[i].Role = role
+				return nil
+			}
+		}
+
+		o.Members = append(o.Members, organizationMember{UserID: member.ID, Role: role})
+		return nil
+	})
+}
+
+// RemoveMember removes a user from the organization.
+// Admins can remove any member and members can leave the organization.
+func (s *OrganizationService) RemoveMember(slug, login string) (*pulpe.Organization, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.session.userService.userBy(bson.M{"login": login})
+	if err != nil {
+		if err == pulpe.ErrUserNotFound {
+			return nil, pulpe.ErrOrganizationMemberNotFound
+		}
+		return nil, err
+	}
+
+	return s.updateMembers(user.ID, slug, func(o *organization) error {
+		if member.ID != user.ID && o.role(user.ID) != pulpe.OrganizationRoleAdmin {
+			return pulpe.ErrOrganizationForbidden
+		}
+
+		for i := range o.Members {
+			if o.Members[i].UserID == member.ID {
+				o.Members = append(o.Members[:i], o.Members[i+1:]...)
+				return nil
+			}
+		}
+
+		return pulpe.ErrOrganizationMemberNotFound
+	})
+}
+
+// updateMembers applies fn to the members of the organization and saves them
+// if they were not modified concurrently. An organization always keeps at least one admin.
+func (s *OrganizationService) updateMembers(userID, slug string, fn func(*organization) error) (*pulpe.Organization, error) {
+	col := s.session.db.C(organizationCol)
+
+	for {
+		o, err := s.organizationBySlug(userID, slug)
+		if err != nil {
+			return nil, err
+		}
+
+		previous := make([]organizationMember, len(o.Members))
+		copy(previous, o.Members)
+
+		err = fn(o)
+		if err != nil {
+			return nil, err
+		}
+
+		var admins int
+		for _, m := range o.Members {
+			if m.Role == pulpe.OrganizationRoleAdmin {
+				admins++
+			}
+		}
+
+		if admins == 0 {
+			return nil, pulpe.ErrOrganizationLastAdmin
+		}
+
+		err = col.Update(
+			bson.M{"_id": o.ID, "members": previous},
+			bson.M{
+				"$set":         bson.M{"members": o.Members},
+				"$currentDate": bson.M{"updatedAt": true},
+			})
+		if err == mgo.ErrNotFound {
+			// the members were modified in the meantime, try again
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return s.withMembers(o)
+	}
+}
+
+// organizationBySlug returns the organization if the given user is a member.
+func (s *OrganizationService) organizationBySlug(userID, slug string) (*organization, error) {
+	var o organization
+
+	err := s.session.db.C(organizationCol).Find(bson.M{
+		"slug":           slug,
+		"members.userID": userID,
+	}).One(&o)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+// memberRole returns the role of the user in the organization with the given id.
+func (s *OrganizationService) memberRole(id, userID string) (string, error) {
+	var o organization
+
+	if !bson.IsObjectIdHex(id) {
+		return "", pulpe.ErrOrganizationNotFound
+	}
+
+	err := s.session.db.C(organizationCol).FindId(bson.ObjectIdHex(id)).One(&o)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return "", pulpe.ErrOrganizationNotFound
+		}
+		return "", err
+	}
+
+	role := o.role(userID)
+	if role == "" {
+		return "", pulpe.ErrOrganizationNotFound
+	}
+
+	return role, nil
+}
+
+// organizationsByIDs returns the organizations matching the given ids, indexed by id.
+func (s *OrganizationService) organizationsByIDs(ids []string) (map[string]*pulpe.Organization, error) {
+	oids := make([]bson.ObjectId, 0, len(ids))
+	for _, id := range ids {
+		if bson.IsObjectIdHex(id) {
+			oids = append(oids, bson.ObjectIdHex(id))
+		}
+	}
+
+	var docs []organization
+	err := s.session.db.C(organizationCol).Find(bson.M{"_id": bson.M{"$in": oids}}).Select(bson.M{"members": 0}).All(&docs)
+	if err != nil {
+		return nil, err
+	}
+
+	orgs := make(map[string]*pulpe.Organization, len(docs))
+	for i := range docs {
+		orgs[docs[i].ID.Hex()] = docs[i].toPulpeOrganization()
+	}
+
+	return orgs, nil
+}
+
+// ownerIDs returns the id of the user followed by the ids of its organizations.
+// Boards, lists and cards owned by any of them are accessible to the user.
+func (s *OrganizationService) ownerIDs(userID string) ([]string, error) {
+	var docs []organization
+
+	err := s.session.db.C(organizationCol).Find(bson.M{"members.userID": userID}).Select(bson.M{"_id": 1}).All(&docs)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(docs)+1)
+	ids = append(ids, userID)
+	for i := range docs {
+		ids = append(ids, docs[i].ID.Hex())
+	}
+
+	return ids, nil
+}
+
+// withMembers returns the pulpe organization with the logins and names of its members.
+func (s *OrganizationService) withMembers(o *organization) (*pulpe.Organization, error) {
+	ids := make([]bson.ObjectId, 0, len(o.Members))
+	for _, m := range o.Members {
+		if bson.IsObjectIdHex(m.UserID) {
+			ids = append(ids, bson.ObjectIdHex(m.UserID))
+		}
+	}
+
+	var us []user
+	err := s.session.db.C(userCol).Find(bson.M{"_id": bson.M{"$in": ids}}).Select(bson.M{"login": 1, "fullName": 1}).All(&us)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]*user, len(us))
+	for i := range us {
+		users[us[i].ID.Hex()] = &us[i]
+	}
+
+	p := o.toPulpeOrganization()
+	p.Members = make([]*pulpe.OrganizationMember, 0, len(o.Members))
+	for _, m := range o.Members {
+		u, ok := users[m.UserID]
+		if !ok {
+			continue
+		}
+
+		p.Members = append(p.Members, &pulpe.OrganizationMember{
+			UserID:   m.UserID,
+			Login:    u.Login,
+			FullName: u.FullName,
+			Role:     m.Role,
+		})
+	}
+
+	return p, nil
+}
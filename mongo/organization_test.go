@@ -0,0 +1,294 @@
+package mongo_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/stretchr/testify/require"
+)
+
+func newOrganization(t require.TestingT, session *Session, name string) *pulpe.Organization {
+	org, err := session.OrganizationService().CreateOrganization(&pulpe.OrganizationCreation{
+		Name: name,
+	})
+	require.NoError(t, err)
+
+	return org
+}
+
+func TestOrganizationService_CreateOrganization(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := sessions.NoAuth.OrganizationService().CreateOrganization(&pulpe.OrganizationCreation{Name: "Winterfell"})
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("New", func(t *testing.T) {
+		s := sessions.Red.OrganizationService()
+
+		org, err := s.CreateOrganization(&pulpe.OrganizationCreation{Name: "House Stark"})
+		require.NoError(t, err)
+		require.NotZero(t, org.ID)
+		require.Equal(t, "house-stark", org.Slug)
+		require.Len(t, org.Members, 1)
+		require.Equal(t, "red", org.Members[0].Login)
+		require.Equal(t, pulpe.OrganizationRoleAdmin, org.Members[0].Role)
+
+		other, err := s.Organization(org.Slug)
+		require.NoError(t, err)
+		require.Equal(t, org, other)
+
+		orgs, err := s.Organizations()
+		require.NoError(t, err)
+		require.Len(t, orgs, 1)
+		require.Equal(t, org.ID, orgs[0].ID)
+
+		// other users don't see the organization
+		_, err = sessions.Blue.OrganizationService().Organization(org.Slug)
+		require.Equal(t, pulpe.ErrOrganizationNotFound, err)
+	})
+
+	t.Run("Namespace", func(t *testing.T) {
+		// the slug conflicts with the login of a user
+		org := newOrganization(t, sessions.Red, "Blue")
+		require.Equal(t, "blue-1", org.Slug)
+
+		org = newOrganization(t, sessions.Red, "Blue")
+		require.Equal(t, "blue-2", org.Slug)
+
+		// the slug is reserved
+		org = newOrganization(t, sessions.Red, "Login")
+		require.Equal(t, "login-1", org.Slug)
+
+		// the login conflicts with the slug of an organization
+		newOrganization(t, sessions.Red, "Sansa")
+		user := createUser(t, sessions.NoAuth.Session, "Sansa")
+		require.Equal(t, "sansa1", user.Login)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		slugs := make(chan string, 10)
+		errs := make(chan error, 10)
+
+		for i := 0; i < 5; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				org, err := sessions.Red.OrganizationService().CreateOrganization(&pulpe.OrganizationCreation{Name: "Tully"})
+				if err != nil {
+					errs <- err
+					return
+				}
+				slugs <- org.Slug
+			}()
+			go func(i int) {
+				defer wg.Done()
+				user, err := sessions.NoAuth.UserService().Register(&pulpe.UserRegistration{
+					FullName: "Tully",
+					Email:    fmt.Sprintf("tully-%d@provider.com", i),
+					Password: userPassword,
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+				slugs <- user.Login
+			}(i)
+		}
+		wg.Wait()
+		close(slugs)
+		close(errs)
+
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		// user logins and organization slugs never collide
+		taken := make(map[string]bool)
+		for slug := range slugs {
+			require.False(t, taken[slug], slug)
+			taken[slug] = true
+		}
+		require.Len(t, taken, 10)
+	})
+}
+
+func TestOrganizationService_SetMember(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	org := newOrganization(t, sessions.Red, "Winterfell")
+
+	blue, err := sessions.Blue.Authenticate()
+	require.NoError(t, err)
+
+	t.Run("NotAMember", func(t *testing.T) {
+		_, err := sessions.Blue.OrganizationService().SetMember(org.Slug, blue.Login, pulpe.OrganizationRoleAdmin)
+		require.Equal(t, pulpe.ErrOrganizationNotFound, err)
+	})
+
+	t.Run("InvalidRole", func(t *testing.T) {
+		_, err := sessions.Red.OrganizationService().SetMember(org.Slug, blue.Login, "king")
+		require.Equal(t, pulpe.ErrOrganizationInvalidRole, err)
+	})
+
+	t.Run("UnknownUser", func(t *testing.T) {
+		_, err := sessions.Red.OrganizationService().SetMember(org.Slug, "unknown", pulpe.OrganizationRoleMember)
+		require.Equal(t, pulpe.ErrUserNotFound, err)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		updated, err := sessions.Red.OrganizationService().SetMember(org.Slug, blue.Login, pulpe.OrganizationRoleMember)
+		require.NoError(t, err)
+		require.Len(t, updated.Members, 2)
+		require.Equal(t, blue.ID, updated.Members[1].UserID)
+		require.Equal(t, pulpe.OrganizationRoleMember, updated.Members[1].Role)
+
+		// members can see the organization but can't manage it
+		_, err = sessions.Blue.OrganizationService().Organization(org.Slug)
+		require.NoError(t, err)
+
+		_, err = sessions.Blue.OrganizationService().SetMember(org.Slug, blue.Login, pulpe.OrganizationRoleAdmin)
+		require.Equal(t, pulpe.ErrOrganizationForbidden, err)
+	})
+
+	t.Run("LastAdmin", func(t *testing.T) {
+		red, err := sessions.Red.Authenticate()
+		require.NoError(t, err)
+
+		_, err = sessions.Red.OrganizationService().SetMember(org.Slug, red.Login, pulpe.OrganizationRoleMember)
+		require.Equal(t, pulpe.ErrOrganizationLastAdmin, err)
+
+		// promote another admin first
+		_, err = sessions.Red.OrganizationService().SetMember(org.Slug, blue.Login, pulpe.OrganizationRoleAdmin)
+		require.NoError(t, err)
+
+		updated, err := sessions.Red.OrganizationService().SetMember(org.Slug, red.Login, pulpe.OrganizationRoleMember)
+		require.NoError(t, err)
+		require.Equal(t, pulpe.OrganizationRoleMember, updated.Members[0].Role)
+	})
+}
+
+func TestOrganizationService_RemoveMember(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	org := newOrganization(t, sessions.Red, "Winterfell")
+
+	red, err := sessions.Red.Authenticate()
+	require.NoError(t, err)
+	blue, err := sessions.Blue.Authenticate()
+	require.NoError(t, err)
+	green, err := sessions.Green.Authenticate()
+	require.NoError(t, err)
+
+	_, err = sessions.Red.OrganizationService().SetMember(org.Slug, blue.Login, pulpe.OrganizationRoleMember)
+	require.NoError(t, err)
+	_, err = sessions.Red.OrganizationService().SetMember(org.Slug, green.Login, pulpe.OrganizationRoleMember)
+	require.NoError(t, err)
+
+	t.Run("Forbidden", func(t *testing.T) {
+		_, err := sessions.Blue.OrganizationService().RemoveMember(org.Slug, green.Login)
+		require.Equal(t, pulpe.ErrOrganizationForbidden, err)
+	})
+
+	t.Run("Leave", func(t *testing.T) {
+		_, err := sessions.Blue.OrganizationService().RemoveMember(org.Slug, blue.Login)
+		require.NoError(t, err)
+
+		_, err = sessions.Blue.OrganizationService().Organization(org.Slug)
+		require.Equal(t, pulpe.ErrOrganizationNotFound, err)
+
+		_, err = sessions.Red.OrganizationService().RemoveMember(org.Slug, blue.Login)
+		require.Equal(t, pulpe.ErrOrganizationMemberNotFound, err)
+	})
+
+	t.Run("ByAdmin", func(t *testing.T) {
+		updated, err := sessions.Red.OrganizationService().RemoveMember(org.Slug, green.Login)
+		require.NoError(t, err)
+		require.Len(t, updated.Members, 1)
+	})
+
+	t.Run("LastAdmin", func(t *testing.T) {
+		_, err := sessions.Red.OrganizationService().RemoveMember(org.Slug, red.Login)
+		require.Equal(t, pulpe.ErrOrganizationLastAdmin, err)
+	})
+}
+
+func TestOrganizationService_Boards(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	org := newOrganization(t, sessions.Red, "Winterfell")
+
+	blue, err := sessions.Blue.Authenticate()
+	require.NoError(t, err)
+
+	_, err = sessions.Red.OrganizationService().SetMember(org.Slug, blue.Login, pulpe.OrganizationRoleMember)
+	require.NoError(t, err)
+
+	board, err := sessions.Red.BoardService().CreateBoard(&pulpe.BoardCreation{
+		Name:         "Crypt",
+		Organization: org.Slug,
+	})
+	require.NoError(t, err)
+	require.Nil(t, board.Owner)
+	require.Equal(t, org.ID, board.Organization.ID)
+	require.Equal(t, "winterfell", board.Namespace())
+
+	t.Run("NotAMember", func(t *testing.T) {
+		_, err := sessions.Green.BoardService().CreateBoard(&pulpe.BoardCreation{
+			Name:         "Crypt",
+			Organization: org.Slug,
+		})
+		require.Equal(t, pulpe.ErrOrganizationNotFound, err)
+
+		_, err = sessions.Green.BoardService().Board(board.ID)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+		_, err = sessions.Green.BoardService().BoardByOwnerAndSlug(org.Slug, board.Slug)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+
+	t.Run("Member", func(t *testing.T) {
+		s := sessions.Blue
+
+		other, err := s.BoardService().BoardByOwnerAndSlug(org.Slug, board.Slug)
+		require.NoError(t, err)
+		require.Equal(t, board.ID, other.ID)
+
+		boards, err := s.BoardService().Boards()
+		require.NoError(t, err)
+		require.Len(t, boards, 1)
+		require.Equal(t, org.Slug, boards[0].Organization.Slug)
+
+		// members share the lists and cards of the board
+		list := newListWithBoardID(t, s, board.ID)
+		require.Equal(t, org.ID, list.OwnerID)
+
+		card, err := sessions.Red.CardService().CreateCard(list.ID, &pulpe.CardCreation{Name: "Ned"})
+		require.NoError(t, err)
+
+		name := "Eddard"
+		_, err = s.CardService().UpdateCard(card.ID, &pulpe.CardUpdate{Name: &name})
+		require.NoError(t, err)
+
+		_, err = sessions.Green.CardService().Card(card.ID)
+		require.Equal(t, pulpe.ErrCardNotFound, err)
+
+		// only admins can delete organization boards
+		err = s.BoardService().DeleteBoard(board.ID)
+		require.Equal(t, pulpe.ErrOrganizationForbidden, err)
+
+		err = sessions.Red.BoardService().DeleteBoard(board.ID)
+		require.NoError(t, err)
+
+		_, err = s.CardService().Card(card.ID)
+		require.Equal(t, pulpe.ErrCardNotFound, err)
+	})
+}
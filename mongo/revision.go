@@ -0,0 +1,234 @@
+package mongo
+
+import (
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/diff"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const revisionCol = "revisions"
+
+// Ensure RevisionService implements pulpe.RevisionService.
+var _ pulpe.RevisionService = new(RevisionService)
+
+// revision representation stored in MongoDB.
+// Revisions of deleted cards are kept, so that they are still there if the deletion is undone.
+type revision struct {
+	ID          bson.ObjectId `bson:"_id"`
+	CardID      string        `bson:"cardID"`
+	AuthorID    string        `bson:"authorID,omitempty"`
+	Name        string        `bson:"name"`
+	Description string        `bson:"description"`
+	CreatedAt   time.Time     `bson:"createdAt"`
+}
+
+func (r *revision) toPulpeRevision() *pulpe.Revision {
+	return &pulpe.Revision{
+		ID:          r.ID.Hex(),
+		CardID:      r.CardID,
+		CreatedAt:   r.CreatedAt.UTC(),
+		AuthorID:    r.AuthorID,
+		Name:        r.Name,
+		Description: r.Description,
+	}
+}
+
+// RevisionService represents a service for managing the revisions of cards.
+type RevisionService struct {
+	session *Session
+}
+
+func (s *RevisionService) ensureIndexes() error {
+	col := s.session.db.C(revisionCol)
+
+	// Revisions of a card
+	err := col.EnsureIndexKey("cardID", "_id")
+	if err != nil {
+		return err
+	}
+
+	// Expiration
+	return ensureTTLIndex(col, "createdAt", s.session.revisionRetention.MaxAge)
+}
+
+// record records the name and the description of a card after a change made by user.
+// before is nil when the card was just created.
+func (s *RevisionService) record(user *pulpe.User, before, after *card) error {
+	if before != nil && before.Name == after.Name && before.Description == after.Description {
+		return nil
+	}
+
+	col := s.session.db.C(revisionCol)
+	cardID := after.ID.Hex()
+
+	if before != nil {
+		n, err := col.Find(bson.M{"cardID": cardID}).Count()
+		if err != nil {
+			return err
+		}
+
+		// the content the card had before its first recorded change can be restored too
+		if n == 0 {
+			createdAt := before.ID.Time()
+			if before.UpdatedAt != nil {
+				createdAt = *before.UpdatedAt
+			}
+
+			err = col.Insert(&revision{
+				ID:          bson.NewObjectId(),
+				CardID:      cardID,
+				Name:        before.Name,
+				Description: before.Description,
+				CreatedAt:   createdAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err := col.Insert(&revision{
+		ID:          bson.NewObjectId(),
+		CardID:      cardID,
+		AuthorID:    user.ID,
+		Name:        after.Name,
+		Description: after.Description,
+		CreatedAt:   s.session.now,
+	})
+	if err != nil {
+		return err
+	}
+
+	max := s.session.revisionRetention.MaxCount
+	if max <= 0 {
+		return nil
+	}
+
+	// only the last revisions are kept
+	var last revision
+	err = col.Find(bson.M{"cardID": cardID}).Sort("-_id").Skip(max).Select(bson.M{"_id": 1}).One(&last)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = col.RemoveAll(bson.M{"cardID": cardID, "_id": bson.M{"$lte": last.ID}})
+	return err
+}
+
+// Revisions returns the revisions of a card, the most recent first.
+func (s *RevisionService) Revisions(cardID string) ([]*pulpe.Revision, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.session.cardService.cardByID(user, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rs []revision
+	err = s.session.db.C(revisionCol).Find(bson.M{"cardID": cardID}).Sort("-_id").All(&rs)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*pulpe.Revision, len(rs))
+	for i := range rs {
+		list[i] = rs[i].toPulpeRevision()
+	}
+
+	return list, nil
+}
+
+// DiffRevisions returns the changes from a revision of a card to another one.
+func (s *RevisionService) DiffRevisions(cardID, fromID, toID string) (*pulpe.RevisionDiff, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.session.cardService.cardByID(user, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := s.revisionByID(cardID, fromID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.revisionByID(cardID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pulpe.RevisionDiff{
+		From:        from.toPulpeRevision(),
+		To:          to.toPulpeRevision(),
+		Name:        toDiffLines(diff.Lines(from.Name, to.Name)),
+		Description: toDiffLines(diff.Lines(from.Description, to.Description)),
+	}, nil
+}
+
+// toDiffLines converts diff lines to pulpe diff lines.
+func toDiffLines(lines []diff.Line) []*pulpe.DiffLine {
+	dl := make([]*pulpe.DiffLine, len(lines))
+	for i, l := range lines {
+		dl[i] = &pulpe.DiffLine{Op: l.Op, Text: l.Text}
+	}
+
+	return dl
+}
+
+// RestoreRevision sets the name and the description of a card back to the ones of a revision.
+// Like any update, it records a new revision and can be undone.
+func (s *RevisionService) RestoreRevision(cardID, revisionID string) (*pulpe.Card, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.session.cardService.cardByID(user, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.revisionByID(cardID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.session.cardService.UpdateCard(cardID, &pulpe.CardUpdate{
+		Name:        &r.Name,
+		Description: &r.Description,
+	})
+}
+
+// revisionByID returns a revision of a card.
+func (s *RevisionService) revisionByID(cardID, id string) (*revision, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, pulpe.ErrRevisionNotFound
+	}
+
+	var r revision
+	err := s.session.db.C(revisionCol).Find(bson.M{
+		"_id":    bson.ObjectIdHex(id),
+		"cardID": cardID,
+	}).One(&r)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrRevisionNotFound
+		}
+
+		return nil, err
+	}
+
+	return &r, nil
+}
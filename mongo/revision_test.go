@@ -0,0 +1,112 @@
+package mongo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mongo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevisionService(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.RevisionService()
+	cards := sessions.Red.CardService()
+	list := newList(t, sessions.Red)
+
+	card, err := cards.CreateCard(list.ID, &pulpe.CardCreation{Name: "name", Description: "title\nold"})
+	require.NoError(t, err)
+
+	description := "title\nnew"
+	_, err = cards.UpdateCard(card.ID, &pulpe.CardUpdate{Description: &description})
+	require.NoError(t, err)
+
+	// changes of other fields aren't recorded
+	pos := 10.0
+	_, err = cards.UpdateCard(card.ID, &pulpe.CardUpdate{Position: &pos})
+	require.NoError(t, err)
+
+	t.Run("Revisions", func(t *testing.T) {
+		revisions, err := s.Revisions(card.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		require.Equal(t, description, revisions[0].Description)
+		require.Equal(t, "title\nold", revisions[1].Description)
+		require.NotEmpty(t, revisions[1].AuthorID)
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		revisions, err := s.Revisions(card.ID)
+		require.NoError(t, err)
+
+		d, err := s.DiffRevisions(card.ID, revisions[1].ID, revisions[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, []*pulpe.DiffLine{{Op: pulpe.DiffEqual, Text: "name"}}, d.Name)
+		require.Equal(t, []*pulpe.DiffLine{
+			{Op: pulpe.DiffEqual, Text: "title"},
+			{Op: pulpe.DiffDelete, Text: "old"},
+			{Op: pulpe.DiffInsert, Text: "new"},
+		}, d.Description)
+
+		_, err = s.DiffRevisions(card.ID, revisions[1].ID, newBoardID())
+		require.Equal(t, pulpe.ErrRevisionNotFound, err)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		revisions, err := s.Revisions(card.ID)
+		require.NoError(t, err)
+
+		c, err := s.RestoreRevision(card.ID, revisions[1].ID)
+		require.NoError(t, err)
+		require.Equal(t, "title\nold", c.Description)
+		require.Equal(t, pos, c.Position)
+
+		revisions, err = s.Revisions(card.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 3)
+		require.Equal(t, "title\nold", revisions[0].Description)
+	})
+
+	t.Run("Card without revisions", func(t *testing.T) {
+		other := newList(t, sessions.Red)
+		cp, err := cards.CopyCard(card.ID, &pulpe.CardCopy{ListID: other.ID})
+		require.NoError(t, err)
+
+		name := "copy"
+		_, err = cards.UpdateCard(cp.ID, &pulpe.CardUpdate{Name: &name})
+		require.NoError(t, err)
+
+		// the content before the first change is kept too
+		revisions, err := s.Revisions(cp.ID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		require.Equal(t, "name", revisions[1].Name)
+		require.Empty(t, revisions[1].AuthorID)
+	})
+
+	t.Run("Bad user", func(t *testing.T) {
+		_, err := sessions.Blue.RevisionService().Revisions(card.ID)
+		require.Equal(t, pulpe.ErrCardNotFound, err)
+
+		revisions, err := s.Revisions(card.ID)
+		require.NoError(t, err)
+
+		_, err = sessions.Blue.RevisionService().RestoreRevision(card.ID, revisions[0].ID)
+		require.Equal(t, pulpe.ErrCardNotFound, err)
+	})
+}
+
+func TestRevisionService_RetentionChange(t *testing.T) {
+	_, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	// the expiration index follows the configured age, even when it is disabled
+	for _, age := range []time.Duration{time.Hour, 2 * time.Hour, 0, mongo.DefaultRevisionRetention.MaxAge} {
+		c := *client.Client
+		c.RevisionRetention.MaxAge = age
+		require.NoError(t, c.EnsureIndexes())
+	}
+}
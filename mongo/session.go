@@ -24,7 +24,15 @@ func newSession(session *mgo.Session) *Session {
 	s.cardService.session = &s
 	s.cardService.store.session = &s
 	s.userService.session = &s
+	s.organizationService.session = &s
+	s.invitationService.session = &s
 	s.userSessionService.session = &s
+	s.twoFactorService.session = &s
+	s.loginAttemptService.session = &s
+	s.adminService.session = &s
+	s.syncService.session = &s
+	s.historyService.session = &s
+	s.revisionService.session = &s
 
 	return &s
 }
@@ -34,18 +42,36 @@ type Session struct {
 	session *mgo.Session
 	db      *mgo.Database
 
-	now time.Time
+	now                time.Time
+	sessionTimeouts    SessionTimeouts
+	loginLimits        LoginLimits
+	invitationLifetime time.Duration
+	tombstoneLifetime  time.Duration
+	revisionRetention  RevisionRetention
+	mailer             pulpe.Mailer
 
 	// Services
-	cardService        CardService
-	listService        ListService
-	boardService       BoardService
-	userService        UserService
-	userSessionService UserSessionService
-
-	authenticator pulpe.Authenticator
-	authToken     string
-	user          *pulpe.User
+	cardService         CardService
+	listService         ListService
+	boardService        BoardService
+	userService         UserService
+	organizationService OrganizationService
+	invitationService   InvitationService
+	userSessionService  UserSessionService
+	twoFactorService    TwoFactorService
+	loginAttemptService LoginAttemptService
+	adminService        AdminService
+	syncService         SyncService
+	historyService      HistoryService
+	revisionService     RevisionService
+
+	authenticator      pulpe.Authenticator
+	credentialVerifier pulpe.CredentialVerifier
+	authToken          string
+	user               *pulpe.User
+
+	// last session returned by GetSession, a request resolves its session once.
+	userSession *pulpe.UserSession
 }
 
 // CardService returns the session CardService
@@ -68,11 +94,51 @@ func (s *Session) UserService() pulpe.UserService {
 	return &s.userService
 }
 
+// OrganizationService returns the session OrganizationService
+func (s *Session) OrganizationService() pulpe.OrganizationService {
+	return &s.organizationService
+}
+
+// InvitationService returns the session InvitationService
+func (s *Session) InvitationService() pulpe.InvitationService {
+	return &s.invitationService
+}
+
 // UserSessionService returns the session UserSessionService
 func (s *Session) UserSessionService() pulpe.UserSessionService {
 	return &s.userSessionService
 }
 
+// TwoFactorService returns the session TwoFactorService
+func (s *Session) TwoFactorService() pulpe.TwoFactorService {
+	return &s.twoFactorService
+}
+
+// LoginAttemptService returns the session LoginAttemptService
+func (s *Session) LoginAttemptService() pulpe.LoginAttemptService {
+	return &s.loginAttemptService
+}
+
+// AdminService returns the session AdminService
+func (s *Session) AdminService() pulpe.AdminService {
+	return &s.adminService
+}
+
+// SyncService returns the session SyncService
+func (s *Session) SyncService() pulpe.SyncService {
+	return &s.syncService
+}
+
+// HistoryService returns the session HistoryService
+func (s *Session) HistoryService() pulpe.HistoryService {
+	return &s.historyService
+}
+
+// RevisionService returns the session RevisionService
+func (s *Session) RevisionService() pulpe.RevisionService {
+	return &s.revisionService
+}
+
 // Authenticate returns the current authenticate user.
 func (s *Session) Authenticate() (*pulpe.User, error) {
 	if s.user != nil {
@@ -59,6 +59,34 @@ func MustGetSessions(t require.TestingT) (*Sessions, func()) {
 
 		_, err = client.Session.DB("").C("cards").RemoveAll(nil)
 		require.NoError(t, err)
+
+		_, err = client.Session.DB("").C("loginAttempts").RemoveAll(nil)
+		require.NoError(t, err)
+
+		_, err = client.Session.DB("").C("lockouts").RemoveAll(nil)
+		require.NoError(t, err)
+
+		_, err = client.Session.DB("").C("organizations").RemoveAll(nil)
+		require.NoError(t, err)
+
+		_, err = client.Session.DB("").C("namespaces").RemoveAll(bson.M{
+			"_id": bson.M{
+				"$nin": []string{"red", "blue", "green"},
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = client.Session.DB("").C("invitations").RemoveAll(nil)
+		require.NoError(t, err)
+
+		_, err = client.Session.DB("").C("tombstones").RemoveAll(nil)
+		require.NoError(t, err)
+
+		_, err = client.Session.DB("").C("history").RemoveAll(nil)
+		require.NoError(t, err)
+
+		_, err = client.Session.DB("").C("revisions").RemoveAll(nil)
+		require.NoError(t, err)
 	}
 }
 
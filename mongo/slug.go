@@ -1,6 +1,7 @@
 package mongo
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -9,6 +10,117 @@ import (
 	"gopkg.in/mgo.v2/bson"
 )
 
+// errNamespaceTaken is returned by slug actions when a name is reserved or
+// already claimed by a user login or an organization slug.
+var errNamespaceTaken = errors.New("namespace already taken")
+
+// reservedNamespaces can't be used as user logins or organization slugs
+// because they collide with the page routes.
+var reservedNamespaces = map[string]bool{
+	"api":    true,
+	"assets": true,
+	"auth":   true,
+	"join":   true,
+	"login":  true,
+	"logout": true,
+}
+
+// namespaceCol holds the user logins and organization slugs in use.
+const namespaceCol = "namespaces"
+
+// namespace is a user login or an organization slug.
+// Its id is the name, so that a name can only be claimed once.
+type namespace struct {
+	Name    string        `bson:"_id"`
+	OwnerID bson.ObjectId `bson:"ownerID"`
+}
+
+// claimNamespace reserves name for the user or organization with the given id,
+// before it is inserted. It returns errNamespaceTaken if name is reserved or
+// already claimed.
+func claimNamespace(db *mgo.Database, name string, ownerID bson.ObjectId) error {
+	if reservedNamespaces[name] {
+		return errNamespaceTaken
+	}
+
+	err := db.C(namespaceCol).Insert(&namespace{Name: name, OwnerID: ownerID})
+	if mgo.IsDup(err) {
+		return errNamespaceTaken
+	}
+
+	return err
+}
+
+// releaseNamespace frees a name claimed for a user or organization that couldn't be inserted.
+func releaseNamespace(db *mgo.Database, name string, ownerID bson.ObjectId) error {
+	err := db.C(namespaceCol).Remove(bson.M{"_id": name, "ownerID": ownerID})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+
+	return err
+}
+
+// insertWithNamespace claims name for ownerID, then calls insert.
+// The name is released if insert fails.
+func insertWithNamespace(db *mgo.Database, name string, ownerID bson.ObjectId, insert func() error) error {
+	err := claimNamespace(db, name, ownerID)
+	if err != nil {
+		return err
+	}
+
+	err = insert()
+	if err != nil {
+		if rerr := releaseNamespace(db, name, ownerID); rerr != nil {
+			return rerr
+		}
+	}
+
+	return err
+}
+
+// claimNamespaces claims the logins and slugs of the users and organizations
+// created by previous versions, which only checked them before inserting.
+// Names used by both a user and an organization stay with the first one claimed.
+func claimNamespaces(db *mgo.Database) error {
+	for _, c := range []struct{ col, field string }{
+		{userCol, "login"},
+		{organizationCol, "slug"},
+	} {
+		var doc bson.M
+		iter := db.C(c.col).Find(nil).Select(bson.M{c.field: 1}).Sort("_id").Iter()
+		for iter.Next(&doc) {
+			name, _ := doc[c.field].(string)
+			id, _ := doc["_id"].(bson.ObjectId)
+			if name == "" {
+				continue
+			}
+
+			err := db.C(namespaceCol).Insert(&namespace{Name: name, OwnerID: id})
+			if err != nil && !mgo.IsDup(err) {
+				iter.Close()
+				return err
+			}
+		}
+
+		err := iter.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isSlugConflict returns true if err was caused by an already used slug.
+func isSlugConflict(err error, slugField string) bool {
+	if err == errNamespaceTaken {
+		return true
+	}
+
+	return mgo.IsDup(err) && strings.Contains(err.Error(), slugField)
+}
+
 func resolveSlugAndDo(col *mgo.Collection, ownerID, slugField, slug, sep string, action func(string) error) (string, error) {
 	// try to execute the given action with the generated slug
 	err := action(slug)
@@ -17,7 +129,7 @@ func resolveSlugAndDo(col *mgo.Collection, ownerID, slugField, slug, sep string,
 		return slug, nil
 	}
 
-	if !mgo.IsDup(err) || !strings.Contains(err.Error(), slugField) {
+	if !isSlugConflict(err, slugField) {
 		// the action failed because of an unknown error, aborting
 		return "", err
 	}
@@ -42,12 +154,16 @@ func resolveSlugAndDo(col *mgo.Collection, ownerID, slugField, slug, sep string,
 		return "", err
 	}
 
-	lastSlug := distinctSlugs[0]
+	// the slug might only be taken in another namespace
+	var lastSlug string
+	if len(distinctSlugs) > 0 {
+		lastSlug = distinctSlugs[0]
+	}
 
 	// extract the counter from the slug
 	var counter int
-	if len(lastSlug) > len(slug) {
-		counterStr := lastSlug[len(slug)+1 : len(lastSlug)]
+	if len(lastSlug) > len(slug)+len(sep) {
+		counterStr := lastSlug[len(slug)+len(sep) : len(lastSlug)]
 		counter, err = strconv.Atoi(counterStr)
 		if err != nil {
 			return "", err
@@ -63,7 +179,7 @@ func resolveSlugAndDo(col *mgo.Collection, ownerID, slugField, slug, sep string,
 			return currentSlug, nil
 		}
 
-		if !mgo.IsDup(err) || !strings.Contains(err.Error(), slugField) {
+		if !isSlugConflict(err, slugField) {
 			return "", err
 		}
 	}
@@ -0,0 +1,855 @@
+package mongo
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/validation"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	tombstoneCol     = "tombstones"
+	syncOperationCol = "syncOperations"
+)
+
+// Sync operation errors, turned into rejections.
+var (
+	errSyncConflict = errors.New("mongo: sync operation is older than the last change")
+	errSyncInvalid  = errors.New("mongo: invalid sync operation")
+)
+
+// syncOverlap is subtracted from the time of a sync to build its token.
+// It covers the writes in progress and the clock differences between
+// the application servers and MongoDB, at the cost of a few duplicates.
+const syncOverlap = 5 * time.Second
+
+// syncClaimTimeout is the time after which an operation that is still being applied
+// is considered abandoned by the sync that claimed it.
+const syncClaimTimeout = time.Minute
+
+// Ensure SyncService implements pulpe.SyncService.
+var _ pulpe.SyncService = new(SyncService)
+
+// tombstone records the deletion of a board, a list or a card, or its move to another board.
+// Tombstones expire after the tombstone lifetime of the client, if any.
+type tombstone struct {
+	ID       bson.ObjectId `bson:"_id"`
+	Kind     string        `bson:"kind"`
+	TargetID string        `bson:"targetID"`
+	BoardID  string        `bson:"boardID"`
+	// OwnerID and MemberIDs are only set on board tombstones,
+	// they tell who could see the board.
+	OwnerID   string    `bson:"ownerID,omitempty"`
+	MemberIDs []string  `bson:"memberIDs,omitempty"`
+	DeletedAt time.Time `bson:"deletedAt"`
+}
+
+func (t *tombstone) toPulpeTombstone() *pulpe.Tombstone {
+	return &pulpe.Tombstone{
+		Kind:      t.Kind,
+		ID:        t.TargetID,
+		BoardID:   t.BoardID,
+		DeletedAt: t.DeletedAt.UTC(),
+	}
+}
+
+// bury records a tombstone.
+func (s *Session) bury(t *tombstone) error {
+	t.ID = bson.NewObjectId()
+	t.DeletedAt = s.now
+
+	return s.db.C(tombstoneCol).Insert(t)
+}
+
+// syncToken returns the token of a sync made at t.
+func syncToken(t time.Time) string {
+	ms := t.Add(-syncOverlap).UnixNano() / int64(time.Millisecond)
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(ms, 10)))
+}
+
+// parseSyncToken returns the time from which the changes are requested.
+func parseSyncToken(token string) (time.Time, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, pulpe.ErrSyncTokenInvalid
+	}
+
+	ms, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}, pulpe.ErrSyncTokenInvalid
+	}
+
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC(), nil
+}
+
+// syncOperation records an operation applied by a client, so that it is skipped when sent again.
+// Operations are forgotten after the tombstone lifetime of the client, if any.
+type syncOperation struct {
+	ID          bson.ObjectId `bson:"_id"`
+	UserID      string        `bson:"userID"`
+	OperationID string        `bson:"operationID"`
+	// Pending is set while the operation is being applied.
+	Pending bool `bson:"pending,omitempty"`
+	// EntityID is the ID of the created list or card.
+	EntityID  string    `bson:"entityID,omitempty"`
+	Reason    string    `bson:"reason,omitempty"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+// stampPatch records at as the time of the write of the fields of the patch.
+// Nested fields are stamped under their top-level field.
+func stampPatch(patch bson.M, at time.Time) {
+	fields := make([]string, 0, len(patch))
+	for k := range patch {
+		if i := strings.IndexByte(k, '.'); i >= 0 {
+			k = k[:i]
+		}
+		fields = append(fields, k)
+	}
+
+	for _, k := range fields {
+		patch["modifiedAt."+k] = at
+	}
+}
+
+// modifiedAt returns the time of the last write of a field. Fields written before
+// the times were recorded fall back to the last change, or to the creation, of the document.
+func modifiedAt(times map[string]time.Time, field string, updatedAt *time.Time, id bson.ObjectId) time.Time {
+	if t, ok := times[field]; ok {
+		return t
+	}
+
+	if len(times) == 0 && updatedAt != nil {
+		return *updatedAt
+	}
+
+	return id.Time()
+}
+
+// lastModified returns the time of the last write of any field.
+func lastModified(times map[string]time.Time, updatedAt *time.Time, id bson.ObjectId) time.Time {
+	if len(times) == 0 {
+		return modifiedAt(nil, "", updatedAt, id)
+	}
+
+	var last time.Time
+	for _, t := range times {
+		if t.After(last) {
+			last = t
+		}
+	}
+
+	return last
+}
+
+// changedSince returns the $or clauses matching the documents created or updated since t.
+// Documents that were never updated are matched by the creation time of their id.
+func changedSince(t time.Time) []bson.M {
+	return []bson.M{
+		{"updatedAt": bson.M{"$gte": t}},
+		{"_id": bson.M{"$gte": bson.NewObjectIdWithTime(t)}},
+	}
+}
+
+// SyncService represents a service for synchronizing clients.
+type SyncService struct {
+	session *Session
+}
+
+func (s *SyncService) ensureIndexes() error {
+	col := s.session.db.C(tombstoneCol)
+
+	// Expiration
+	index := mgo.Index{
+		Key:         []string{"deletedAt"},
+		ExpireAfter: s.session.tombstoneLifetime,
+	}
+
+	err := col.EnsureIndex(index)
+	if err != nil {
+		return err
+	}
+
+	// Content of a board
+	err = col.EnsureIndexKey("boardID", "deletedAt")
+	if err != nil {
+		return err
+	}
+
+	// Boards of a user
+	err = col.EnsureIndexKey("ownerID", "deletedAt")
+	if err != nil {
+		return err
+	}
+
+	err = col.EnsureIndexKey("memberIDs", "deletedAt")
+	if err != nil {
+		return err
+	}
+
+	col = s.session.db.C(syncOperationCol)
+
+	// Operations of a user
+	index = mgo.Index{
+		Key:    []string{"userID", "operationID"},
+		Unique: true,
+	}
+
+	err = col.EnsureIndex(index)
+	if err != nil {
+		return err
+	}
+
+	// Expiration
+	index = mgo.Index{
+		Key:         []string{"createdAt"},
+		ExpireAfter: s.session.tombstoneLifetime,
+	}
+
+	return col.EnsureIndex(index)
+}
+
+// since parses a sync token. It returns nil if the token is empty.
+// Tokens older than the tombstone lifetime are expired.
+func (s *SyncService) since(token string) (*time.Time, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	t, err := parseSyncToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := s.session.tombstoneLifetime
+	if lifetime > 0 && t.Before(s.session.now.Add(-lifetime)) {
+		return nil, pulpe.ErrSyncTokenExpired
+	}
+
+	return &t, nil
+}
+
+// BoardsChanges returns the boards of the authenticated user, of its organizations
+// and the ones it was invited to, created, updated or deleted since the token.
+// Boards the user lost access to are not reported.
+func (s *SyncService) BoardsChanges(token string) (*pulpe.Changes, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	since, err := s.since(token)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := pulpe.Changes{
+		Token: syncToken(s.session.now),
+	}
+
+	acc, err := s.session.access(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{"$or": acc.boards()}
+	if since != nil {
+		query = bson.M{"$and": []bson.M{query, {"$or": changedSince(*since)}}}
+	}
+
+	bs, err := s.session.boardService.store.findBoards(query, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ch.Boards, err = s.session.boardService.toPulpeBoards(user, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	if since != nil {
+		ch.Deleted, err = s.tombstones(bson.M{
+			"kind": pulpe.TombstoneBoard,
+			"$or": []bson.M{
+				{"ownerID": bson.M{"$in": acc.ownerIDs}},
+				{"memberIDs": user.ID},
+			},
+			"deletedAt": bson.M{"$gte": *since},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ch, nil
+}
+
+// BoardChanges returns the board with the lists and cards created, updated
+// or deleted since the token. Boards are readable by their members and,
+// read-only, by anyone if they are public or opened with a valid share token.
+func (s *SyncService) BoardChanges(boardID, token string, options ...pulpe.BoardGetOption) (*pulpe.Changes, error) {
+	user, err := s.session.Authenticate()
+	if err != nil && err != pulpe.ErrUserAuthenticationFailed {
+		return nil, err
+	}
+
+	// anonymous users can't know if a private board exists
+	notFound := pulpe.ErrBoardNotFound
+	if user == nil {
+		notFound = pulpe.ErrUserAuthenticationFailed
+	}
+
+	since, err := s.since(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts pulpe.BoardGetOptions
+
+	for i := range options {
+		options[i](&opts)
+	}
+
+	ch := pulpe.Changes{
+		Token: syncToken(s.session.now),
+	}
+
+	if !bson.IsObjectIdHex(boardID) {
+		return nil, notFound
+	}
+
+	var b board
+	err = s.session.db.C(boardCol).FindId(bson.ObjectIdHex(boardID)).One(&b)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, notFound
+		}
+
+		return nil, err
+	}
+
+	member, err := s.session.boardService.readAccess(user, &b, opts.ShareToken)
+	if err != nil {
+		if err == pulpe.ErrBoardNotFound {
+			return nil, notFound
+		}
+
+		return nil, err
+	}
+
+	board, err := s.session.boardService.toPulpeBoard(user, &b)
+	if err != nil {
+		return nil, err
+	}
+
+	board.ReadOnly = !member
+	ch.Boards = []*pulpe.Board{board}
+
+	query := bson.M{"boardID": boardID}
+	if since != nil {
+		query["$or"] = changedSince(*since)
+	}
+
+	var ls []list
+	err = s.session.db.C(listCol).Find(query).Sort("position", "_id").All(&ls)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range ls {
+		ch.Lists = append(ch.Lists, ls[i].toPulpeList())
+	}
+
+	cs, err := s.session.cardService.store.findCards(query, 0, "_id")
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range cs {
+		ch.Cards = append(ch.Cards, cs[i].toPulpeCard())
+	}
+
+	if since != nil {
+		ch.Deleted, err = s.tombstones(bson.M{
+			"boardID":   boardID,
+			"kind":      bson.M{"$in": []string{pulpe.TombstoneList, pulpe.TombstoneCard}},
+			"deletedAt": bson.M{"$gte": *since},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ch, nil
+}
+
+// tombstones returns the tombstones matching the query, oldest first.
+func (s *SyncService) tombstones(query bson.M) ([]*pulpe.Tombstone, error) {
+	var ts []tombstone
+	err := s.session.db.C(tombstoneCol).Find(query).Sort("deletedAt", "_id").All(&ts)
+	if err != nil {
+		return nil, err
+	}
+
+	var tombstones []*pulpe.Tombstone
+	for i := range ts {
+		tombstones = append(tombstones, ts[i].toPulpeTombstone())
+	}
+
+	return tombstones, nil
+}
+
+// Sync applies the operations of a client on a board, then returns the changes of the board
+// since the token. Operations already applied, or rejected, by a previous sync are skipped.
+func (s *SyncService) Sync(boardID, token string, ops []*pulpe.SyncOperation) (*pulpe.SyncResult, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	// the token is checked before anything is applied
+	_, err = s.since(token)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.session.boardService.boardByID(user, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	var r pulpe.SyncResult
+	ids := make(map[string]string)
+
+	for _, op := range ops {
+		done, err := s.claim(user, boardID, ids, op)
+		if err != nil {
+			return nil, err
+		}
+
+		if done.EntityID != "" {
+			ids[op.EntityID] = done.EntityID
+		}
+
+		if done.Reason != "" {
+			r.Rejected = append(r.Rejected, &pulpe.SyncRejection{
+				OperationID: op.ID,
+				Reason:      done.Reason,
+			})
+		}
+	}
+
+	if len(ids) > 0 {
+		r.IDs = ids
+	}
+
+	r.Changes, err = s.BoardChanges(boardID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// claim applies a sync operation once. The operation is recorded before it is applied, so that
+// the unique index lets a single sync apply it; its result is recorded once it is applied.
+func (s *SyncService) claim(user *pulpe.User, boardID string, ids map[string]string, op *pulpe.SyncOperation) (*syncOperation, error) {
+	col := s.session.db.C(syncOperationCol)
+
+	done := syncOperation{
+		ID:          bson.NewObjectId(),
+		UserID:      user.ID,
+		OperationID: op.ID,
+		Pending:     true,
+		CreatedAt:   s.session.now,
+	}
+
+	err := col.Insert(&done)
+	if mgo.IsDup(err) {
+		err = col.Find(bson.M{"userID": user.ID, "operationID": op.ID}).One(&done)
+		if err != nil {
+			return nil, err
+		}
+
+		if !done.Pending {
+			return &done, nil
+		}
+
+		if s.session.now.Sub(done.CreatedAt) < syncClaimTimeout {
+			return nil, pulpe.ErrSyncInProgress
+		}
+
+		// the sync that claimed the operation died while applying it: since it may have been
+		// applied, it is rejected rather than applied twice
+		done.Pending = false
+		done.Reason = pulpe.SyncRejectedConflict
+		err = col.UpdateId(done.ID, bson.M{
+			"$set":   bson.M{"reason": done.Reason},
+			"$unset": bson.M{"pending": ""},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	done.EntityID, done.Reason, err = s.apply(user, boardID, ids, op)
+	if err != nil {
+		// nothing was applied, the operation can be sent again
+		if rerr := col.RemoveId(done.ID); rerr != nil {
+			return nil, rerr
+		}
+
+		return nil, err
+	}
+
+	done.Pending = false
+	err = col.UpdateId(done.ID, bson.M{
+		"$set": bson.M{
+			"entityID": done.EntityID,
+			"reason":   done.Reason,
+		},
+		"$unset": bson.M{"pending": ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &done, nil
+}
+
+// apply applies a sync operation. It returns the ID of the created list or card,
+// or the reason why the operation was rejected.
+func (s *SyncService) apply(user *pulpe.User, boardID string, ids map[string]string, op *pulpe.SyncOperation) (string, string, error) {
+	// clients can't write in the future
+	at := op.Time.UTC()
+	if at.After(s.session.now) {
+		at = s.session.now
+	}
+
+	var id string
+	var err error
+
+	switch op.Entity {
+	case pulpe.SyncList:
+		id, err = s.applyList(user, boardID, ids, op, at)
+	case pulpe.SyncCard:
+		id, err = s.applyCard(user, boardID, ids, op, at)
+	default:
+		err = errSyncInvalid
+	}
+
+	switch {
+	case err == nil:
+		return id, "", nil
+	case err == pulpe.ErrBoardNotFound, err == pulpe.ErrListNotFound, err == pulpe.ErrCardNotFound:
+		return "", pulpe.SyncRejectedNotFound, nil
+	case err == errSyncConflict:
+		return "", pulpe.SyncRejectedConflict, nil
+	case err == errSyncInvalid, validation.IsError(err):
+		return "", pulpe.SyncRejectedInvalid, nil
+	}
+
+	return "", "", err
+}
+
+// applyList applies a sync operation on a list.
+func (s *SyncService) applyList(user *pulpe.User, boardID string, ids map[string]string, op *pulpe.SyncOperation, at time.Time) (string, error) {
+	target := resolveSyncID(ids, op.BoardID)
+
+	if op.Kind == pulpe.SyncCreate {
+		if op.Name == nil {
+			return "", errSyncInvalid
+		}
+
+		if target == nil {
+			target = &boardID
+		}
+
+		// operations only apply to the synced board
+		if *target != boardID {
+			return "", pulpe.ErrBoardNotFound
+		}
+
+		lc := pulpe.ListCreation{Name: *op.Name}
+		if op.Position != nil {
+			pos, err := s.freePosition(listCol, "boardID", *target, "", *op.Position)
+			if err != nil {
+				return "", err
+			}
+			lc.Position = pos
+		}
+
+		l, err := s.session.listService.CreateList(*target, &lc)
+		if err != nil {
+			return "", err
+		}
+
+		return l.ID, s.stamp(listCol, l.ID, syncedListFields, at)
+	}
+
+	id := *resolveSyncID(ids, &op.EntityID)
+	l, err := s.session.listService.listByID(user, id)
+	if err != nil {
+		if err == pulpe.ErrListNotFound && op.Kind == pulpe.SyncDelete {
+			// already deleted
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	if l.BoardID != boardID {
+		return "", pulpe.ErrListNotFound
+	}
+
+	switch op.Kind {
+	case pulpe.SyncUpdate, pulpe.SyncMove:
+		won := func(field string) bool {
+			return !at.Before(modifiedAt(l.ModifiedAt, field, l.UpdatedAt, l.ID))
+		}
+
+		var u pulpe.ListUpdate
+		if op.Name != nil && won("name") {
+			u.Name = op.Name
+		}
+
+		if target != nil && won("boardID") {
+			u.BoardID = target
+		}
+
+		if op.Position != nil && won("position") {
+			parent := l.BoardID
+			if u.BoardID != nil {
+				parent = *u.BoardID
+			}
+
+			pos, err := s.freePosition(listCol, "boardID", parent, l.ID, *op.Position)
+			if err != nil {
+				return "", err
+			}
+			u.Position = &pos
+		}
+
+		if u == (pulpe.ListUpdate{}) {
+			return "", errSyncConflict
+		}
+
+		_, err = s.session.listService.updateList(user, id, &u, at)
+		return "", err
+	case pulpe.SyncDelete:
+		if lastModified(l.ModifiedAt, l.UpdatedAt, l.ID).After(at) {
+			return "", errSyncConflict
+		}
+
+		err = s.session.listService.deleteList(l)
+		if err == pulpe.ErrListNotFound {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	return "", errSyncInvalid
+}
+
+// applyCard applies a sync operation on a card.
+func (s *SyncService) applyCard(user *pulpe.User, boardID string, ids map[string]string, op *pulpe.SyncOperation, at time.Time) (string, error) {
+	target := resolveSyncID(ids, op.ListID)
+
+	if op.Kind == pulpe.SyncCreate {
+		if op.Name == nil || target == nil {
+			return "", errSyncInvalid
+		}
+
+		// operations only apply to the synced board
+		l, err := s.session.listService.listByID(user, *target)
+		if err != nil {
+			return "", err
+		}
+
+		if l.BoardID != boardID {
+			return "", pulpe.ErrListNotFound
+		}
+
+		cc := pulpe.CardCreation{
+			Name:   *op.Name,
+			Labels: op.Labels,
+		}
+
+		if op.Description != nil {
+			cc.Description = *op.Description
+		}
+
+		if op.Due != nil && !op.Due.IsZero() {
+			cc.Due = op.Due
+		}
+
+		if op.Position != nil {
+			pos, err := s.freePosition(cardCol, "listID", *target, "", *op.Position)
+			if err != nil {
+				return "", err
+			}
+			cc.Position = pos
+		}
+
+		c, err := s.session.cardService.CreateCard(*target, &cc)
+		if err != nil {
+			return "", err
+		}
+
+		return c.ID, s.stamp(cardCol, c.ID, syncedCardFields, at)
+	}
+
+	id := *resolveSyncID(ids, &op.EntityID)
+	c, err := s.session.cardService.cardByID(user, id)
+	if err != nil {
+		if err == pulpe.ErrCardNotFound && op.Kind == pulpe.SyncDelete {
+			// already deleted
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	if c.BoardID != boardID {
+		return "", pulpe.ErrCardNotFound
+	}
+
+	switch op.Kind {
+	case pulpe.SyncUpdate, pulpe.SyncMove:
+		won := func(field string) bool {
+			return !at.Before(modifiedAt(c.ModifiedAt, field, c.UpdatedAt, c.ID))
+		}
+
+		var u pulpe.CardUpdate
+		var changed bool
+		if op.Name != nil && won("name") {
+			u.Name = op.Name
+			changed = true
+		}
+
+		if op.Description != nil && won("description") {
+			u.Description = op.Description
+			changed = true
+		}
+
+		if op.Labels != nil && won("labels") {
+			u.Labels = op.Labels
+			changed = true
+		}
+
+		if op.Due != nil && won("due") {
+			u.Due = op.Due
+			changed = true
+		}
+
+		if target != nil && won("listID") {
+			u.ListID = target
+			changed = true
+		}
+
+		if op.Position != nil && won("position") {
+			parent := c.ListID
+			if u.ListID != nil {
+				parent = *u.ListID
+			}
+
+			pos, err := s.freePosition(cardCol, "listID", parent, c.ID, *op.Position)
+			if err != nil {
+				return "", err
+			}
+			u.Position = &pos
+			changed = true
+		}
+
+		if !changed {
+			return "", errSyncConflict
+		}
+
+		_, err = s.session.cardService.updateCard(user, id, &u, at)
+		return "", err
+	case pulpe.SyncDelete:
+		if lastModified(c.ModifiedAt, c.UpdatedAt, c.ID).After(at) {
+			return "", errSyncConflict
+		}
+
+		err = s.session.cardService.deleteCard(c)
+		if err == pulpe.ErrCardNotFound {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	return "", errSyncInvalid
+}
+
+// resolveSyncID replaces a temporary ID by the ID of the list or card created for it.
+func resolveSyncID(ids map[string]string, id *string) *string {
+	if id == nil {
+		return nil
+	}
+
+	if created, ok := ids[*id]; ok {
+		return &created
+	}
+
+	return id
+}
+
+// freePosition returns the position if no other list of the board, or card of the list, has it.
+// Otherwise, it returns a position between it and the following one, so that items moved
+// to the same place by several clients are kept in the order of the operations.
+func (s *SyncService) freePosition(col, parentField, parentID string, exclude bson.ObjectId, pos float64) (float64, error) {
+	query := bson.M{
+		parentField: parentID,
+		"position":  pos,
+	}
+
+	if exclude != "" {
+		query["_id"] = bson.M{"$ne": exclude}
+	}
+
+	n, err := s.session.db.C(col).Find(query).Count()
+	if err != nil || n == 0 {
+		return pos, err
+	}
+
+	var next struct {
+		Position float64 `bson:"position"`
+	}
+
+	err = s.session.db.C(col).Find(bson.M{
+		parentField: parentID,
+		"position":  bson.M{"$gt": pos},
+	}).Sort("position").Select(bson.M{"position": 1}).One(&next)
+	if err == mgo.ErrNotFound {
+		return pos + 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return (pos + next.Position) / 2, nil
+}
+
+// stamp records at as the time of the write of the fields of a list or a card created by a client.
+func (s *SyncService) stamp(col, id string, fields []string, at time.Time) error {
+	patch := make(bson.M, len(fields))
+	for _, f := range fields {
+		patch["modifiedAt."+f] = at
+	}
+
+	return s.session.db.C(col).UpdateId(bson.ObjectIdHex(id), bson.M{"$set": patch})
+}
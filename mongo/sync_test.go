@@ -0,0 +1,271 @@
+package mongo_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoardService_Boards_Page(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.BoardService()
+	for i := 0; i < 3; i++ {
+		newBoard(t, sessions.Red)
+	}
+
+	page, err := s.Boards(pulpe.BoardPage(2, ""))
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+
+	next, err := s.Boards(pulpe.BoardPage(2, page[1].ID))
+	require.NoError(t, err)
+	require.Len(t, next, 1)
+	require.True(t, next[0].ID > page[1].ID)
+
+	_, err = s.Boards(pulpe.BoardPage(2, "bad"))
+	require.Error(t, err)
+}
+
+func TestSyncService_BoardsChanges(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.SyncService()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := sessions.NoAuth.SyncService().BoardsChanges("")
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		_, err := s.BoardsChanges("!!!")
+		require.Equal(t, pulpe.ErrSyncTokenInvalid, err)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		_, err := s.BoardsChanges(base64.RawURLEncoding.EncodeToString([]byte("1000")))
+		require.Equal(t, pulpe.ErrSyncTokenExpired, err)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		full, err := s.BoardsChanges("")
+		require.NoError(t, err)
+		require.NotEmpty(t, full.Token)
+		require.Empty(t, full.Deleted)
+
+		board := newBoard(t, sessions.Red)
+		deleted := newBoard(t, sessions.Red)
+		err = sessions.Red.BoardService().DeleteBoard(deleted.ID)
+		require.NoError(t, err)
+
+		ch, err := s.BoardsChanges(full.Token)
+		require.NoError(t, err)
+		require.Len(t, ch.Boards, 1)
+		require.Equal(t, board.ID, ch.Boards[0].ID)
+		require.Len(t, ch.Deleted, 1)
+		require.Equal(t, pulpe.TombstoneBoard, ch.Deleted[0].Kind)
+		require.Equal(t, deleted.ID, ch.Deleted[0].ID)
+
+		// boards of other users are not reported
+		ch, err = sessions.Blue.SyncService().BoardsChanges(full.Token)
+		require.NoError(t, err)
+		require.Empty(t, ch.Boards)
+		require.Empty(t, ch.Deleted)
+	})
+}
+
+func TestSyncService_BoardChanges(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.SyncService()
+	board := newBoard(t, sessions.Red)
+	list := newListWithBoardID(t, sessions.Red, board.ID)
+	card := newCardWithListID(t, sessions.Red, list.ID)
+
+	t.Run("Full", func(t *testing.T) {
+		ch, err := s.BoardChanges(board.ID, "")
+		require.NoError(t, err)
+		require.Len(t, ch.Boards, 1)
+		require.Equal(t, board.ID, ch.Boards[0].ID)
+		require.Len(t, ch.Lists, 1)
+		require.Len(t, ch.Cards, 1)
+		require.Empty(t, ch.Deleted)
+	})
+
+	t.Run("Tombstones", func(t *testing.T) {
+		full, err := s.BoardChanges(board.ID, "")
+		require.NoError(t, err)
+
+		deleted := newCardWithListID(t, sessions.Red, list.ID)
+		err = sessions.Red.CardService().DeleteCard(deleted.ID)
+		require.NoError(t, err)
+
+		// moving a card to another board buries it on its former board
+		target := newList(t, sessions.Red)
+		_, err = sessions.Red.CardService().UpdateCard(card.ID, &pulpe.CardUpdate{ListID: &target.ID})
+		require.NoError(t, err)
+
+		removed := newListWithBoardID(t, sessions.Red, board.ID)
+		err = sessions.Red.ListService().DeleteList(removed.ID)
+		require.NoError(t, err)
+
+		ch, err := s.BoardChanges(board.ID, full.Token)
+		require.NoError(t, err)
+		require.Len(t, ch.Deleted, 3)
+		require.Equal(t, pulpe.Tombstone{Kind: pulpe.TombstoneCard, ID: deleted.ID, BoardID: board.ID, DeletedAt: ch.Deleted[0].DeletedAt}, *ch.Deleted[0])
+		require.Equal(t, card.ID, ch.Deleted[1].ID)
+		require.Equal(t, pulpe.TombstoneList, ch.Deleted[2].Kind)
+
+		ch, err = s.BoardChanges(target.BoardID, full.Token)
+		require.NoError(t, err)
+		require.Len(t, ch.Cards, 1)
+		require.Equal(t, card.ID, ch.Cards[0].ID)
+	})
+
+	t.Run("Bad user", func(t *testing.T) {
+		_, err := sessions.Blue.SyncService().BoardChanges(board.ID, "")
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+
+		_, err = sessions.NoAuth.SyncService().BoardChanges(board.ID, "")
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("Public board", func(t *testing.T) {
+		public := pulpe.BoardVisibilityPublic
+		_, err := sessions.Red.BoardService().UpdateBoard(board.ID, &pulpe.BoardUpdate{Visibility: &public})
+		require.NoError(t, err)
+
+		ch, err := sessions.NoAuth.SyncService().BoardChanges(board.ID, "")
+		require.NoError(t, err)
+		require.True(t, ch.Boards[0].ReadOnly)
+	})
+}
+
+func TestSyncService_Sync(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	s := sessions.Red.SyncService()
+	board := newBoard(t, sessions.Red)
+	base := time.Now().Add(-time.Hour)
+
+	str := func(s string) *string { return &s }
+	pos := func(p float64) *float64 { return &p }
+
+	t.Run("Create", func(t *testing.T) {
+		r, err := s.Sync(board.ID, "", []*pulpe.SyncOperation{
+			{ID: "c1", Kind: pulpe.SyncCreate, Entity: pulpe.SyncList, EntityID: "list", Time: base, Name: str("list")},
+			{ID: "c2", Kind: pulpe.SyncCreate, Entity: pulpe.SyncCard, EntityID: "card", Time: base, Name: str("card"), ListID: str("list"), Position: pos(1)},
+			{ID: "c3", Kind: pulpe.SyncUpdate, Entity: pulpe.SyncCard, EntityID: "card", Time: base.Add(time.Minute), Description: str("desc")},
+		})
+		require.NoError(t, err)
+		require.Empty(t, r.Rejected)
+		require.Len(t, r.IDs, 2)
+		require.Len(t, r.Changes.Lists, 1)
+		require.Equal(t, r.IDs["list"], r.Changes.Lists[0].ID)
+		require.Len(t, r.Changes.Cards, 1)
+		require.Equal(t, r.IDs["card"], r.Changes.Cards[0].ID)
+		require.Equal(t, r.IDs["list"], r.Changes.Cards[0].ListID)
+		require.Equal(t, "desc", r.Changes.Cards[0].Description)
+
+		// operations sent again are skipped
+		again, err := s.Sync(board.ID, r.Changes.Token, []*pulpe.SyncOperation{
+			{ID: "c2", Kind: pulpe.SyncCreate, Entity: pulpe.SyncCard, EntityID: "card", Time: base, Name: str("card"), ListID: str("list")},
+		})
+		require.NoError(t, err)
+		require.Equal(t, r.IDs["card"], again.IDs["card"])
+
+		cards, err := sessions.Red.CardService().CardsByBoard(board.ID)
+		require.NoError(t, err)
+		require.Len(t, cards, 1)
+	})
+
+	t.Run("Last writer wins", func(t *testing.T) {
+		list := newListWithBoardID(t, sessions.Red, board.ID)
+
+		r, err := s.Sync(board.ID, "", []*pulpe.SyncOperation{
+			{ID: "w0", Kind: pulpe.SyncCreate, Entity: pulpe.SyncCard, EntityID: "card", Time: base, Name: str("card"), ListID: &list.ID},
+			{ID: "w1", Kind: pulpe.SyncUpdate, Entity: pulpe.SyncCard, EntityID: "card", Time: base.Add(2 * time.Minute), Name: str("second")},
+			{ID: "w2", Kind: pulpe.SyncUpdate, Entity: pulpe.SyncCard, EntityID: "card", Time: base.Add(time.Minute), Name: str("first"), Description: str("desc")},
+			{ID: "w3", Kind: pulpe.SyncUpdate, Entity: pulpe.SyncCard, EntityID: "card", Time: base, Name: str("zero")},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []*pulpe.SyncRejection{{OperationID: "w3", Reason: pulpe.SyncRejectedConflict}}, r.Rejected)
+
+		c, err := sessions.Red.CardService().Card(r.IDs["card"])
+		require.NoError(t, err)
+		require.Equal(t, "second", c.Name)
+		require.Equal(t, "desc", c.Description)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		r, err := s.Sync(board.ID, "", []*pulpe.SyncOperation{
+			{ID: "d0", Kind: pulpe.SyncCreate, Entity: pulpe.SyncList, EntityID: "list", Time: base, Name: str("list")},
+			{ID: "d1", Kind: pulpe.SyncCreate, Entity: pulpe.SyncCard, EntityID: "card", Time: base, Name: str("card"), ListID: str("list")},
+		})
+		require.NoError(t, err)
+
+		// changed online after the deletion was made offline
+		_, err = sessions.Red.CardService().UpdateCard(r.IDs["card"], &pulpe.CardUpdate{Name: str("online")})
+		require.NoError(t, err)
+
+		r, err = s.Sync(board.ID, "", []*pulpe.SyncOperation{
+			{ID: "d2", Kind: pulpe.SyncDelete, Entity: pulpe.SyncCard, EntityID: r.IDs["card"], Time: base.Add(time.Minute)},
+			{ID: "d3", Kind: pulpe.SyncDelete, Entity: pulpe.SyncList, EntityID: r.IDs["list"], Time: time.Now().Add(time.Hour)},
+			{ID: "d4", Kind: pulpe.SyncUpdate, Entity: pulpe.SyncList, EntityID: r.IDs["list"], Time: time.Now(), Name: str("name")},
+			{ID: "d5", Kind: pulpe.SyncDelete, Entity: pulpe.SyncList, EntityID: r.IDs["list"], Time: time.Now()},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []*pulpe.SyncRejection{
+			{OperationID: "d2", Reason: pulpe.SyncRejectedConflict},
+			{OperationID: "d4", Reason: pulpe.SyncRejectedNotFound},
+		}, r.Rejected)
+	})
+
+	t.Run("Position merging", func(t *testing.T) {
+		list := newListWithBoardID(t, sessions.Red, board.ID)
+
+		r, err := s.Sync(board.ID, "", []*pulpe.SyncOperation{
+			{ID: "p1", Kind: pulpe.SyncCreate, Entity: pulpe.SyncCard, EntityID: "a", Time: base, Name: str("a"), ListID: &list.ID, Position: pos(1)},
+			{ID: "p2", Kind: pulpe.SyncCreate, Entity: pulpe.SyncCard, EntityID: "b", Time: base, Name: str("b"), ListID: &list.ID, Position: pos(2)},
+			{ID: "p3", Kind: pulpe.SyncCreate, Entity: pulpe.SyncCard, EntityID: "c", Time: base, Name: str("c"), ListID: &list.ID, Position: pos(1)},
+			{ID: "p4", Kind: pulpe.SyncMove, Entity: pulpe.SyncCard, EntityID: "b", Time: base.Add(time.Minute), Position: pos(1)},
+		})
+		require.NoError(t, err)
+		require.Empty(t, r.Rejected)
+
+		positions := make(map[string]float64)
+		for _, c := range r.Changes.Cards {
+			positions[c.Name] = c.Position
+		}
+
+		require.Equal(t, 1.0, positions["a"])
+		require.Equal(t, 1.5, positions["c"])
+		require.Equal(t, 1.25, positions["b"])
+	})
+
+	t.Run("Other board", func(t *testing.T) {
+		other := newBoard(t, sessions.Red)
+		list := newListWithBoardID(t, sessions.Red, other.ID)
+
+		r, err := s.Sync(board.ID, "", []*pulpe.SyncOperation{
+			{ID: "o1", Kind: pulpe.SyncUpdate, Entity: pulpe.SyncList, EntityID: list.ID, Time: time.Now(), Name: str("name")},
+			{ID: "o2", Kind: pulpe.SyncCreate, Entity: pulpe.SyncCard, EntityID: "card", Time: time.Now(), Name: str("card"), ListID: &list.ID},
+		})
+		require.NoError(t, err)
+		require.Len(t, r.Rejected, 2)
+		require.Equal(t, pulpe.SyncRejectedNotFound, r.Rejected[0].Reason)
+		require.Equal(t, pulpe.SyncRejectedNotFound, r.Rejected[1].Reason)
+	})
+
+	t.Run("Bad user", func(t *testing.T) {
+		_, err := sessions.Blue.SyncService().Sync(board.ID, "", nil)
+		require.Equal(t, pulpe.ErrBoardNotFound, err)
+	})
+}
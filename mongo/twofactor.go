@@ -0,0 +1,228 @@
+package mongo
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/totp"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	twoFactorIssuer       = "Pulpe"
+	twoFactorChallengeTTL = 5 * time.Minute
+	twoFactorMaxAttempts  = 5
+	recoveryCodesCount    = 10
+)
+
+// Ensure TwoFactorService implements pulpe.TwoFactorService.
+var _ pulpe.TwoFactorService = new(TwoFactorService)
+
+// TwoFactorService represents a service for managing two-factor authentication.
+type TwoFactorService struct {
+	session *Session
+}
+
+// EnrollTwoFactor generates a new secret for the authenticated user.
+// The secret is only used once confirmed with ConfirmTwoFactor.
+func (s *TwoFactorService) EnrollTwoFactor() (*pulpe.TwoFactorEnrollment, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	// the authenticated user might be outdated
+	u, err := s.userByID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTPSecret != "" {
+		return nil, pulpe.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.session.db.C(userCol).UpdateId(u.ID, bson.M{
+		"$set":         bson.M{"totpPendingSecret": secret},
+		"$currentDate": bson.M{"updatedAt": true},
+	})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &pulpe.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(twoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication if the code matches the enrolled secret.
+func (s *TwoFactorService) ConfirmTwoFactor(code string) ([]string, error) {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := s.userByID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTPSecret != "" {
+		return nil, pulpe.ErrTwoFactorAlreadyEnabled
+	}
+
+	if u.TOTPPendingSecret == "" {
+		return nil, pulpe.ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := totp.Validate(u.TOTPPendingSecret, code, s.session.now, 1)
+	if !ok {
+		return nil, pulpe.ErrTwoFactorInvalidCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.session.db.C(userCol).Update(
+		bson.M{
+			"_id":               u.ID,
+			"totpPendingSecret": u.TOTPPendingSecret,
+		},
+		bson.M{
+			"$set": bson.M{
+				"totpSecret":    u.TOTPPendingSecret,
+				"totpLastStep":  step,
+				"recoveryCodes": hashes,
+			},
+			"$unset":       bson.M{"totpPendingSecret": ""},
+			"$currentDate": bson.M{"updatedAt": true},
+		})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrTwoFactorNotEnrolled
+		}
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor disables two-factor authentication if the code is valid.
+func (s *TwoFactorService) DisableTwoFactor(code string) error {
+	user, err := s.session.Authenticate()
+	if err != nil {
+		return err
+	}
+
+	err = s.checkCode(user.ID, code)
+	if err != nil {
+		return err
+	}
+
+	err = s.session.db.C(userCol).UpdateId(bson.ObjectIdHex(user.ID), bson.M{
+		"$unset": bson.M{
+			"totpSecret":    "",
+			"totpLastStep":  "",
+			"recoveryCodes": "",
+		},
+		"$currentDate": bson.M{"updatedAt": true},
+	})
+	if err == mgo.ErrNotFound {
+		return pulpe.ErrUserNotFound
+	}
+
+	return err
+}
+
+// checkCode verifies a TOTP or a recovery code of the given user.
+// Each code can only be used once.
+func (s *TwoFactorService) checkCode(userID, code string) error {
+	u, err := s.userByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if u.TOTPSecret == "" {
+		return pulpe.ErrTwoFactorNotEnabled
+	}
+
+	query := bson.M{"_id": u.ID}
+	var update bson.M
+
+	if step, ok := totp.Validate(u.TOTPSecret, code, s.session.now, 1); ok {
+		// reject codes that were already used
+		query["totpLastStep"] = bson.M{"$lt": step}
+		update = bson.M{"$set": bson.M{"totpLastStep": step}}
+	} else {
+		hash := hashRecoveryCode(code)
+		query["recoveryCodes"] = hash
+		update = bson.M{"$pull": bson.M{"recoveryCodes": hash}}
+	}
+
+	err = s.session.db.C(userCol).Update(query, update)
+	if err == mgo.ErrNotFound {
+		return pulpe.ErrTwoFactorInvalidCode
+	}
+
+	return err
+}
+
+// userByID returns the stored user, including its two-factor authentication secrets.
+func (s *TwoFactorService) userByID(id string) (*user, error) {
+	var u user
+
+	if !bson.IsObjectIdHex(id) {
+		return nil, pulpe.ErrUserNotFound
+	}
+
+	err := s.session.db.C(userCol).FindId(bson.ObjectIdHex(id)).One(&u)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// generateRecoveryCodes returns a list of recovery codes and their hashes.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+
+	for i := range codes {
+		b, err := generateRandomBytes(6)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		c := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+		codes[i] = c[:5] + "-" + c[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.Replace(code, "-", "", -1)
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,258 @@
+package mongo_test
+
+import (
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mongo"
+	"github.com/blankrobot/pulpe/totp"
+	"github.com/stretchr/testify/require"
+)
+
+// enableTwoFactor creates a user with two-factor authentication enabled and returns the user, its secret and its recovery codes.
+func enableTwoFactor(t *testing.T, session *mongo.Session) (*pulpe.User, string, []string) {
+	user := createUser(t, session, "TwoFactor")
+	us, err := session.UserSessionService().CreateSession(user)
+	require.NoError(t, err)
+	session.SetAuthToken(us.ID)
+
+	enrollment, err := session.TwoFactorService().EnrollTwoFactor()
+	require.NoError(t, err)
+	require.NotEmpty(t, enrollment.Secret)
+	require.Equal(t, totp.URI("Pulpe", user.Email, enrollment.Secret), enrollment.URI)
+
+	code, err := totp.Code(enrollment.Secret, Now())
+	require.NoError(t, err)
+
+	codes, err := session.TwoFactorService().ConfirmTwoFactor(code)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+
+	return user, enrollment.Secret, codes
+}
+
+func TestTwoFactorService_Enroll(t *testing.T) {
+	_, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("OK", func(t *testing.T) {
+		session := connectAt(Now())
+		defer session.Close()
+
+		user, _, _ := enableTwoFactor(t, session)
+
+		u, err := session.UserService().User(user.ID)
+		require.NoError(t, err)
+		require.True(t, u.TwoFactor)
+
+		_, err = session.TwoFactorService().EnrollTwoFactor()
+		require.Equal(t, pulpe.ErrTwoFactorAlreadyEnabled, err)
+	})
+
+	t.Run("NotEnrolled", func(t *testing.T) {
+		session := connectAt(Now())
+		defer session.Close()
+
+		user := createUser(t, session, "TwoFactor")
+		us, err := session.UserSessionService().CreateSession(user)
+		require.NoError(t, err)
+		session.SetAuthToken(us.ID)
+
+		_, err = session.TwoFactorService().ConfirmTwoFactor("123456")
+		require.Equal(t, pulpe.ErrTwoFactorNotEnrolled, err)
+	})
+
+	t.Run("InvalidCode", func(t *testing.T) {
+		session := connectAt(Now())
+		defer session.Close()
+
+		user := createUser(t, session, "TwoFactor")
+		us, err := session.UserSessionService().CreateSession(user)
+		require.NoError(t, err)
+		session.SetAuthToken(us.ID)
+
+		_, err = session.TwoFactorService().EnrollTwoFactor()
+		require.NoError(t, err)
+
+		_, err = session.TwoFactorService().ConfirmTwoFactor("000000")
+		require.Equal(t, pulpe.ErrTwoFactorInvalidCode, err)
+
+		u, err := session.UserService().User(user.ID)
+		require.NoError(t, err)
+		require.False(t, u.TwoFactor)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		session := connectAt(Now())
+		defer session.Close()
+
+		_, err := session.TwoFactorService().EnrollTwoFactor()
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+}
+
+func TestTwoFactorService_Login(t *testing.T) {
+	_, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("OK", func(t *testing.T) {
+		now := Now()
+		session := connectAt(now)
+		defer session.Close()
+
+		user, secret, _ := enableTwoFactor(t, session)
+
+		later := connectAt(now.Add(totp.Period))
+		defer later.Close()
+
+		pending, err := later.UserSessionService().Login(user.Login, userPassword, pulpe.RememberMe())
+		require.NoError(t, err)
+		require.True(t, pending.Pending)
+		require.True(t, pending.Remember)
+
+		challenge, err := later.UserSessionService().Challenge(pending.ID)
+		require.NoError(t, err)
+		require.Equal(t, pending, challenge)
+
+		// pending sessions can't be used to authenticate
+		_, err = later.UserSessionService().GetSession(pending.ID)
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+
+		code, err := totp.Code(secret, now.Add(totp.Period))
+		require.NoError(t, err)
+
+		us, err := later.UserSessionService().VerifyTwoFactor(pending.ID, code)
+		require.NoError(t, err)
+		require.False(t, us.Pending)
+		require.True(t, us.Remember)
+		require.Equal(t, user.ID, us.UserID)
+		require.NotEqual(t, pending.ID, us.ID)
+
+		_, err = later.UserSessionService().GetSession(us.ID)
+		require.NoError(t, err)
+
+		// full sessions are not challenges
+		_, err = later.UserSessionService().Challenge(us.ID)
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+
+		// a challenge can only be completed once
+		_, err = later.UserSessionService().VerifyTwoFactor(pending.ID, code)
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		now := Now()
+		session := connectAt(now)
+		defer session.Close()
+
+		user, secret, _ := enableTwoFactor(t, session)
+
+		// the code used to confirm the enrollment can't be used again
+		pending, err := session.UserSessionService().Login(user.Login, userPassword)
+		require.NoError(t, err)
+
+		code, err := totp.Code(secret, now)
+		require.NoError(t, err)
+
+		_, err = session.UserSessionService().VerifyTwoFactor(pending.ID, code)
+		require.Equal(t, pulpe.ErrTwoFactorInvalidCode, err)
+	})
+
+	t.Run("RecoveryCode", func(t *testing.T) {
+		now := Now()
+		session := connectAt(now)
+		defer session.Close()
+
+		user, _, codes := enableTwoFactor(t, session)
+
+		pending, err := session.UserSessionService().Login(user.Login, userPassword)
+		require.NoError(t, err)
+
+		_, err = session.UserSessionService().VerifyTwoFactor(pending.ID, codes[0])
+		require.NoError(t, err)
+
+		// recovery codes are single use
+		pending, err = session.UserSessionService().Login(user.Login, userPassword)
+		require.NoError(t, err)
+
+		_, err = session.UserSessionService().VerifyTwoFactor(pending.ID, codes[0])
+		require.Equal(t, pulpe.ErrTwoFactorInvalidCode, err)
+
+		_, err = session.UserSessionService().VerifyTwoFactor(pending.ID, codes[1])
+		require.NoError(t, err)
+	})
+
+	t.Run("TooManyAttempts", func(t *testing.T) {
+		now := Now()
+		session := connectAt(now)
+		defer session.Close()
+
+		user, _, codes := enableTwoFactor(t, session)
+
+		pending, err := session.UserSessionService().Login(user.Login, userPassword)
+		require.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			_, err = session.UserSessionService().VerifyTwoFactor(pending.ID, "000000")
+			require.Equal(t, pulpe.ErrTwoFactorInvalidCode, err)
+		}
+
+		_, err = session.UserSessionService().VerifyTwoFactor(pending.ID, codes[0])
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		now := Now()
+		session := connectAt(now)
+		defer session.Close()
+
+		user, _, codes := enableTwoFactor(t, session)
+
+		pending, err := session.UserSessionService().Login(user.Login, userPassword)
+		require.NoError(t, err)
+
+		later := connectAt(pending.ExpiresAt)
+		defer later.Close()
+
+		_, err = later.UserSessionService().Challenge(pending.ID)
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+
+		_, err = later.UserSessionService().VerifyTwoFactor(pending.ID, codes[0])
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+	})
+}
+
+func TestTwoFactorService_Disable(t *testing.T) {
+	_, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("OK", func(t *testing.T) {
+		now := Now()
+		session := connectAt(now)
+		defer session.Close()
+
+		user, secret, _ := enableTwoFactor(t, session)
+
+		later := connectAt(now.Add(totp.Period))
+		defer later.Close()
+		us, err := later.UserSessionService().CreateSession(user)
+		require.NoError(t, err)
+		later.SetAuthToken(us.ID)
+
+		err = later.TwoFactorService().DisableTwoFactor("000000")
+		require.Equal(t, pulpe.ErrTwoFactorInvalidCode, err)
+
+		code, err := totp.Code(secret, now.Add(totp.Period))
+		require.NoError(t, err)
+
+		err = later.TwoFactorService().DisableTwoFactor(code)
+		require.NoError(t, err)
+
+		err = later.TwoFactorService().DisableTwoFactor(code)
+		require.Equal(t, pulpe.ErrTwoFactorNotEnabled, err)
+
+		us, err = later.UserSessionService().Login(user.Login, userPassword)
+		require.NoError(t, err)
+		require.False(t, us.Pending)
+	})
+}
@@ -15,7 +15,6 @@ import (
 const (
 	userCol        = "users"
 	userSessionCol = "userSessions"
-	userSessionTTL = 24 * time.Hour
 )
 
 // Ensure UserService implements pulpe.UserService.
@@ -29,6 +28,28 @@ type user struct {
 	Login     string        `bson:"login"`
 	Email     string        `bson:"email"`
 	Password  string        `bson:"password"`
+
+	// Two-factor authentication
+	TOTPSecret        string   `bson:"totpSecret,omitempty"`
+	TOTPPendingSecret string   `bson:"totpPendingSecret,omitempty"`
+	TOTPLastStep      int64    `bson:"totpLastStep,omitempty"`
+	RecoveryCodes     []string `bson:"recoveryCodes,omitempty"`
+
+	// Roles granted by a directory
+	Roles []string `bson:"roles,omitempty"`
+
+	// Identities asserted by external identity providers
+	Identities []userIdentity `bson:"identities,omitempty"`
+
+	// Instance administration
+	Admin    bool `bson:"admin,omitempty"`
+	Disabled bool `bson:"disabled,omitempty"`
+}
+
+// userIdentity links a user to an external identity provider.
+type userIdentity struct {
+	Issuer  string `bson:"issuer"`
+	Subject string `bson:"subject"`
 }
 
 func (u *user) toPulpeUser() *pulpe.User {
@@ -38,6 +59,16 @@ func (u *user) toPulpeUser() *pulpe.User {
 		FullName:  u.FullName,
 		Login:     u.Login,
 		Email:     u.Email,
+		TwoFactor: u.TOTPSecret != "",
+		Roles:     u.Roles,
+		Admin:     u.Admin,
+		Disabled:  u.Disabled,
+	}
+
+	for _, role := range u.Roles {
+		if role == pulpe.RoleAdmin {
+			p.Admin = true
+		}
 	}
 
 	if u.UpdatedAt != nil {
@@ -80,14 +111,11 @@ func (s *UserService) ensureIndexes() error {
 		return err
 	}
 
-	// Sessions
-	col = s.session.db.C(userSessionCol)
-
-	// Sessions expiration
+	// External identities can only be linked once
 	index = mgo.Index{
-		Key:         []string{"updatedAt"},
-		Sparse:      true,
-		ExpireAfter: userSessionTTL,
+		Key:    []string{"identities.issuer", "identities.subject"},
+		Unique: true,
+		Sparse: true,
 	}
 
 	return col.EnsureIndex(index)
@@ -112,9 +140,12 @@ func (s *UserService) Register(uc *pulpe.UserRegistration) (*pulpe.User, error)
 
 	u.Password = string(passwd)
 
+	// user logins and organization slugs share the same namespace
 	u.Login, err = resolveSlugAndDo(col, "", "login", u.Login, "", func(login string) error {
 		u.Login = login
-		return col.Insert(u)
+		return insertWithNamespace(s.session.db, login, u.ID, func() error {
+			return col.Insert(u)
+		})
 	})
 
 	if err != nil && mgo.IsDup(err) && strings.Contains(err.Error(), "email") {
@@ -135,6 +166,13 @@ func (s *UserService) User(id string) (*pulpe.User, error) {
 	})
 }
 
+// UserByLogin returns a User by login.
+func (s *UserService) UserByLogin(login string) (*pulpe.User, error) {
+	return s.userBy(bson.M{
+		"login": login,
+	})
+}
+
 func (s *UserService) userBy(query bson.M) (*pulpe.User, error) {
 	var u user
 
@@ -150,8 +188,69 @@ func (s *UserService) userBy(query bson.M) (*pulpe.User, error) {
 	return u.toPulpeUser(), nil
 }
 
+// publicProfiles returns the users matching the given ids, indexed by id.
+// Only the informations visible to other users are returned.
+func (s *UserService) publicProfiles(ids []string) (map[string]*pulpe.User, error) {
+	oids := make([]bson.ObjectId, 0, len(ids))
+	for _, id := range ids {
+		if bson.IsObjectIdHex(id) {
+			oids = append(oids, bson.ObjectIdHex(id))
+		}
+	}
+
+	var us []user
+	err := s.session.db.C(userCol).Find(bson.M{"_id": bson.M{"$in": oids}}).Select(bson.M{"login": 1, "fullName": 1}).All(&us)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]*pulpe.User, len(us))
+	for i := range us {
+		users[us[i].ID.Hex()] = &pulpe.User{
+			ID:        us[i].ID.Hex(),
+			CreatedAt: us[i].ID.Time().UTC(),
+			Login:     us[i].Login,
+			FullName:  us[i].FullName,
+		}
+	}
+
+	return users, nil
+}
+
 // MatchPassword checks is the login or email and password are correct.
 func (s *UserService) MatchPassword(loginOrEmail, passwd string) (string, error) {
+	u, err := s.userByLoginOrEmail(loginOrEmail, bson.M{"_id": 1, "password": 1})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return "", pulpe.ErrUserAuthenticationFailed
+		}
+
+		return "", err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(passwd))
+	if err != nil {
+		return "", pulpe.ErrUserAuthenticationFailed
+	}
+
+	return u.ID.Hex(), nil
+}
+
+// MatchLogin returns the id of the user MatchPassword checks the password of.
+func (s *UserService) MatchLogin(loginOrEmail string) (string, error) {
+	u, err := s.userByLoginOrEmail(loginOrEmail, bson.M{"_id": 1})
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return "", pulpe.ErrUserNotFound
+		}
+
+		return "", err
+	}
+
+	return u.ID.Hex(), nil
+}
+
+func (s *UserService) userByLoginOrEmail(loginOrEmail string, fields bson.M) (*user, error) {
 	var u user
 	var field string
 
@@ -161,30 +260,248 @@ func (s *UserService) MatchPassword(loginOrEmail, passwd string) (string, error)
 		field = "login"
 	}
 
-	query := s.session.db.C(userCol).Find(bson.M{field: loginOrEmail})
-	query = query.Select(bson.M{"_id": 1, "password": 1})
-	err := query.One(&u)
+	err := s.session.db.C(userCol).Find(bson.M{field: loginOrEmail}).Select(fields).One(&u)
 	if err != nil {
-		if err == mgo.ErrNotFound {
-			return "", pulpe.ErrUserAuthenticationFailed
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// ResolveIdentity returns the user linked to the given identity.
+// If there is none, the identity is linked to the authenticated user,
+// or, for authoritative identities, to the user with the same verified email.
+// Otherwise a new user is created, unless the email is already used.
+// The profile of users resolved from authoritative identities is updated.
+func (s *UserService) ResolveIdentity(identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+	col := s.session.db.C(userCol)
+	link := userIdentity{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+	}
+
+	u, err := s.userBy(bson.M{
+		"identities": bson.M{"$elemMatch": bson.M{"issuer": link.Issuer, "subject": link.Subject}},
+	})
+	switch err {
+	case nil:
+		if identity.Authoritative {
+			return s.syncProfile(u.ID, identity)
 		}
+		return u, nil
+	case pulpe.ErrUserNotFound:
+	default:
+		return nil, err
+	}
 
-		return "", err
+	// link the identity to the authenticated user
+	if !identity.Authoritative {
+		current, err := s.session.Authenticate()
+		if err == nil {
+			return current, s.linkIdentity(bson.M{"_id": bson.ObjectIdHex(current.ID)}, &link)
+		}
+		if err != pulpe.ErrUserAuthenticationFailed {
+			return nil, err
+		}
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(passwd))
+	if identity.Email == "" {
+		return nil, pulpe.ErrUserIdentityNoEmail
+	}
+
+	u, err = s.userBy(bson.M{"email": identity.Email})
+	switch err {
+	case nil:
+		// only the directory used to verify passwords can claim an existing account,
+		// other identities must be linked by the user while signed in
+		if !identity.Authoritative || !identity.EmailVerified {
+			return nil, pulpe.ErrUserEmailConflict
+		}
+
+		err = s.linkIdentity(bson.M{"_id": bson.ObjectIdHex(u.ID)}, &link)
+		if err != nil {
+			return nil, err
+		}
+
+		if identity.Authoritative {
+			return s.syncProfile(u.ID, identity)
+		}
+		return u, nil
+	case pulpe.ErrUserNotFound:
+	default:
+		return nil, err
+	}
+
+	fullName := identity.FullName
+	if fullName == "" {
+		fullName = identity.Email[:strings.Index(identity.Email+"@", "@")]
+	}
+
+	nu := user{
+		ID:         bson.NewObjectId(),
+		FullName:   fullName,
+		Login:      strings.Replace(slugify.Slugify(fullName), "-", "", -1),
+		Email:      identity.Email,
+		Roles:      identity.Roles,
+		Identities: []userIdentity{link},
+	}
+
+	if nu.Login == "" {
+		nu.Login = "user"
+	}
+
+	_, err = resolveSlugAndDo(col, "", "login", nu.Login, "", func(login string) error {
+		nu.Login = login
+		return insertWithNamespace(s.session.db, login, nu.ID, func() error {
+			return col.Insert(nu)
+		})
+	})
 	if err != nil {
-		return "", pulpe.ErrUserAuthenticationFailed
+		if mgo.IsDup(err) && strings.Contains(err.Error(), "email") {
+			return nil, pulpe.ErrUserEmailConflict
+		}
+		return nil, err
 	}
 
-	return u.ID.Hex(), nil
+	return nu.toPulpeUser(), nil
+}
+
+// syncProfile updates the user profile from an authoritative identity.
+func (s *UserService) syncProfile(id string, identity *pulpe.ExternalIdentity) (*pulpe.User, error) {
+	set := bson.M{}
+	if identity.FullName != "" {
+		set["fullName"] = identity.FullName
+	}
+	if identity.Email != "" {
+		set["email"] = identity.Email
+	}
+	if identity.Roles != nil {
+		set["roles"] = identity.Roles
+	}
+
+	var u user
+	_, err := s.session.db.C(userCol).FindId(bson.ObjectIdHex(id)).Apply(mgo.Change{
+		Update: bson.M{
+			"$set":         set,
+			"$currentDate": bson.M{"updatedAt": true},
+		},
+		ReturnNew: true,
+	}, &u)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrUserNotFound
+		}
+		if mgo.IsDup(err) && strings.Contains(err.Error(), "email") {
+			return nil, pulpe.ErrUserEmailConflict
+		}
+		return nil, err
+	}
+
+	return u.toPulpeUser(), nil
+}
+
+// linkIdentity adds the identity to the user matching the query.
+func (s *UserService) linkIdentity(query bson.M, link *userIdentity) error {
+	err := s.session.db.C(userCol).Update(query, bson.M{
+		"$addToSet":    bson.M{"identities": link},
+		"$currentDate": bson.M{"updatedAt": true},
+	})
+	if err == mgo.ErrNotFound {
+		return pulpe.ErrUserNotFound
+	}
+
+	return err
 }
 
 // userSession is stored and represents a logged in user.
 type userSession struct {
 	ID        string    `bson:"_id"`
+	CreatedAt time.Time `bson:"createdAt"`
 	UpdatedAt time.Time `bson:"updatedAt"`
+	ExpiresAt time.Time `bson:"expiresAt"`
 	UserID    string    `bson:"userID"`
+	Remember  bool      `bson:"remember,omitempty"`
+	Pending   bool      `bson:"pending,omitempty"`
+	Attempts  int       `bson:"attempts,omitempty"`
+}
+
+// expiration returns the date after which the session is no longer valid.
+func (us *userSession) expiration(timeouts SessionTimeouts) time.Time {
+	if us.Pending {
+		return us.CreatedAt.Add(twoFactorChallengeTTL)
+	}
+
+	idle := timeouts.Idle
+	if us.Remember {
+		idle = timeouts.Remember
+	}
+
+	expiresAt := us.UpdatedAt.Add(idle)
+
+	if timeouts.Max > 0 {
+		createdAt := us.CreatedAt
+		// sessions created by previous versions don't have a creation date
+		if createdAt.IsZero() {
+			createdAt = us.UpdatedAt
+		}
+
+		if limit := createdAt.Add(timeouts.Max); limit.Before(expiresAt) {
+			expiresAt = limit
+		}
+	}
+
+	return expiresAt
+}
+
+// legacySessionTTL is the duration after which previous versions expired sessions,
+// counted from their last update.
+const legacySessionTTL = 24 * time.Hour
+
+// setSessionsExpiration gives the sessions created by previous versions the
+// expiration date they had, so they can be expired through expiresAt.
+func setSessionsExpiration(db *mgo.Database) error {
+	col := db.C(userSessionCol)
+
+	// they could never be expired, their expiration can't be computed
+	_, err := col.RemoveAll(bson.M{
+		"expiresAt": bson.M{"$exists": false},
+		"updatedAt": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return err
+	}
+
+	var us userSession
+	iter := col.Find(bson.M{"expiresAt": bson.M{"$exists": false}}).Iter()
+	for iter.Next(&us) {
+		err = col.UpdateId(us.ID, bson.M{
+			"$set": bson.M{"expiresAt": us.UpdatedAt.Add(legacySessionTTL)},
+		})
+		if err != nil && err != mgo.ErrNotFound {
+			iter.Close()
+			return err
+		}
+	}
+
+	return iter.Close()
+}
+
+func (us *userSession) toPulpeUserSession() *pulpe.UserSession {
+	p := pulpe.UserSession{
+		ID:        us.ID,
+		UserID:    us.UserID,
+		CreatedAt: us.CreatedAt.UTC(),
+		UpdatedAt: us.UpdatedAt.UTC(),
+		ExpiresAt: us.ExpiresAt.UTC(),
+		Remember:  us.Remember,
+		Pending:   us.Pending,
+	}
+
+	if us.CreatedAt.IsZero() {
+		p.CreatedAt = p.UpdatedAt
+	}
+
+	return &p
 }
 
 // UserSessionService represents a service for managing user sessions.
@@ -200,16 +517,49 @@ func (s *UserSessionService) ensureIndexes() error {
 
 	// Sessions expiration
 	index := mgo.Index{
-		Key:         []string{"updatedAt"},
+		Key:         []string{"expiresAt"},
 		Sparse:      true,
-		ExpireAfter: userSessionTTL,
+		ExpireAfter: time.Second,
 	}
 
-	return col.EnsureIndex(index)
+	err := col.EnsureIndex(index)
+	if err != nil {
+		return err
+	}
+
+	// Previous versions expired sessions 24 hours after their last update,
+	// regardless of their configured lifetime.
+	// Their sessions are given the same expiration before the index is dropped.
+	err = setSessionsExpiration(s.session.db)
+	if err != nil {
+		return err
+	}
+
+	indexes, err := col.Indexes()
+	if err != nil {
+		return err
+	}
+
+	for _, idx := range indexes {
+		if len(idx.Key) == 1 && idx.Key[0] == "updatedAt" {
+			err = col.DropIndexName(idx.Name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
 // CreateSession store a user session in the database.
-func (s *UserSessionService) CreateSession(user *pulpe.User) (*pulpe.UserSession, error) {
+func (s *UserSessionService) CreateSession(user *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+	var opts pulpe.SessionOptions
+
+	for i := range options {
+		options[i](&opts)
+	}
+
 	sid, err := generateRandomString(32)
 	if err != nil {
 		return nil, err
@@ -218,27 +568,35 @@ func (s *UserSessionService) CreateSession(user *pulpe.User) (*pulpe.UserSession
 	session := userSession{
 		ID:        sid,
 		UserID:    user.ID,
+		CreatedAt: s.session.now,
 		UpdatedAt: s.session.now,
+		Remember:  opts.Remember,
 	}
+	session.ExpiresAt = session.expiration(s.session.sessionTimeouts)
 
 	err = s.session.db.C(userSessionCol).Insert(&session)
 	if err != nil {
 		return nil, err
 	}
 
-	return &pulpe.UserSession{
-		ID:        sid,
-		UserID:    user.ID,
-		UpdatedAt: s.session.now,
-		ExpiresAt: s.session.now.Add(userSessionTTL),
-	}, nil
+	return session.toPulpeUserSession(), nil
 }
 
 // GetSession gets a session and resets the session expiration date.
+// To avoid writing on every call, the session is only renewed if it
+// hasn't been since the configured renewal interval.
+// The session is only read once per pulpe session.
 func (s *UserSessionService) GetSession(id string) (*pulpe.UserSession, error) {
+	if cached := s.session.userSession; cached != nil && cached.ID == id {
+		us := *cached
+		return &us, nil
+	}
+
 	var us userSession
 
-	err := s.session.db.C(userSessionCol).FindId(id).One(&us)
+	col := s.session.db.C(userSessionCol)
+
+	err := col.FindId(id).One(&us)
 	if err != nil {
 		if err == mgo.ErrNotFound {
 			return nil, pulpe.ErrUserSessionUnknownID
@@ -246,26 +604,189 @@ func (s *UserSessionService) GetSession(id string) (*pulpe.UserSession, error) {
 		return nil, err
 	}
 
-	return &pulpe.UserSession{
-		ID:        us.ID,
-		UserID:    us.UserID,
-		UpdatedAt: us.UpdatedAt.UTC(),
-		ExpiresAt: us.UpdatedAt.Add(userSessionTTL).UTC(),
-	}, nil
+	// pending sessions can only be used to complete a two-factor authentication challenge.
+	if us.Pending {
+		return nil, pulpe.ErrUserSessionUnknownID
+	}
+
+	timeouts := s.session.sessionTimeouts
+
+	// MongoDB removes expired sessions periodically, they might still be found.
+	us.ExpiresAt = us.expiration(timeouts)
+	if !s.session.now.Before(us.ExpiresAt) {
+		return nil, pulpe.ErrUserSessionUnknownID
+	}
+
+	if s.session.now.Sub(us.UpdatedAt) >= timeouts.Renew {
+		us.UpdatedAt = s.session.now
+		us.ExpiresAt = us.expiration(timeouts)
+
+		err = col.UpdateId(id, bson.M{
+			"$set": bson.M{
+				"updatedAt": us.UpdatedAt,
+				"expiresAt": us.ExpiresAt,
+			},
+		})
+		if err != nil {
+			if err == mgo.ErrNotFound {
+				return nil, pulpe.ErrUserSessionUnknownID
+			}
+			return nil, err
+		}
+	}
+
+	p := us.toPulpeUserSession()
+	cached := *p
+	s.session.userSession = &cached
+	return p, nil
 }
 
 // Login user with login or email and password.
-func (s *UserSessionService) Login(loginOrEmail, password string) (*pulpe.UserSession, error) {
-	id, err := s.session.UserService().MatchPassword(loginOrEmail, password)
+// If the user enabled two-factor authentication, a pending session is returned
+// and must be verified with VerifyTwoFactor.
+func (s *UserSessionService) Login(loginOrEmail, password string, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+	id, err := s.session.credentialVerifier.VerifyCredentials(s.session, loginOrEmail, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.StartSession(&pulpe.User{ID: id}, options...)
+}
+
+// StartSession creates a session for a user authenticated without a password.
+// If the user enabled two-factor authentication, a pending session is returned
+// and must be verified with VerifyTwoFactor.
+func (s *UserSessionService) StartSession(u *pulpe.User, options ...pulpe.SessionOption) (*pulpe.UserSession, error) {
+	user, err := s.session.UserService().User(u.ID)
+	if err != nil {
+		if err == pulpe.ErrUserNotFound {
+			return nil, pulpe.ErrUserAuthenticationFailed
+		}
+
+		return nil, err
+	}
+
+	if user.Disabled {
+		return nil, pulpe.ErrUserAuthenticationFailed
+	}
+
+	if !user.TwoFactor {
+		return s.CreateSession(user, options...)
+	}
+
+	var opts pulpe.SessionOptions
+
+	for i := range options {
+		options[i](&opts)
+	}
+
+	sid, err := generateRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	session := userSession{
+		ID:        sid,
+		UserID:    user.ID,
+		CreatedAt: s.session.now,
+		UpdatedAt: s.session.now,
+		Remember:  opts.Remember,
+		Pending:   true,
+	}
+	session.ExpiresAt = session.expiration(s.session.sessionTimeouts)
+
+	err = s.session.db.C(userSessionCol).Insert(&session)
+	if err != nil {
+		return nil, err
+	}
+
+	return session.toPulpeUserSession(), nil
+}
+
+// Challenge returns the pending session of a two-factor authentication challenge.
+func (s *UserSessionService) Challenge(id string) (*pulpe.UserSession, error) {
+	us, err := s.challenge(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return us.toPulpeUserSession(), nil
+}
+
+func (s *UserSessionService) challenge(id string) (*userSession, error) {
+	var us userSession
+
+	err := s.session.db.C(userSessionCol).Find(bson.M{"_id": id, "pending": true}).One(&us)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrUserSessionUnknownID
+		}
+		return nil, err
+	}
+
+	us.ExpiresAt = us.expiration(s.session.sessionTimeouts)
+	if !s.session.now.Before(us.ExpiresAt) {
+		return nil, pulpe.ErrUserSessionUnknownID
+	}
+
+	return &us, nil
+}
+
+// VerifyTwoFactor completes the two-factor authentication challenge of a pending session.
+// On success, the pending session is replaced by a new one.
+func (s *UserSessionService) VerifyTwoFactor(id, code string) (*pulpe.UserSession, error) {
+	col := s.session.db.C(userSessionCol)
+
+	us, err := s.challenge(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.session.twoFactorService.checkCode(us.UserID, code)
+	if err == pulpe.ErrTwoFactorInvalidCode {
+		// limit the number of attempts for a given challenge
+		_, err = col.FindId(id).Apply(mgo.Change{
+			Update:    bson.M{"$inc": bson.M{"attempts": 1}},
+			ReturnNew: true,
+		}, us)
+		if err != nil && err != mgo.ErrNotFound {
+			return nil, err
+		}
+
+		if err == nil && us.Attempts >= twoFactorMaxAttempts {
+			err = col.RemoveId(id)
+			if err != nil && err != mgo.ErrNotFound {
+				return nil, err
+			}
+		}
+
+		return nil, pulpe.ErrTwoFactorInvalidCode
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return s.CreateSession(&pulpe.User{ID: id})
+	// a challenge can only be completed once
+	err = col.RemoveId(id)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, pulpe.ErrUserSessionUnknownID
+		}
+		return nil, err
+	}
+
+	var opts []pulpe.SessionOption
+	if us.Remember {
+		opts = append(opts, pulpe.RememberMe())
+	}
+
+	return s.CreateSession(&pulpe.User{ID: us.UserID}, opts...)
 }
 
 // DeleteSession removes the given session.
 func (s *UserSessionService) DeleteSession(id string) error {
+	s.session.userSession = nil
+
 	err := s.session.db.C(userSessionCol).RemoveId(id)
 	if err == mgo.ErrNotFound {
 		return pulpe.ErrUserSessionUnknownID
@@ -273,6 +794,27 @@ func (s *UserSessionService) DeleteSession(id string) error {
 	return err
 }
 
+// Ensure PasswordVerifier implements pulpe.CredentialVerifier.
+var _ pulpe.CredentialVerifier = new(PasswordVerifier)
+
+// PasswordVerifier checks credentials against the passwords stored in MongoDB.
+type PasswordVerifier struct{}
+
+// VerifyCredentials checks the password of the user and returns its id.
+func (v *PasswordVerifier) VerifyCredentials(session pulpe.Session, loginOrEmail, password string) (string, error) {
+	return session.UserService().MatchPassword(loginOrEmail, password)
+}
+
+// AccountKey returns the id of the user.
+func (v *PasswordVerifier) AccountKey(session pulpe.Session, loginOrEmail string) (string, error) {
+	id, err := session.UserService().MatchLogin(loginOrEmail)
+	if err == pulpe.ErrUserNotFound {
+		return "", nil
+	}
+
+	return id, err
+}
+
 // Ensure Authenticator implements pulpe.Authenticator.
 var _ pulpe.Authenticator = new(Authenticator)
 
@@ -286,5 +828,15 @@ func (a Authenticator) Authenticate(session pulpe.Session, token string) (*pulpe
 		return nil, err
 	}
 
-	return session.UserService().User(us.UserID)
+	u, err := session.UserService().User(us.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// sessions are revoked when an account is disabled, this is a safety net.
+	if u.Disabled {
+		return nil, pulpe.ErrUserAuthenticationFailed
+	}
+
+	return u, nil
 }
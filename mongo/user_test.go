@@ -2,9 +2,14 @@ package mongo_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/ldap"
+	"github.com/blankrobot/pulpe/ldap/ldaptest"
+	"github.com/blankrobot/pulpe/mongo"
 	"github.com/stretchr/testify/require"
+	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -129,6 +134,143 @@ func TestUserService_User(t *testing.T) {
 	})
 }
 
+func TestUserService_UserByLogin(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	t.Run("OK", func(t *testing.T) {
+		red, err := sessions.Red.Authenticate()
+		require.NoError(t, err)
+
+		user, err := sessions.NoAuth.UserService().UserByLogin(red.Login)
+		require.NoError(t, err)
+		require.Equal(t, red.ID, user.ID)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		_, err := sessions.NoAuth.UserService().UserByLogin("something")
+		require.Equal(t, pulpe.ErrUserNotFound, err)
+	})
+}
+
+func TestUserService_ResolveIdentity(t *testing.T) {
+	sessions, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	identity := func(subject, email string, verified bool) *pulpe.ExternalIdentity {
+		return &pulpe.ExternalIdentity{
+			Issuer:        "https://idp.wall.com",
+			Subject:       subject,
+			Email:         email,
+			EmailVerified: verified,
+			FullName:      "Jon Snow",
+		}
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		s := sessions.NoAuth.UserService()
+
+		user, err := s.ResolveIdentity(identity("create", "create@wall.com", true))
+		require.NoError(t, err)
+		require.Equal(t, "Jon Snow", user.FullName)
+		require.Equal(t, "create@wall.com", user.Email)
+		require.NotEmpty(t, user.Login)
+
+		// the same identity resolves to the same user
+		same, err := s.ResolveIdentity(identity("create", "other@wall.com", true))
+		require.NoError(t, err)
+		require.Equal(t, user.ID, same.ID)
+
+		// created users have no password
+		_, err = s.MatchPassword(user.Login, "")
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+
+	t.Run("LinkVerifiedEmail", func(t *testing.T) {
+		s := sessions.NoAuth.UserService()
+
+		user, err := s.Register(&pulpe.UserRegistration{
+			FullName: "Jon Snow",
+			Email:    "verified@wall.com",
+			Password: "ygritte",
+		})
+		require.NoError(t, err)
+
+		// the account must be linked while signed in
+		_, err = s.ResolveIdentity(identity("verified", "verified@wall.com", true))
+		require.Equal(t, pulpe.ErrUserEmailConflict, err)
+
+		// unless the identity comes from the directory used to verify passwords
+		id := identity("verified", "verified@wall.com", true)
+		id.Authoritative = true
+		linked, err := s.ResolveIdentity(id)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, linked.ID)
+	})
+
+	t.Run("UnverifiedEmailConflict", func(t *testing.T) {
+		s := sessions.NoAuth.UserService()
+
+		_, err := s.Register(&pulpe.UserRegistration{
+			FullName: "Jon Snow",
+			Email:    "unverified@wall.com",
+			Password: "ygritte",
+		})
+		require.NoError(t, err)
+
+		_, err = s.ResolveIdentity(identity("unverified", "unverified@wall.com", false))
+		require.Equal(t, pulpe.ErrUserEmailConflict, err)
+	})
+
+	t.Run("LinkAuthenticatedUser", func(t *testing.T) {
+		red, err := sessions.Red.Authenticate()
+		require.NoError(t, err)
+
+		linked, err := sessions.Red.UserService().ResolveIdentity(identity("red", "someone@else.com", false))
+		require.NoError(t, err)
+		require.Equal(t, red.ID, linked.ID)
+
+		user, err := sessions.NoAuth.UserService().ResolveIdentity(identity("red", "", false))
+		require.NoError(t, err)
+		require.Equal(t, red.ID, user.ID)
+	})
+
+	t.Run("NoEmail", func(t *testing.T) {
+		_, err := sessions.NoAuth.UserService().ResolveIdentity(identity("noemail", "", true))
+		require.Equal(t, pulpe.ErrUserIdentityNoEmail, err)
+	})
+
+	t.Run("Authoritative", func(t *testing.T) {
+		id := identity("authoritative", "authoritative@wall.com", true)
+		id.Authoritative = true
+		id.Roles = []string{"member"}
+
+		// authoritative identities are never linked to the authenticated user
+		user, err := sessions.Blue.UserService().ResolveIdentity(id)
+		require.NoError(t, err)
+		blue, err := sessions.Blue.Authenticate()
+		require.NoError(t, err)
+		require.NotEqual(t, blue.ID, user.ID)
+		require.Equal(t, []string{"member"}, user.Roles)
+
+		// the profile is updated at each login
+		id.FullName = "Lord Snow"
+		id.Roles = []string{"admin"}
+		updated, err := sessions.NoAuth.UserService().ResolveIdentity(id)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, updated.ID)
+		require.Equal(t, "Lord Snow", updated.FullName)
+		require.Equal(t, user.Login, updated.Login)
+		require.Equal(t, []string{"admin"}, updated.Roles)
+
+		// nil roles are left untouched
+		id.Roles = nil
+		updated, err = sessions.NoAuth.UserService().ResolveIdentity(id)
+		require.NoError(t, err)
+		require.Equal(t, []string{"admin"}, updated.Roles)
+	})
+}
+
 func TestUserSessionService_Login(t *testing.T) {
 	t.Run("WithEmailOK", func(t *testing.T) {
 		sessions, cleanup := MustGetSessions(t)
@@ -215,6 +357,87 @@ func TestUserSessionService_Login(t *testing.T) {
 		require.Error(t, err)
 		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
 	})
+
+	t.Run("WithoutVerifier", func(t *testing.T) {
+		sessions, cleanup := MustGetSessions(t)
+		defer cleanup()
+
+		u := pulpe.UserRegistration{
+			FullName: "Jon Snow",
+			Email:    "jon.snow@wall.com",
+			Password: "ygritte",
+		}
+
+		user, err := sessions.NoAuth.UserService().Register(&u)
+		require.NoError(t, err)
+
+		// clients built without NewClient check the stored passwords
+		c := *client.Client
+		c.CredentialVerifier = nil
+		session := c.Connect().(*mongo.Session)
+		defer session.Close()
+
+		userSession, err := session.UserSessionService().Login(u.Email, u.Password)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, userSession.UserID)
+	})
+}
+
+func TestUserSessionService_LoginWithLDAP(t *testing.T) {
+	_, cleanup := MustGetSessions(t)
+	defer cleanup()
+
+	srv := ldaptest.NewServer(
+		ldaptest.Entry{
+			DN:       "cn=pulpe,dc=wall,dc=com",
+			Password: "service",
+		},
+		ldaptest.Entry{
+			DN:       "uid=ldapsnow,ou=people,dc=wall,dc=com",
+			Password: "ygritte",
+			Attributes: map[string][]string{
+				"uid":      {"ldapsnow"},
+				"cn":       {"Jon Snow"},
+				"mail":     {"ldap.snow@wall.com"},
+				"memberOf": {"cn=watch,ou=groups,dc=wall,dc=com"},
+			},
+		},
+	)
+	defer srv.Close()
+
+	c := *client.Client
+	c.CredentialVerifier = ldap.NewVerifier(ldap.Config{
+		URL:          srv.URL(),
+		BindDN:       "cn=pulpe,dc=wall,dc=com",
+		BindPassword: "service",
+		BaseDN:       "ou=people,dc=wall,dc=com",
+		GroupRoles: map[string]string{
+			"cn=watch,ou=groups,dc=wall,dc=com": "member",
+		},
+	})
+	session := c.Connect()
+	defer session.Close()
+
+	us, err := session.UserSessionService().Login("ldapsnow", "ygritte")
+	require.NoError(t, err)
+
+	user, err := session.UserService().User(us.UserID)
+	require.NoError(t, err)
+	require.Equal(t, "Jon Snow", user.FullName)
+	require.Equal(t, "ldap.snow@wall.com", user.Email)
+	require.Equal(t, []string{"member"}, user.Roles)
+
+	// the same local user is used on next logins
+	us, err = session.UserSessionService().Login("ldapsnow", "ygritte")
+	require.NoError(t, err)
+	require.Equal(t, user.ID, us.UserID)
+
+	_, err = session.UserSessionService().Login("ldapsnow", "wrong")
+	require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+
+	// local passwords are not checked
+	_, err = session.UserSessionService().Login(user.Login, "ygritte")
+	require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
 }
 
 func TestUserSessionService_CreateSession(t *testing.T) {
@@ -262,6 +485,162 @@ func TestUserSessionService_GetSession(t *testing.T) {
 		require.Error(t, err)
 		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
 	})
+
+	t.Run("Renew", func(t *testing.T) {
+		now := Now()
+		timeouts := mongo.DefaultSessionTimeouts
+
+		session := connectAt(now)
+		defer session.Close()
+		us, err := session.UserSessionService().CreateSession(&pulpe.User{ID: "id"})
+		require.NoError(t, err)
+		require.Equal(t, now.Add(timeouts.Idle), us.ExpiresAt)
+
+		// used before the renewal interval
+		later := connectAt(now.Add(timeouts.Renew / 2))
+		defer later.Close()
+		sess, err := later.UserSessionService().GetSession(us.ID)
+		require.NoError(t, err)
+		require.Equal(t, us, sess)
+
+		// used after the renewal interval
+		later = connectAt(now.Add(timeouts.Idle - time.Second))
+		defer later.Close()
+		sess, err = later.UserSessionService().GetSession(us.ID)
+		require.NoError(t, err)
+		require.Equal(t, now.Add(timeouts.Idle-time.Second), sess.UpdatedAt)
+		require.Equal(t, now.Add(2*timeouts.Idle-time.Second), sess.ExpiresAt)
+		require.Equal(t, us.CreatedAt, sess.CreatedAt)
+
+		// the renewal must be persisted
+		later = connectAt(now.Add(timeouts.Idle + time.Second))
+		defer later.Close()
+		_, err = later.UserSessionService().GetSession(us.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Once per session", func(t *testing.T) {
+		session := connectAt(Now())
+		defer session.Close()
+		us, err := session.UserSessionService().CreateSession(&pulpe.User{ID: "id"})
+		require.NoError(t, err)
+
+		later := connectAt(Now().Add(mongo.DefaultSessionTimeouts.Renew))
+		defer later.Close()
+		sess, err := later.UserSessionService().GetSession(us.ID)
+		require.NoError(t, err)
+
+		// the session is not read again, nor renewed
+		require.NoError(t, client.Session.DB("").C("userSessions").UpdateId(us.ID, bson.M{
+			"$set": bson.M{"userID": "other"},
+		}))
+		cached, err := later.UserSessionService().GetSession(us.ID)
+		require.NoError(t, err)
+		require.Equal(t, sess, cached)
+
+		require.NoError(t, later.UserSessionService().DeleteSession(us.ID))
+		_, err = later.UserSessionService().GetSession(us.ID)
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		now := Now()
+
+		session := connectAt(now)
+		defer session.Close()
+		us, err := session.UserSessionService().CreateSession(&pulpe.User{ID: "id"})
+		require.NoError(t, err)
+
+		later := connectAt(now.Add(mongo.DefaultSessionTimeouts.Idle))
+		defer later.Close()
+		_, err = later.UserSessionService().GetSession(us.ID)
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+	})
+
+	t.Run("RememberMe", func(t *testing.T) {
+		now := Now()
+		timeouts := mongo.DefaultSessionTimeouts
+
+		session := connectAt(now)
+		defer session.Close()
+		us, err := session.UserSessionService().CreateSession(&pulpe.User{ID: "id"}, pulpe.RememberMe())
+		require.NoError(t, err)
+		require.True(t, us.Remember)
+		require.Equal(t, now.Add(timeouts.Remember), us.ExpiresAt)
+
+		later := connectAt(now.Add(timeouts.Idle + time.Hour))
+		defer later.Close()
+		sess, err := later.UserSessionService().GetSession(us.ID)
+		require.NoError(t, err)
+		require.True(t, sess.Remember)
+	})
+
+	t.Run("MaxLifetime", func(t *testing.T) {
+		now := Now()
+		timeouts := mongo.DefaultSessionTimeouts
+
+		session := connectAt(now)
+		defer session.Close()
+		us, err := session.UserSessionService().CreateSession(&pulpe.User{ID: "id"}, pulpe.RememberMe())
+		require.NoError(t, err)
+
+		// keep using the session until it reaches its maximum lifetime
+		at := now
+		for at.Add(timeouts.Remember).Before(now.Add(timeouts.Max)) {
+			at = at.Add(timeouts.Remember - time.Hour)
+			later := connectAt(at)
+			sess, err := later.UserSessionService().GetSession(us.ID)
+			later.Close()
+			require.NoError(t, err)
+			require.False(t, sess.ExpiresAt.After(now.Add(timeouts.Max)))
+		}
+
+		later := connectAt(now.Add(timeouts.Max))
+		defer later.Close()
+		_, err = later.UserSessionService().GetSession(us.ID)
+		require.Equal(t, pulpe.ErrUserSessionUnknownID, err)
+	})
+}
+
+func TestUserSessionService_PreviousVersions(t *testing.T) {
+	col := client.Session.DB("").C("userSessions")
+	require.NoError(t, col.EnsureIndex(mgo.Index{
+		Key:         []string{"updatedAt"},
+		Sparse:      true,
+		ExpireAfter: 24 * time.Hour,
+	}))
+
+	updatedAt := Now().Add(-time.Hour)
+	require.NoError(t, col.Insert(
+		bson.M{"_id": "legacy", "userID": newUserID(), "updatedAt": updatedAt},
+		bson.M{"_id": "legacy-dateless", "userID": newUserID()},
+	))
+	defer col.RemoveId("legacy")
+
+	require.NoError(t, client.EnsureIndexes())
+
+	var session bson.M
+	require.NoError(t, col.FindId("legacy").One(&session))
+	require.Equal(t, updatedAt.Add(24*time.Hour), session["expiresAt"].(time.Time).UTC())
+
+	err := col.FindId("legacy-dateless").One(&session)
+	require.Equal(t, mgo.ErrNotFound, err)
+
+	indexes, err := col.Indexes()
+	require.NoError(t, err)
+	for _, idx := range indexes {
+		require.NotEqual(t, []string{"updatedAt"}, idx.Key)
+	}
+}
+
+// connectAt returns a session whose clock is set to the given time.
+func connectAt(now time.Time) *mongo.Session {
+	c := *client.Client
+	c.Now = func() time.Time {
+		return now
+	}
+
+	return c.Connect().(*mongo.Session)
 }
 
 func TestUserSessionService_DeleteSession(t *testing.T) {
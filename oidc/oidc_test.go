@@ -0,0 +1,175 @@
+package oidc_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe/oidc"
+	"github.com/blankrobot/pulpe/oidc/oidctest"
+	"github.com/stretchr/testify/require"
+)
+
+func newProvider(t *testing.T) (*oidc.Provider, *oidctest.Server) {
+	srv := oidctest.NewServer("client", "secret")
+	srv.Identity = oidctest.Identity{
+		Subject:       "1234",
+		Email:         "jon.snow@wall.com",
+		EmailVerified: true,
+		Name:          "Jon Snow",
+	}
+
+	p, err := oidc.NewProvider(oidc.Config{
+		Issuer:       srv.Issuer(),
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://pulpe.local/auth/oidc/callback",
+		Scopes:       []string{"email", "profile"},
+	})
+	require.NoError(t, err)
+
+	return p, srv
+}
+
+// authorize follows the authorization URL and returns the code and state sent to the redirect URL.
+func authorize(t *testing.T, authURL string) (string, string) {
+	client := http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	u, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, "pulpe.local", u.Host)
+
+	return u.Query().Get("code"), u.Query().Get("state")
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		p, srv := newProvider(t)
+		defer srv.Close()
+
+		u, err := url.Parse(p.AuthCodeURL("state", "nonce", "verifier"))
+		require.NoError(t, err)
+		require.Equal(t, "openid email profile", u.Query().Get("scope"))
+		require.Equal(t, oidc.Challenge("verifier"), u.Query().Get("code_challenge"))
+
+		code, state := authorize(t, u.String())
+		require.Equal(t, "state", state)
+
+		token, err := p.Exchange(code, "verifier")
+		require.NoError(t, err)
+		require.Equal(t, srv.Issuer(), token.Issuer)
+		require.Equal(t, "1234", token.Subject)
+		require.Equal(t, "nonce", token.Nonce)
+		require.Equal(t, "jon.snow@wall.com", token.Email)
+		require.True(t, token.EmailVerified)
+		require.Equal(t, "Jon Snow", token.Name)
+
+		// codes are single use
+		_, err = p.Exchange(code, "verifier")
+		require.Error(t, err)
+	})
+
+	t.Run("BadVerifier", func(t *testing.T) {
+		p, srv := newProvider(t)
+		defer srv.Close()
+
+		code, _ := authorize(t, p.AuthCodeURL("state", "nonce", "verifier"))
+
+		_, err := p.Exchange(code, "other")
+		require.Error(t, err)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		p, srv := newProvider(t)
+		defer srv.Close()
+
+		p.Now = func() time.Time {
+			return time.Now().Add(2 * time.Hour)
+		}
+
+		code, _ := authorize(t, p.AuthCodeURL("state", "nonce", "verifier"))
+
+		_, err := p.Exchange(code, "verifier")
+		require.Equal(t, oidc.ErrInvalidToken, err)
+	})
+}
+
+func TestProvider_Verify(t *testing.T) {
+	p, srv := newProvider(t)
+	defer srv.Close()
+
+	claims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss": srv.Issuer(),
+			"sub": "1234",
+			"aud": []string{"other", "client"},
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		raw, err := srv.Sign(claims())
+		require.NoError(t, err)
+
+		token, err := p.Verify(raw)
+		require.NoError(t, err)
+		require.Equal(t, "1234", token.Subject)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		_, err := p.Verify("a.b.c")
+		require.Equal(t, oidc.ErrInvalidToken, err)
+
+		_, err = p.Verify("not a token")
+		require.Equal(t, oidc.ErrInvalidToken, err)
+	})
+
+	t.Run("BadSignature", func(t *testing.T) {
+		other := oidctest.NewServer("client", "secret")
+		defer other.Close()
+
+		raw, err := other.Sign(claims())
+		require.NoError(t, err)
+
+		_, err = p.Verify(raw)
+		require.Equal(t, oidc.ErrInvalidToken, err)
+	})
+
+	t.Run("BadAudience", func(t *testing.T) {
+		c := claims()
+		c["aud"] = "other"
+		raw, err := srv.Sign(c)
+		require.NoError(t, err)
+
+		_, err = p.Verify(raw)
+		require.Equal(t, oidc.ErrInvalidToken, err)
+	})
+
+	t.Run("BadIssuer", func(t *testing.T) {
+		c := claims()
+		c["iss"] = "http://evil.com"
+		raw, err := srv.Sign(c)
+		require.NoError(t, err)
+
+		_, err = p.Verify(raw)
+		require.Equal(t, oidc.ErrInvalidToken, err)
+	})
+}
+
+func TestNewProvider(t *testing.T) {
+	srv := oidctest.NewServer("client", "secret")
+	defer srv.Close()
+
+	_, err := oidc.NewProvider(oidc.Config{Issuer: srv.Issuer() + "/other"})
+	require.Error(t, err)
+}
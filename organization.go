@@ -0,0 +1,57 @@
+package pulpe
+
+import "time"
+
+// Organization errors
+const (
+	ErrOrganizationNotFound       = Error("organization not found")
+	ErrOrganizationForbidden      = Error("organization admin role required")
+	ErrOrganizationMemberNotFound = Error("organization member not found")
+	ErrOrganizationLastAdmin      = Error("organization must have at least one admin")
+	ErrOrganizationInvalidRole    = Error("invalid organization role")
+)
+
+// Organization roles
+const (
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleMember = "member"
+)
+
+// An Organization owns boards shared with its members.
+// Its slug lives in the same namespace as user logins.
+type Organization struct {
+	ID        string                `json:"id"`
+	Slug      string                `json:"slug"`
+	CreatedAt time.Time             `json:"createdAt"`
+	UpdatedAt *time.Time            `json:"updatedAt,omitempty"`
+	Name      string                `json:"name"`
+	Members   []*OrganizationMember `json:"members,omitempty"`
+}
+
+// OrganizationMember is a user member of an organization.
+type OrganizationMember struct {
+	UserID   string `json:"userID"`
+	Login    string `json:"login"`
+	FullName string `json:"fullName"`
+	Role     string `json:"role"`
+}
+
+// OrganizationCreation is used to create an organization.
+type OrganizationCreation struct {
+	Name string
+}
+
+// OrganizationService represents a service for managing organizations.
+// Organizations are only visible to their members.
+type OrganizationService interface {
+	// CreateOrganization creates an organization administrated by the authenticated user.
+	CreateOrganization(*OrganizationCreation) (*Organization, error)
+	Organization(slug string) (*Organization, error)
+	// Organizations returns the organizations of the authenticated user.
+	Organizations() ([]*Organization, error)
+	// SetMember adds a user to the organization or changes its role. Admin only.
+	SetMember(slug, login, role string) (*Organization, error)
+	// RemoveMember removes a user from the organization. Admin only,
+	// unless users remove themselves.
+	RemoveMember(slug, login string) (*Organization, error)
+}
@@ -0,0 +1,54 @@
+package pulpe
+
+import "time"
+
+// Revision errors
+const (
+	ErrRevisionNotFound = Error("revision not found")
+)
+
+// A Revision is the name and the description of a card after a change.
+type Revision struct {
+	ID        string    `json:"id"`
+	CardID    string    `json:"cardID"`
+	CreatedAt time.Time `json:"createdAt"`
+	// AuthorID is the ID of the user who made the change.
+	// It's empty for the content a card had before its first recorded change.
+	AuthorID    string `json:"authorID,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Diff line operations
+const (
+	DiffEqual  = "="
+	DiffInsert = "+"
+	DiffDelete = "-"
+)
+
+// A DiffLine is a line kept, inserted or deleted between two revisions.
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// RevisionDiff contains the changes of the name and the description of a card
+// between two of its revisions, line by line.
+type RevisionDiff struct {
+	From        *Revision   `json:"from"`
+	To          *Revision   `json:"to"`
+	Name        []*DiffLine `json:"name"`
+	Description []*DiffLine `json:"description"`
+}
+
+// RevisionService represents a service for managing the revisions of cards.
+// Revisions are recorded each time the name or the description of a card changes,
+// and are removed according to the retention policy of the service.
+type RevisionService interface {
+	// Revisions returns the revisions of a card, the most recent first.
+	Revisions(cardID string) ([]*Revision, error)
+	// DiffRevisions returns the changes from a revision of a card to another one.
+	DiffRevisions(cardID, fromID, toID string) (*RevisionDiff, error)
+	// RestoreRevision sets the name and the description of a card back to the ones of a revision.
+	RestoreRevision(cardID, revisionID string) (*Card, error)
+}
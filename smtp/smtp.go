@@ -0,0 +1,88 @@
+// Package smtp implements a pulpe.Mailer that sends emails through an SMTP server.
+package smtp
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+)
+
+// Config of an SMTP server.
+type Config struct {
+	// Address of the server, host:port.
+	Addr string
+
+	// Credentials used with PLAIN authentication. No authentication if empty.
+	Username string
+	Password string
+
+	// Sender address.
+	From string
+
+	// Public URL of pulpe, used to build the links sent by email.
+	BaseURL string
+}
+
+// Ensure Mailer implements pulpe.Mailer.
+var _ pulpe.Mailer = new(Mailer)
+
+// Mailer sends plain text emails through an SMTP server.
+type Mailer struct {
+	config Config
+}
+
+// NewMailer returns a Mailer.
+func NewMailer(config Config) *Mailer {
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	return &Mailer{config: config}
+}
+
+// SendInvitation sends the link of a board invitation to the invitee.
+func (m *Mailer) SendInvitation(inv *pulpe.Invitation, board *pulpe.Board, inviter *pulpe.User) error {
+	name := inviter.FullName
+	if name == "" {
+		name = inviter.Login
+	}
+
+	link := fmt.Sprintf("%s/join?invitation=%s", m.config.BaseURL, url.QueryEscape(inv.Token))
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "%s invited you to join the board %q on pulpe.\r\n\r\n", name, board.Name)
+	fmt.Fprintf(&body, "Follow this link to accept the invitation:\r\n%s\r\n\r\n", link)
+	fmt.Fprintf(&body, "The invitation expires on %s.\r\n", inv.ExpiresAt.UTC().Format(time.RFC1123))
+
+	return m.send(inv.Email, fmt.Sprintf("%s invited you to %s", name, board.Name), body.Bytes())
+}
+
+func (m *Mailer) send(to, subject string, body []byte) error {
+	var msg bytes.Buffer
+
+	// the subject is encoded to prevent header injections
+	fmt.Fprintf(&msg, "From: %s\r\n", m.config.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.Write(body)
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		host, _, err := net.SplitHostPort(m.config.Addr)
+		if err != nil {
+			return err
+		}
+
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, host)
+	}
+
+	return smtp.SendMail(m.config.Addr, auth, m.config.From, []string{to}, msg.Bytes())
+}
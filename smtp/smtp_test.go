@@ -0,0 +1,47 @@
+package smtp_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/blankrobot/pulpe/smtp"
+	"github.com/blankrobot/pulpe/smtp/smtptest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailer_SendInvitation(t *testing.T) {
+	srv := smtptest.NewServer()
+	defer srv.Close()
+
+	m := smtp.NewMailer(smtp.Config{
+		Addr:    srv.Addr(),
+		From:    "pulpe@example.com",
+		BaseURL: "https://pulpe.example.com/",
+	})
+
+	inv := pulpe.Invitation{
+		Email:     "arya@winterfell.com",
+		Token:     "a+b=",
+		ExpiresAt: mock.Now.Add(time.Hour),
+	}
+
+	err := m.SendInvitation(&inv, &pulpe.Board{Name: "Wall\r\nBcc: cersei@lannister.com"}, &pulpe.User{FullName: "Jon Snow"})
+	require.NoError(t, err)
+
+	msgs := srv.Messages()
+	require.Len(t, msgs, 1)
+	require.Equal(t, "pulpe@example.com", msgs[0].From)
+	require.Equal(t, []string{"arya@winterfell.com"}, msgs[0].To)
+	require.Contains(t, msgs[0].Data, "To: arya@winterfell.com\r\n")
+	require.Contains(t, msgs[0].Data, "https://pulpe.example.com/join?invitation=a%2Bb%3D\r\n")
+	require.Contains(t, msgs[0].Data, "Sat, 01 Jan 2000 01:00:00 UTC")
+
+	// the board name can't inject headers
+	headers := msgs[0].Data[:strings.Index(msgs[0].Data, "\r\n\r\n")]
+	for _, h := range strings.Split(headers, "\r\n") {
+		require.False(t, strings.HasPrefix(h, "Bcc:"), h)
+	}
+}
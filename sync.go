@@ -0,0 +1,122 @@
+package pulpe
+
+import "time"
+
+// Sync errors
+const (
+	ErrSyncTokenInvalid = Error("invalid sync token")
+	// ErrSyncTokenExpired is returned when the deletions that happened since the token
+	// are forgotten. The client must sync again without token.
+	ErrSyncTokenExpired = Error("sync token expired")
+	// ErrSyncInProgress is returned when operations are being applied by another sync.
+	// The client must sync again later.
+	ErrSyncInProgress = Error("operations are being applied by another sync")
+)
+
+// Tombstone kinds
+const (
+	TombstoneBoard = "board"
+	TombstoneList  = "list"
+	TombstoneCard  = "card"
+)
+
+// A Tombstone records that a board, a list or a card was deleted, or moved to another board.
+// Deleting a board or a list doesn't record tombstones for its content.
+type Tombstone struct {
+	Kind      string    `json:"kind"`
+	ID        string    `json:"id"`
+	BoardID   string    `json:"boardID"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+// Changes contains what was created, updated or deleted since a sync token.
+// Deletions must be applied first, since an item can be moved away then back.
+// Items can be sent twice in a row, applying them must be idempotent.
+type Changes struct {
+	Boards  []*Board     `json:"boards,omitempty"`
+	Lists   []*List      `json:"lists,omitempty"`
+	Cards   []*Card      `json:"cards,omitempty"`
+	Deleted []*Tombstone `json:"deleted,omitempty"`
+	// Token to send with the next sync.
+	Token string `json:"token"`
+}
+
+// SyncService lets clients download only what changed since their last sync.
+// Tokens are opaque. With an empty token, everything is returned.
+type SyncService interface {
+	// BoardsChanges returns the changes of the boards of the authenticated user,
+	// of its organizations and of the ones it was invited to.
+	BoardsChanges(token string) (*Changes, error)
+	// BoardChanges returns the board and the changes of its lists and cards.
+	// Like BoardByOwnerAndSlug, public boards and boards opened with a valid
+	// share token can be read without being a member.
+	BoardChanges(boardID, token string, options ...BoardGetOption) (*Changes, error)
+	// Sync applies the operations of a client in order, on a board the authenticated user
+	// is a member of, and returns the changes of the board since the token.
+	// Each field keeps the value written last according to the client times,
+	// and deletions are rejected if the list or the card was changed after them.
+	// Items moved to a position already taken are placed after it.
+	Sync(boardID, token string, ops []*SyncOperation) (*SyncResult, error)
+}
+
+// Sync operation kinds
+const (
+	SyncCreate = "create"
+	SyncUpdate = "update"
+	SyncMove   = "move"
+	SyncDelete = "delete"
+)
+
+// Sync entities
+const (
+	SyncList = "list"
+	SyncCard = "card"
+)
+
+// Reasons of the rejection of a sync operation
+const (
+	// The list or the card doesn't exist, or the target of a move isn't accessible.
+	SyncRejectedNotFound = "not found"
+	// The list or the card was changed after the operation was made.
+	SyncRejectedConflict = "conflict"
+	// The operation is malformed.
+	SyncRejectedInvalid = "invalid"
+)
+
+// SyncOperation is a change made by a client, possibly while offline.
+type SyncOperation struct {
+	// ID is chosen by the client. Operations already applied are skipped when sent again.
+	ID     string
+	Kind   string
+	Entity string
+	// EntityID is the ID of the list or the card. Creations use a temporary ID
+	// that the following operations can reference until the real one is known.
+	EntityID string
+	// Time at which the change was made on the client.
+	// Times in the future are replaced by the time of the server.
+	Time        time.Time
+	Name        *string
+	Description *string
+	Position    *float64
+	Labels      []string
+	// Due is removed if zero.
+	Due *time.Time
+	// ListID moves a card and BoardID moves a list. Temporary IDs can be used.
+	ListID  *string
+	BoardID *string
+}
+
+// SyncRejection tells why a sync operation wasn't applied.
+type SyncRejection struct {
+	OperationID string `json:"operationID"`
+	Reason      string `json:"reason"`
+}
+
+// SyncResult is returned once the operations of a client are applied.
+type SyncResult struct {
+	// Changes of the board since the token sent by the client, including the applied operations.
+	Changes *Changes `json:"changes"`
+	// IDs of the created lists and cards, keyed by temporary ID.
+	IDs      map[string]string `json:"ids,omitempty"`
+	Rejected []*SyncRejection  `json:"rejected,omitempty"`
+}
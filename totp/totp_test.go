@@ -0,0 +1,92 @@
+package totp_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/blankrobot/pulpe/totp"
+	"github.com/stretchr/testify/require"
+)
+
+// base32 encoding of the RFC 6238 test secret "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B test vectors, truncated to 6 digits.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		code, err := totp.Code(rfcSecret, time.Unix(test.unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, test.code, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	t.Run("OK", func(t *testing.T) {
+		step, ok := totp.Validate(rfcSecret, "081804", now, 1)
+		require.True(t, ok)
+		require.Equal(t, totp.Step(now), step)
+	})
+
+	t.Run("Skew", func(t *testing.T) {
+		previous, err := totp.Code(rfcSecret, now.Add(-totp.Period))
+		require.NoError(t, err)
+
+		step, ok := totp.Validate(rfcSecret, previous, now, 1)
+		require.True(t, ok)
+		require.Equal(t, totp.Step(now)-1, step)
+
+		_, ok = totp.Validate(rfcSecret, previous, now, 0)
+		require.False(t, ok)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, ok := totp.Validate(rfcSecret, "123456", now, 1)
+		require.False(t, ok)
+
+		_, ok = totp.Validate(rfcSecret, "0818", now, 1)
+		require.False(t, ok)
+
+		_, ok = totp.Validate("not base32!", "081804", now, 1)
+		require.False(t, ok)
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+
+	other, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, secret, other)
+
+	_, err = totp.Code(secret, time.Now())
+	require.NoError(t, err)
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("Pulpe", "jon.snow@wall.com", rfcSecret)
+
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/Pulpe:jon.snow@wall.com", u.Path)
+	require.Equal(t, rfcSecret, u.Query().Get("secret"))
+	require.Equal(t, "Pulpe", u.Query().Get("issuer"))
+}
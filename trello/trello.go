@@ -0,0 +1,267 @@
+// Package trello imports Trello board exports into pulpe.
+package trello
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"unicode/utf8"
+
+	"github.com/blankrobot/pulpe"
+)
+
+// Errors
+var (
+	ErrInvalidExport = errors.New("trello: invalid export")
+)
+
+// Report statuses
+const (
+	StatusImported = "imported"
+	StatusExisting = "existing"
+	StatusSkipped  = "skipped"
+)
+
+// Report kinds
+const (
+	KindBoard = "board"
+	KindList  = "list"
+	KindCard  = "card"
+)
+
+// Name lengths accepted by the API.
+const (
+	maxBoardName = 64
+	maxListName  = 64
+	maxCardName  = 256
+)
+
+// Export is the JSON document produced by the Trello "Export as JSON" menu.
+// Only the fields mapped onto pulpe are decoded.
+type Export struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Closed bool   `json:"closed"`
+	Lists  []List `json:"lists"`
+	Cards  []Card `json:"cards"`
+}
+
+// List of a Trello board.
+type List struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Closed bool    `json:"closed"`
+	Pos    float64 `json:"pos"`
+}
+
+// Card of a Trello board.
+type Card struct {
+	ID     string  `json:"id"`
+	IDList string  `json:"idList"`
+	Name   string  `json:"name"`
+	Desc   string  `json:"desc"`
+	Closed bool    `json:"closed"`
+	Pos    float64 `json:"pos"`
+}
+
+// Decode reads a Trello export.
+func Decode(r io.Reader) (*Export, error) {
+	var e Export
+
+	err := json.NewDecoder(r).Decode(&e)
+	if err != nil || e.ID == "" {
+		return nil, ErrInvalidExport
+	}
+
+	return &e, nil
+}
+
+// Report describes what was done with each item of an export.
+type Report struct {
+	Board   *pulpe.Board `json:"board"`
+	Entries []Entry      `json:"entries"`
+}
+
+// Entry is the outcome of the import of a Trello item.
+type Entry struct {
+	Kind     string `json:"kind"`
+	TrelloID string `json:"trelloID"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Count returns the number of entries of the given kind and status.
+func (r *Report) Count(kind, status string) int {
+	var n int
+	for i := range r.Entries {
+		if r.Entries[i].Kind == kind && r.Entries[i].Status == status {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (r *Report) add(kind, id, name, status, reason string) {
+	r.Entries = append(r.Entries, Entry{
+		Kind:     kind,
+		TrelloID: id,
+		Name:     name,
+		Status:   status,
+		Reason:   reason,
+	})
+}
+
+// importID returns the ImportID of a Trello item.
+func importID(id string) string {
+	return "trello:" + id
+}
+
+// Import creates the board of the export with its lists and cards
+// on behalf of the user authenticated by the session.
+// If organization is not empty, the board is created in that organization.
+// Items imported by a previous run are recognized by their ImportID and
+// left untouched, so importing the same export twice doesn't duplicate them.
+// Archived lists and cards are skipped since pulpe has no archive.
+func Import(session pulpe.Session, e *Export, organization string) (*Report, error) {
+	user, err := session.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+
+	var r Report
+
+	board, err := findBoard(session, user, importID(e.ID), organization)
+	if err != nil {
+		return nil, err
+	}
+
+	if board != nil {
+		r.add(KindBoard, e.ID, e.Name, StatusExisting, "")
+	} else {
+		board, err = session.BoardService().CreateBoard(&pulpe.BoardCreation{
+			Name:         name(e.Name, maxBoardName),
+			Organization: organization,
+			ImportID:     importID(e.ID),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		r.add(KindBoard, e.ID, e.Name, StatusImported, "")
+	}
+
+	r.Board = board
+
+	// items imported by a previous run, by ImportID
+	lists := make(map[string]string)
+	for _, l := range board.Lists {
+		if l.ImportID != "" {
+			lists[l.ImportID] = l.ID
+		}
+	}
+
+	cards := make(map[string]bool)
+	for _, c := range board.Cards {
+		if c.ImportID != "" {
+			cards[c.ImportID] = true
+		}
+	}
+
+	archived := make(map[string]bool)
+	for _, tl := range e.Lists {
+		if tl.Closed {
+			archived[tl.ID] = true
+			r.add(KindList, tl.ID, tl.Name, StatusSkipped, "archived")
+			continue
+		}
+
+		if _, ok := lists[importID(tl.ID)]; ok {
+			r.add(KindList, tl.ID, tl.Name, StatusExisting, "")
+			continue
+		}
+
+		l, err := session.ListService().CreateList(board.ID, &pulpe.ListCreation{
+			Name:     name(tl.Name, maxListName),
+			Position: tl.Pos,
+			ImportID: importID(tl.ID),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		lists[l.ImportID] = l.ID
+		r.add(KindList, tl.ID, tl.Name, StatusImported, "")
+	}
+
+	for _, tc := range e.Cards {
+		listID, ok := lists[importID(tc.IDList)]
+		switch {
+		case tc.Closed:
+			r.add(KindCard, tc.ID, tc.Name, StatusSkipped, "archived")
+		case archived[tc.IDList]:
+			r.add(KindCard, tc.ID, tc.Name, StatusSkipped, "archived list")
+		case !ok:
+			r.add(KindCard, tc.ID, tc.Name, StatusSkipped, "unknown list")
+		case cards[importID(tc.ID)]:
+			r.add(KindCard, tc.ID, tc.Name, StatusExisting, "")
+		default:
+			_, err = session.CardService().CreateCard(listID, &pulpe.CardCreation{
+				Name:        name(tc.Name, maxCardName),
+				Description: tc.Desc,
+				Position:    tc.Pos,
+				ImportID:    importID(tc.ID),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			cards[importID(tc.ID)] = true
+			r.add(KindCard, tc.ID, tc.Name, StatusImported, "")
+		}
+	}
+
+	return &r, nil
+}
+
+// findBoard returns the board previously imported in the same namespace, with its lists and cards.
+// It returns nil if there is none.
+func findBoard(session pulpe.Session, user *pulpe.User, id, organization string) (*pulpe.Board, error) {
+	boards, err := session.BoardService().Boards()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range boards {
+		if b.ImportID != id {
+			continue
+		}
+
+		if organization != "" {
+			if b.Organization == nil || b.Organization.Slug != organization {
+				continue
+			}
+		} else if b.Organization != nil || b.Owner == nil || b.Owner.ID != user.ID {
+			continue
+		}
+
+		return session.BoardService().Board(b.ID, pulpe.WithLists(), pulpe.WithCards())
+	}
+
+	return nil, nil
+}
+
+// name returns a name accepted by the API: untitled items are named
+// and names that are too long are truncated.
+func name(s string, max int) string {
+	if s == "" {
+		return "Untitled"
+	}
+
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	return string([]rune(s)[:max])
+}
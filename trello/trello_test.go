@@ -0,0 +1,232 @@
+package trello_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/blankrobot/pulpe"
+	"github.com/blankrobot/pulpe/mock"
+	"github.com/blankrobot/pulpe/trello"
+	"github.com/stretchr/testify/require"
+)
+
+const export = `{
+	"id": "b1",
+	"name": "Roadmap",
+	"closed": false,
+	"prefs": {"background": "blue"},
+	"lists": [
+		{"id": "l1", "name": "Todo", "closed": false, "pos": 16384},
+		{"id": "l2", "name": "Old", "closed": true, "pos": 32768},
+		{"id": "l3", "name": "Done", "closed": false, "pos": 49152}
+	],
+	"cards": [
+		{"id": "c1", "idList": "l1", "name": "Write docs", "desc": "In **markdown**", "closed": false, "pos": 65535},
+		{"id": "c2", "idList": "l1", "name": "", "desc": "", "closed": false, "pos": 131070},
+		{"id": "c3", "idList": "l2", "name": "Forgotten", "desc": "", "closed": false, "pos": 1},
+		{"id": "c4", "idList": "l3", "name": "Shipped", "desc": "", "closed": true, "pos": 1},
+		{"id": "c5", "idList": "l9", "name": "Lost", "desc": "", "closed": false, "pos": 1}
+	]
+}`
+
+// newClient returns a mock client storing created boards, lists and cards in memory.
+func newClient() *mock.Client {
+	c := mock.NewClient()
+	user := &pulpe.User{ID: "u1", Login: "jonsnow"}
+
+	var boards []*pulpe.Board
+	var lists []*pulpe.List
+	var cards []*pulpe.Card
+
+	c.Session.AuthenticateFn = func() (*pulpe.User, error) {
+		return user, nil
+	}
+
+	c.BoardService.CreateBoardFn = func(bc *pulpe.BoardCreation) (*pulpe.Board, error) {
+		b := pulpe.Board{ID: fmt.Sprintf("board%d", len(boards)), Name: bc.Name, Owner: user, ImportID: bc.ImportID}
+		boards = append(boards, &b)
+		return &b, nil
+	}
+
+	c.BoardService.BoardsFn = func(...pulpe.BoardListOption) ([]*pulpe.Board, error) {
+		return boards, nil
+	}
+
+	c.BoardService.BoardFn = func(id string, options ...pulpe.BoardGetOption) (*pulpe.Board, error) {
+		for _, b := range boards {
+			if b.ID == id {
+				board := *b
+				for _, l := range lists {
+					if l.BoardID == id {
+						board.Lists = append(board.Lists, l)
+					}
+				}
+				for _, c := range cards {
+					if c.BoardID == id {
+						board.Cards = append(board.Cards, c)
+					}
+				}
+				return &board, nil
+			}
+		}
+
+		return nil, pulpe.ErrBoardNotFound
+	}
+
+	c.ListService.CreateListFn = func(boardID string, lc *pulpe.ListCreation) (*pulpe.List, error) {
+		l := pulpe.List{ID: fmt.Sprintf("list%d", len(lists)), BoardID: boardID, Name: lc.Name, Position: lc.Position, ImportID: lc.ImportID}
+		lists = append(lists, &l)
+		return &l, nil
+	}
+
+	c.CardService.CreateCardFn = func(listID string, cc *pulpe.CardCreation) (*pulpe.Card, error) {
+		var boardID string
+		for _, l := range lists {
+			if l.ID == listID {
+				boardID = l.BoardID
+			}
+		}
+
+		card := pulpe.Card{
+			ID:          fmt.Sprintf("card%d", len(cards)),
+			BoardID:     boardID,
+			ListID:      listID,
+			Name:        cc.Name,
+			Description: cc.Description,
+			Position:    cc.Position,
+			ImportID:    cc.ImportID,
+		}
+		cards = append(cards, &card)
+		return &card, nil
+	}
+
+	return c
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		e, err := trello.Decode(strings.NewReader(export))
+		require.NoError(t, err)
+		require.Equal(t, "b1", e.ID)
+		require.Len(t, e.Lists, 3)
+		require.Len(t, e.Cards, 5)
+		require.Equal(t, trello.Card{ID: "c1", IDList: "l1", Name: "Write docs", Desc: "In **markdown**", Pos: 65535}, e.Cards[0])
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		_, err := trello.Decode(strings.NewReader(`{"id": `))
+		require.Equal(t, trello.ErrInvalidExport, err)
+
+		_, err = trello.Decode(strings.NewReader(`{"name": "not a board"}`))
+		require.Equal(t, trello.ErrInvalidExport, err)
+	})
+}
+
+func TestImport(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		c := newClient()
+		session := c.Connect()
+
+		e, err := trello.Decode(strings.NewReader(export))
+		require.NoError(t, err)
+
+		r, err := trello.Import(session, e, "")
+		require.NoError(t, err)
+		require.Equal(t, "board0", r.Board.ID)
+		require.Equal(t, "trello:b1", r.Board.ImportID)
+		require.Equal(t, []trello.Entry{
+			{Kind: trello.KindBoard, TrelloID: "b1", Name: "Roadmap", Status: trello.StatusImported},
+			{Kind: trello.KindList, TrelloID: "l1", Name: "Todo", Status: trello.StatusImported},
+			{Kind: trello.KindList, TrelloID: "l2", Name: "Old", Status: trello.StatusSkipped, Reason: "archived"},
+			{Kind: trello.KindList, TrelloID: "l3", Name: "Done", Status: trello.StatusImported},
+			{Kind: trello.KindCard, TrelloID: "c1", Name: "Write docs", Status: trello.StatusImported},
+			{Kind: trello.KindCard, TrelloID: "c2", Name: "", Status: trello.StatusImported},
+			{Kind: trello.KindCard, TrelloID: "c3", Name: "Forgotten", Status: trello.StatusSkipped, Reason: "archived list"},
+			{Kind: trello.KindCard, TrelloID: "c4", Name: "Shipped", Status: trello.StatusSkipped, Reason: "archived"},
+			{Kind: trello.KindCard, TrelloID: "c5", Name: "Lost", Status: trello.StatusSkipped, Reason: "unknown list"},
+		}, r.Entries)
+
+		board, err := session.BoardService().Board("board0")
+		require.NoError(t, err)
+		require.Len(t, board.Lists, 2)
+		require.Equal(t, 49152.0, board.Lists[1].Position)
+		require.Len(t, board.Cards, 2)
+		require.Equal(t, "list0", board.Cards[0].ListID)
+		require.Equal(t, "In **markdown**", board.Cards[0].Description)
+		require.Equal(t, 65535.0, board.Cards[0].Position)
+		require.Equal(t, "Untitled", board.Cards[1].Name)
+	})
+
+	t.Run("Idempotent", func(t *testing.T) {
+		c := newClient()
+		session := c.Connect()
+
+		e, err := trello.Decode(strings.NewReader(export))
+		require.NoError(t, err)
+
+		_, err = trello.Import(session, e, "")
+		require.NoError(t, err)
+
+		// a card added to Trello since the last import
+		e.Cards = append(e.Cards, trello.Card{ID: "c6", IDList: "l3", Name: "New", Pos: 2})
+
+		r, err := trello.Import(session, e, "")
+		require.NoError(t, err)
+		require.Equal(t, "board0", r.Board.ID)
+		require.Equal(t, 1, r.Count(trello.KindBoard, trello.StatusExisting))
+		require.Equal(t, 2, r.Count(trello.KindList, trello.StatusExisting))
+		require.Equal(t, 0, r.Count(trello.KindList, trello.StatusImported))
+		require.Equal(t, 2, r.Count(trello.KindCard, trello.StatusExisting))
+		require.Equal(t, 1, r.Count(trello.KindCard, trello.StatusImported))
+
+		boards, err := session.BoardService().Boards()
+		require.NoError(t, err)
+		require.Len(t, boards, 1)
+
+		board, err := session.BoardService().Board("board0")
+		require.NoError(t, err)
+		require.Len(t, board.Cards, 3)
+		require.Equal(t, "list1", board.Cards[2].ListID)
+	})
+
+	t.Run("OtherNamespace", func(t *testing.T) {
+		c := newClient()
+		session := c.Connect()
+
+		e, err := trello.Decode(strings.NewReader(export))
+		require.NoError(t, err)
+
+		_, err = trello.Import(session, e, "")
+		require.NoError(t, err)
+
+		// the same export imported in an organization is a different board
+		c.BoardService.CreateBoardFn = func(bc *pulpe.BoardCreation) (*pulpe.Board, error) {
+			require.Equal(t, "wall", bc.Organization)
+			return nil, pulpe.ErrOrganizationNotFound
+		}
+
+		_, err = trello.Import(session, e, "wall")
+		require.Equal(t, pulpe.ErrOrganizationNotFound, err)
+	})
+
+	t.Run("LongNames", func(t *testing.T) {
+		c := newClient()
+		session := c.Connect()
+
+		e := trello.Export{ID: "b1", Name: strings.Repeat("é", 100)}
+		r, err := trello.Import(session, &e, "")
+		require.NoError(t, err)
+		require.Equal(t, strings.Repeat("é", 64), r.Board.Name)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		c := mock.NewClient()
+		c.Session.AuthenticateFn = func() (*pulpe.User, error) {
+			return nil, pulpe.ErrUserAuthenticationFailed
+		}
+
+		_, err := trello.Import(c.Connect(), &trello.Export{ID: "b1"}, "")
+		require.Equal(t, pulpe.ErrUserAuthenticationFailed, err)
+	})
+}
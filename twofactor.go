@@ -0,0 +1,26 @@
+package pulpe
+
+// Two-factor authentication errors
+const (
+	ErrTwoFactorInvalidCode    = Error("invalid two-factor authentication code")
+	ErrTwoFactorNotEnrolled    = Error("two-factor authentication not enrolled")
+	ErrTwoFactorAlreadyEnabled = Error("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = Error("two-factor authentication not enabled")
+)
+
+// TwoFactorEnrollment contains the informations needed to register
+// an authenticator app.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TwoFactorService manages the two-factor authentication of the authenticated user.
+type TwoFactorService interface {
+	// EnrollTwoFactor generates a new secret that must be confirmed with a valid code.
+	EnrollTwoFactor() (*TwoFactorEnrollment, error)
+	// ConfirmTwoFactor enables two-factor authentication and returns single-use recovery codes.
+	ConfirmTwoFactor(code string) ([]string, error)
+	// DisableTwoFactor disables two-factor authentication. Recovery codes are accepted.
+	DisableTwoFactor(code string) error
+}
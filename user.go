@@ -8,8 +8,12 @@ const (
 	ErrUserEmailConflict        = Error("email already exists")
 	ErrUserAuthenticationFailed = Error("authentication failed")
 	ErrUserSessionUnknownID     = Error("unknown sid")
+	ErrUserIdentityNoEmail      = Error("identity has no email")
 )
 
+// RoleAdmin makes the users it is granted to by a directory instance administrators.
+const RoleAdmin = "admin"
+
 // User informations.
 type User struct {
 	ID        string     `json:"id"`
@@ -18,6 +22,10 @@ type User struct {
 	FullName  string     `json:"fullName"`
 	Login     string     `json:"login"`
 	Email     string     `json:"email"`
+	TwoFactor bool       `json:"twoFactor,omitempty"`
+	Roles     []string   `json:"roles,omitempty"`
+	Admin     bool       `json:"admin,omitempty"`
+	Disabled  bool       `json:"disabled,omitempty"`
 }
 
 // UserRegistration is used to register a User.
@@ -27,29 +35,88 @@ type UserRegistration struct {
 	Password string
 }
 
+// ExternalIdentity is a user identity asserted by an external identity provider.
+type ExternalIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FullName      string
+
+	// Authoritative identities come from a trusted directory. The user profile is
+	// updated at each login and they are never linked to the authenticated user.
+	Authoritative bool
+
+	// Roles granted by the identity provider. Nil leaves the user roles untouched.
+	Roles []string
+}
+
 // UserService represents a service for managing users.
 type UserService interface {
 	Register(*UserRegistration) (*User, error)
 	User(id string) (*User, error)
+	UserByLogin(login string) (*User, error)
 	MatchPassword(loginOrEmail, password string) (string, error)
+	// MatchLogin returns the id of the user MatchPassword checks the password of.
+	MatchLogin(loginOrEmail string) (string, error)
+	// ResolveIdentity returns the user linked to the given identity.
+	// If there is none, the identity is linked to the authenticated user,
+	// or, for authoritative identities, to the user with the same verified email.
+	// Otherwise a new user is created.
+	ResolveIdentity(*ExternalIdentity) (*User, error)
 }
 
 // UserSession is stored and represents a logged in user.
+// A pending session is returned when the user must complete
+// a two-factor authentication challenge and can't be used to authenticate.
 type UserSession struct {
 	ID        string
 	UserID    string
+	CreatedAt time.Time
 	UpdatedAt time.Time
 	ExpiresAt time.Time
+	Remember  bool
+	Pending   bool
 }
 
 // UserSessionService manages user sessions.
 type UserSessionService interface {
-	CreateSession(*User) (*UserSession, error)
+	CreateSession(user *User, options ...SessionOption) (*UserSession, error)
+	// StartSession creates a session for a user authenticated without a password.
+	// Like Login, it returns a pending session if the user enabled two-factor authentication.
+	StartSession(user *User, options ...SessionOption) (*UserSession, error)
 	GetSession(id string) (*UserSession, error)
-	Login(loginOrEmail, password string) (*UserSession, error)
+	Login(loginOrEmail, password string, options ...SessionOption) (*UserSession, error)
+	// Challenge returns the pending session of a two-factor authentication challenge.
+	Challenge(id string) (*UserSession, error)
+	VerifyTwoFactor(id, code string) (*UserSession, error)
 	DeleteSession(id string) error
 }
 
+// SessionOption is a function used to customize the way a session is created.
+type SessionOption func(*SessionOptions)
+
+// SessionOptions contains the list of options to customize the way a session is created.
+type SessionOptions struct {
+	Remember bool
+}
+
+// RememberMe is used to tell the UserSessionService to create a long lived session.
+func RememberMe() SessionOption {
+	return func(s *SessionOptions) {
+		s.Remember = true
+	}
+}
+
+// CredentialVerifier checks the credentials of a user and returns its id.
+type CredentialVerifier interface {
+	VerifyCredentials(session Session, loginOrEmail, password string) (string, error)
+	// AccountKey returns a key identifying the account the login or email refers to,
+	// the same for every spelling VerifyCredentials accepts.
+	// It returns an empty key if there is no such account.
+	AccountKey(session Session, loginOrEmail string) (string, error)
+}
+
 // Authenticator represents a service for authenticating users.
 type Authenticator interface {
 	Authenticate(session Session, token string) (*User, error)
@@ -0,0 +1,134 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blankrobot/pulpe"
+)
+
+// MaxCustomFieldTextLength is the maximum number of characters of a text custom field value.
+const MaxCustomFieldTextLength = 1000
+
+// CustomFieldValues checks the custom field values of a card against the fields of its board
+// and converts them to their Go type: string, float64, time.Time or bool.
+// Nil values are kept as is, they are used to remove a value.
+// Errors are reported under the "fields.<id>" name.
+func CustomFieldValues(fields []*pulpe.CustomField, values map[string]interface{}) (map[string]interface{}, error) {
+	var verr error
+
+	converted := make(map[string]interface{}, len(values))
+	for id, v := range values {
+		f := customField(fields, id)
+		if f == nil {
+			verr = AddError(verr, "fields."+id, pulpe.ErrCustomFieldNotFound)
+			continue
+		}
+
+		if v == nil {
+			converted[id] = nil
+			continue
+		}
+
+		c, err := customFieldValue(f, v)
+		if err != nil {
+			verr = AddError(verr, "fields."+id, err)
+			continue
+		}
+
+		converted[id] = c
+	}
+
+	if verr != nil {
+		return nil, verr
+	}
+
+	return converted, nil
+}
+
+// ParseCustomFieldValue parses the text representation of a custom field value,
+// as found in query strings. Errors are reported under the "fields.<id>" name.
+func ParseCustomFieldValue(f *pulpe.CustomField, s string) (interface{}, error) {
+	var v interface{} = s
+
+	switch f.Type {
+	case pulpe.CustomFieldNumber:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, AddError(nil, "fields."+f.ID, errors.New("should be a number"))
+		}
+		v = n
+	case pulpe.CustomFieldCheckbox:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, AddError(nil, "fields."+f.ID, errors.New("should be a boolean"))
+		}
+		v = b
+	}
+
+	c, err := customFieldValue(f, v)
+	if err != nil {
+		return nil, AddError(nil, "fields."+f.ID, err)
+	}
+
+	return c, nil
+}
+
+func customField(fields []*pulpe.CustomField, id string) *pulpe.CustomField {
+	for _, f := range fields {
+		if f.ID == id {
+			return f
+		}
+	}
+
+	return nil
+}
+
+func customFieldValue(f *pulpe.CustomField, v interface{}) (interface{}, error) {
+	switch f.Type {
+	case pulpe.CustomFieldText:
+		s, ok := v.(string)
+		if !ok || s == "" || utf8.RuneCountInString(s) > MaxCustomFieldTextLength {
+			return nil, fmt.Errorf("should be a text of 1 to %d characters", MaxCustomFieldTextLength)
+		}
+		return s, nil
+	case pulpe.CustomFieldNumber:
+		n, ok := v.(float64)
+		if !ok {
+			return nil, errors.New("should be a number")
+		}
+		return n, nil
+	case pulpe.CustomFieldDate:
+		switch t := v.(type) {
+		case time.Time:
+			return t.UTC(), nil
+		case string:
+			d, err := time.Parse(time.RFC3339, t)
+			if err == nil {
+				return d.UTC(), nil
+			}
+		}
+		return nil, errors.New("should be a RFC 3339 date")
+	case pulpe.CustomFieldDropdown:
+		s, ok := v.(string)
+		if ok {
+			for _, o := range f.Options {
+				if o == s {
+					return s, nil
+				}
+			}
+		}
+		return nil, errors.New("should be one of the options of the field")
+	case pulpe.CustomFieldCheckbox:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, errors.New("should be a boolean")
+		}
+		return b, nil
+	}
+
+	return nil, pulpe.ErrCustomFieldInvalidType
+}
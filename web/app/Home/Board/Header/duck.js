@@ -4,6 +4,7 @@ import { Observable } from 'rxjs/Observable';
 import { successOf } from '@/services/api/ajaxEpic';
 import { UPDATE, DELETE } from '@/Home/Board/duck';
 import { getBoards } from '@/Home/Menu/BoardList/duck';
+import boardOwner from '@/services/board';
 import { hideModal } from '@/components/Modal/duck';
 
 // types
@@ -15,7 +16,7 @@ const redirectOnBoardDeletionEpic = (action$, store) => action$.ofType(successOf
     setTimeout(() => {
       const boards = getBoards(store.getState());
       if (boards && boards.length > 0) {
-        browserHistory.push(`/${boards[0].owner.login}/${boards[0].slug}`);
+        browserHistory.push(`/${boardOwner(boards[0])}/${boards[0].slug}`);
       } else {
         window.location.replace('/');
       }
@@ -29,7 +30,7 @@ const closeModalOnDeletionEpic = action$ => action$.ofType(successOf(DELETE))
 const redirectOnBoardUpdateEpic = action$ => action$.ofType(successOf(UPDATE))
   .do((action) => {
     const board = action.response.entities.boards[action.response.result];
-    browserHistory.push(`/${board.owner.login}/${board.slug}`);
+    browserHistory.push(`/${boardOwner(board)}/${board.slug}`);
   })
   .mergeMap(() => Observable.empty());
 
@@ -3,6 +3,7 @@ import { browserHistory } from 'react-router';
 import { successOf } from '@/services/api/ajaxEpic';
 import { getBoardSelector } from '@/Home/Board/duck';
 import { getListSelector } from '@/Home/Board/List/duck';
+import boardOwner from '@/services/board';
 import { UPDATE, DELETE, updateCard } from '@/Home/Board/List/Card/duck';
 
 export const DOMAIN = 'pulpe/home/board/list/card/detail';
@@ -25,14 +26,14 @@ const redirectCardModalOnNameUpdateSuccessEpic = (action$, store) => action$.ofT
     const card = action.response.entities.cards[action.response.result];
     const board = getBoardSelector(store.getState());
     const list = getListSelector(store.getState(), card.listID);
-    browserHistory.push(`/${board.owner.login}/${board.slug}/${list.slug}/${card.slug}`);
+    browserHistory.push(`/${boardOwner(board)}/${board.slug}/${list.slug}/${card.slug}`);
   })
   .ignoreElements();
 
 const closeCardModalOnDeleteSuccessEpic = (action$, store) => action$.ofType(successOf(DELETE))
   .do(() => {
     const board = getBoardSelector(store.getState());
-    browserHistory.push(`/${board.owner.login}/${board.slug}`);
+    browserHistory.push(`/${boardOwner(board)}/${board.slug}`);
   })
   .ignoreElements();
 
@@ -6,6 +6,7 @@ import { getModalProps, getModalType, hideModal } from '@/components/Modal/duck'
 import Sub, { SubOpener, SubClosed, SubOpened } from '@/components/Sub';
 import { subsStillOpened, popSub, closeSub, closeAllSubs, getLastOpened } from '@/components/Sub/duck';
 import { getBoardSelector } from '@/Home/Board/duck';
+import boardOwner from '@/services/board';
 import { MODAL_CARD_DETAIL, fetchCard, patchCard, deleteCard, getCardBySlugSelector } from '@/Home/Board/List/Card/duck';
 import { saveCard } from './duck';
 import RichEditor from './RichEditor';
@@ -165,7 +166,7 @@ export default connect(
         dispatch(closeSub(lastOpened));
       } else {
         dispatch(hideModal());
-        browserHistory.push(`/${board.owner.login}/${board.slug}`);
+        browserHistory.push(`/${boardOwner(board)}/${board.slug}`);
       }
     },
     init: () => dispatch(closeAllSubs()),
@@ -1,11 +1,12 @@
 import React from 'react';
 import { browserHistory } from 'react-router';
+import boardOwner from '@/services/board';
 
 const Card = (props) => {
   const { card = {}, list = {}, board = {}, isDragging, isDragged, style } = props;
 
   return (
-    <div style={style} className={`card-item ${isDragged ? 'dragged' : ''} ${isDragging ? 'shadow' : ''}`} onClick={() => browserHistory.push(`/${board.owner.login}/${board.slug}/${list.slug}/${card.slug}`)}>
+    <div style={style} className={`card-item ${isDragged ? 'dragged' : ''} ${isDragging ? 'shadow' : ''}`} onClick={() => browserHistory.push(`/${boardOwner(board)}/${board.slug}/${list.slug}/${card.slug}`)}>
       <div className="card-wrapper">
         <h3 className="card-title">{ card.name }</h3>
       </div>
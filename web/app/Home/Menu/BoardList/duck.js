@@ -2,6 +2,7 @@ import { schema } from 'normalizr';
 import { browserHistory } from 'react-router';
 import { combineEpics } from 'redux-observable';
 import client from '@/services/api/client';
+import boardOwner from '@/services/board';
 import ajaxEpic, { successOf, requestOf } from '@/services/api/ajaxEpic';
 import { UPDATE as BOARD_UPDATE, DELETE as BOARD_DELETE } from '@/Home/Board/duck';
 
@@ -10,6 +11,7 @@ const DOMAIN = 'pulpe/home/menu/boardList';
 // types
 const FETCH = `${DOMAIN}/fetch`;
 const CREATE = `${DOMAIN}/create`;
+const FETCH_TEMPLATES = `${DOMAIN}/fetchTemplates`;
 export const MODAL_CREATE_BOARD = `${DOMAIN}/modalCreateBoard`;
 
 // schemas
@@ -21,9 +23,14 @@ export const fetchBoards = (filters) => ({
   filters
 });
 
-export const createBoard = (name) => ({
+export const createBoard = (name, templateID) => ({
   type: requestOf(CREATE),
-  name
+  name,
+  templateID
+});
+
+export const fetchTemplates = () => ({
+  type: requestOf(FETCH_TEMPLATES)
 });
 
 // epics
@@ -33,6 +40,12 @@ const fetchBoardsEpic = ajaxEpic(
   [boardSchema]
 );
 
+const fetchTemplatesEpic = ajaxEpic(
+  FETCH_TEMPLATES,
+  () => client.getTemplates(),
+  [boardSchema]
+);
+
 const createBoardEpic = ajaxEpic(
   CREATE,
   action => client.createBoard(action),
@@ -42,18 +55,23 @@ const createBoardEpic = ajaxEpic(
 const redirectOnBoardCreationEpic = action$ => action$.ofType(successOf(CREATE))
   .do((action) => {
     const board = action.response.entities.boards[action.response.result];
-    browserHistory.push(`/${board.owner.login}/${board.slug}`);
+    browserHistory.push(`/${boardOwner(board)}/${board.slug}`);
   })
   .mapTo({ type: '' });
 
 const fetchOnBoardUpateEpic = action$ => action$.ofType(successOf(BOARD_UPDATE))
   .mapTo(fetchBoards());
 
+const fetchTemplatesOnBoardUpdateEpic = action$ => action$.ofType(successOf(BOARD_UPDATE))
+  .mapTo(fetchTemplates());
+
 export const epics = combineEpics(
   fetchBoardsEpic,
   createBoardEpic,
   redirectOnBoardCreationEpic,
   fetchOnBoardUpateEpic,
+  fetchTemplatesEpic,
+  fetchTemplatesOnBoardUpdateEpic,
 );
 
 // reducer
@@ -84,8 +102,24 @@ const reducer = (state = [], action = {}) => {
   }
 };
 
+const templatesReducer = (state = [], action = {}) => {
+  switch (action.type) {
+    case successOf(FETCH_TEMPLATES): {
+      return action.response.result.map(id => action.response.entities.boards[id]);
+    }
+    case successOf(BOARD_DELETE): {
+      return state.filter(b => b.id !== action.response.id);
+    }
+    default:
+      return state;
+  }
+};
+
 export default {
-  [DOMAIN]: reducer
+  [DOMAIN]: reducer,
+  [`${DOMAIN}/templates`]: templatesReducer
 };
 
 export const getBoards = (state) => state[DOMAIN];
+
+export const getTemplates = (state) => state[`${DOMAIN}/templates`];
@@ -3,16 +3,18 @@ import { connect } from 'react-redux';
 import { Link } from 'react-router';
 import Editable from '@/components/Editable';
 import { getActiveBoard } from '@/Home/duck';
+import boardOwner from '@/services/board';
 import * as duck from './duck';
 
 class BoardList extends Component {
   componentDidMount() {
-    const { fetchBoards } = this.props;
+    const { fetchBoards, fetchTemplates } = this.props;
     fetchBoards();
+    fetchTemplates();
   }
 
   render() {
-    const { boards = [], onCreate, activeBoard = {} } = this.props;
+    const { boards = [], templates = [], onCreate, activeBoard = {} } = this.props;
 
     return (
       <div>
@@ -23,13 +25,23 @@ class BoardList extends Component {
                 {board.name}
               </li> :
               <li key={board.id} className="left-menu__item">
-                <Link to={`/${board.owner.login}/${board.slug}`}>{board.name}</Link>
+                <Link to={`/${boardOwner(board)}/${board.slug}`}>{board.name}</Link>
               </li>
           ))}
         </ul>
         <Editable editorClassName="left-menu__create-input" onSave={onCreate} >
           <button className="left-menu__create-button">Create a new board</button>
         </Editable>
+        {templates.length > 0 &&
+          <ul className="list-unstyled left-menu__template-list">
+            {templates.map((template) => (
+              <li key={template.id} className="left-menu__item">
+                <button className="left-menu__create-button" onClick={() => onCreate(template.name, template.id)}>
+                  Create from {template.name}
+                </button>
+              </li>
+            ))}
+          </ul>}
       </div>
     );
   }
@@ -37,6 +49,7 @@ class BoardList extends Component {
 
 const mapStateToProps = (state) => ({
   boards: duck.getBoards(state),
+  templates: duck.getTemplates(state),
   activeBoard: getActiveBoard(state),
 });
 
@@ -45,5 +58,6 @@ export default connect(
   {
     onCreate: duck.createBoard,
     fetchBoards: duck.fetchBoards,
+    fetchTemplates: duck.fetchTemplates,
   },
 )(BoardList);
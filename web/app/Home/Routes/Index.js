@@ -2,6 +2,7 @@ import { Component } from 'react';
 import { connect } from 'react-redux';
 import { browserHistory } from 'react-router';
 import { getBoards } from '@/Home/Menu/BoardList/duck';
+import boardOwner from '@/services/board';
 
 class BoardIndex extends Component {
   componentDidMount() {
@@ -16,7 +17,7 @@ class BoardIndex extends Component {
     const { boards = [] } = this.props;
 
     if (boards.length > 0) {
-      browserHistory.push(`/${boards[0].owner.login}/${boards[0].slug}`);
+      browserHistory.push(`/${boardOwner(boards[0])}/${boards[0].slug}`);
     }
   }
 
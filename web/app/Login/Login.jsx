@@ -1,8 +1,45 @@
 import React from 'react';
 import { connect } from 'react-redux';
-import { login, getErrors } from './duck';
+import { login, loginTwoFactor, getErrors, getTwoFactor, getChallenge } from './duck';
 
-const Login = ({ onSubmit, errors }) => {
+const TwoFactorForm = ({ onSubmit, errors, challenge }) => {
+  let inputCode;
+
+  const submit = (e) => {
+    e.preventDefault();
+
+    const code = inputCode.value.trim();
+    if (!code) {
+      return;
+    }
+
+    onSubmit(challenge ? { challenge, code } : { code });
+  };
+
+  return (
+    <form onSubmit={submit}>
+      <div className={`form-group ${errors.err && 'has-danger'}`}>
+        <label htmlFor="codeField">Authentication code or recovery code</label>
+        <input
+          type="text"
+          id="codeField"
+          className="form-control"
+          autoComplete="one-time-code"
+          required
+          autoFocus
+          ref={(node) => { inputCode = node; }}
+        />
+        {errors.err && <div className="form-control-feedback">{errors.err}</div>}
+      </div>
+      <button className="btn btn-primary btn-block" type="submit">Verify</button>
+      <p className="card-text" style={{ marginTop: '10px' }}>
+        <a href="/login">Start again</a>
+      </p>
+    </form>
+  );
+};
+
+const PasswordForm = ({ onSubmit, errors }) => {
   let inputLogin;
   let inputPassword;
 
@@ -23,61 +60,71 @@ const Login = ({ onSubmit, errors }) => {
   };
 
   return (
-    <div className="container">
-      <div className="row justify-content-md-center">
-        <div className="col col-lg-4">
-          <h3
-            style={{
-              textAlign: 'center',
-              margin: '20px auto'
-            }}
-          >
-          Sign in to Pulpe
-        </h3>
-          <div className="card">
-            <div className="card-block">
-              <form onSubmit={submit}>
-                <div className={`form-group ${errors.err && 'has-danger'}`}>
-                  <label htmlFor="loginField">Login or email address</label>
-                  <input
-                    type="text"
-                    id="loginField"
-                    className="form-control"
-                    required
-                    autoFocus
-                    ref={(node) => { inputLogin = node; }}
-                  />
-                </div>
-                <div className={`form-group ${errors.err && 'has-danger'}`}>
-                  <label htmlFor="passwordField">Password</label>
-                  <input
-                    type="password"
-                    id="passwordField"
-                    className="form-control"
-                    required
-                    ref={(node) => { inputPassword = node; }}
-                  />
-                </div>
-                <button className="btn btn-primary btn-block" type="submit">Sign in</button>
-              </form>
-            </div>
+    <form onSubmit={submit}>
+      <div className={`form-group ${errors.err && 'has-danger'}`}>
+        <label htmlFor="loginField">Login or email address</label>
+        <input
+          type="text"
+          id="loginField"
+          className="form-control"
+          required
+          autoFocus
+          ref={(node) => { inputLogin = node; }}
+        />
+      </div>
+      <div className={`form-group ${errors.err && 'has-danger'}`}>
+        <label htmlFor="passwordField">Password</label>
+        <input
+          type="password"
+          id="passwordField"
+          className="form-control"
+          required
+          ref={(node) => { inputPassword = node; }}
+        />
+      </div>
+      <button className="btn btn-primary btn-block" type="submit">Sign in</button>
+    </form>
+  );
+};
+
+// users who enabled two-factor authentication are asked for a code once signed in
+const Login = ({ onSubmit, onSubmitCode, errors, twoFactor, challenge }) => (
+  <div className="container">
+    <div className="row justify-content-md-center">
+      <div className="col col-lg-4">
+        <h3
+          style={{
+            textAlign: 'center',
+            margin: '20px auto'
+          }}
+        >
+        Sign in to Pulpe
+      </h3>
+        <div className="card">
+          <div className="card-block">
+            {twoFactor ?
+              <TwoFactorForm onSubmit={onSubmitCode} errors={errors} challenge={challenge} /> :
+              <PasswordForm onSubmit={onSubmit} errors={errors} />}
           </div>
-          <div className="card" style={{ textAlign: 'center' }}>
-            <div className="card-block">
-              <p className="card-text">New to Pulpe? <a href="/join">Create an account</a>.</p>
-            </div>
+        </div>
+        <div className="card" style={{ textAlign: 'center' }}>
+          <div className="card-block">
+            <p className="card-text">New to Pulpe? <a href="/join">Create an account</a>.</p>
           </div>
         </div>
       </div>
     </div>
-  );
-};
+  </div>
+);
 
 export default connect(
   (state) => ({
     errors: getErrors(state),
+    twoFactor: getTwoFactor(state),
+    challenge: getChallenge(state),
   }),
   {
-    onSubmit: login
+    onSubmit: login,
+    onSubmitCode: loginTwoFactor
   }
 )(Login);
@@ -7,6 +7,7 @@ export const DOMAIN = 'pulpe/login';
 
 // types
 export const LOGIN = 'pulpe/login';
+export const LOGIN_TWO_FACTOR = 'pulpe/login/two-factor';
 
 // action creators
 export const login = (payload) => ({
@@ -14,6 +15,13 @@ export const login = (payload) => ({
   payload
 });
 
+// the challenge is omitted when it was started by an identity provider,
+// the server keeps it in a cookie.
+export const loginTwoFactor = (payload) => ({
+  type: requestOf(LOGIN_TWO_FACTOR),
+  payload
+});
+
 
 // epics
 const loginEpic = ajaxEpic(
@@ -21,16 +29,45 @@ const loginEpic = ajaxEpic(
   action => client.login(action.payload)
 );
 
-const redirectOnLoginSuccessEpic = action$ => action$.ofType(successOf(LOGIN))
+const loginTwoFactorEpic = ajaxEpic(
+  LOGIN_TWO_FACTOR,
+  action => client.loginTwoFactor(action.payload)
+);
+
+// a challenge is returned instead of a session when two-factor authentication is enabled
+const redirectOnLoginSuccessEpic = action$ => action$
+  .filter(action => (action.type === successOf(LOGIN) && !(action.response && action.response.challenge))
+    || action.type === successOf(LOGIN_TWO_FACTOR))
   .do(() => window.location.replace('/'))
   .mergeMap(() => Observable.empty());
 
 
-const reducer = (state = {}, action) => {
+const initialState = {
+  errors: {},
+  // identity providers redirect here once the password step is done
+  twoFactor: new URLSearchParams(window.location.search).get('step') === 'two-factor',
+  challenge: '',
+};
+
+const reducer = (state = initialState, action) => {
   switch (action.type) {
-    case failureOf(LOGIN): {
+    case successOf(LOGIN): {
+      if (!action.response || !action.response.challenge) {
+        return state;
+      }
+
+      return {
+        ...state,
+        errors: {},
+        twoFactor: true,
+        challenge: action.response.challenge,
+      };
+    }
+    case failureOf(LOGIN):
+    case failureOf(LOGIN_TWO_FACTOR): {
       return {
-        ...action.payload,
+        ...state,
+        errors: { ...action.payload },
       };
     }
     default:
@@ -44,7 +81,10 @@ export default {
 
 export const epics = combineEpics(
   loginEpic,
+  loginTwoFactorEpic,
   redirectOnLoginSuccessEpic
 );
 
-export const getErrors = (state) => state[DOMAIN];
+export const getErrors = (state) => state[DOMAIN].errors;
+export const getTwoFactor = (state) => state[DOMAIN].twoFactor;
+export const getChallenge = (state) => state[DOMAIN].challenge;
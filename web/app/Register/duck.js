@@ -16,9 +16,13 @@ export const register = (payload) => ({
 
 
 // epics
+
+// invitation links point to /join?invitation=<token>
+const invitation = () => new URLSearchParams(window.location.search).get('invitation');
+
 const registerEpic = ajaxEpic(
   REGISTER,
-  action => client.register(action.payload)
+  action => client.register(invitation() ? { ...action.payload, invitation: invitation() } : action.payload)
 );
 
 const redirectOnRegisterSuccessEpic = action$ => action$.ofType(successOf(REGISTER))
@@ -9,6 +9,9 @@ class Client {
 
   login = (payload) => post(`${this.url}/login`, payload)
 
+  // the challenge is read from a cookie if omitted
+  loginTwoFactor = (payload) => post(`${this.url}/login/2fa`, payload)
+
   getBoards = (filters = {}) => get(`${this.url}/user/boards${
     filters ?
       Object.keys(filters)
@@ -16,10 +19,57 @@ class Client {
         .reduce((a, c) => a + c, '')
       : ''}`)
 
-  getBoard = (owner, slug) => get(`${this.url}/boards/${owner}/${slug}`)
+  // forward the share token of the page, if any
+  getBoard = (owner, slug) => {
+    const token = new URLSearchParams(window.location.search).get('token');
+    return get(`${this.url}/boards/${owner}/${slug}${token ? `?token=${encodeURIComponent(token)}` : ''}`);
+  }
+
+  // since is the token returned by the previous sync, or empty to get everything
+  getBoardsChanges = (since = '') => get(`${this.url}/user/boards?since=${encodeURIComponent(since)}`)
+
+  getBoardChanges = (owner, slug, since = '') => {
+    const token = new URLSearchParams(window.location.search).get('token');
+    return get(`${this.url}/boards/${owner}/${slug}?since=${encodeURIComponent(since)}${token ? `&token=${encodeURIComponent(token)}` : ''}`);
+  }
+
+  syncBoard = (id, token, operations) => post(`${this.url}/boards/${id}/sync`, { token, operations })
+
+  undo = () => post(`${this.url}/undo`)
+
+  redo = () => post(`${this.url}/redo`)
 
   createBoard = (payload) => post(`${this.url}/user/boards`, payload)
 
+  copyBoard = ({ id, ...rest }) => post(`${this.url}/boards/${id}/copy`, rest)
+
+  getTemplates = () => get(`${this.url}/user/templates`)
+
+  getCardTemplates = (owner, slug) => get(`${this.url}/boards/${owner}/${slug}/card-templates`)
+
+  createCardTemplate = ({ boardID, ...rest }) => post(`${this.url}/boards/${boardID}/card-templates`, rest)
+
+  updateCardTemplate = ({ boardID, id, patch }) => update(`${this.url}/boards/${boardID}/card-templates/${id}`, patch)
+
+  deleteCardTemplate = ({ boardID, id }) => del(`${this.url}/boards/${boardID}/card-templates/${id}`)
+
+  createCustomField = ({ boardID, ...rest }) => post(`${this.url}/boards/${boardID}/custom-fields`, rest)
+
+  updateCustomField = ({ boardID, id, patch }) => update(`${this.url}/boards/${boardID}/custom-fields/${id}`, patch)
+
+  deleteCustomField = ({ boardID, id }) => del(`${this.url}/boards/${boardID}/custom-fields/${id}`)
+
+  // filters: list, label, assignee, dueAfter, dueBefore, q, updatedSince, limit, after and field.<id>
+  // sort: position, due, updated or field.<id>, prefixed by - to reverse it
+  getCards = (owner, slug, { sort, ...filters } = {}) => {
+    const params = Object.keys(filters).map(k => `${encodeURIComponent(k)}=${encodeURIComponent(filters[k])}`);
+    if (sort) {
+      params.push(`sort=${encodeURIComponent(sort)}`);
+    }
+
+    return get(`${this.url}/boards/${owner}/${slug}/cards${params.length ? `?${params.join('&')}` : ''}`);
+  }
+
   deleteBoard = (id) => del(`${this.url}/boards/${id}`)
 
   updateBoard = ({ id, patch }) => update(`${this.url}/boards/${id}`, patch)
@@ -32,16 +82,28 @@ class Client {
 
   getCard = (id) => get(`${this.url}/cards/${id}`)
 
-  createCard = ({ id, listID, name, description, position }) => post(`${this.url}/lists/${listID}/cards`, {
+  createCard = ({ id, listID, name, description, position, labels, checklist, templateID, due, assignees, fields }) => post(`${this.url}/lists/${listID}/cards`, {
     id,
     name,
     description,
-    position
+    position,
+    labels,
+    checklist,
+    templateID,
+    due,
+    assignees,
+    fields
   })
 
   deleteCard = (id) => del(`${this.url}/cards/${id}`)
 
   updateCard = ({ id, patch }) => update(`${this.url}/cards/${id}`, patch)
+
+  getRevisions = (cardID) => get(`${this.url}/cards/${cardID}/revisions`)
+
+  diffRevisions = (cardID, from, to) => get(`${this.url}/cards/${cardID}/revisions/${from}/diff?to=${encodeURIComponent(to)}`)
+
+  restoreRevision = (cardID, revisionID) => post(`${this.url}/cards/${cardID}/revisions/${revisionID}/restore`)
 }
 
 const get = (url) => Observable.ajax.getJSON(url);
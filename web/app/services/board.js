@@ -0,0 +1,4 @@
+// boardOwner returns the login or the organization slug under which the board is found.
+const boardOwner = board => (board.organization ? board.organization.slug : board.owner.login);
+
+export default boardOwner;